@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Vahsek/distrokv/pkg/client"
+)
+
+func runGet(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	readConsistency := consistencyFlags(flags)
+	siblings := flags.Bool("siblings", false, "print every concurrent value and the context to write back with")
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if flags.NArg() != 1 {
+		return usageError{"get takes exactly one key"}
+	}
+	consistency, err := readConsistency("get")
+	if err != nil {
+		return err
+	}
+	if *siblings {
+		found, err := kvClient.GetSiblings(ctx, flags.Arg(0), consistency)
+		if err != nil {
+			return err
+		}
+		return out.siblings(flags.Arg(0), found)
+	}
+	value, err := kvClient.GetWithConsistency(ctx, flags.Arg(0), consistency)
+	if err != nil {
+		return err
+	}
+	return out.keyValues([]client.KeyValue{{Key: flags.Arg(0), Value: value}}, true)
+}
+
+// consistencyFlags adds the read consistency flags to flags and returns a
+// function resolving them once they are parsed
+func consistencyFlags(flags *flag.FlagSet) func(command string) (client.ReadConsistency, error) {
+	maxStaleness := flags.Duration("max-staleness", 0, "read from any member at most this far behind the leader")
+	anyReplica := flags.Bool("any", false, "read from any member however far behind it is")
+	readQuorum := flags.Int("r", 0, "replicas to read from in leaderless mode, 0 for the cluster default")
+	return func(command string) (client.ReadConsistency, error) {
+		chosen := 0
+		for _, set := range []bool{*anyReplica, *maxStaleness > 0, *readQuorum > 0} {
+			if set {
+				chosen++
+			}
+		}
+		if chosen > 1 {
+			return client.ReadConsistency{}, usageError{command + " takes only one of -any, -max-staleness and -r"}
+		}
+
+		switch {
+		case *readQuorum > 0:
+			return client.Quorum(*readQuorum), nil
+		case *anyReplica:
+			return client.AnyReplica, nil
+		case *maxStaleness > 0:
+			return client.BoundedStaleness(*maxStaleness), nil
+		}
+		return client.Linearizable, nil
+	}
+}
+
+func runPut(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	flags := flag.NewFlagSet("put", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	writeQuorum := flags.Int("w", 0, "replicas that must acknowledge in leaderless mode, 0 for the cluster default")
+	causalContext := flags.String("context", "", "context of the read the value resolves, with vector clocks")
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if flags.NArg() != 2 {
+		return usageError{"put takes a key and a value"}
+	}
+	if *writeQuorum > 0 && *causalContext != "" {
+		return usageError{"put takes either -w or -context"}
+	}
+	put := func() error { return kvClient.PutWithQuorum(ctx, flags.Arg(0), flags.Arg(1), *writeQuorum) }
+	if *causalContext != "" {
+		put = func() error { return kvClient.PutWithContext(ctx, flags.Arg(0), flags.Arg(1), *causalContext) }
+	}
+	if err := put(); err != nil {
+		return err
+	}
+	return out.result("put", flags.Arg(0))
+}
+
+func runDelete(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	flags := flag.NewFlagSet("del", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	writeQuorum := flags.Int("w", 0, "replicas that must acknowledge in leaderless mode, 0 for the cluster default")
+	causalContext := flags.String("context", "", "context of the read the delete resolves, with vector clocks")
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if flags.NArg() != 1 {
+		return usageError{"del takes exactly one key"}
+	}
+	if *writeQuorum > 0 && *causalContext != "" {
+		return usageError{"del takes either -w or -context"}
+	}
+	del := func() error { return kvClient.DeleteWithQuorum(ctx, flags.Arg(0), *writeQuorum) }
+	if *causalContext != "" {
+		del = func() error { return kvClient.DeleteWithContext(ctx, flags.Arg(0), *causalContext) }
+	}
+	if err := del(); err != nil {
+		return err
+	}
+	return out.result("del", flags.Arg(0))
+}
+
+func runIncrement(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) < 1 || len(args) > 2 {
+		return usageError{"incr takes a key and an optional delta"}
+	}
+	delta := int64(1)
+	if len(args) == 2 {
+		var err error
+		if delta, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return usageError{fmt.Sprintf("invalid delta %q", args[1])}
+		}
+	}
+	value, err := kvClient.Increment(ctx, args[0], delta)
+	if err != nil {
+		return err
+	}
+	return out.crdt(args[0], &client.CRDT{Type: client.CRDTCounter, Counter: value})
+}
+
+func runSetAdd(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 2 {
+		return usageError{"sadd takes a key and an element"}
+	}
+	if err := kvClient.SetAdd(ctx, args[0], args[1]); err != nil {
+		return err
+	}
+	return out.result("sadd", args[0])
+}
+
+func runSetRemove(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 2 {
+		return usageError{"srem takes a key and an element"}
+	}
+	if err := kvClient.SetRemove(ctx, args[0], args[1]); err != nil {
+		return err
+	}
+	return out.result("srem", args[0])
+}
+
+func runRegisterSet(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 2 {
+		return usageError{"rset takes a key and a value"}
+	}
+	if err := kvClient.RegisterSet(ctx, args[0], args[1]); err != nil {
+		return err
+	}
+	return out.result("rset", args[0])
+}
+
+func runGetCRDT(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	flags := flag.NewFlagSet("crdt", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	readConsistency := consistencyFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if flags.NArg() != 1 {
+		return usageError{"crdt takes exactly one key"}
+	}
+	consistency, err := readConsistency("crdt")
+	if err != nil {
+		return err
+	}
+
+	value, err := kvClient.GetCRDT(ctx, flags.Arg(0), consistency)
+	if err != nil {
+		return err
+	}
+	return out.crdt(flags.Arg(0), value)
+}
+
+func runScan(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	limit := flags.Int("limit", 0, "maximum number of keys to return, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if flags.NArg() > 2 {
+		return usageError{"scan takes at most a start and an end key"}
+	}
+
+	keyValues, err := kvClient.Scan(ctx, flags.Arg(0), flags.Arg(1), *limit)
+	if err != nil {
+		return err
+	}
+	return out.keyValues(keyValues, false)
+}
+
+func runWatch(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	prefix := flags.Bool("prefix", false, "watch every key starting with start")
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usageError{"watch takes a start key and an optional end key"}
+	}
+
+	startKey, endKey := flags.Arg(0), flags.Arg(1)
+	if *prefix {
+		if endKey != "" {
+			return usageError{"-prefix cannot be combined with an end key"}
+		}
+		endKey = prefixEnd(startKey)
+	}
+
+	events, err := kvClient.Watch(ctx, startKey, endKey)
+	if err != nil {
+		return err
+	}
+	for event := range events {
+		if err := out.watchEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runMembers(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 0 {
+		return usageError{"members takes no arguments"}
+	}
+	members, err := kvClient.Members(ctx)
+	if err != nil {
+		return err
+	}
+	return out.members(members)
+}
+
+func runLeader(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 0 {
+		return usageError{"leader takes no arguments"}
+	}
+	leader, err := kvClient.Leader(ctx)
+	if err != nil {
+		return err
+	}
+	return out.leader(*leader)
+}
+
+type clusterStatus struct {
+	Members []client.Member
+	Leader  *client.Member
+	Ranges  []client.Range
+}
+
+// antiEntropy sums what the repairs run by every member found
+func (status clusterStatus) antiEntropy() (int64, int64) {
+	var divergentRanges, repairedKeys int64
+	for _, member := range status.Members {
+		divergentRanges += member.DivergentRanges
+		repairedKeys += member.RepairedKeys
+	}
+	return divergentRanges, repairedKeys
+}
+
+func runStatus(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 0 {
+		return usageError{"status takes no arguments"}
+	}
+	members, err := kvClient.Members(ctx)
+	if err != nil {
+		return err
+	}
+	ranges, err := kvClient.Ranges(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := clusterStatus{Members: members, Ranges: ranges}
+	for index := range members {
+		if members[index].Leader {
+			status.Leader = &members[index]
+		}
+	}
+	return out.status(status)
+}
+
+func runDrain(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 1 {
+		return usageError{"drain takes exactly one node id or hostname"}
+	}
+	member, err := findMember(ctx, kvClient, args[0], "drain")
+	if err != nil {
+		return err
+	}
+	if err := kvClient.Drain(ctx, member); err != nil {
+		return err
+	}
+	return out.result("drain", args[0])
+}
+
+func runPromote(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 1 {
+		return usageError{"promote takes exactly one node id or hostname"}
+	}
+	member, err := findMember(ctx, kvClient, args[0], "promote")
+	if err != nil {
+		return err
+	}
+	if err := kvClient.PromoteLearner(ctx, member); err != nil {
+		return err
+	}
+	return out.result("promote", args[0])
+}
+
+func runTransfer(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 1 {
+		return usageError{"transfer takes exactly one node id or hostname"}
+	}
+	member, err := findMember(ctx, kvClient, args[0], "transfer")
+	if err != nil {
+		return err
+	}
+	if err := kvClient.TransferLeadership(ctx, member); err != nil {
+		return err
+	}
+	return out.result("transfer", args[0])
+}
+
+func runConfig(ctx context.Context, kvClient *client.Client, args []string, out *printer) error {
+	if len(args) != 0 {
+		return usageError{"config takes no arguments"}
+	}
+	configuration, err := kvClient.Configuration(ctx)
+	if err != nil {
+		return err
+	}
+	members, err := kvClient.Members(ctx)
+	if err != nil {
+		return err
+	}
+	return out.configuration(*configuration, members)
+}
+
+// findMember looks a member up by id or hostname. An exact id wins, a hostname
+// has to be unique.
+func findMember(ctx context.Context, kvClient *client.Client, idOrHostname string, verb string) (client.Member, error) {
+	members, err := kvClient.Members(ctx)
+	if err != nil {
+		return client.Member{}, err
+	}
+
+	var matches []client.Member
+	for _, member := range members {
+		if member.ID == idOrHostname {
+			return member, nil
+		}
+		if member.Hostname == idOrHostname {
+			matches = append(matches, member)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return client.Member{}, fmt.Errorf("%w: no member with id or hostname %s", client.ErrNotFound, idOrHostname)
+	case 1:
+		return matches[0], nil
+	default:
+		return client.Member{}, fmt.Errorf("%d members share the hostname %s, %s by node id instead", len(matches), idOrHostname, verb)
+	}
+}
+
+// prefixEnd returns the smallest key that sorts after every key with the
+// given prefix, or "" when no such key exists
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for index := len(end) - 1; index >= 0; index-- {
+		if end[index] < 0xff {
+			end[index]++
+			return string(end[:index+1])
+		}
+	}
+	return ""
+}
+
+func memberAddress(member client.Member) string {
+	return fmt.Sprintf("%s:%s", member.IP, member.DataPort)
+}
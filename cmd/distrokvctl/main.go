@@ -0,0 +1,152 @@
+// Command distrokvctl reads and writes keys and inspects a distrokv cluster.
+//
+// Usage:
+//
+//	distrokvctl [flags] <command> [args]
+//
+// The registry address is taken from -registry or DISTROKV_REGISTRY.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Vahsek/distrokv/pkg/client"
+)
+
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+)
+
+type command struct {
+	usage       string
+	description string
+	run         func(ctx context.Context, kvClient *client.Client, args []string, out *printer) error
+}
+
+var commands = map[string]command{
+	"get":      {"get [-any | -max-staleness duration | -r n] [-siblings] <key>", "Print the value of a key", runGet},
+	"put":      {"put [-w n | -context context] <key> <value>", "Set a key", runPut},
+	"del":      {"del [-w n | -context context] <key>", "Delete a key", runDelete},
+	"incr":     {"incr <key> [delta]", "Add to a counter, 1 by default", runIncrement},
+	"sadd":     {"sadd <key> <element>", "Add an element to a set", runSetAdd},
+	"srem":     {"srem <key> <element>", "Remove an element from a set", runSetRemove},
+	"rset":     {"rset <key> <value>", "Set a register", runRegisterSet},
+	"crdt":     {"crdt [-any | -max-staleness duration | -r n] <key>", "Print the value of a counter, set or register", runGetCRDT},
+	"scan":     {"scan [-limit n] [start] [end]", "List keys in [start, end)", runScan},
+	"watch":    {"watch [-prefix] <start> [end]", "Stream changes to keys in [start, end)", runWatch},
+	"members":  {"members", "List the registered nodes", runMembers},
+	"leader":   {"leader", "Show the primary node", runLeader},
+	"status":   {"status", "Summarise the cluster", runStatus},
+	"drain":    {"drain <node id|hostname>", "Hand a node's data off and remove it", runDrain},
+	"promote":  {"promote <node id|hostname>", "Turn a learner into a voter", runPromote},
+	"transfer": {"transfer <node id|hostname>", "Hand leadership to a voter", runTransfer},
+	"config":   {"config", "Show the current and pending voters", runConfig},
+}
+
+var commandOrder = []string{"get", "put", "del", "incr", "sadd", "srem", "rset", "crdt", "scan", "watch", "members", "leader", "status", "drain", "promote", "transfer", "config"}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("distrokvctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	registryAddress := flags.String("registry", envOrDefault("DISTROKV_REGISTRY", "127.0.0.1:8080"), "registry address (host:port)")
+	output := flags.String("o", "table", "output format: table, json or raw")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout for each command, watch is not affected")
+	flags.Usage = func() { printUsage(flags, stderr) }
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		printUsage(flags, stderr)
+		return exitUsage
+	}
+
+	cmd, exists := commands[flags.Arg(0)]
+	if !exists {
+		fmt.Fprintf(stderr, "unknown command %q\n", flags.Arg(0))
+		printUsage(flags, stderr)
+		return exitUsage
+	}
+	out, err := newPrinter(*output, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	setupCtx, cancel := context.WithTimeout(ctx, *timeout)
+	kvClient, err := client.New(setupCtx, client.Config{
+		RegistryAddress: *registryAddress,
+		RequestTimeout:  *timeout,
+	})
+	cancel()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to connect to %s: %v\n", *registryAddress, err)
+		return exitError
+	}
+	defer kvClient.Close()
+
+	commandCtx := ctx
+	if flags.Arg(0) != "watch" {
+		var cancel context.CancelFunc
+		commandCtx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	err = cmd.run(commandCtx, kvClient, flags.Args()[1:], out)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%v\nusage: distrokvctl %s\n", err, cmd.usage)
+		return exitUsage
+	case errors.Is(err, client.ErrNotFound):
+		fmt.Fprintln(stderr, err)
+		return exitNotFound
+	default:
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+}
+
+func printUsage(flags *flag.FlagSet, stderr io.Writer) {
+	fmt.Fprintln(stderr, "usage: distrokvctl [flags] <command> [args]")
+	fmt.Fprintln(stderr, "\ncommands:")
+	for _, name := range commandOrder {
+		fmt.Fprintf(stderr, "  %-32s %s\n", commands[name].usage, commands[name].description)
+	}
+	fmt.Fprintln(stderr, "\nflags:")
+	flags.PrintDefaults()
+}
+
+func envOrDefault(name string, fallback string) string {
+	if value, exists := os.LookupEnv(name); exists && value != "" {
+		return value
+	}
+	return fallback
+}
+
+type usageError struct {
+	message string
+}
+
+func (err usageError) Error() string {
+	return err.message
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Vahsek/distrokv/pkg/client"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatRaw   = "raw"
+)
+
+// printer renders command results as an aligned table, JSON or raw values
+// suitable for piping into other tools
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+func newPrinter(format string, out io.Writer) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatRaw:
+		return &printer{format: format, out: out}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, expected table, json or raw", format)
+}
+
+type jsonKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (p *printer) keyValues(keyValues []client.KeyValue, single bool) error {
+	switch p.format {
+	case formatJSON:
+		encoded := make([]jsonKeyValue, 0, len(keyValues))
+		for _, keyValue := range keyValues {
+			encoded = append(encoded, jsonKeyValue{Key: keyValue.Key, Value: keyValue.Value})
+		}
+		if single {
+			return p.json(encoded[0])
+		}
+		return p.json(encoded)
+	case formatRaw:
+		for _, keyValue := range keyValues {
+			if single {
+				fmt.Fprintln(p.out, keyValue.Value)
+			} else {
+				fmt.Fprintf(p.out, "%s\t%s\n", keyValue.Key, keyValue.Value)
+			}
+		}
+		return nil
+	}
+
+	rows := make([][]string, 0, len(keyValues))
+	for _, keyValue := range keyValues {
+		rows = append(rows, []string{keyValue.Key, keyValue.Value})
+	}
+	return p.table([]string{"KEY", "VALUE"}, rows)
+}
+
+// siblings prints every concurrent value of key. Raw output puts the context
+// on the first line and one value per line after it.
+func (p *printer) siblings(key string, siblings *client.Siblings) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(struct {
+			Key     string   `json:"key"`
+			Values  []string `json:"values"`
+			Context string   `json:"context"`
+		}{key, siblings.Values, siblings.Context})
+	case formatRaw:
+		fmt.Fprintln(p.out, siblings.Context)
+		for _, value := range siblings.Values {
+			fmt.Fprintln(p.out, value)
+		}
+		return nil
+	}
+
+	rows := make([][]string, 0, len(siblings.Values))
+	for _, value := range siblings.Values {
+		rows = append(rows, []string{key, value})
+	}
+	if err := p.table([]string{"KEY", "VALUE"}, rows); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(p.out, "\nContext: %s\n", siblings.Context)
+	return err
+}
+
+// crdt prints the value of a CRDT key, raw output is the counter, one set
+// element per line or the register value
+func (p *printer) crdt(key string, value *client.CRDT) error {
+	var typeName, rendered string
+	switch value.Type {
+	case client.CRDTCounter:
+		typeName, rendered = "counter", strconv.FormatInt(value.Counter, 10)
+	case client.CRDTSet:
+		typeName, rendered = "set", strings.Join(value.Elements, ", ")
+	case client.CRDTRegister:
+		typeName, rendered = "register", value.Register
+	}
+
+	switch p.format {
+	case formatJSON:
+		encoded := map[string]any{"key": key, "type": typeName}
+		switch value.Type {
+		case client.CRDTCounter:
+			encoded["counter"] = value.Counter
+		case client.CRDTSet:
+			encoded["elements"] = value.Elements
+		case client.CRDTRegister:
+			encoded["register"] = value.Register
+		}
+		return p.json(encoded)
+	case formatRaw:
+		if value.Type == client.CRDTSet {
+			for _, element := range value.Elements {
+				fmt.Fprintln(p.out, element)
+			}
+			return nil
+		}
+		_, err := fmt.Fprintln(p.out, rendered)
+		return err
+	}
+	return p.table([]string{"KEY", "TYPE", "VALUE"}, [][]string{{key, typeName, rendered}})
+}
+
+func (p *printer) result(operation string, key string) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(map[string]string{"operation": operation, "key": key, "result": "ok"})
+	case formatRaw:
+		_, err := fmt.Fprintln(p.out, "OK")
+		return err
+	}
+	return p.table([]string{"OPERATION", "KEY", "RESULT"}, [][]string{{operation, key, "OK"}})
+}
+
+func (p *printer) watchEvent(event client.WatchEvent) error {
+	eventType := "PUT"
+	if event.Type == client.EventDelete {
+		eventType = "DELETE"
+	}
+
+	switch p.format {
+	case formatJSON:
+		return p.json(map[string]string{"type": eventType, "key": event.Key, "value": event.Value})
+	case formatRaw:
+		_, err := fmt.Fprintf(p.out, "%s\t%s\t%s\n", eventType, event.Key, event.Value)
+		return err
+	}
+	_, err := fmt.Fprintf(p.out, "%-6s %s %s\n", eventType, event.Key, event.Value)
+	return err
+}
+
+type jsonMember struct {
+	ID          string `json:"id"`
+	Hostname    string `json:"hostname"`
+	IP          string `json:"ip"`
+	ControlPort string `json:"controlPort"`
+	DataPort    string `json:"dataPort"`
+	Leader      bool   `json:"leader"`
+	Draining    bool   `json:"draining"`
+	Learner     bool   `json:"learner"`
+	Health      string `json:"health"`
+	// Anti-entropy counts since the member started
+	DivergentRanges int64 `json:"divergentRanges"`
+	RepairedKeys    int64 `json:"repairedKeys"`
+}
+
+func toJSONMember(member client.Member) jsonMember {
+	return jsonMember{
+		ID:          member.ID,
+		Hostname:    member.Hostname,
+		IP:          member.IP,
+		ControlPort: member.ControlPort,
+		DataPort:    member.DataPort,
+		Leader:      member.Leader,
+		Draining:    member.Draining,
+		Learner:     member.Learner,
+		Health:      member.Health,
+
+		DivergentRanges: member.DivergentRanges,
+		RepairedKeys:    member.RepairedKeys,
+	}
+}
+
+func (p *printer) members(members []client.Member) error {
+	switch p.format {
+	case formatJSON:
+		encoded := make([]jsonMember, 0, len(members))
+		for _, member := range members {
+			encoded = append(encoded, toJSONMember(member))
+		}
+		return p.json(encoded)
+	case formatRaw:
+		for _, member := range members {
+			fmt.Fprintln(p.out, memberAddress(member))
+		}
+		return nil
+	}
+	return p.table([]string{"ID", "HOSTNAME", "IP", "CONTROL PORT", "DATA PORT", "ROLE", "HEALTH"}, memberRows(members))
+}
+
+func (p *printer) leader(leader client.Member) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(toJSONMember(leader))
+	case formatRaw:
+		_, err := fmt.Fprintln(p.out, memberAddress(leader))
+		return err
+	}
+	return p.table([]string{"ID", "HOSTNAME", "IP", "CONTROL PORT", "DATA PORT", "ROLE", "HEALTH"}, memberRows([]client.Member{leader}))
+}
+
+type jsonRange struct {
+	ID         int64  `json:"id"`
+	StartKey   string `json:"startKey"`
+	EndKey     string `json:"endKey"`
+	Generation int64  `json:"generation"`
+}
+
+func (p *printer) status(status clusterStatus) error {
+	switch p.format {
+	case formatJSON:
+		encoded := struct {
+			Members []jsonMember `json:"members"`
+			Leader  *jsonMember  `json:"leader"`
+			Ranges  []jsonRange  `json:"ranges"`
+		}{}
+		for _, member := range status.Members {
+			encoded.Members = append(encoded.Members, toJSONMember(member))
+		}
+		if status.Leader != nil {
+			leader := toJSONMember(*status.Leader)
+			encoded.Leader = &leader
+		}
+		for _, clusterRange := range status.Ranges {
+			encoded.Ranges = append(encoded.Ranges, jsonRange(clusterRange))
+		}
+		return p.json(encoded)
+	case formatRaw:
+		leader := ""
+		if status.Leader != nil {
+			leader = memberAddress(*status.Leader)
+		}
+		divergentRanges, repairedKeys := status.antiEntropy()
+		_, err := fmt.Fprintf(p.out, "members=%d leader=%s ranges=%d divergentRanges=%d repairedKeys=%d\n", len(status.Members), leader, len(status.Ranges), divergentRanges, repairedKeys)
+		return err
+	}
+
+	leader := "<none>"
+	if status.Leader != nil {
+		leader = fmt.Sprintf("%s (%s)", status.Leader.Hostname, memberAddress(*status.Leader))
+	}
+	divergentRanges, repairedKeys := status.antiEntropy()
+	fmt.Fprintf(p.out, "Leader:  %s\nMembers: %d\nRanges:  %d\nRepairs: %d divergent ranges, %d keys repaired\n\n", leader, len(status.Members), len(status.Ranges), divergentRanges, repairedKeys)
+	if err := p.table([]string{"ID", "HOSTNAME", "IP", "CONTROL PORT", "DATA PORT", "ROLE", "HEALTH"}, memberRows(status.Members)); err != nil {
+		return err
+	}
+	fmt.Fprintln(p.out)
+
+	rows := make([][]string, 0, len(status.Ranges))
+	for _, clusterRange := range status.Ranges {
+		endKey := "<end>"
+		if clusterRange.EndKey != "" {
+			endKey = strconv.Quote(clusterRange.EndKey)
+		}
+		rows = append(rows, []string{
+			strconv.FormatInt(clusterRange.ID, 10),
+			strconv.Quote(clusterRange.StartKey),
+			endKey,
+			strconv.FormatInt(clusterRange.Generation, 10),
+		})
+	}
+	return p.table([]string{"RANGE", "START", "END", "GENERATION"}, rows)
+}
+
+type jsonConfiguration struct {
+	Version      int64    `json:"version"`
+	Voters       []string `json:"voters"`
+	NewVoters    []string `json:"newVoters"`
+	Joint        bool     `json:"joint"`
+	Acknowledged []string `json:"acknowledged"`
+}
+
+func (p *printer) configuration(configuration client.Configuration, members []client.Member) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(jsonConfiguration(configuration))
+	case formatRaw:
+		_, err := fmt.Fprintf(p.out, "version=%d joint=%t voters=%s newVoters=%s\n",
+			configuration.Version,
+			configuration.Joint,
+			strings.Join(configuration.Voters, ","),
+			strings.Join(configuration.NewVoters, ","))
+		return err
+	}
+
+	state := "committed"
+	if configuration.Joint {
+		state = "joint"
+	}
+	fmt.Fprintf(p.out, "Version: %d (%s)\n\n", configuration.Version, state)
+
+	hostnames := make(map[string]string, len(members))
+	for _, member := range members {
+		hostnames[member.ID] = member.Hostname
+	}
+	nodeIDs := append([]string{}, configuration.Voters...)
+	for _, nodeID := range configuration.NewVoters {
+		if !slices.Contains(nodeIDs, nodeID) {
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	rows := make([][]string, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		hostname, exists := hostnames[nodeID]
+		if !exists {
+			hostname = "<gone>"
+		}
+		pending := "-"
+		if configuration.Joint {
+			pending = yesNo(slices.Contains(configuration.NewVoters, nodeID))
+		}
+		rows = append(rows, []string{
+			nodeID,
+			hostname,
+			yesNo(slices.Contains(configuration.Voters, nodeID)),
+			pending,
+			yesNo(slices.Contains(configuration.Acknowledged, nodeID)),
+		})
+	}
+	return p.table([]string{"ID", "HOSTNAME", "VOTER", "PENDING VOTER", "ACKNOWLEDGED"}, rows)
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func memberRows(members []client.Member) [][]string {
+	rows := make([][]string, 0, len(members))
+	for _, member := range members {
+		role := "follower"
+		if member.Leader {
+			role = "leader"
+		} else if member.Draining {
+			role = "draining"
+		} else if member.Learner {
+			role = "learner"
+		}
+		rows = append(rows, []string{member.ID, member.Hostname, member.IP, member.ControlPort, member.DataPort, role, member.Health})
+	}
+	return rows
+}
+
+func (p *printer) table(header []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	writeRow(writer, header)
+	for _, row := range rows {
+		writeRow(writer, row)
+	}
+	return writer.Flush()
+}
+
+func writeRow(writer io.Writer, row []string) {
+	for index, column := range row {
+		if index > 0 {
+			fmt.Fprint(writer, "\t")
+		}
+		fmt.Fprint(writer, column)
+	}
+	fmt.Fprintln(writer)
+}
+
+func (p *printer) json(value any) error {
+	encoder := json.NewEncoder(p.out)
+	return encoder.Encode(value)
+}
@@ -0,0 +1,79 @@
+// Command node runs a distrokv worker node.
+//
+// Settings are read from flags, DISTROKV_* environment variables and an
+// optional YAML file passed with -config; run with -h for the full list.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Vahsek/distrokv/internal/config"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	node_service "github.com/Vahsek/distrokv/internal/worker_node/service"
+)
+
+func main() {
+	nodeConfig, err := config.LoadNodeConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid node configuration:", err)
+		os.Exit(2)
+	}
+
+	logger, err := nodeConfig.Log.NewLogger()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	nodeID, err := data.LoadOrCreateNodeID(nodeConfig.DataDir)
+	if err != nil {
+		logger.Error("Failed to load the node id", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Starting worker node",
+		"nodeId", nodeID,
+		"hostname", nodeConfig.Hostname,
+		"ip", nodeConfig.IP,
+		"controlPort", nodeConfig.ControlPort,
+		"dataPort", nodeConfig.DataPort,
+		"role", nodeConfig.Role(),
+		"registry", nodeConfig.RegistryAddress)
+
+	workerNodeService, err := node_service.InitializeNewNodeService(node_service.Config{
+		NodeID:            nodeID,
+		Hostname:          nodeConfig.Hostname,
+		IP:                nodeConfig.IP,
+		ControlPort:       nodeConfig.ControlPort,
+		DataPort:          nodeConfig.DataPort,
+		NodeType:          1,
+		Role:              nodeConfig.Role(),
+		RegistryAddress:   nodeConfig.RegistryAddress,
+		HeartbeatInterval: nodeConfig.HeartbeatInterval,
+		Membership:        nodeConfig.MembershipConfig(),
+		Lease:             nodeConfig.LeaseConfig(),
+		WAL:               nodeConfig.WALConfig(),
+		AntiEntropy:       nodeConfig.AntiEntropyConfig(),
+		Hints:             nodeConfig.HintsConfig(),
+		Leaderless:        nodeConfig.LeaderlessConfig(),
+		MaxClockSkew:      nodeConfig.MaxClockSkew,
+	}, *logger)
+	if err != nil {
+		logger.Error("Failed to open the store", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := workerNodeService.BootstrapWorkerNode(ctx, nodeConfig.ShutdownTimeout); err != nil {
+		logger.Error("Worker node exited with an error", "error", err)
+		os.Exit(1)
+	}
+}
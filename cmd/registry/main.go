@@ -0,0 +1,52 @@
+// Command registry runs the distrokv registry.
+//
+// Settings are read from flags, DISTROKV_* environment variables and an
+// optional YAML file passed with -config; run with -h for the full list.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Vahsek/distrokv/internal/config"
+	registry "github.com/Vahsek/distrokv/internal/registry"
+)
+
+func main() {
+	registryConfig, err := config.LoadRegistryConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid registry configuration:", err)
+		os.Exit(2)
+	}
+
+	logger, err := registryConfig.Log.NewLogger()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Info("Starting registry", "listenAddress", registryConfig.ListenAddress)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = registry.StartRegistryServer(
+		ctx,
+		registryConfig.ListenAddress,
+		registryConfig.RangeThresholds(),
+		registryConfig.RangeMode(),
+		registryConfig.HealthThresholds(),
+		registryConfig.DataDir,
+		registryConfig.ShutdownTimeout,
+		*logger)
+	if err != nil {
+		logger.Error("Registry exited with an error", "error", err)
+		os.Exit(1)
+	}
+}
@@ -2,6 +2,7 @@ package clientcommon
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"sync"
 
@@ -54,3 +55,21 @@ func GetClient[T any](ctx context.Context, factory *ClientFactory, builder GrpcB
 	}
 	return clientConstructor(conn), nil
 }
+
+// Close closes every pooled connection. The factory can keep being used
+// afterwards and will dial new connections on demand.
+func (factory *ClientFactory) Close() error {
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+
+	factory.logger.Info("Closing all client connections", "count", len(factory.ConnectionMap))
+	var closeErr error
+	for target, conn := range factory.ConnectionMap {
+		if err := conn.Close(); err != nil {
+			factory.logger.Error("Error in closing client connection", "target", target)
+			closeErr = errors.Join(closeErr, err)
+		}
+		delete(factory.ConnectionMap, target)
+	}
+	return closeErr
+}
@@ -0,0 +1,149 @@
+// Package failuredetector implements the phi accrual failure detector. Rather
+// than a binary up or down it reports phi, a suspicion level derived from how
+// unlikely the current silence is given the heartbeat inter-arrival times seen
+// so far, so callers can pick how eagerly they act on it.
+package failuredetector
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// MaxSampleSize is the number of inter-arrival times remembered
+	MaxSampleSize int
+	// MinStdDeviation keeps very regular heartbeats from making phi jump on
+	// the slightest delay
+	MinStdDeviation time.Duration
+	// AcceptableHeartbeatPause is added to the mean interval so pauses such
+	// as garbage collection up to this long barely raise phi
+	AcceptableHeartbeatPause time.Duration
+	// FirstHeartbeatEstimate seeds the history of a newly monitored endpoint
+	FirstHeartbeatEstimate time.Duration
+}
+
+func DefaultConfig(heartbeatInterval time.Duration) Config {
+	return Config{
+		MaxSampleSize:            200,
+		MinStdDeviation:          heartbeatInterval / 10,
+		AcceptableHeartbeatPause: heartbeatInterval,
+		FirstHeartbeatEstimate:   heartbeatInterval,
+	}
+}
+
+type heartbeatHistory struct {
+	intervals     []float64
+	next          int
+	sum           float64
+	squaredSum    float64
+	lastHeartbeat time.Time
+}
+
+func (history *heartbeatHistory) add(interval float64, maxSampleSize int) {
+	if len(history.intervals) < maxSampleSize {
+		history.intervals = append(history.intervals, interval)
+	} else {
+		dropped := history.intervals[history.next]
+		history.sum -= dropped
+		history.squaredSum -= dropped * dropped
+		history.intervals[history.next] = interval
+		history.next = (history.next + 1) % maxSampleSize
+	}
+	history.sum += interval
+	history.squaredSum += interval * interval
+}
+
+func (history *heartbeatHistory) mean() float64 {
+	return history.sum / float64(len(history.intervals))
+}
+
+func (history *heartbeatHistory) stdDeviation() float64 {
+	mean := history.mean()
+	variance := history.squaredSum/float64(len(history.intervals)) - mean*mean
+	return math.Sqrt(math.Max(variance, 0))
+}
+
+// Detector tracks the heartbeats of any number of endpoints
+type Detector struct {
+	config    Config
+	histories map[string]*heartbeatHistory
+	mu        sync.Mutex
+}
+
+func NewDetector(config Config) *Detector {
+	return &Detector{
+		config:    config,
+		histories: make(map[string]*heartbeatHistory),
+	}
+}
+
+func (detector *Detector) Heartbeat(endpoint string) {
+	detector.HeartbeatAt(endpoint, time.Now())
+}
+
+func (detector *Detector) HeartbeatAt(endpoint string, now time.Time) {
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+
+	history, exists := detector.histories[endpoint]
+	if !exists {
+		// Two samples around the estimate give the first heartbeat a
+		// plausible mean and deviation
+		estimate := float64(detector.config.FirstHeartbeatEstimate.Milliseconds())
+		deviation := estimate / 4
+		history = &heartbeatHistory{}
+		history.add(estimate-deviation, detector.config.MaxSampleSize)
+		history.add(estimate+deviation, detector.config.MaxSampleSize)
+		history.lastHeartbeat = now
+		detector.histories[endpoint] = history
+		return
+	}
+
+	interval := float64(now.Sub(history.lastHeartbeat).Milliseconds())
+	if interval < 0 {
+		return
+	}
+	history.add(interval, detector.config.MaxSampleSize)
+	history.lastHeartbeat = now
+}
+
+func (detector *Detector) Phi(endpoint string) float64 {
+	return detector.PhiAt(endpoint, time.Now())
+}
+
+// PhiAt returns the suspicion level of endpoint at now. A phi of 1 means a
+// 10% chance the endpoint is still alive and is merely late, 2 means 1%, 3
+// means 0.1% and so on. Endpoints without heartbeats have a phi of 0.
+func (detector *Detector) PhiAt(endpoint string, now time.Time) float64 {
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+
+	history, exists := detector.histories[endpoint]
+	if !exists {
+		return 0
+	}
+	elapsed := float64(now.Sub(history.lastHeartbeat).Milliseconds())
+	mean := history.mean() + float64(detector.config.AcceptableHeartbeatPause.Milliseconds())
+	stdDeviation := math.Max(history.stdDeviation(), math.Max(float64(detector.config.MinStdDeviation.Milliseconds()), 1))
+	return phi(elapsed, mean, stdDeviation)
+}
+
+// Remove forgets an endpoint, a later heartbeat starts a fresh history
+func (detector *Detector) Remove(endpoint string) {
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+
+	delete(detector.histories, endpoint)
+}
+
+// phi uses a logistic approximation of the normal distribution's cumulative
+// distribution function, which stays accurate far into the tail
+func phi(elapsed float64, mean float64, stdDeviation float64) float64 {
+	y := (elapsed - mean) / stdDeviation
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
@@ -0,0 +1,166 @@
+package hlc
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataKey carries the sender's timestamp on requests and the server's on
+// response headers
+const MetadataKey = "distrokv-hlc"
+
+// skewReason tells a rejected skewed timestamp apart from other failed
+// preconditions
+const (
+	skewDomain = "distrokv"
+	skewReason = "CLOCK_SKEW"
+)
+
+// SkewError turns an ErrClockSkew into a FailedPrecondition status carrying
+// an ErrorInfo detail that IsSkew recognizes
+func SkewError(err error) error {
+	skewStatus, detailErr := status.New(codes.FailedPrecondition, err.Error()).WithDetails(&errdetails.ErrorInfo{
+		Domain: skewDomain,
+		Reason: skewReason,
+	})
+	if detailErr != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return skewStatus.Err()
+}
+
+// IsSkew reports whether a status was returned by SkewError
+func IsSkew(grpcStatus *status.Status) bool {
+	if grpcStatus.Code() != codes.FailedPrecondition {
+		return false
+	}
+	for _, detail := range grpcStatus.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == skewDomain && info.Reason == skewReason {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerOptions propagate the clock on every RPC a server handles. A request
+// from a node whose clock is too far ahead fails with SkewError.
+func (clock *Clock) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(clock.unaryServerInterceptor),
+		grpc.ChainStreamInterceptor(clock.streamServerInterceptor),
+	}
+}
+
+// DialOptions propagate the clock on every RPC made over a connection. The
+// clock is left alone when the server's is too far ahead, the server has
+// already handled the request by then.
+func (clock *Clock) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(clock.unaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(clock.streamClientInterceptor),
+	}
+}
+
+// Format and Parse turn a timestamp into metadata and back
+func Format(timestamp int64) string {
+	return strconv.FormatInt(timestamp, 10)
+}
+
+func Parse(value string) (int64, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (clock *Clock) unaryServerInterceptor(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := clock.receive(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	response, err := handler(ctx, request)
+	// The response leaves after everything the handler did
+	grpc.SetHeader(ctx, metadata.Pairs(MetadataKey, Format(clock.Now())))
+	return response, err
+}
+
+func (clock *Clock) streamServerInterceptor(server any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := clock.receive(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	stream.SetHeader(metadata.Pairs(MetadataKey, Format(clock.Now())))
+	return handler(server, stream)
+}
+
+// receive moves the clock past the timestamp of a request, a request without
+// one comes from a client that keeps no clock
+func (clock *Clock) receive(ctx context.Context, method string) error {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	values := incoming.Get(MetadataKey)
+	if len(values) == 0 {
+		return nil
+	}
+	timestamp, err := Parse(values[0])
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid %s metadata %q", MetadataKey, values[0])
+	}
+	if err := clock.Update(timestamp); err != nil {
+		clock.logger.Warn("Rejected a request from a node with a skewed clock", "method", method, "error", err)
+		return SkewError(err)
+	}
+	return nil
+}
+
+func (clock *Clock) unaryClientInterceptor(ctx context.Context, method string, request any, reply any, conn *grpc.ClientConn, invoker grpc.UnaryInvoker, options ...grpc.CallOption) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, Format(clock.Now()))
+	var header metadata.MD
+	err := invoker(ctx, method, request, reply, conn, append(options, grpc.Header(&header))...)
+	clock.observe(header, method)
+	return err
+}
+
+func (clock *Clock) streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string, streamer grpc.Streamer, options ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, Format(clock.Now()))
+	stream, err := streamer(ctx, desc, conn, method, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &clientStream{ClientStream: stream, clock: clock, method: method}, nil
+}
+
+// observe moves the clock past the timestamp in a response header
+func (clock *Clock) observe(header metadata.MD, method string) {
+	values := header.Get(MetadataKey)
+	if len(values) == 0 {
+		return
+	}
+	timestamp, err := Parse(values[0])
+	if err != nil {
+		return
+	}
+	if err := clock.Update(timestamp); err != nil {
+		clock.logger.Warn("Ignored the clock of a server with a skewed clock", "method", method, "error", err)
+	}
+}
+
+// clientStream reads the server's timestamp once the first message arrived,
+// reading the header earlier would block
+type clientStream struct {
+	grpc.ClientStream
+	clock  *Clock
+	method string
+	once   sync.Once
+}
+
+func (stream *clientStream) RecvMsg(message any) error {
+	err := stream.ClientStream.RecvMsg(message)
+	stream.once.Do(func() {
+		if header, headerErr := stream.Header(); headerErr == nil {
+			stream.clock.observe(header, stream.method)
+		}
+	})
+	return err
+}
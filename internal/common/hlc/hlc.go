@@ -0,0 +1,93 @@
+// Package hlc implements a hybrid logical clock. A timestamp is the wall clock
+// in nanoseconds with its low 16 bits replaced by a logical counter, so it
+// still reads as a point in time and timestamps compare as plain integers.
+// The clock never goes backwards and moves past every timestamp it receives,
+// so an event caused by another is ordered after it whatever the skew between
+// the nodes. Timestamps more than the max skew ahead of the local wall clock
+// are rejected rather than dragging the clock along.
+package hlc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSkew is how far ahead of the local wall clock a received
+// timestamp may be
+const DefaultMaxSkew = 500 * time.Millisecond
+
+const (
+	logicalBits = 16
+	logicalMask = 1<<logicalBits - 1
+)
+
+var ErrClockSkew = errors.New("Clock skew exceeds the maximum")
+
+type Clock struct {
+	mu   sync.Mutex
+	last int64
+	// maxSkew <= 0 accepts any timestamp
+	maxSkew time.Duration
+	logger  slog.Logger
+}
+
+func NewClock(maxSkew time.Duration, logger slog.Logger) *Clock {
+	return &Clock{maxSkew: maxSkew, logger: logger}
+}
+
+// Now returns a timestamp after every one the clock returned or received.
+// The logical counter carries into the wall clock bits once it runs out,
+// the clock then runs a little ahead until the wall clock catches up.
+func (clock *Clock) Now() int64 {
+	physical := physicalNow()
+
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	if physical > clock.last {
+		clock.last = physical
+	} else {
+		clock.last++
+	}
+	return clock.last
+}
+
+// Update moves the clock up to timestamp, received from another node, so the
+// next timestamp is after it. A timestamp too far ahead leaves the clock as it
+// is and returns ErrClockSkew.
+func (clock *Clock) Update(timestamp int64) error {
+	if err := CheckSkew(timestamp, clock.maxSkew); err != nil {
+		return err
+	}
+	clock.Advance(timestamp)
+	return nil
+}
+
+// Advance moves the clock up to a timestamp it issued itself, such as one
+// read back from disk after a restart, without checking the skew. The clock
+// may have been ahead of the wall clock back then and has to stay ahead.
+func (clock *Clock) Advance(timestamp int64) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.last = max(clock.last, timestamp)
+}
+
+// CheckSkew returns ErrClockSkew if timestamp is more than maxSkew ahead of
+// the local wall clock. maxSkew <= 0 accepts any timestamp.
+func CheckSkew(timestamp int64, maxSkew time.Duration) error {
+	if skew := time.Duration(timestamp&^logicalMask - physicalNow()); maxSkew > 0 && skew > maxSkew {
+		return fmt.Errorf("%w: timestamp is %s ahead of the local clock, at most %s is allowed", ErrClockSkew, skew, maxSkew)
+	}
+	return nil
+}
+
+// FromTime returns the first timestamp at t
+func FromTime(t time.Time) int64 {
+	return t.UnixNano() &^ logicalMask
+}
+
+func physicalNow() int64 {
+	return FromTime(time.Now())
+}
@@ -0,0 +1,98 @@
+package hlc
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testClock(maxSkew time.Duration) *Clock {
+	return NewClock(maxSkew, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestNowIncreases(t *testing.T) {
+	clock := testClock(DefaultMaxSkew)
+	previous := clock.Now()
+	for range 100000 {
+		next := clock.Now()
+		if next <= previous {
+			t.Fatalf("expected timestamps to increase, got %d after %d", next, previous)
+		}
+		previous = next
+	}
+}
+
+func TestUpdateOrdersLaterEventsAfterReceivedTimestamp(t *testing.T) {
+	clock := testClock(DefaultMaxSkew)
+	// A node whose clock runs a little ahead, within the allowed skew
+	received := FromTime(time.Now().Add(DefaultMaxSkew/2)) + 7
+	if err := clock.Update(received); err != nil {
+		t.Fatalf("update within the skew failed: %v", err)
+	}
+	if now := clock.Now(); now <= received {
+		t.Fatalf("expected the next timestamp to be after the received one, got %d after %d", now, received)
+	}
+
+	// An older timestamp leaves the clock where it is
+	before := clock.Now()
+	if err := clock.Update(received - 1000); err != nil {
+		t.Fatalf("update with an older timestamp failed: %v", err)
+	}
+	if now := clock.Now(); now <= before {
+		t.Fatalf("expected the clock not to go backwards, got %d after %d", now, before)
+	}
+}
+
+func TestUpdateRejectsSkewedTimestamp(t *testing.T) {
+	clock := testClock(DefaultMaxSkew)
+	skewed := FromTime(time.Now().Add(10 * DefaultMaxSkew))
+	if err := clock.Update(skewed); !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("expected ErrClockSkew, got %v", err)
+	}
+	if now := clock.Now(); now >= skewed {
+		t.Fatalf("expected a rejected timestamp to leave the clock alone, got %d for %d", now, skewed)
+	}
+
+	unchecked := testClock(0)
+	if err := unchecked.Update(skewed); err != nil {
+		t.Fatalf("expected a clock without a max skew to accept any timestamp, got %v", err)
+	}
+	if now := unchecked.Now(); now <= skewed {
+		t.Fatalf("expected the clock to move past the accepted timestamp, got %d after %d", now, skewed)
+	}
+}
+
+func TestAdvanceSkipsTheSkewCheck(t *testing.T) {
+	clock := testClock(DefaultMaxSkew)
+	ahead := FromTime(time.Now().Add(time.Hour))
+	clock.Advance(ahead)
+	if now := clock.Now(); now <= ahead {
+		t.Fatalf("expected the clock to move past its own timestamp, got %d after %d", now, ahead)
+	}
+}
+
+func TestLogicalCounterCarriesIntoWallClock(t *testing.T) {
+	clock := testClock(DefaultMaxSkew)
+	// The counter is exhausted for the current wall clock tick
+	start := FromTime(time.Now().Add(time.Minute)) | logicalMask
+	clock.Advance(start)
+	if now := clock.Now(); now != start+1 || now&logicalMask != 0 {
+		t.Fatalf("expected the counter to carry into the next tick, got %d after %d", now, start)
+	}
+}
+
+func TestSkewErrorIsRecognized(t *testing.T) {
+	err := SkewError(CheckSkew(FromTime(time.Now().Add(time.Hour)), DefaultMaxSkew))
+	skewStatus := status.Convert(err)
+	if skewStatus.Code() != codes.FailedPrecondition || !IsSkew(skewStatus) {
+		t.Fatalf("expected a FailedPrecondition recognized as skew, got %v", err)
+	}
+	if IsSkew(status.New(codes.FailedPrecondition, ErrClockSkew.Error())) {
+		t.Fatalf("expected other failed preconditions not to be taken for skew")
+	}
+}
@@ -5,16 +5,35 @@ const (
 	workerNode
 )
 
+// NodeRole decides whether a node counts toward quorum. Learners receive every
+// write but never vote and are never picked as primary.
+type NodeRole int
+
+const (
+	RoleVoter NodeRole = iota
+	RoleLearner
+)
+
+func (role NodeRole) String() string {
+	if role == RoleLearner {
+		return "learner"
+	}
+	return "voter"
+}
+
 type Node struct {
+	NodeID          string
 	NodeHostname    string
 	NodeIP          string
 	NodeControlPort string
 	NodeDataPort    string
 	NodeType        int
+	Role            NodeRole
 }
 
-func InitializeNode(hostname, IP, nodeCPNumber, nodeDPNumber string, nodeType int) *Node {
+func InitializeNode(nodeID, hostname, IP, nodeCPNumber, nodeDPNumber string, nodeType int) *Node {
 	return &Node{
+		NodeID:          nodeID,
 		NodeHostname:    hostname,
 		NodeIP:          IP,
 		NodeControlPort: nodeCPNumber,
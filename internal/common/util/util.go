@@ -1,8 +1,10 @@
 package util
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 )
 
 func GenerateHash(plaintext string) string {
@@ -10,3 +12,14 @@ func GenerateHash(plaintext string) string {
 	hasher.Write([]byte(plaintext))
 	return hex.EncodeToString(hasher.Sum(nil))
 }
+
+// GenerateUUID returns a random version 4 UUID
+func GenerateUUID() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
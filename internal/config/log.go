@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	logging "github.com/Vahsek/distrokv/internal/logging"
+)
+
+// LogConfig controls the rotating log files. Logs always go to stdout and are
+// also written to Directory when it is set.
+type LogConfig struct {
+	Directory     string `yaml:"directory"`
+	FilePrefix    string `yaml:"filePrefix"`
+	FileExtension string `yaml:"fileExtension"`
+	MaxFileSize   int64  `yaml:"maxFileSize"`
+	MaxFileCount  int32  `yaml:"maxFileCount"`
+}
+
+func defaultLogConfig() LogConfig {
+	return LogConfig{
+		Directory:     "",
+		FilePrefix:    "logfile",
+		FileExtension: ".log",
+		MaxFileSize:   10 * 1024 * 1024,
+		MaxFileCount:  10,
+	}
+}
+
+func (logConfig *LogConfig) options() []option {
+	return []option{
+		{"log-dir", "DISTROKV_LOG_DIR", "directory for rotating log files, empty logs to stdout only", stringValue{&logConfig.Directory}},
+		{"log-max-file-size", "DISTROKV_LOG_MAX_FILE_SIZE", "size in bytes at which a log file is rotated", int64Value{&logConfig.MaxFileSize}},
+		{"log-max-files", "DISTROKV_LOG_MAX_FILES", "number of log files kept", int32Value{&logConfig.MaxFileCount}},
+	}
+}
+
+func (logConfig *LogConfig) validate() error {
+	if logConfig.Directory == "" {
+		return nil
+	}
+	if logConfig.FilePrefix == "" {
+		return fmt.Errorf("log file prefix must not be empty")
+	}
+	if logConfig.MaxFileSize <= 0 {
+		return fmt.Errorf("log max file size must be positive, got %d", logConfig.MaxFileSize)
+	}
+	if logConfig.MaxFileCount <= 0 {
+		return fmt.Errorf("log max file count must be positive, got %d", logConfig.MaxFileCount)
+	}
+	return nil
+}
+
+// NewLogger builds the process logger, writing to stdout and, when a log
+// directory is configured, to rotating files in it
+func (logConfig *LogConfig) NewLogger() (*slog.Logger, error) {
+	if logConfig.Directory == "" {
+		return logging.GetLogger(os.Stdout), nil
+	}
+	if err := os.MkdirAll(logConfig.Directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	fileLoggerProvider := logging.NewFileLoggerProvider(
+		logConfig.FilePrefix,
+		logConfig.FileExtension,
+		logConfig.Directory,
+		logConfig.MaxFileSize,
+		logConfig.MaxFileCount)
+	return logging.GetLogger(fileLoggerProvider, os.Stdout), nil
+}
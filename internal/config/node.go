@@ -0,0 +1,326 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/antientropy"
+	"github.com/Vahsek/distrokv/internal/worker_node/clients"
+	"github.com/Vahsek/distrokv/internal/worker_node/hints"
+	"github.com/Vahsek/distrokv/internal/worker_node/leaderless"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	"github.com/Vahsek/distrokv/internal/worker_node/membership"
+)
+
+type NodeConfig struct {
+	Hostname          string            `yaml:"hostname"`
+	IP                string            `yaml:"ip"`
+	ControlPort       string            `yaml:"controlPort"`
+	DataPort          string            `yaml:"dataPort"`
+	RegistryAddress   string            `yaml:"registryAddress"`
+	HeartbeatInterval time.Duration     `yaml:"heartbeatInterval"`
+	DataDir           string            `yaml:"dataDir"`
+	ShutdownTimeout   time.Duration     `yaml:"shutdownTimeout"`
+	MaxClockSkew      time.Duration     `yaml:"maxClockSkew"`
+	Learner           bool              `yaml:"learner"`
+	Gossip            GossipConfig      `yaml:"gossip"`
+	Lease             LeaseConfig       `yaml:"lease"`
+	WAL               WALConfig         `yaml:"wal"`
+	AntiEntropy       AntiEntropyConfig `yaml:"antiEntropy"`
+	Hints             HintsConfig       `yaml:"hints"`
+	Leaderless        LeaderlessConfig  `yaml:"leaderless"`
+	Log               LogConfig         `yaml:"log"`
+}
+
+type LeaseConfig struct {
+	Duration      time.Duration `yaml:"duration"`
+	MaxClockDrift float64       `yaml:"maxClockDrift"`
+}
+
+type WALConfig struct {
+	GroupCommitWindow  time.Duration `yaml:"groupCommitWindow"`
+	GroupCommitBytes   int64         `yaml:"groupCommitBytes"`
+	CompactBytes       int64         `yaml:"compactBytes"`
+	TombstoneRetention time.Duration `yaml:"tombstoneRetention"`
+}
+
+type AntiEntropyConfig struct {
+	Interval time.Duration `yaml:"interval"`
+}
+
+type HintsConfig struct {
+	MaxBytesPerPeer int64 `yaml:"maxBytesPerPeer"`
+}
+
+type LeaderlessConfig struct {
+	Enabled      bool  `yaml:"enabled"`
+	Replicas     int32 `yaml:"replicas"`
+	ReadQuorum   int32 `yaml:"readQuorum"`
+	WriteQuorum  int32 `yaml:"writeQuorum"`
+	VectorClocks bool  `yaml:"vectorClocks"`
+}
+
+type GossipConfig struct {
+	SuspectPhi               float64       `yaml:"suspectPhi"`
+	AcceptableHeartbeatPause time.Duration `yaml:"acceptableHeartbeatPause"`
+}
+
+// defaultShutdownTimeout bounds how long in flight requests may take to finish
+// once a binary is asked to stop
+const defaultShutdownTimeout = 30 * time.Second
+
+func DefaultNodeConfig() NodeConfig {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	gossip := membership.DefaultConfig()
+	readLease := lease.DefaultConfig()
+	return NodeConfig{
+		Hostname:          hostname,
+		IP:                "127.0.0.1",
+		ControlPort:       "8002",
+		DataPort:          "9002",
+		RegistryAddress:   "127.0.0.1:8080",
+		HeartbeatInterval: clients.DefaultHeartbeatInterval,
+		DataDir:           "node-data",
+		ShutdownTimeout:   defaultShutdownTimeout,
+		MaxClockSkew:      hlc.DefaultMaxSkew,
+		Gossip: GossipConfig{
+			SuspectPhi:               gossip.SuspectPhi,
+			AcceptableHeartbeatPause: gossip.AcceptableHeartbeatPause,
+		},
+		Lease: LeaseConfig{
+			Duration:      readLease.Duration,
+			MaxClockDrift: readLease.MaxClockDrift,
+		},
+		AntiEntropy: AntiEntropyConfig{
+			Interval: antientropy.DefaultInterval,
+		},
+		WAL: WALConfig{
+			GroupCommitWindow:  storage.DefaultGroupCommitWindow,
+			GroupCommitBytes:   storage.DefaultGroupCommitBytes,
+			CompactBytes:       storage.DefaultCompactBytes,
+			TombstoneRetention: storage.DefaultTombstoneRetention,
+		},
+		Hints: HintsConfig{
+			MaxBytesPerPeer: hints.DefaultMaxBytesPerPeer,
+		},
+		Leaderless: LeaderlessConfig{
+			Replicas:    leaderless.DefaultReplicas,
+			ReadQuorum:  leaderless.DefaultReadQuorum,
+			WriteQuorum: leaderless.DefaultWriteQuorum,
+		},
+		Log: defaultLogConfig(),
+	}
+}
+
+// LoadNodeConfig resolves the worker node configuration from args, the
+// environment and the config file and validates the result
+func LoadNodeConfig(args []string) (*NodeConfig, error) {
+	nodeConfig := DefaultNodeConfig()
+	options := []option{
+		{"hostname", "DISTROKV_HOSTNAME", "hostname the node registers with", stringValue{&nodeConfig.Hostname}},
+		{"ip", "DISTROKV_IP", "IP address peers and clients reach the node on", stringValue{&nodeConfig.IP}},
+		{"control-port", "DISTROKV_CONTROL_PORT", "port of the control plane server", stringValue{&nodeConfig.ControlPort}},
+		{"data-port", "DISTROKV_DATA_PORT", "port of the data plane server", stringValue{&nodeConfig.DataPort}},
+		{"registry", "DISTROKV_REGISTRY", "registry address (host:port)", stringValue{&nodeConfig.RegistryAddress}},
+		{"data-dir", "DISTROKV_DATA_DIR", "directory the node id, the write ahead log and the hints are kept in", stringValue{&nodeConfig.DataDir}},
+		{"shutdown-timeout", "DISTROKV_SHUTDOWN_TIMEOUT", "time in flight requests get to finish on shutdown", durationValue{&nodeConfig.ShutdownTimeout}},
+		{"max-clock-skew", "DISTROKV_MAX_CLOCK_SKEW", "how far ahead of the local clock a peer's hybrid logical clock may be before its requests are rejected, 0 accepts any", durationValue{&nodeConfig.MaxClockSkew}},
+		{"learner", "DISTROKV_LEARNER", "join as a learner that does not count toward quorum until promoted", boolValue{&nodeConfig.Learner}},
+		{"heartbeat-interval", "DISTROKV_HEARTBEAT_INTERVAL", "how often the node reports to the registry, must match the registry's heartbeat-interval", durationValue{&nodeConfig.HeartbeatInterval}},
+		{"suspect-phi", "DISTROKV_SUSPECT_PHI", "phi a peer that failed a probe must reach before it is suspected", float64Value{&nodeConfig.Gossip.SuspectPhi}},
+		{"acceptable-heartbeat-pause", "DISTROKV_ACCEPTABLE_HEARTBEAT_PAUSE", "silence from a peer tolerated before phi starts to climb", durationValue{&nodeConfig.Gossip.AcceptableHeartbeatPause}},
+		{"lease-duration", "DISTROKV_LEASE_DURATION", "how long a read lease granted to the primary lasts", durationValue{&nodeConfig.Lease.Duration}},
+		{"max-clock-drift", "DISTROKV_MAX_CLOCK_DRIFT", "bound on the relative clock drift between nodes, 0.01 is 1%", float64Value{&nodeConfig.Lease.MaxClockDrift}},
+		{"wal-group-commit-window", "DISTROKV_WAL_GROUP_COMMIT_WINDOW", "how long a write waits for others to share its wal fsync", durationValue{&nodeConfig.WAL.GroupCommitWindow}},
+		{"wal-group-commit-bytes", "DISTROKV_WAL_GROUP_COMMIT_BYTES", "buffered wal bytes that trigger an fsync before the window ends", int64Value{&nodeConfig.WAL.GroupCommitBytes}},
+		{"wal-compact-bytes", "DISTROKV_WAL_COMPACT_BYTES", "wal size that triggers a rewrite with just the live keys, 0 only rewrites it on start", int64Value{&nodeConfig.WAL.CompactBytes}},
+		{"wal-tombstone-retention", "DISTROKV_WAL_TOMBSTONE_RETENTION", "how long compaction keeps the tombstones of deleted keys", durationValue{&nodeConfig.WAL.TombstoneRetention}},
+		{"anti-entropy-interval", "DISTROKV_ANTI_ENTROPY_INTERVAL", "how often the primary compares the Merkle trees of its peers and repairs them", durationValue{&nodeConfig.AntiEntropy.Interval}},
+		{"hint-max-bytes", "DISTROKV_HINT_MAX_BYTES", "bytes of missed writes kept for an unreachable peer before it is left to a snapshot", int64Value{&nodeConfig.Hints.MaxBytesPerPeer}},
+		{"leaderless", "DISTROKV_LEADERLESS", "replicate keys over a hash ring with read and write quorums instead of through a primary, all nodes must agree", boolValue{&nodeConfig.Leaderless.Enabled}},
+		{"replicas", "DISTROKV_REPLICAS", "number of nodes every key is stored on in leaderless mode (N)", int32Value{&nodeConfig.Leaderless.Replicas}},
+		{"read-quorum", "DISTROKV_READ_QUORUM", "replicas a leaderless read waits for unless the request asks otherwise (R)", int32Value{&nodeConfig.Leaderless.ReadQuorum}},
+		{"write-quorum", "DISTROKV_WRITE_QUORUM", "replicas that must acknowledge a leaderless write unless the request asks otherwise (W)", int32Value{&nodeConfig.Leaderless.WriteQuorum}},
+		{"vector-clocks", "DISTROKV_VECTOR_CLOCKS", "keep concurrent leaderless writes as siblings instead of letting the last writer win", boolValue{&nodeConfig.Leaderless.VectorClocks}},
+	}
+	options = append(options, nodeConfig.Log.options()...)
+
+	reset := func() { nodeConfig = DefaultNodeConfig() }
+	if err := load("node", args, &nodeConfig, reset, options); err != nil {
+		return nil, err
+	}
+	if err := nodeConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return &nodeConfig, nil
+}
+
+func (nodeConfig *NodeConfig) Validate() error {
+	var errs []error
+	if nodeConfig.Hostname == "" {
+		errs = append(errs, fmt.Errorf("hostname must not be empty"))
+	}
+	if net.ParseIP(nodeConfig.IP) == nil {
+		errs = append(errs, fmt.Errorf("invalid ip %q", nodeConfig.IP))
+	}
+	if err := validatePort(nodeConfig.ControlPort); err != nil {
+		errs = append(errs, fmt.Errorf("control port: %w", err))
+	}
+	if err := validatePort(nodeConfig.DataPort); err != nil {
+		errs = append(errs, fmt.Errorf("data port: %w", err))
+	}
+	if nodeConfig.ControlPort == nodeConfig.DataPort {
+		errs = append(errs, fmt.Errorf("control and data port must differ, both are %s", nodeConfig.ControlPort))
+	}
+	if err := validateAddress(nodeConfig.RegistryAddress); err != nil {
+		errs = append(errs, fmt.Errorf("registry address: %w", err))
+	}
+	if nodeConfig.DataDir == "" {
+		errs = append(errs, fmt.Errorf("data dir must not be empty"))
+	}
+	if nodeConfig.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must be positive, got %s", nodeConfig.ShutdownTimeout))
+	}
+	if nodeConfig.MaxClockSkew < 0 {
+		errs = append(errs, fmt.Errorf("max clock skew must not be negative, got %s", nodeConfig.MaxClockSkew))
+	}
+	if nodeConfig.HeartbeatInterval <= 0 {
+		errs = append(errs, fmt.Errorf("heartbeat interval must be positive, got %s", nodeConfig.HeartbeatInterval))
+	}
+	if nodeConfig.Gossip.SuspectPhi <= 0 {
+		errs = append(errs, fmt.Errorf("suspect phi must be positive, got %g", nodeConfig.Gossip.SuspectPhi))
+	}
+	if nodeConfig.Gossip.AcceptableHeartbeatPause < 0 {
+		errs = append(errs, fmt.Errorf("acceptable heartbeat pause must not be negative, got %s", nodeConfig.Gossip.AcceptableHeartbeatPause))
+	}
+	if nodeConfig.Lease.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("lease duration must be positive, got %s", nodeConfig.Lease.Duration))
+	}
+	if nodeConfig.Lease.MaxClockDrift < 0 || nodeConfig.Lease.MaxClockDrift >= 1 {
+		errs = append(errs, fmt.Errorf("max clock drift must be between 0 and 1, got %g", nodeConfig.Lease.MaxClockDrift))
+	}
+	if nodeConfig.AntiEntropy.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("anti-entropy interval must be positive, got %s", nodeConfig.AntiEntropy.Interval))
+	}
+	if nodeConfig.WAL.GroupCommitWindow < 0 {
+		errs = append(errs, fmt.Errorf("wal group commit window must not be negative, got %s", nodeConfig.WAL.GroupCommitWindow))
+	}
+	if nodeConfig.WAL.GroupCommitBytes <= 0 {
+		errs = append(errs, fmt.Errorf("wal group commit bytes must be positive, got %d", nodeConfig.WAL.GroupCommitBytes))
+	}
+	if nodeConfig.WAL.CompactBytes < 0 {
+		errs = append(errs, fmt.Errorf("wal compact bytes must not be negative, got %d", nodeConfig.WAL.CompactBytes))
+	}
+	if nodeConfig.WAL.TombstoneRetention <= 0 {
+		errs = append(errs, fmt.Errorf("wal tombstone retention must be positive, got %s", nodeConfig.WAL.TombstoneRetention))
+	}
+	if nodeConfig.Hints.MaxBytesPerPeer <= 0 {
+		errs = append(errs, fmt.Errorf("hint max bytes must be positive, got %d", nodeConfig.Hints.MaxBytesPerPeer))
+	}
+	if nodeConfig.Leaderless.Replicas < 1 {
+		errs = append(errs, fmt.Errorf("replicas must be at least 1, got %d", nodeConfig.Leaderless.Replicas))
+	}
+	if nodeConfig.Leaderless.ReadQuorum < 1 || nodeConfig.Leaderless.ReadQuorum > nodeConfig.Leaderless.Replicas {
+		errs = append(errs, fmt.Errorf("read quorum must be between 1 and replicas (%d), got %d", nodeConfig.Leaderless.Replicas, nodeConfig.Leaderless.ReadQuorum))
+	}
+	if nodeConfig.Leaderless.WriteQuorum < 1 || nodeConfig.Leaderless.WriteQuorum > nodeConfig.Leaderless.Replicas {
+		errs = append(errs, fmt.Errorf("write quorum must be between 1 and replicas (%d), got %d", nodeConfig.Leaderless.Replicas, nodeConfig.Leaderless.WriteQuorum))
+	}
+	if nodeConfig.Leaderless.VectorClocks && !nodeConfig.Leaderless.Enabled {
+		errs = append(errs, fmt.Errorf("vector clocks require leaderless mode"))
+	}
+	if err := nodeConfig.Log.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Role is the role the node registers with, a node promoted earlier stays a
+// voter regardless
+func (nodeConfig *NodeConfig) Role() nodecommon.NodeRole {
+	if nodeConfig.Learner {
+		return nodecommon.RoleLearner
+	}
+	return nodecommon.RoleVoter
+}
+
+func (nodeConfig *NodeConfig) MembershipConfig() membership.Config {
+	membershipConfig := membership.DefaultConfig()
+	membershipConfig.SuspectPhi = nodeConfig.Gossip.SuspectPhi
+	membershipConfig.AcceptableHeartbeatPause = nodeConfig.Gossip.AcceptableHeartbeatPause
+	return membershipConfig
+}
+
+// LeaseConfig renews the lease three times per lease duration so a single
+// lost round does not let it run out
+func (nodeConfig *NodeConfig) LeaseConfig() lease.Config {
+	leaseConfig := lease.DefaultConfig()
+	leaseConfig.Duration = nodeConfig.Lease.Duration
+	leaseConfig.MaxClockDrift = nodeConfig.Lease.MaxClockDrift
+	leaseConfig.RenewInterval = nodeConfig.Lease.Duration / 3
+	return leaseConfig
+}
+
+func (nodeConfig *NodeConfig) AntiEntropyConfig() antientropy.Config {
+	antiEntropyConfig := antientropy.DefaultConfig()
+	antiEntropyConfig.Interval = nodeConfig.AntiEntropy.Interval
+	return antiEntropyConfig
+}
+
+// WALConfig keeps the write ahead log in the wal directory under DataDir
+func (nodeConfig *NodeConfig) WALConfig() storage.WALConfig {
+	walConfig := storage.DefaultWALConfig(filepath.Join(nodeConfig.DataDir, "wal"))
+	walConfig.GroupCommitWindow = nodeConfig.WAL.GroupCommitWindow
+	walConfig.GroupCommitBytes = int(nodeConfig.WAL.GroupCommitBytes)
+	walConfig.CompactBytes = nodeConfig.WAL.CompactBytes
+	walConfig.TombstoneRetention = nodeConfig.WAL.TombstoneRetention
+	return walConfig
+}
+
+// HintsConfig keeps the hints for unreachable peers in the hints directory
+// under DataDir
+func (nodeConfig *NodeConfig) HintsConfig() hints.Config {
+	hintsConfig := hints.DefaultConfig(filepath.Join(nodeConfig.DataDir, "hints"))
+	hintsConfig.MaxBytesPerPeer = nodeConfig.Hints.MaxBytesPerPeer
+	return hintsConfig
+}
+
+func (nodeConfig *NodeConfig) LeaderlessConfig() leaderless.Config {
+	leaderlessConfig := leaderless.DefaultConfig()
+	leaderlessConfig.Enabled = nodeConfig.Leaderless.Enabled
+	leaderlessConfig.Replicas = int(nodeConfig.Leaderless.Replicas)
+	leaderlessConfig.ReadQuorum = int(nodeConfig.Leaderless.ReadQuorum)
+	leaderlessConfig.WriteQuorum = int(nodeConfig.Leaderless.WriteQuorum)
+	leaderlessConfig.VectorClocks = nodeConfig.Leaderless.VectorClocks
+	return leaderlessConfig
+}
+
+func validatePort(port string) error {
+	number, err := strconv.Atoi(port)
+	if err != nil || number < 1 || number > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+func validateAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	if host == "" {
+		return fmt.Errorf("invalid address %q: missing host", address)
+	}
+	return validatePort(port)
+}
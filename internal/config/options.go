@@ -0,0 +1,200 @@
+// Package config loads the startup configuration of the node and registry
+// binaries. Values are resolved from, in increasing order of precedence, the
+// built in defaults, a YAML config file, DISTROKV_* environment variables and
+// command line flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const ConfigFileEnv = "DISTROKV_CONFIG"
+
+// option binds a single setting to its flag and environment variable
+type option struct {
+	flagName string
+	envName  string
+	usage    string
+	value    flag.Value
+}
+
+type stringValue struct{ target *string }
+
+func (value stringValue) String() string {
+	if value.target == nil {
+		return ""
+	}
+	return *value.target
+}
+
+func (value stringValue) Set(raw string) error {
+	*value.target = raw
+	return nil
+}
+
+type int64Value struct{ target *int64 }
+
+func (value int64Value) String() string {
+	if value.target == nil {
+		return "0"
+	}
+	return strconv.FormatInt(*value.target, 10)
+}
+
+func (value int64Value) Set(raw string) error {
+	parsed, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", raw)
+	}
+	*value.target = parsed
+	return nil
+}
+
+type int32Value struct{ target *int32 }
+
+func (value int32Value) String() string {
+	if value.target == nil {
+		return "0"
+	}
+	return strconv.FormatInt(int64(*value.target), 10)
+}
+
+func (value int32Value) Set(raw string) error {
+	parsed, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", raw)
+	}
+	*value.target = int32(parsed)
+	return nil
+}
+
+type float64Value struct{ target *float64 }
+
+func (value float64Value) String() string {
+	if value.target == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*value.target, 'g', -1, 64)
+}
+
+func (value float64Value) Set(raw string) error {
+	parsed, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", raw)
+	}
+	*value.target = parsed
+	return nil
+}
+
+type boolValue struct{ target *bool }
+
+func (value boolValue) String() string {
+	if value.target == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*value.target)
+}
+
+func (value boolValue) Set(raw string) error {
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", raw)
+	}
+	*value.target = parsed
+	return nil
+}
+
+// IsBoolFlag lets the flag be passed without a value
+func (value boolValue) IsBoolFlag() bool {
+	return true
+}
+
+type durationValue struct{ target *time.Duration }
+
+func (value durationValue) String() string {
+	if value.target == nil {
+		return "0s"
+	}
+	return value.target.String()
+}
+
+func (value durationValue) Set(raw string) error {
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", raw)
+	}
+	*value.target = parsed
+	return nil
+}
+
+// load resolves the settings bound by options into cfg. Flags are parsed up
+// front so -config can name the file, and re-applied after the file and the
+// environment so they always win.
+func load(name string, args []string, cfg any, reset func(), options []option) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv(ConfigFileEnv), "path to a YAML config file (env "+ConfigFileEnv+")")
+	for _, opt := range options {
+		flags.Var(opt.value, opt.flagName, fmt.Sprintf("%s (env %s)", opt.usage, opt.envName))
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	explicitFlags := make(map[string]string)
+	flags.Visit(func(set *flag.Flag) {
+		explicitFlags[set.Name] = set.Value.String()
+	})
+
+	reset()
+	if *configPath != "" {
+		if err := loadFile(*configPath, cfg); err != nil {
+			return err
+		}
+	}
+	for _, opt := range options {
+		raw, exists := os.LookupEnv(opt.envName)
+		if !exists || raw == "" {
+			continue
+		}
+		if err := opt.value.Set(raw); err != nil {
+			return fmt.Errorf("%s: %w", opt.envName, err)
+		}
+	}
+	for _, opt := range options {
+		raw, exists := explicitFlags[opt.flagName]
+		if !exists {
+			continue
+		}
+		if err := opt.value.Set(raw); err != nil {
+			return fmt.Errorf("-%s: %w", opt.flagName, err)
+		}
+	}
+	return nil
+}
+
+// loadFile decodes a YAML file on top of cfg. Unknown keys are rejected so a
+// typo does not silently fall back to a default.
+func loadFile(path string, cfg any) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
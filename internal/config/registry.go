@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/registry/controllers"
+)
+
+type RangeConfig struct {
+	MaxRangeSizeBytes int64   `yaml:"maxRangeSizeBytes"`
+	MaxRangeQPS       float64 `yaml:"maxRangeQPS"`
+	MinRangeSizeBytes int64   `yaml:"minRangeSizeBytes"`
+	// Mode is primary to write every range through the primary or owner to
+	// spread the ranges over the voters
+	Mode string `yaml:"mode"`
+}
+
+type HealthConfig struct {
+	HeartbeatInterval        time.Duration `yaml:"heartbeatInterval"`
+	SuspectPhi               float64       `yaml:"suspectPhi"`
+	DeadPhi                  float64       `yaml:"deadPhi"`
+	AcceptableHeartbeatPause time.Duration `yaml:"acceptableHeartbeatPause"`
+}
+
+type RegistryConfig struct {
+	ListenAddress   string        `yaml:"listenAddress"`
+	DataDir         string        `yaml:"dataDir"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	Ranges          RangeConfig   `yaml:"ranges"`
+	Health          HealthConfig  `yaml:"health"`
+	Log             LogConfig     `yaml:"log"`
+}
+
+func DefaultRegistryConfig() RegistryConfig {
+	thresholds := controllers.DefaultRangeThresholds()
+	health := controllers.DefaultNodeHealthThresholds()
+	return RegistryConfig{
+		ListenAddress:   ":8080",
+		DataDir:         "registry-data",
+		ShutdownTimeout: defaultShutdownTimeout,
+		Ranges: RangeConfig{
+			MaxRangeSizeBytes: thresholds.MaxRangeSizeBytes,
+			MaxRangeQPS:       thresholds.MaxRangeQPS,
+			MinRangeSizeBytes: thresholds.MinRangeSizeBytes,
+			Mode:              string(controllers.RangeModePrimary),
+		},
+		Health: HealthConfig{
+			HeartbeatInterval:        health.HeartbeatInterval,
+			SuspectPhi:               health.SuspectPhi,
+			DeadPhi:                  health.DeadPhi,
+			AcceptableHeartbeatPause: health.AcceptableHeartbeatPause,
+		},
+		Log: defaultLogConfig(),
+	}
+}
+
+// LoadRegistryConfig resolves the registry configuration from args, the
+// environment and the config file and validates the result
+func LoadRegistryConfig(args []string) (*RegistryConfig, error) {
+	registryConfig := DefaultRegistryConfig()
+	options := []option{
+		{"listen", "DISTROKV_LISTEN_ADDRESS", "address the registry listens on", stringValue{&registryConfig.ListenAddress}},
+		{"data-dir", "DISTROKV_DATA_DIR", "directory the registry state is persisted in", stringValue{&registryConfig.DataDir}},
+		{"shutdown-timeout", "DISTROKV_SHUTDOWN_TIMEOUT", "time in flight requests get to finish on shutdown", durationValue{&registryConfig.ShutdownTimeout}},
+		{"max-range-size", "DISTROKV_MAX_RANGE_SIZE_BYTES", "size in bytes above which a range is split", int64Value{&registryConfig.Ranges.MaxRangeSizeBytes}},
+		{"max-range-qps", "DISTROKV_MAX_RANGE_QPS", "queries per second above which a range is split", float64Value{&registryConfig.Ranges.MaxRangeQPS}},
+		{"min-range-size", "DISTROKV_MIN_RANGE_SIZE_BYTES", "combined size in bytes below which adjacent ranges are merged", int64Value{&registryConfig.Ranges.MinRangeSizeBytes}},
+		{"range-mode", "DISTROKV_RANGE_MODE", "primary to write every range through the primary, owner to spread range ownership over the voters", stringValue{&registryConfig.Ranges.Mode}},
+		{"heartbeat-interval", "DISTROKV_HEARTBEAT_INTERVAL", "interval the failure detector expects node heartbeats at, must match the nodes' heartbeat-interval", durationValue{&registryConfig.Health.HeartbeatInterval}},
+		{"suspect-phi", "DISTROKV_SUSPECT_PHI", "phi at which a node that missed heartbeats is graded suspect", float64Value{&registryConfig.Health.SuspectPhi}},
+		{"dead-phi", "DISTROKV_DEAD_PHI", "phi at which a node that missed heartbeats is graded dead", float64Value{&registryConfig.Health.DeadPhi}},
+		{"acceptable-heartbeat-pause", "DISTROKV_ACCEPTABLE_HEARTBEAT_PAUSE", "heartbeat delay tolerated before phi starts to climb", durationValue{&registryConfig.Health.AcceptableHeartbeatPause}},
+	}
+	options = append(options, registryConfig.Log.options()...)
+
+	reset := func() { registryConfig = DefaultRegistryConfig() }
+	if err := load("registry", args, &registryConfig, reset, options); err != nil {
+		return nil, err
+	}
+	if err := registryConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return &registryConfig, nil
+}
+
+func (registryConfig *RegistryConfig) Validate() error {
+	var errs []error
+	if _, port, err := net.SplitHostPort(registryConfig.ListenAddress); err != nil {
+		errs = append(errs, fmt.Errorf("invalid listen address %q: %w", registryConfig.ListenAddress, err))
+	} else if err := validatePort(port); err != nil {
+		errs = append(errs, fmt.Errorf("listen address: %w", err))
+	}
+	if registryConfig.DataDir == "" {
+		errs = append(errs, fmt.Errorf("data dir must not be empty"))
+	}
+	if registryConfig.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must be positive, got %s", registryConfig.ShutdownTimeout))
+	}
+	ranges := registryConfig.Ranges
+	if ranges.MaxRangeSizeBytes <= 0 {
+		errs = append(errs, fmt.Errorf("max range size must be positive, got %d", ranges.MaxRangeSizeBytes))
+	}
+	if ranges.MaxRangeQPS <= 0 {
+		errs = append(errs, fmt.Errorf("max range qps must be positive, got %g", ranges.MaxRangeQPS))
+	}
+	if ranges.MinRangeSizeBytes < 0 || ranges.MinRangeSizeBytes >= ranges.MaxRangeSizeBytes {
+		errs = append(errs, fmt.Errorf("min range size must be between 0 and the max range size, got %d", ranges.MinRangeSizeBytes))
+	}
+	switch controllers.RangeMode(ranges.Mode) {
+	case controllers.RangeModePrimary, controllers.RangeModeOwner:
+	default:
+		errs = append(errs, fmt.Errorf("range mode must be %s or %s, got %q", controllers.RangeModePrimary, controllers.RangeModeOwner, ranges.Mode))
+	}
+	health := registryConfig.Health
+	if health.HeartbeatInterval <= 0 {
+		errs = append(errs, fmt.Errorf("heartbeat interval must be positive, got %s", health.HeartbeatInterval))
+	}
+	if health.SuspectPhi <= 0 {
+		errs = append(errs, fmt.Errorf("suspect phi must be positive, got %g", health.SuspectPhi))
+	}
+	if health.DeadPhi <= health.SuspectPhi {
+		errs = append(errs, fmt.Errorf("dead phi must be above the suspect phi, got %g", health.DeadPhi))
+	}
+	if health.AcceptableHeartbeatPause < 0 {
+		errs = append(errs, fmt.Errorf("acceptable heartbeat pause must not be negative, got %s", health.AcceptableHeartbeatPause))
+	}
+	if err := registryConfig.Log.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (registryConfig *RegistryConfig) RangeThresholds() controllers.RangeThresholds {
+	return controllers.RangeThresholds{
+		MaxRangeSizeBytes: registryConfig.Ranges.MaxRangeSizeBytes,
+		MaxRangeQPS:       registryConfig.Ranges.MaxRangeQPS,
+		MinRangeSizeBytes: registryConfig.Ranges.MinRangeSizeBytes,
+	}
+}
+
+func (registryConfig *RegistryConfig) RangeMode() controllers.RangeMode {
+	return controllers.RangeMode(registryConfig.Ranges.Mode)
+}
+
+func (registryConfig *RegistryConfig) HealthThresholds() controllers.NodeHealthThresholds {
+	return controllers.NodeHealthThresholds{
+		HeartbeatInterval:        registryConfig.Health.HeartbeatInterval,
+		AcceptableHeartbeatPause: registryConfig.Health.AcceptableHeartbeatPause,
+		SuspectPhi:               registryConfig.Health.SuspectPhi,
+		DeadPhi:                  registryConfig.Health.DeadPhi,
+	}
+}
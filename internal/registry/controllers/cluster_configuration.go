@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"slices"
+	"sort"
+
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	pb "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+// Voters are added and removed with joint consensus. The committed
+// configuration lists the voters. A change first moves to a joint
+// configuration holding the old and the new voters, during which every quorum
+// needs a majority of both, and commits the new voters once a majority of both
+// acknowledged the joint configuration. The next change waits until a
+// majority of the committed voters acknowledged it, so no two configurations
+// in a row can form disjoint quorums. The registry state is the log the
+// configurations are recorded in and nodes acknowledge the version they
+// applied with every heartbeat.
+type clusterConfiguration struct {
+	version   int64
+	voters    []string
+	newVoters []string
+	joint     bool
+	// acknowledged holds the nodes that applied version
+	acknowledged map[string]bool
+}
+
+// PersistedConfiguration is the on disk form of the cluster configuration
+type PersistedConfiguration struct {
+	Version   int64    `json:"version"`
+	Voters    []string `json:"voters"`
+	NewVoters []string `json:"newVoters,omitempty"`
+	Joint     bool     `json:"joint,omitempty"`
+}
+
+// Configuration returns the current configuration along with the nodes that
+// acknowledged it
+func (nodeRegistry *NodeRegistry) Configuration() (*pb.ClusterConfiguration, []string) {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	acknowledged := make([]string, 0, len(nodeRegistry.configuration.acknowledged))
+	for nodeId := range nodeRegistry.configuration.acknowledged {
+		acknowledged = append(acknowledged, nodeId)
+	}
+	sort.Strings(acknowledged)
+	return nodeRegistry.configurationResponse(), acknowledged
+}
+
+// configurationResponse expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) configurationResponse() *pb.ClusterConfiguration {
+	configuration := nodeRegistry.configuration
+	return &pb.ClusterConfiguration{
+		Version:   configuration.version,
+		Voters:    slices.Clone(configuration.voters),
+		NewVoters: slices.Clone(configuration.newVoters),
+		Joint:     configuration.joint,
+	}
+}
+
+// acknowledgeConfiguration records the configuration version a node applied
+// and expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) acknowledgeConfiguration(nodeId string, version int64) {
+	if version < nodeRegistry.configuration.version {
+		return
+	}
+	nodeRegistry.configuration.acknowledged[nodeId] = true
+}
+
+// isVoter reports whether the node is a voter of the committed configuration
+// and expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) isVoter(nodeId string) bool {
+	return slices.Contains(nodeRegistry.configuration.voters, nodeId)
+}
+
+// reconcileConfiguration moves the configuration one step towards the voters
+// that are registered and expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) reconcileConfiguration() {
+	configuration := &nodeRegistry.configuration
+	if configuration.joint {
+		// Once none of the old voters is left nobody could form a quorum
+		// under the old configuration anymore, the new voters still have to
+		// acknowledge the joint configuration first
+		oldVotersDone := nodeRegistry.majorityAcknowledged(configuration.voters) || !nodeRegistry.anyRegistered(configuration.voters)
+		newVotersDone := nodeRegistry.majorityAcknowledged(configuration.newVoters) || len(configuration.newVoters) == 0
+		if oldVotersDone && newVotersDone {
+			nodeRegistry.logger.Info("Committing cluster configuration", "version", configuration.version+1, "voters", configuration.newVoters)
+			nodeRegistry.setConfiguration(configuration.newVoters, nil, false)
+		}
+		return
+	}
+
+	target := nodeRegistry.targetVoters()
+	if slices.Equal(target, configuration.voters) {
+		return
+	}
+	if !nodeRegistry.anyRegistered(configuration.voters) {
+		// Nothing to be joint with, as when the first node registers or
+		// the last voter left
+		nodeRegistry.logger.Info("Replacing cluster configuration without registered voters", "version", configuration.version+1, "voters", target)
+		nodeRegistry.setConfiguration(target, nil, false)
+		return
+	}
+	if !nodeRegistry.majorityAcknowledged(configuration.voters) {
+		return
+	}
+	nodeRegistry.logger.Info("Entering joint cluster configuration", "version", configuration.version+1, "voters", configuration.voters, "newVoters", target)
+	nodeRegistry.setConfiguration(configuration.voters, target, true)
+}
+
+// setConfiguration expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) setConfiguration(voters []string, newVoters []string, joint bool) {
+	nodeRegistry.configuration = clusterConfiguration{
+		version:      nodeRegistry.configuration.version + 1,
+		voters:       voters,
+		newVoters:    newVoters,
+		joint:        joint,
+		acknowledged: make(map[string]bool),
+	}
+}
+
+// targetVoters returns the sorted ids of the registered voters that are not
+// draining. It expects the caller to hold the lock.
+func (nodeRegistry *NodeRegistry) targetVoters() []string {
+	voters := make([]string, 0, len(nodeRegistry.nodes))
+	for nodeId, value := range nodeRegistry.nodes {
+		if value.nodeDetails.Role == nodecommon.RoleVoter && !value.draining {
+			voters = append(voters, nodeId)
+		}
+	}
+	sort.Strings(voters)
+	return voters
+}
+
+// majorityAcknowledged expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) majorityAcknowledged(voters []string) bool {
+	acknowledged := 0
+	for _, nodeId := range voters {
+		if nodeRegistry.configuration.acknowledged[nodeId] {
+			acknowledged++
+		}
+	}
+	return acknowledged > len(voters)/2
+}
+
+// anyRegistered expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) anyRegistered(nodeIds []string) bool {
+	for _, nodeId := range nodeIds {
+		if _, exists := nodeRegistry.nodes[nodeId]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+func (nodeRegistry *NodeRegistry) SnapshotConfiguration() PersistedConfiguration {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	configuration := nodeRegistry.configuration
+	return PersistedConfiguration{
+		Version:   configuration.version,
+		Voters:    slices.Clone(configuration.voters),
+		NewVoters: slices.Clone(configuration.newVoters),
+		Joint:     configuration.joint,
+	}
+}
+
+// RestoreConfiguration replaces the configuration with one taken by
+// SnapshotConfiguration. Nodes acknowledge it again with their next heartbeat.
+func (nodeRegistry *NodeRegistry) RestoreConfiguration(persisted PersistedConfiguration) {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	nodeRegistry.configuration = clusterConfiguration{
+		version:      persisted.Version,
+		voters:       persisted.Voters,
+		newVoters:    persisted.NewVoters,
+		joint:        persisted.Joint,
+		acknowledged: make(map[string]bool),
+	}
+	nodeRegistry.logger.Info("Restored cluster configuration", "version", persisted.Version, "voters", persisted.Voters, "joint", persisted.Joint)
+	nodeRegistry.reconcileConfiguration()
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	pb "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+func testNodeRegistry() *NodeRegistry {
+	return InitializeNodeRegistry(DefaultNodeHealthThresholds(), *slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func registerVoter(t *testing.T, nodeRegistry *NodeRegistry, nodeId string) {
+	t.Helper()
+	request := &pb.RegisterNodeRequest{NodeId: nodeId, Hostname: nodeId, IpAddress: "127.0.0.1", PortNumber: nodeId, Role: pb.NodeRole_VOTER}
+	if _, err := nodeRegistry.RegisterNewNode(request); err != nil {
+		t.Fatalf("registering %s failed: %v", nodeId, err)
+	}
+}
+
+func acknowledge(t *testing.T, nodeRegistry *NodeRegistry, nodeId string) {
+	t.Helper()
+	configuration, _ := nodeRegistry.Configuration()
+	if _, _, err := nodeRegistry.RegisterNodeHeartBeat(&pb.HeartBeatRequest{NodeId: nodeId, ConfigVersion: configuration.Version}); err != nil {
+		t.Fatalf("heartbeat of %s failed: %v", nodeId, err)
+	}
+}
+
+func TestJointConfigurationWaitsForTheNewVoters(t *testing.T) {
+	nodeRegistry := testNodeRegistry()
+	registerVoter(t, nodeRegistry, "n1")
+	registerVoter(t, nodeRegistry, "n2")
+	registerVoter(t, nodeRegistry, "n3")
+	acknowledge(t, nodeRegistry, "n1")
+
+	joint, _ := nodeRegistry.Configuration()
+	if !joint.Joint || !slices.Equal(joint.Voters, []string{"n1"}) || !slices.Equal(joint.NewVoters, []string{"n1", "n2", "n3"}) {
+		t.Fatalf("expected a joint configuration from n1 to n1, n2 and n3, got %v", joint)
+	}
+
+	// The only old voter leaves before any new voter acknowledged the joint
+	// configuration
+	if err := nodeRegistry.DeregisterNode(&pb.DeregisterNodeRequest{NodeId: "n1"}); err != nil {
+		t.Fatalf("deregistering n1 failed: %v", err)
+	}
+	if configuration, _ := nodeRegistry.Configuration(); !configuration.Joint {
+		t.Fatalf("expected the joint configuration to wait for the new voters, got %v", configuration)
+	}
+
+	acknowledge(t, nodeRegistry, "n2")
+	if configuration, _ := nodeRegistry.Configuration(); !configuration.Joint {
+		t.Fatalf("expected a minority of the new voters not to commit the configuration, got %v", configuration)
+	}
+	acknowledge(t, nodeRegistry, "n3")
+	committed, _ := nodeRegistry.Configuration()
+	if committed.Joint || !slices.Equal(committed.Voters, joint.NewVoters) {
+		t.Fatalf("expected the new voters %v to be committed, got %v", joint.NewVoters, committed)
+	}
+}
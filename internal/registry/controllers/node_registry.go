@@ -1,88 +1,323 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
+	failuredetector "github.com/Vahsek/distrokv/internal/common/failure_detector"
 	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
-	"github.com/Vahsek/distrokv/internal/common/util"
 	pb "github.com/Vahsek/distrokv/pkg/registry"
 )
 
+var (
+	ErrNodeNotFound  = errors.New("node not registered")
+	ErrNoActiveNode  = errors.New("no active node")
+	ErrMissingNodeID = errors.New("node id is required")
+	ErrNodeNotReady  = errors.New("node cannot be promoted")
+	ErrNotPrimary    = errors.New("node is not the primary")
+)
+
+type NodeHealth string
+
+const (
+	NodeHealthy NodeHealth = "healthy"
+	NodeSuspect NodeHealth = "suspect"
+	NodeDead    NodeHealth = "dead"
+)
+
+const (
+	DefaultHeartbeatInterval         = 10 * time.Second
+	DefaultSuspectPhi        float64 = 8
+	DefaultDeadPhi           float64 = 16
+)
+
+// NodeHealthThresholds grades nodes by the phi of their heartbeats. A node is
+// suspect once phi reaches SuspectPhi and dead once it reaches DeadPhi. Raising
+// AcceptableHeartbeatPause lets slow nodes, for example ones pausing for
+// garbage collection, miss a heartbeat or two before phi starts to climb.
+type NodeHealthThresholds struct {
+	HeartbeatInterval        time.Duration
+	AcceptableHeartbeatPause time.Duration
+	SuspectPhi               float64
+	DeadPhi                  float64
+}
+
+func DefaultNodeHealthThresholds() NodeHealthThresholds {
+	return NodeHealthThresholds{
+		HeartbeatInterval:        DefaultHeartbeatInterval,
+		AcceptableHeartbeatPause: DefaultHeartbeatInterval,
+		SuspectPhi:               DefaultSuspectPhi,
+		DeadPhi:                  DefaultDeadPhi,
+	}
+}
+
 type RegisteredNodeDetails struct {
 	nodeDetails       nodecommon.Node
 	registrationTime  time.Time
 	lastHeartBeatTime time.Time
+	draining          bool
+	// steppedDown is reported by a primary that lost contact with a quorum
+	steppedDown bool
+	// antiEntropy is what the repairs run by the node found, as of its last
+	// heartbeat
+	antiEntropy *pb.AntiEntropyStats
 }
 
 type NodeRegistryInterface interface {
 	RegisterNode(nodeDetails *pb.RegisterNodeRequest) error
-	UpdateHeartbeat(nodeDetails *pb.HeartBeatRequest) error
+	UpdateHeartbeat(nodeDetails *pb.HeartBeatRequest) (bool, error)
+	DeregisterNode(nodeDetails *pb.DeregisterNodeRequest) error
+	DrainNode(nodeDetails *pb.DrainNodeRequest) error
+	PromoteLearner(nodeDetails *pb.PromoteLearnerRequest) error
+	TransferPrimary(fromNodeId string, toNodeId string) (string, error)
 	GetNodeList() []*pb.NodeDetails
+	GetPrimaryNode() (*pb.PrimaryNodeResponse, error)
 }
 
 type NodeRegistry struct {
-	nodes  map[string]RegisteredNodeDetails
-	mu     sync.Mutex
-	logger slog.Logger
+	nodes            map[string]RegisteredNodeDetails
+	detector         *failuredetector.Detector
+	healthThresholds NodeHealthThresholds
+	configuration    clusterConfiguration
+	// preferredPrimary is the node last picked as the primary or handed
+	// leadership to. It stays the primary while it is a healthy voter that
+	// is not draining, so a node rejoining after a partition does not depose
+	// it even if it registered first.
+	preferredPrimary string
+	mu               sync.Mutex
+	logger           slog.Logger
 }
 
-func InitializeNodeRegistry(logger slog.Logger) *NodeRegistry {
+func InitializeNodeRegistry(healthThresholds NodeHealthThresholds, logger slog.Logger) *NodeRegistry {
+	detectorConfig := failuredetector.DefaultConfig(healthThresholds.HeartbeatInterval)
+	detectorConfig.AcceptableHeartbeatPause = healthThresholds.AcceptableHeartbeatPause
 	return &NodeRegistry{
-		nodes:  make(map[string]RegisteredNodeDetails),
-		logger: logger,
+		nodes:            make(map[string]RegisteredNodeDetails),
+		detector:         failuredetector.NewDetector(detectorConfig),
+		healthThresholds: healthThresholds,
+		configuration:    clusterConfiguration{acknowledged: make(map[string]bool)},
+		logger:           logger,
+	}
+}
+
+// nodeHealth grades a node by the phi of its heartbeats
+func (nodeRegistry *NodeRegistry) nodeHealth(nodeId string) NodeHealth {
+	phi := nodeRegistry.detector.Phi(nodeId)
+	switch {
+	case phi >= nodeRegistry.healthThresholds.DeadPhi:
+		return NodeDead
+	case phi >= nodeRegistry.healthThresholds.SuspectPhi:
+		return NodeSuspect
 	}
+	return NodeHealthy
 }
 
-func (nodeRegistry *NodeRegistry) RegisterNewNode(nodeDetails *pb.RegisterNodeRequest) error {
+// RegisterNewNode records the node and returns the role it registered with
+func (nodeRegistry *NodeRegistry) RegisterNewNode(nodeDetails *pb.RegisterNodeRequest) (nodecommon.NodeRole, error) {
 	nodeRegistry.mu.Lock()
 	defer nodeRegistry.mu.Unlock()
 
-	nodeRegistry.logger.Info("Creating new Node with Nodename: %s NodeIP: %s", nodeDetails.Hostname, nodeDetails.IpAddress)
-	var newNodeDetails nodecommon.Node = *nodecommon.InitializeNode(nodeDetails.Hostname, nodeDetails.IpAddress, nodeDetails.PortNumber, "8080", 1)
+	nodeRegistry.logger.Info("Creating new Node", "nodeId", nodeDetails.NodeId, "hostname", nodeDetails.Hostname, "ip", nodeDetails.IpAddress)
+	if nodeDetails.NodeId == "" {
+		nodeRegistry.logger.Error("Node registered without a node id")
+		return nodecommon.RoleVoter, ErrMissingNodeID
+	}
+	var newNodeDetails nodecommon.Node = *nodecommon.InitializeNode(nodeDetails.NodeId, nodeDetails.Hostname, nodeDetails.IpAddress, nodeDetails.PortNumber, nodeDetails.DataPlanePort, 1)
+	newNodeDetails.Role = nodecommon.NodeRole(nodeDetails.Role)
 	var newNode RegisteredNodeDetails = RegisteredNodeDetails{
 		nodeDetails:       newNodeDetails,
 		registrationTime:  time.Now(),
 		lastHeartBeatTime: time.Now(),
 	}
-	nodeHash := util.GenerateHash(newNode.nodeDetails.NodeHostname +
-		newNode.nodeDetails.NodeIP +
-		newNode.nodeDetails.NodeControlPort)
 
-	nodeRegistry.logger.Info("Checking the node hash")
-	_, exists := nodeRegistry.nodes[nodeHash]
+	nodeRegistry.logger.Info("Checking the node id")
+	existingNode, exists := nodeRegistry.nodes[nodeDetails.NodeId]
 
+	// A node that restarts before it was deregistered, possibly on a new
+	// address, or that was restored from persisted state registers again with
+	// fresh timestamps
 	if exists {
-		nodeRegistry.logger.Error("Node hash already exists. Skipping adding the node")
-		return fmt.Errorf("Node hash already exists. Skipping adding the node")
+		nodeRegistry.logger.Info("Node id already exists. Replacing the previous registration")
+		// A promotion outlives restarts of the node
+		if existingNode.nodeDetails.Role == nodecommon.RoleVoter {
+			newNode.nodeDetails.Role = nodecommon.RoleVoter
+		}
+	}
+
+	// A different id on the same address means the node lost its data
+	// directory, the old registration can never heartbeat again
+	for nodeId, value := range nodeRegistry.nodes {
+		if nodeId != nodeDetails.NodeId && sameAddress(value.nodeDetails, newNodeDetails) {
+			nodeRegistry.logger.Info("Replacing stale registration on the same address", "staleNodeId", nodeId)
+			delete(nodeRegistry.nodes, nodeId)
+			nodeRegistry.detector.Remove(nodeId)
+		}
 	}
 	nodeRegistry.logger.Info("Adding node to node dictionary")
-	nodeRegistry.nodes[nodeHash] = newNode
-	return nil
+	nodeRegistry.nodes[nodeDetails.NodeId] = newNode
+	nodeRegistry.detector.Remove(nodeDetails.NodeId)
+	nodeRegistry.detector.Heartbeat(nodeDetails.NodeId)
+	nodeRegistry.reconcileConfiguration()
+	return newNode.nodeDetails.Role, nil
+}
+
+func sameAddress(left nodecommon.Node, right nodecommon.Node) bool {
+	return left.NodeHostname == right.NodeHostname &&
+		left.NodeIP == right.NodeIP &&
+		left.NodeControlPort == right.NodeControlPort
 }
 
-func (nodeRegistry *NodeRegistry) RegisterNodeHeartBeat(nodeDetails *pb.HeartBeatRequest) error {
+// RegisterNodeHeartBeat records the heartbeat along with the configuration
+// version the node applied and reports whether the node has been asked to
+// drain along with its role
+func (nodeRegistry *NodeRegistry) RegisterNodeHeartBeat(nodeDetails *pb.HeartBeatRequest) (bool, nodecommon.NodeRole, error) {
 	nodeRegistry.mu.Lock()
 	defer nodeRegistry.mu.Unlock()
 
-	nodeRegistry.logger.Info("Heatbeat for node with Nodename: %s NodeIP: %s", nodeDetails.Hostname, nodeDetails.IpAddress)
-	nodeHash := util.GenerateHash(nodeDetails.Hostname +
-		nodeDetails.IpAddress +
-		nodeDetails.PortNumber)
-	existingNode, exists := nodeRegistry.nodes[nodeHash]
+	nodeRegistry.logger.Info("Heatbeat for node", "nodeId", nodeDetails.NodeId, "hostname", nodeDetails.Hostname)
+	existingNode, exists := nodeRegistry.nodes[nodeDetails.NodeId]
 
 	if !exists {
 		nodeRegistry.logger.Error("Node Doesn't exists")
-		return fmt.Errorf("Node Doesn't exists")
+		return false, nodecommon.RoleVoter, fmt.Errorf("Node Doesn't exists")
 	}
 	nodeRegistry.logger.Info("Registering node heartbeat")
+	if health := nodeRegistry.nodeHealth(nodeDetails.NodeId); health != NodeHealthy {
+		nodeRegistry.logger.Info("Heartbeat from node that was graded unhealthy", "nodeId", nodeDetails.NodeId, "health", health)
+	}
 	existingNode.lastHeartBeatTime = time.Now()
-	nodeRegistry.nodes[nodeHash] = existingNode
+	if nodeDetails.SteppedDown && !existingNode.steppedDown {
+		nodeRegistry.logger.Warn("Node stepped down for lack of a quorum", "nodeId", nodeDetails.NodeId)
+	}
+	existingNode.steppedDown = nodeDetails.SteppedDown
+	if nodeDetails.AntiEntropy != nil {
+		existingNode.antiEntropy = nodeDetails.AntiEntropy
+	}
+	nodeRegistry.nodes[nodeDetails.NodeId] = existingNode
+	nodeRegistry.detector.Heartbeat(nodeDetails.NodeId)
+	nodeRegistry.acknowledgeConfiguration(nodeDetails.NodeId, nodeDetails.ConfigVersion)
+	nodeRegistry.reconcileConfiguration()
+	return existingNode.draining, existingNode.nodeDetails.Role, nil
+}
+
+func (nodeRegistry *NodeRegistry) DeregisterNode(nodeDetails *pb.DeregisterNodeRequest) error {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	nodeRegistry.logger.Info("Deregistering node", "nodeId", nodeDetails.NodeId, "hostname", nodeDetails.Hostname)
+	if _, exists := nodeRegistry.nodes[nodeDetails.NodeId]; !exists {
+		nodeRegistry.logger.Error("Node Doesn't exists")
+		return fmt.Errorf("Node Doesn't exists")
+	}
+	delete(nodeRegistry.nodes, nodeDetails.NodeId)
+	nodeRegistry.detector.Remove(nodeDetails.NodeId)
+	nodeRegistry.reconcileConfiguration()
+	return nil
+}
+
+// DrainNode marks a node as draining. A draining node is no longer picked as
+// primary and stays registered until it deregisters itself, so the last live
+// node that is not draining cannot be drained and neither can the last such
+// voter.
+func (nodeRegistry *NodeRegistry) DrainNode(nodeDetails *pb.DrainNodeRequest) error {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	nodeRegistry.logger.Info("Draining node", "nodeId", nodeDetails.NodeId, "hostname", nodeDetails.Hostname)
+	existingNode, exists := nodeRegistry.nodes[nodeDetails.NodeId]
+	if !exists {
+		nodeRegistry.logger.Error("Node Doesn't exists")
+		return fmt.Errorf("Node Doesn't exists: %w", ErrNodeNotFound)
+	}
+	if existingNode.draining {
+		return nil
+	}
+
+	remaining := 0
+	for otherNodeId, value := range nodeRegistry.nodes {
+		if otherNodeId == nodeDetails.NodeId || value.draining || nodeRegistry.nodeHealth(otherNodeId) == NodeDead {
+			continue
+		}
+		if existingNode.nodeDetails.Role == nodecommon.RoleLearner || value.nodeDetails.Role == nodecommon.RoleVoter {
+			remaining++
+		}
+	}
+	if remaining == 0 {
+		nodeRegistry.logger.Error("Refusing to drain the last active node")
+		return fmt.Errorf("No other active node to hand the data off to: %w", ErrNoActiveNode)
+	}
+
+	existingNode.draining = true
+	nodeRegistry.nodes[nodeDetails.NodeId] = existingNode
+	nodeRegistry.reconcileConfiguration()
+	return nil
+}
+
+// PromoteLearner turns a learner into a voter. Only a healthy learner that is
+// not draining is promoted, promoting a voter does nothing. The node starts
+// counting toward quorum once the configuration change adding it commits.
+func (nodeRegistry *NodeRegistry) PromoteLearner(nodeDetails *pb.PromoteLearnerRequest) error {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	nodeRegistry.logger.Info("Promoting learner", "nodeId", nodeDetails.NodeId, "hostname", nodeDetails.Hostname)
+	existingNode, exists := nodeRegistry.nodes[nodeDetails.NodeId]
+	if !exists {
+		nodeRegistry.logger.Error("Node Doesn't exists")
+		return fmt.Errorf("Node Doesn't exists: %w", ErrNodeNotFound)
+	}
+	if existingNode.nodeDetails.Role == nodecommon.RoleVoter {
+		return nil
+	}
+	if existingNode.draining {
+		return fmt.Errorf("Node is draining: %w", ErrNodeNotReady)
+	}
+	if health := nodeRegistry.nodeHealth(nodeDetails.NodeId); health != NodeHealthy {
+		return fmt.Errorf("Node is %s: %w", health, ErrNodeNotReady)
+	}
+
+	existingNode.nodeDetails.Role = nodecommon.RoleVoter
+	nodeRegistry.nodes[nodeDetails.NodeId] = existingNode
+	nodeRegistry.reconcileConfiguration()
 	return nil
 }
 
+// TransferPrimary hands the primary role from the current primary to another
+// voter of the committed configuration that is healthy and not draining.
+// It returns the id of the new primary.
+func (nodeRegistry *NodeRegistry) TransferPrimary(fromNodeId string, toNodeId string) (string, error) {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	nodeRegistry.logger.Info("Transferring primary", "from", fromNodeId, "to", toNodeId)
+	if toNodeId == "" {
+		return "", ErrMissingNodeID
+	}
+	if primaryNodeId, _ := nodeRegistry.primaryNode(); primaryNodeId != fromNodeId {
+		return primaryNodeId, fmt.Errorf("Primary is %q: %w", primaryNodeId, ErrNotPrimary)
+	}
+	target, exists := nodeRegistry.nodes[toNodeId]
+	if !exists {
+		return fromNodeId, fmt.Errorf("Node Doesn't exists: %w", ErrNodeNotFound)
+	}
+	if !nodeRegistry.isVoter(toNodeId) {
+		return fromNodeId, fmt.Errorf("Node is not a voter of the committed configuration: %w", ErrNodeNotReady)
+	}
+	if target.draining {
+		return fromNodeId, fmt.Errorf("Node is draining: %w", ErrNodeNotReady)
+	}
+	if health := nodeRegistry.nodeHealth(toNodeId); health != NodeHealthy {
+		return fromNodeId, fmt.Errorf("Node is %s: %w", health, ErrNodeNotReady)
+	}
+
+	nodeRegistry.preferredPrimary = toNodeId
+	return toNodeId, nil
+}
+
 func (nodeRegistry *NodeRegistry) GetNodeList() []*pb.NodeDetails {
 	nodeRegistry.mu.Lock()
 	defer nodeRegistry.mu.Unlock()
@@ -93,11 +328,166 @@ func (nodeRegistry *NodeRegistry) GetNodeList() []*pb.NodeDetails {
 	registeredNodesMap := nodeRegistry.nodes
 	for _, value := range registeredNodesMap {
 		nodeDetail := &pb.NodeDetails{
+			NodeId:          value.nodeDetails.NodeID,
 			NodeIP:          value.nodeDetails.NodeIP,
 			NodeHostname:    value.nodeDetails.NodeHostname,
 			NodeControlPort: value.nodeDetails.NodeControlPort,
+			NodeDataPort:    value.nodeDetails.NodeDataPort,
+			Draining:        value.draining,
+			Health:          string(nodeRegistry.nodeHealth(value.nodeDetails.NodeID)),
+			Role:            pb.NodeRole(value.nodeDetails.Role),
+			AntiEntropy:     value.antiEntropy,
 		}
 		nodes = append(nodes, nodeDetail)
 	}
 	return nodes
 }
+
+// GetPrimaryNode keeps the current primary while it is a healthy voter and
+// otherwise returns the voter of the committed configuration that has been
+// registered the longest. Ties are broken on the node id so every caller sees
+// the same primary. Dead nodes are never picked, suspect nodes only when no
+// healthy node is left and draining nodes or ones that stepped down for lack of
+// a quorum only when no other node is left.
+func (nodeRegistry *NodeRegistry) GetPrimaryNode() (*pb.PrimaryNodeResponse, error) {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	_, primary := nodeRegistry.primaryNode()
+	if primary == nil {
+		nodeRegistry.logger.Error("No live voters registered to pick a primary from")
+		return nil, fmt.Errorf("No live voters registered")
+	}
+	nodeRegistry.logger.Info("Selected primary node", "hostname", primary.nodeDetails.NodeHostname)
+	return &pb.PrimaryNodeResponse{
+		Hostname:      primary.nodeDetails.NodeHostname,
+		IpAddress:     primary.nodeDetails.NodeIP,
+		PortNumber:    primary.nodeDetails.NodeControlPort,
+		DataPlanePort: primary.nodeDetails.NodeDataPort,
+		NodeId:        primary.nodeDetails.NodeID,
+	}, nil
+}
+
+// RangePlacement returns the voters that may own ranges, the healthy ones that
+// are neither draining nor stepped down, and the nodes holding a copy of every
+// range, which is every registered node. Both are sorted by node id.
+func (nodeRegistry *NodeRegistry) RangePlacement() ([]string, []string) {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	var candidates, replicas []string
+	for nodeId, value := range nodeRegistry.nodes {
+		replicas = append(replicas, nodeId)
+		if nodeRegistry.isVoter(nodeId) && !value.draining && !value.steppedDown && nodeRegistry.nodeHealth(nodeId) == NodeHealthy {
+			candidates = append(candidates, nodeId)
+		}
+	}
+	sort.Strings(candidates)
+	sort.Strings(replicas)
+	return candidates, replicas
+}
+
+// primaryNode expects the caller to hold the lock
+func (nodeRegistry *NodeRegistry) primaryNode() (string, *RegisteredNodeDetails) {
+	var primaryNodeId string
+	var primary *RegisteredNodeDetails
+	var primaryRank int
+	for nodeId, value := range nodeRegistry.nodes {
+		health := nodeRegistry.nodeHealth(nodeId)
+		if health == NodeDead || !nodeRegistry.isVoter(nodeId) {
+			continue
+		}
+		rank := 0
+		if value.draining || value.steppedDown {
+			rank += 2
+		}
+		if health == NodeSuspect {
+			rank++
+		}
+		if rank == 0 && nodeId == nodeRegistry.preferredPrimary {
+			rank = -1
+		}
+		if primary == nil ||
+			rank < primaryRank ||
+			(rank == primaryRank && value.registrationTime.Before(primary.registrationTime)) ||
+			(rank == primaryRank && value.registrationTime.Equal(primary.registrationTime) && nodeId < primaryNodeId) {
+			registeredNode := value
+			primary = &registeredNode
+			primaryNodeId = nodeId
+			primaryRank = rank
+		}
+	}
+	if primary != nil && primaryNodeId != nodeRegistry.preferredPrimary && primaryRank == 0 {
+		nodeRegistry.logger.Info("Primary changed", "from", nodeRegistry.preferredPrimary, "to", primaryNodeId)
+		nodeRegistry.preferredPrimary = primaryNodeId
+	}
+	return primaryNodeId, primary
+}
+
+// PersistedNode is the on disk form of a registration
+type PersistedNode struct {
+	NodeID            string    `json:"nodeId"`
+	Hostname          string    `json:"hostname"`
+	IP                string    `json:"ip"`
+	ControlPort       string    `json:"controlPort"`
+	DataPort          string    `json:"dataPort"`
+	RegistrationTime  time.Time `json:"registrationTime"`
+	LastHeartBeatTime time.Time `json:"lastHeartBeatTime"`
+	Draining          bool      `json:"draining,omitempty"`
+	Learner           bool      `json:"learner,omitempty"`
+	PreferredPrimary  bool      `json:"preferredPrimary,omitempty"`
+}
+
+func (nodeRegistry *NodeRegistry) Snapshot() []PersistedNode {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	nodes := make([]PersistedNode, 0, len(nodeRegistry.nodes))
+	for _, value := range nodeRegistry.nodes {
+		nodes = append(nodes, PersistedNode{
+			NodeID:            value.nodeDetails.NodeID,
+			Hostname:          value.nodeDetails.NodeHostname,
+			IP:                value.nodeDetails.NodeIP,
+			ControlPort:       value.nodeDetails.NodeControlPort,
+			DataPort:          value.nodeDetails.NodeDataPort,
+			RegistrationTime:  value.registrationTime,
+			LastHeartBeatTime: value.lastHeartBeatTime,
+			Draining:          value.draining,
+			Learner:           value.nodeDetails.Role == nodecommon.RoleLearner,
+			PreferredPrimary:  value.nodeDetails.NodeID == nodeRegistry.preferredPrimary,
+		})
+	}
+	return nodes
+}
+
+// Restore replaces the registered nodes with a snapshot taken by Snapshot
+func (nodeRegistry *NodeRegistry) Restore(nodes []PersistedNode) {
+	nodeRegistry.mu.Lock()
+	defer nodeRegistry.mu.Unlock()
+
+	nodeRegistry.nodes = make(map[string]RegisteredNodeDetails, len(nodes))
+	nodeRegistry.preferredPrimary = ""
+	for _, node := range nodes {
+		if node.NodeID == "" {
+			nodeRegistry.logger.Warn("Skipping persisted node without a node id", "hostname", node.Hostname)
+			continue
+		}
+		nodeDetails := *nodecommon.InitializeNode(node.NodeID, node.Hostname, node.IP, node.ControlPort, node.DataPort, 1)
+		if node.Learner {
+			nodeDetails.Role = nodecommon.RoleLearner
+		}
+		if node.PreferredPrimary {
+			nodeRegistry.preferredPrimary = node.NodeID
+		}
+		nodeRegistry.nodes[node.NodeID] = RegisteredNodeDetails{
+			nodeDetails:       nodeDetails,
+			registrationTime:  node.RegistrationTime,
+			lastHeartBeatTime: node.LastHeartBeatTime,
+			draining:          node.Draining,
+		}
+		// Restored nodes get one heartbeat period to check in before they
+		// are graded unhealthy
+		nodeRegistry.detector.Heartbeat(node.NodeID)
+	}
+	nodeRegistry.logger.Info("Restored registered nodes", "count", len(nodes))
+}
@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	pb "github.com/Vahsek/distrokv/pkg/registry"
+	"google.golang.org/protobuf/proto"
+)
+
+// Range descriptors live in the metadata range under metaRangePrefix followed
+// by the start key of the range they describe, so a scan of the metadata range
+// returns them in key order
+const (
+	metaRangePrefix = "meta/range/"
+	metaRangeEnd    = "meta/range0"
+)
+
+const (
+	DefaultMaxRangeSizeBytes int64   = 64 * 1024 * 1024
+	DefaultMaxRangeQPS       float64 = 2500
+	DefaultMinRangeSizeBytes int64   = 16 * 1024 * 1024
+)
+
+// RangeThresholds controls when ranges are split and merged. A range is split
+// once it grows past MaxRangeSizeBytes or serves more than MaxRangeQPS. Two
+// adjacent ranges are merged when together they are smaller than
+// MinRangeSizeBytes and serve less than half of MaxRangeQPS.
+type RangeThresholds struct {
+	MaxRangeSizeBytes int64
+	MaxRangeQPS       float64
+	MinRangeSizeBytes int64
+}
+
+func DefaultRangeThresholds() RangeThresholds {
+	return RangeThresholds{
+		MaxRangeSizeBytes: DefaultMaxRangeSizeBytes,
+		MaxRangeQPS:       DefaultMaxRangeQPS,
+		MinRangeSizeBytes: DefaultMinRangeSizeBytes,
+	}
+}
+
+// RangeMode decides which node coordinates the writes to a range
+type RangeMode string
+
+const (
+	// RangeModePrimary sends the writes to every range through the primary,
+	// ranges only track load
+	RangeModePrimary RangeMode = "primary"
+	// RangeModeOwner spreads the ranges over the voters, each range is
+	// written through its owner
+	RangeModeOwner RangeMode = "owner"
+)
+
+type RangeRegistryInterface interface {
+	GetRangeDescriptors() ([]*pb.RangeDescriptor, error)
+	ApplyRangeStats(stats []*pb.RangeStats) error
+	AssignOwners(candidates []string, replicas []string, primary string) error
+}
+
+type RangeRegistry struct {
+	metaRange   *storage.KeyValueStore
+	rangeStats  map[int64]*pb.RangeStats
+	nextRangeId int64
+	thresholds  RangeThresholds
+	mode        RangeMode
+	mu          sync.Mutex
+	logger      slog.Logger
+}
+
+func InitializeRangeRegistry(thresholds RangeThresholds, mode RangeMode, logger slog.Logger) *RangeRegistry {
+	rangeRegistry := &RangeRegistry{
+		metaRange:   storage.NewKeyValueStore(logger),
+		rangeStats:  make(map[int64]*pb.RangeStats),
+		nextRangeId: 2,
+		thresholds:  thresholds,
+		mode:        mode,
+		logger:      logger,
+	}
+
+	// The keyspace starts out as a single unbounded range
+	err := rangeRegistry.writeDescriptor(&pb.RangeDescriptor{
+		RangeId:    1,
+		StartKey:   "",
+		EndKey:     "",
+		Generation: 1,
+	})
+	if err != nil {
+		logger.Error("Failed to write the initial range descriptor", "error", err)
+	}
+	return rangeRegistry
+}
+
+func (rangeRegistry *RangeRegistry) GetRangeDescriptors() ([]*pb.RangeDescriptor, error) {
+	rangeRegistry.mu.Lock()
+	defer rangeRegistry.mu.Unlock()
+
+	return rangeRegistry.readDescriptors()
+}
+
+// ApplyRangeStats records the stats reported by a node and splits or merges
+// ranges that crossed the configured thresholds. Stats reported against an
+// older generation of a range are ignored.
+func (rangeRegistry *RangeRegistry) ApplyRangeStats(stats []*pb.RangeStats) error {
+	rangeRegistry.mu.Lock()
+	defer rangeRegistry.mu.Unlock()
+
+	descriptors, err := rangeRegistry.readDescriptors()
+	if err != nil {
+		return err
+	}
+	descriptorById := make(map[int64]*pb.RangeDescriptor)
+	for _, descriptor := range descriptors {
+		descriptorById[descriptor.RangeId] = descriptor
+	}
+
+	for _, stat := range stats {
+		descriptor, exists := descriptorById[stat.RangeId]
+		if !exists || descriptor.Generation != stat.Generation {
+			rangeRegistry.logger.Info("Ignoring stats for stale range", "rangeId", stat.RangeId, "generation", stat.Generation)
+			continue
+		}
+		rangeRegistry.rangeStats[stat.RangeId] = stat
+	}
+
+	for _, descriptor := range descriptors {
+		stat, exists := rangeRegistry.rangeStats[descriptor.RangeId]
+		if !exists || !rangeRegistry.shouldSplit(descriptor, stat) {
+			continue
+		}
+		if err := rangeRegistry.splitRange(descriptor, stat.SplitKey); err != nil {
+			return err
+		}
+	}
+
+	descriptors, err = rangeRegistry.readDescriptors()
+	if err != nil {
+		return err
+	}
+	for index := 0; index+1 < len(descriptors); index++ {
+		left, right := descriptors[index], descriptors[index+1]
+		if !rangeRegistry.shouldMerge(left, right) {
+			continue
+		}
+		if err := rangeRegistry.mergeRanges(left, right); err != nil {
+			return err
+		}
+		index++
+	}
+	return nil
+}
+
+// AssignOwners hands every range an owner and records the nodes holding a copy
+// of it. In RangeModePrimary the primary owns every range. In RangeModeOwner a
+// range keeps its owner while that node is one of the candidates, otherwise it
+// goes to the candidate owning the fewest ranges. Ranges are then moved one at
+// a time from the busiest candidate until no candidate owns two more ranges
+// than another, so a node that joins takes its share.
+func (rangeRegistry *RangeRegistry) AssignOwners(candidates []string, replicas []string, primary string) error {
+	rangeRegistry.mu.Lock()
+	defer rangeRegistry.mu.Unlock()
+
+	descriptors, err := rangeRegistry.readDescriptors()
+	if err != nil {
+		return err
+	}
+	owners := make([]string, len(descriptors))
+	if rangeRegistry.mode == RangeModePrimary {
+		for index := range descriptors {
+			owners[index] = primary
+		}
+	} else {
+		owners = assignRangeOwners(descriptors, candidates)
+	}
+
+	for index, descriptor := range descriptors {
+		if owners[index] == descriptor.OwnerNodeId && slices.Equal(replicas, descriptor.ReplicaNodeIds) {
+			continue
+		}
+		if owners[index] != descriptor.OwnerNodeId {
+			rangeRegistry.logger.Info("Assigning range owner", "rangeId", descriptor.RangeId, "from", descriptor.OwnerNodeId, "to", owners[index])
+		}
+		updated := proto.Clone(descriptor).(*pb.RangeDescriptor)
+		updated.OwnerNodeId = owners[index]
+		updated.ReplicaNodeIds = slices.Clone(replicas)
+		if err := rangeRegistry.writeDescriptor(updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignRangeOwners returns the owner of every descriptor in RangeModeOwner, an
+// empty owner when there are no candidates
+func assignRangeOwners(descriptors []*pb.RangeDescriptor, candidates []string) []string {
+	owners := make([]string, len(descriptors))
+	if len(candidates) == 0 {
+		return owners
+	}
+	owned := make(map[string]int, len(candidates))
+	for _, candidate := range candidates {
+		owned[candidate] = 0
+	}
+	for index, descriptor := range descriptors {
+		if _, exists := owned[descriptor.OwnerNodeId]; exists {
+			owners[index] = descriptor.OwnerNodeId
+			owned[descriptor.OwnerNodeId]++
+		}
+	}
+	// Candidates are sorted, ties go to the lowest node id so every call hands
+	// out the same owners
+	leastLoaded := func() string {
+		least := candidates[0]
+		for _, candidate := range candidates[1:] {
+			if owned[candidate] < owned[least] {
+				least = candidate
+			}
+		}
+		return least
+	}
+	for index := range descriptors {
+		if owners[index] == "" {
+			owners[index] = leastLoaded()
+			owned[owners[index]]++
+		}
+	}
+	for {
+		least := leastLoaded()
+		busiest := candidates[0]
+		for _, candidate := range candidates[1:] {
+			if owned[candidate] > owned[busiest] {
+				busiest = candidate
+			}
+		}
+		if owned[busiest]-owned[least] < 2 {
+			return owners
+		}
+		index := slices.Index(owners, busiest)
+		owners[index] = least
+		owned[busiest]--
+		owned[least]++
+	}
+}
+
+func (rangeRegistry *RangeRegistry) shouldSplit(descriptor *pb.RangeDescriptor, stat *pb.RangeStats) bool {
+	if stat.SizeBytes <= rangeRegistry.thresholds.MaxRangeSizeBytes &&
+		stat.QueriesPerSecond <= rangeRegistry.thresholds.MaxRangeQPS {
+		return false
+	}
+	// The split key has to leave a non empty range on either side
+	if stat.SplitKey <= descriptor.StartKey {
+		return false
+	}
+	return descriptor.EndKey == "" || stat.SplitKey < descriptor.EndKey
+}
+
+func (rangeRegistry *RangeRegistry) shouldMerge(left *pb.RangeDescriptor, right *pb.RangeDescriptor) bool {
+	leftStat, leftExists := rangeRegistry.rangeStats[left.RangeId]
+	rightStat, rightExists := rangeRegistry.rangeStats[right.RangeId]
+	if !leftExists || !rightExists {
+		return false
+	}
+	return leftStat.SizeBytes+rightStat.SizeBytes < rangeRegistry.thresholds.MinRangeSizeBytes &&
+		leftStat.QueriesPerSecond+rightStat.QueriesPerSecond < rangeRegistry.thresholds.MaxRangeQPS/2
+}
+
+func (rangeRegistry *RangeRegistry) splitRange(descriptor *pb.RangeDescriptor, splitKey string) error {
+	rangeRegistry.logger.Info("Splitting range", "rangeId", descriptor.RangeId, "splitKey", splitKey)
+	left := proto.Clone(descriptor).(*pb.RangeDescriptor)
+	left.EndKey = splitKey
+	left.Generation++
+
+	// The right half is handed an owner of its own by the next AssignOwners
+	right := &pb.RangeDescriptor{
+		RangeId:        rangeRegistry.nextRangeId,
+		StartKey:       splitKey,
+		EndKey:         descriptor.EndKey,
+		Generation:     left.Generation,
+		ReplicaNodeIds: slices.Clone(descriptor.ReplicaNodeIds),
+	}
+	rangeRegistry.nextRangeId++
+
+	if err := rangeRegistry.writeDescriptor(left); err != nil {
+		return err
+	}
+	if err := rangeRegistry.writeDescriptor(right); err != nil {
+		return err
+	}
+	delete(rangeRegistry.rangeStats, descriptor.RangeId)
+	return nil
+}
+
+func (rangeRegistry *RangeRegistry) mergeRanges(left *pb.RangeDescriptor, right *pb.RangeDescriptor) error {
+	rangeRegistry.logger.Info("Merging ranges", "leftRangeId", left.RangeId, "rightRangeId", right.RangeId)
+	// The merged range stays with the owner of the left one
+	merged := proto.Clone(left).(*pb.RangeDescriptor)
+	merged.EndKey = right.EndKey
+	merged.Generation = max(left.Generation, right.Generation) + 1
+
+	if err := rangeRegistry.writeDescriptor(merged); err != nil {
+		return err
+	}
+	if err := rangeRegistry.metaRange.Delete(metaRangePrefix + right.StartKey); err != nil {
+		rangeRegistry.logger.Error("Failed to remove merged range descriptor", "rangeId", right.RangeId)
+		return err
+	}
+	delete(rangeRegistry.rangeStats, left.RangeId)
+	delete(rangeRegistry.rangeStats, right.RangeId)
+	return nil
+}
+
+func (rangeRegistry *RangeRegistry) writeDescriptor(descriptor *pb.RangeDescriptor) error {
+	encoded, err := proto.Marshal(descriptor)
+	if err != nil {
+		rangeRegistry.logger.Error("Failed to encode range descriptor", "rangeId", descriptor.RangeId)
+		return fmt.Errorf("Failed to encode range descriptor %d: %w", descriptor.RangeId, err)
+	}
+	return rangeRegistry.metaRange.Set(metaRangePrefix+descriptor.StartKey, string(encoded))
+}
+
+func (rangeRegistry *RangeRegistry) readDescriptors() ([]*pb.RangeDescriptor, error) {
+	entries, err := rangeRegistry.metaRange.Scan(metaRangePrefix, metaRangeEnd, 0)
+	if err != nil {
+		rangeRegistry.logger.Error("Failed to scan the metadata range")
+		return nil, err
+	}
+
+	descriptors := make([]*pb.RangeDescriptor, 0, len(entries))
+	for _, entry := range entries {
+		descriptor := &pb.RangeDescriptor{}
+		if err := proto.Unmarshal([]byte(entry.Value), descriptor); err != nil {
+			rangeRegistry.logger.Error("Failed to decode range descriptor", "metaKey", entry.Key)
+			return nil, fmt.Errorf("Failed to decode range descriptor %s: %w", entry.Key, err)
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].StartKey < descriptors[j].StartKey
+	})
+	return descriptors, nil
+}
+
+// PersistedRanges is the on disk form of the metadata range
+type PersistedRanges struct {
+	NextRangeId int64            `json:"nextRangeId"`
+	Ranges      []PersistedRange `json:"ranges"`
+}
+
+type PersistedRange struct {
+	RangeId        int64    `json:"rangeId"`
+	StartKey       string   `json:"startKey"`
+	EndKey         string   `json:"endKey"`
+	Generation     int64    `json:"generation"`
+	OwnerNodeId    string   `json:"ownerNodeId,omitempty"`
+	ReplicaNodeIds []string `json:"replicaNodeIds,omitempty"`
+}
+
+func (rangeRegistry *RangeRegistry) Snapshot() (PersistedRanges, error) {
+	rangeRegistry.mu.Lock()
+	defer rangeRegistry.mu.Unlock()
+
+	descriptors, err := rangeRegistry.readDescriptors()
+	if err != nil {
+		return PersistedRanges{}, err
+	}
+	snapshot := PersistedRanges{NextRangeId: rangeRegistry.nextRangeId}
+	for _, descriptor := range descriptors {
+		snapshot.Ranges = append(snapshot.Ranges, PersistedRange{
+			RangeId:        descriptor.RangeId,
+			StartKey:       descriptor.StartKey,
+			EndKey:         descriptor.EndKey,
+			Generation:     descriptor.Generation,
+			OwnerNodeId:    descriptor.OwnerNodeId,
+			ReplicaNodeIds: descriptor.ReplicaNodeIds,
+		})
+	}
+	return snapshot, nil
+}
+
+// Restore replaces the metadata range with a snapshot taken by Snapshot. An
+// empty snapshot leaves the current ranges in place.
+func (rangeRegistry *RangeRegistry) Restore(snapshot PersistedRanges) error {
+	if len(snapshot.Ranges) == 0 {
+		return nil
+	}
+
+	rangeRegistry.mu.Lock()
+	defer rangeRegistry.mu.Unlock()
+
+	existing, err := rangeRegistry.metaRange.Scan(metaRangePrefix, metaRangeEnd, 0)
+	if err != nil {
+		return err
+	}
+	for _, entry := range existing {
+		if err := rangeRegistry.metaRange.Delete(entry.Key); err != nil {
+			return err
+		}
+	}
+	for _, persistedRange := range snapshot.Ranges {
+		err := rangeRegistry.writeDescriptor(&pb.RangeDescriptor{
+			RangeId:        persistedRange.RangeId,
+			StartKey:       persistedRange.StartKey,
+			EndKey:         persistedRange.EndKey,
+			Generation:     persistedRange.Generation,
+			OwnerNodeId:    persistedRange.OwnerNodeId,
+			ReplicaNodeIds: persistedRange.ReplicaNodeIds,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	rangeRegistry.nextRangeId = snapshot.NextRangeId
+	rangeRegistry.rangeStats = make(map[int64]*pb.RangeStats)
+	rangeRegistry.logger.Info("Restored range descriptors", "count", len(snapshot.Ranges))
+	return nil
+}
@@ -9,13 +9,15 @@ import (
 
 type server struct {
 	pb.UnimplementedRegistryServiceServer
-	logger       slog.Logger
-	nodeRegistry *controllers.NodeRegistry
+	logger        slog.Logger
+	nodeRegistry  *controllers.NodeRegistry
+	rangeRegistry *controllers.RangeRegistry
 }
 
-func InitializeNewServer(logger slog.Logger) *server {
+func InitializeNewServer(rangeThresholds controllers.RangeThresholds, rangeMode controllers.RangeMode, healthThresholds controllers.NodeHealthThresholds, logger slog.Logger) *server {
 	return &server{
-		logger:       logger,
-		nodeRegistry: controllers.InitializeNodeRegistry(logger),
+		logger:        logger,
+		nodeRegistry:  controllers.InitializeNodeRegistry(healthThresholds, logger),
+		rangeRegistry: controllers.InitializeRangeRegistry(rangeThresholds, rangeMode, logger),
 	}
 }
@@ -2,9 +2,13 @@ package registry
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net"
+	"time"
 
+	"github.com/Vahsek/distrokv/internal/registry/controllers"
 	pb "github.com/Vahsek/distrokv/pkg/registry"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -14,7 +18,14 @@ import (
 func (registryServer *server) RegisterNode(ctx context.Context, request *pb.RegisterNodeRequest) (*pb.RegisterNodeResponse, error) {
 	logger := registryServer.logger
 	logger.Info("Request to regsister new node")
-	err := registryServer.nodeRegistry.RegisterNewNode(request)
+	role, err := registryServer.nodeRegistry.RegisterNewNode(request)
+	if errors.Is(err, controllers.ErrMissingNodeID) {
+		logger.Error("Node registered without a node id")
+		return &pb.RegisterNodeResponse{
+			Status:  "400",
+			Message: "Node id is required",
+		}, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		logger.Error("Failed to register the node")
 		return &pb.RegisterNodeResponse{
@@ -23,19 +34,28 @@ func (registryServer *server) RegisterNode(ctx context.Context, request *pb.Regi
 		}, status.Error(codes.Internal, err.Error())
 	}
 	logger.Info("Successfully registered the node")
+	configuration, _ := registryServer.nodeRegistry.Configuration()
 	return &pb.RegisterNodeResponse{
-		Status:  "200",
-		Message: "Node registered successfully",
+		Status:        "200",
+		Message:       "Node registered successfully",
+		PrimaryNodeId: registryServer.primaryNodeID(),
+		Role:          pb.NodeRole(role),
+		Configuration: configuration,
 	}, nil
 }
 
 func (registryServer *server) GetPrimaryNode(ctx context.Context, request *pb.PrimaryNodeRequest) (*pb.PrimaryNodeResponse, error) {
-	return nil, nil
+	primary, err := registryServer.nodeRegistry.GetPrimaryNode()
+	if err != nil {
+		registryServer.logger.Error("Failed to get the primary node")
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return primary, nil
 }
 
 func (registryServer *server) NodeHeartBeat(ctx context.Context, request *pb.HeartBeatRequest) (*pb.HeartBeatResponse, error) {
 	logger := registryServer.logger
-	err := registryServer.nodeRegistry.RegisterNodeHeartBeat(request)
+	draining, role, err := registryServer.nodeRegistry.RegisterNodeHeartBeat(request)
 	if err != nil {
 		logger.Error("Failed to register node heatbeat")
 		return &pb.HeartBeatResponse{
@@ -44,13 +64,47 @@ func (registryServer *server) NodeHeartBeat(ctx context.Context, request *pb.Hea
 		}, status.Error(codes.Internal, err.Error())
 	}
 
+	if err := registryServer.rangeRegistry.ApplyRangeStats(request.RangeStats); err != nil {
+		logger.Error("Failed to apply range stats", "error", err)
+	}
+	ranges, err := registryServer.rangeDescriptors()
+	if err != nil {
+		logger.Error("Failed to read range descriptors", "error", err)
+	}
+
 	logger.Info("successfully registered the node heartbeat")
+	configuration, _ := registryServer.nodeRegistry.Configuration()
 	return &pb.HeartBeatResponse{
-		Status:  "200",
-		Message: "Heartbeat registed successfully",
+		Status:        "200",
+		Message:       "Heartbeat registed successfully",
+		Ranges:        ranges,
+		Draining:      draining,
+		PrimaryNodeId: registryServer.primaryNodeID(),
+		Role:          pb.NodeRole(role),
+		Configuration: configuration,
 	}, nil
 }
 
+// primaryNodeID tells nodes who the primary is so it can hold the read lease,
+// an empty id means there is no primary
+func (registryServer *server) primaryNodeID() string {
+	primary, err := registryServer.nodeRegistry.GetPrimaryNode()
+	if err != nil {
+		return ""
+	}
+	return primary.NodeId
+}
+
+// rangeDescriptors hands out the ranges once every one of them has an owner
+// among the live nodes
+func (registryServer *server) rangeDescriptors() ([]*pb.RangeDescriptor, error) {
+	candidates, replicas := registryServer.nodeRegistry.RangePlacement()
+	if err := registryServer.rangeRegistry.AssignOwners(candidates, replicas, registryServer.primaryNodeID()); err != nil {
+		return nil, err
+	}
+	return registryServer.rangeRegistry.GetRangeDescriptors()
+}
+
 func (registryServer *server) GetNodeList(ctx context.Context, request *pb.NodeListRequest) (*pb.NodeListResponse, error) {
 	nodeList := registryServer.nodeRegistry.GetNodeList()
 	return &pb.NodeListResponse{
@@ -58,18 +112,166 @@ func (registryServer *server) GetNodeList(ctx context.Context, request *pb.NodeL
 	}, nil
 }
 
-func StartRegistryServer(portNumber string, logger slog.Logger) {
+func (registryServer *server) GetRangeDescriptors(ctx context.Context, request *pb.RangeDescriptorsRequest) (*pb.RangeDescriptorsResponse, error) {
+	ranges, err := registryServer.rangeDescriptors()
+	if err != nil {
+		registryServer.logger.Error("Failed to read range descriptors")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.RangeDescriptorsResponse{
+		Ranges: ranges,
+	}, nil
+}
+
+func (registryServer *server) DeregisterNode(ctx context.Context, request *pb.DeregisterNodeRequest) (*pb.DeregisterNodeResponse, error) {
+	logger := registryServer.logger
+	logger.Info("Request to deregister node")
+	err := registryServer.nodeRegistry.DeregisterNode(request)
+	if err != nil {
+		logger.Error("Failed to deregister the node")
+		return &pb.DeregisterNodeResponse{
+			Status:  "404",
+			Message: "Node is not registered",
+		}, status.Error(codes.NotFound, err.Error())
+	}
+	logger.Info("Successfully deregistered the node")
+	return &pb.DeregisterNodeResponse{
+		Status:  "200",
+		Message: "Node deregistered successfully",
+	}, nil
+}
+
+func (registryServer *server) DrainNode(ctx context.Context, request *pb.DrainNodeRequest) (*pb.DrainNodeResponse, error) {
+	logger := registryServer.logger
+	logger.Info("Request to drain node")
+	err := registryServer.nodeRegistry.DrainNode(request)
+	if errors.Is(err, controllers.ErrNodeNotFound) {
+		return &pb.DrainNodeResponse{
+			Status:  "404",
+			Message: "Node is not registered",
+		}, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logger.Error("Failed to drain the node")
+		return &pb.DrainNodeResponse{
+			Status:  "412",
+			Message: "Node cannot be drained",
+		}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	logger.Info("Node is draining")
+	return &pb.DrainNodeResponse{
+		Status:  "200",
+		Message: "Node is draining",
+	}, nil
+}
+
+func (registryServer *server) PromoteLearner(ctx context.Context, request *pb.PromoteLearnerRequest) (*pb.PromoteLearnerResponse, error) {
+	logger := registryServer.logger
+	logger.Info("Request to promote learner")
+	err := registryServer.nodeRegistry.PromoteLearner(request)
+	if errors.Is(err, controllers.ErrNodeNotFound) {
+		return &pb.PromoteLearnerResponse{
+			Status:  "404",
+			Message: "Node is not registered",
+		}, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logger.Error("Failed to promote the learner", "error", err)
+		return &pb.PromoteLearnerResponse{
+			Status:  "412",
+			Message: "Node cannot be promoted",
+		}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	logger.Info("Node is a voter")
+	return &pb.PromoteLearnerResponse{
+		Status:  "200",
+		Message: "Node is a voter",
+	}, nil
+}
+
+func (registryServer *server) TransferPrimary(ctx context.Context, request *pb.TransferPrimaryRequest) (*pb.TransferPrimaryResponse, error) {
+	logger := registryServer.logger
+	logger.Info("Request to transfer the primary")
+	primaryNodeId, err := registryServer.nodeRegistry.TransferPrimary(request.FromNodeId, request.ToNodeId)
+	if errors.Is(err, controllers.ErrNodeNotFound) {
+		return &pb.TransferPrimaryResponse{
+			Status:        "404",
+			Message:       "Node is not registered",
+			PrimaryNodeId: primaryNodeId,
+		}, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logger.Error("Failed to transfer the primary", "error", err)
+		return &pb.TransferPrimaryResponse{
+			Status:        "412",
+			Message:       "Primary cannot be transferred",
+			PrimaryNodeId: primaryNodeId,
+		}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	logger.Info("Primary transferred", "primaryNodeId", primaryNodeId)
+	return &pb.TransferPrimaryResponse{
+		Status:        "200",
+		Message:       "Primary transferred",
+		PrimaryNodeId: primaryNodeId,
+	}, nil
+}
+
+func (registryServer *server) GetClusterConfiguration(ctx context.Context, request *pb.ClusterConfigurationRequest) (*pb.ClusterConfigurationResponse, error) {
+	configuration, acknowledged := registryServer.nodeRegistry.Configuration()
+	return &pb.ClusterConfigurationResponse{
+		Configuration: configuration,
+		Acknowledged:  acknowledged,
+	}, nil
+}
+
+// StartRegistryServer restores the registry state from dataDir and serves
+// until ctx is done. In flight RPCs get shutdownTimeout to finish before the
+// state is written back to dataDir.
+func StartRegistryServer(ctx context.Context, portNumber string, rangeThresholds controllers.RangeThresholds, rangeMode controllers.RangeMode, healthThresholds controllers.NodeHealthThresholds, dataDir string, shutdownTimeout time.Duration, logger slog.Logger) error {
+	registryServer := InitializeNewServer(rangeThresholds, rangeMode, healthThresholds, logger)
+	if err := registryServer.loadState(dataDir); err != nil {
+		logger.Error("Failed to restore registry state", "error", err)
+		return err
+	}
+
 	logger.Info("Creating TCP Socket on port" + portNumber)
 	lis, err := net.Listen("tcp", portNumber)
 	if err != nil {
 		logger.Error("Error in Creating TCP socket")
+		return err
 	}
 	regServer := grpc.NewServer()
 	logger.Info("Initializing GRPC service for registry")
-	pb.RegisterRegistryServiceServer(regServer, InitializeNewServer(logger))
-	if err := regServer.Serve(lis); err != nil {
-		logger.Info("Failed to initialize GRPC server for registry")
-	} else {
-		logger.Info("Successfully initialized GRPC server")
+	pb.RegisterRegistryServiceServer(regServer, registryServer)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- regServer.Serve(lis)
+	}()
+
+	var errs []error
+	select {
+	case err := <-serveErr:
+		logger.Error("GRPC server for registry stopped", "error", err)
+		errs = append(errs, err)
+	case <-ctx.Done():
+		logger.Info("Shutting down the registry")
+		stopped := make(chan struct{})
+		go func() {
+			regServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(shutdownTimeout):
+			regServer.Stop()
+			errs = append(errs, fmt.Errorf("in flight requests did not finish within %s", shutdownTimeout))
+		}
+	}
+
+	if err := registryServer.saveState(dataDir); err != nil {
+		logger.Error("Failed to persist registry state", "error", err)
+		errs = append(errs, err)
 	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Vahsek/distrokv/internal/registry/controllers"
+)
+
+const registryStateFile = "registry-state.json"
+
+// registryState is everything the registry needs to come back with the same
+// view of the cluster after a restart
+type registryState struct {
+	Nodes         []controllers.PersistedNode        `json:"nodes"`
+	Ranges        controllers.PersistedRanges        `json:"ranges"`
+	Configuration controllers.PersistedConfiguration `json:"configuration"`
+}
+
+// saveState writes the registry state to dataDir. The file is replaced
+// atomically so a crash while saving leaves the previous state intact.
+func (registryServer *server) saveState(dataDir string) error {
+	ranges, err := registryServer.rangeRegistry.Snapshot()
+	if err != nil {
+		return err
+	}
+	encoded, err := json.MarshalIndent(registryState{
+		Nodes:         registryServer.nodeRegistry.Snapshot(),
+		Ranges:        ranges,
+		Configuration: registryServer.nodeRegistry.SnapshotConfiguration(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode registry state: %w", err)
+	}
+
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return fmt.Errorf("Failed to create data directory %s: %w", dataDir, err)
+	}
+	statePath := filepath.Join(dataDir, registryStateFile)
+	tempFile, err := os.CreateTemp(dataDir, registryStateFile+".*")
+	if err != nil {
+		return fmt.Errorf("Failed to create registry state file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(encoded); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("Failed to write registry state: %w", err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("Failed to sync registry state: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("Failed to close registry state file: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), statePath); err != nil {
+		return fmt.Errorf("Failed to replace registry state: %w", err)
+	}
+	registryServer.logger.Info("Persisted registry state", "path", statePath)
+	return nil
+}
+
+// loadState restores the state saved by saveState. A missing file means the
+// registry starts out empty.
+func (registryServer *server) loadState(dataDir string) error {
+	statePath := filepath.Join(dataDir, registryStateFile)
+	encoded, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		registryServer.logger.Info("No persisted registry state found", "path", statePath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read registry state: %w", err)
+	}
+
+	var state registryState
+	if err := json.Unmarshal(encoded, &state); err != nil {
+		return fmt.Errorf("Failed to decode registry state %s: %w", statePath, err)
+	}
+	registryServer.nodeRegistry.Restore(state.Nodes)
+	registryServer.nodeRegistry.RestoreConfiguration(state.Configuration)
+	return registryServer.rangeRegistry.Restore(state.Ranges)
+}
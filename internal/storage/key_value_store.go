@@ -1,78 +1,500 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+)
+
+var (
+	ErrKeyNotFound = errors.New("key not found")
+	ErrStoreClosed = errors.New("store is closed")
 )
 
 type KeyValueStoreOperations interface {
 	Get(key string) (string, error)
 	Set(key string, value string) error
 	Delete(key string) error
+	Scan(startKey string, endKey string, limit int) ([]KeyValue, error)
+	Txn(compares []TxnCompare, success []TxnOperation, failure []TxnOperation) (TxnResult, error)
+}
+
+// KeyValue is a key with its value and the hybrid logical clock timestamp of
+// the write that set it. Deleted marks a tombstone, only ScanMerkleLeaves
+// returns those.
+type KeyValue struct {
+	Key       string
+	Value     string
+	Timestamp int64
+	Deleted   bool
 }
 
 type KeyValueStore struct {
-	data   map[string]string
-	mu     sync.RWMutex
-	logger slog.Logger
+	data map[string]string
+	// timestamps holds the timestamp of every key in data, stamped by
+	// clock unless the write carried one
+	timestamps map[string]int64
+	// tombstones holds the timestamp of every deleted key until it is
+	// dropped by compaction, so a put older than the delete cannot bring
+	// the key back
+	tombstones map[string]int64
+	clock      *hlc.Clock
+	mu         sync.RWMutex
+	rangeUsage rangeUsageTracker
+	watchers   watcherRegistry
+	// merkle is kept in step with data by put and remove
+	merkle merkleLeaves
+	// wal is nil for a store that keeps its data in memory only
+	wal *writeAheadLog
+	// mergeValues is nil unless values written on several nodes concurrently
+	// are merged rather than the newest one kept
+	mergeValues MergeFunc
+	closed      bool
+	logger      slog.Logger
 }
 
+// MergeFunc returns the value holding the writes of both stored and incoming
+// and whether it could merge them at all
+type MergeFunc func(stored string, incoming string) (string, bool)
+
+// NewKeyValueStore returns an in memory store that stamps writes with a clock
+// of its own
 func NewKeyValueStore(logger slog.Logger) *KeyValueStore {
+	return newKeyValueStore(hlc.NewClock(hlc.DefaultMaxSkew, logger), logger)
+}
+
+func newKeyValueStore(clock *hlc.Clock, logger slog.Logger) *KeyValueStore {
 	return &KeyValueStore{
-		data:   make(map[string]string),
-		logger: logger,
+		data:       make(map[string]string),
+		timestamps: make(map[string]int64),
+		tombstones: make(map[string]int64),
+		clock:      clock,
+		logger:     logger,
 	}
 }
 
+// SetMergeFunc makes replicated and restored writes to a key merge with the
+// stored value whenever merge accepts both, instead of the newer one winning.
+// Writes stamped by this store are never merged.
+func (kvs *KeyValueStore) SetMergeFunc(merge MergeFunc) {
+	kvs.mu.Lock()
+	defer kvs.mu.Unlock()
+
+	kvs.mergeValues = merge
+}
+
 func (kvs *KeyValueStore) Get(key string) (string, error) {
 	kvs.mu.RLock()
 	defer kvs.mu.RUnlock()
 
 	kvs.logger.Info("Get Request for Key", "key", key)
+	if kvs.closed {
+		return "", ErrStoreClosed
+	}
+	kvs.rangeUsage.recordOperation(key)
 	value, exists := kvs.data[key]
 	if !exists {
 		kvs.logger.Error("The Key doesn't exist", "key", key)
-		return "", fmt.Errorf("The Key %s doesn't exists", key)
+		return "", fmt.Errorf("The Key %s doesn't exists: %w", key, ErrKeyNotFound)
 	}
 	return value, nil
 }
 
+// Set returns once the write is durable. With a log the write is visible to
+// readers as soon as it is applied, a little before it is acknowledged, and
+// is rolled back if the log fails to commit it.
 func (kvs *KeyValueStore) Set(key string, value string) error {
+	_, err := kvs.SetStamped(key, value)
+	return err
+}
+
+// SetStamped is Set returning the timestamp the write was stamped with. It is
+// taken under the store lock, so writes to a key are stamped in the order they
+// are applied and replicas that keep the newest one end up with the same value.
+func (kvs *KeyValueStore) SetStamped(key string, value string) (int64, error) {
+	timestamp, commit, err := kvs.set(key, value)
+	if err != nil {
+		return 0, err
+	}
+	return timestamp, commit.wait()
+}
+
+func (kvs *KeyValueStore) set(key string, value string) (int64, *walCommit, error) {
 	kvs.mu.Lock()
 	defer kvs.mu.Unlock()
 
 	kvs.logger.Info("Set Request", "key", key, "value", value)
-	kvs.data[key] = value
+	if kvs.closed {
+		return 0, nil, ErrStoreClosed
+	}
+	timestamp := kvs.clock.Now()
+	commit, err := kvs.log(walOperation{key: key, value: value, timestamp: timestamp})
+	if err != nil {
+		return 0, nil, err
+	}
+	kvs.rangeUsage.recordOperation(key)
+	kvs.put(key, value, timestamp)
 	_, exist := kvs.data[key]
 	if !exist {
 		kvs.logger.Error("Failed to set key", "key", key)
-		return fmt.Errorf("Failed to set the key %s", key)
+		return 0, nil, fmt.Errorf("Failed to set the key %s", key)
 	}
+	kvs.notifyWatchers(WatchEvent{Type: WatchEventPut, Key: key, Value: value})
 	kvs.logger.Info("Successfully set the key", "key", key)
-	return nil
+	return timestamp, commit, nil
 }
 
+// Delete returns once the delete is durable
 func (kvs *KeyValueStore) Delete(key string) error {
+	_, err := kvs.DeleteStamped(key)
+	return err
+}
+
+// DeleteStamped is Delete returning the timestamp the delete was stamped
+// with. The key leaves a tombstone with it, so replicas that keep the newest
+// write to the key agree on whether it exists.
+func (kvs *KeyValueStore) DeleteStamped(key string) (int64, error) {
+	timestamp, commit, err := kvs.delete(key)
+	if err != nil {
+		return 0, err
+	}
+	return timestamp, commit.wait()
+}
+
+func (kvs *KeyValueStore) delete(key string) (int64, *walCommit, error) {
 	kvs.mu.Lock()
 	defer kvs.mu.Unlock()
 
 	kvs.logger.Info("Delete Request for key: ", "key", key)
+	if kvs.closed {
+		return 0, nil, ErrStoreClosed
+	}
+	kvs.rangeUsage.recordOperation(key)
 	_, exist := kvs.data[key]
 	if !exist {
 		kvs.logger.Error("Failed to delete key", "key", key)
-		return fmt.Errorf("Key doesn't exist. Failed to delete the key %s", key)
+		return 0, nil, fmt.Errorf("Key doesn't exist. Failed to delete the key %s: %w", key, ErrKeyNotFound)
+	}
+	timestamp := kvs.clock.Now()
+	commit, err := kvs.log(walOperation{delete: true, key: key, timestamp: timestamp})
+	if err != nil {
+		return 0, nil, err
 	}
 
-	delete(kvs.data, key)
+	kvs.remove(key, timestamp)
 
 	_, existAfterDelete := kvs.data[key]
 
 	if existAfterDelete {
 		kvs.logger.Error("Failed to delete the key:", "key", key)
-		return fmt.Errorf("Failed to delete the key %s", key)
+		return 0, nil, fmt.Errorf("Failed to delete the key %s", key)
 	}
 
+	kvs.notifyWatchers(WatchEvent{Type: WatchEventDelete, Key: key})
 	kvs.logger.Info("Key deleted successfully")
+	return timestamp, commit, nil
+}
+
+// Apply applies independent puts and deletes under a single write lock and
+// returns an error for each, a delete of a missing key fails alone with
+// ErrKeyNotFound but still leaves its tombstone. A write carrying a timestamp
+// the key's current write or tombstone is not older than is dropped, so
+// replicated writes that arrive out of order end up with the last writer's
+// value. A write stamped too far ahead of the local clock fails with
+// hlc.ErrClockSkew. The writes share one log record and are durable on return.
+func (kvs *KeyValueStore) Apply(operations []TxnOperation) []error {
+	errs, durable := kvs.ApplyAsync(operations)
+	if err := durable(); err != nil {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+	return errs
+}
+
+// ApplyAsync is Apply without waiting for the group commit, durable blocks
+// until the writes are. Callers that apply in order but wait apart keep
+// several commits in flight.
+func (kvs *KeyValueStore) ApplyAsync(operations []TxnOperation) (errs []error, durable func() error) {
+	errs, commit := kvs.apply(operations)
+	return errs, commit.wait
+}
+
+func (kvs *KeyValueStore) apply(operations []TxnOperation) ([]error, *walCommit) {
+	kvs.mu.Lock()
+	defer kvs.mu.Unlock()
+
+	errs := make([]error, len(operations))
+	if kvs.closed {
+		for i := range errs {
+			errs[i] = ErrStoreClosed
+		}
+		return errs, nil
+	}
+	var writes []walOperation
+	values := make([]string, len(operations))
+	timestamps := make([]int64, len(operations))
+	superseded := make([]bool, len(operations))
+	for i, operation := range operations {
+		if operation.Type != OperationPut && operation.Type != OperationDelete {
+			errs[i] = fmt.Errorf("Unsupported operation %d", operation.Type)
+			continue
+		}
+		value, timestamp := operation.Value, operation.Timestamp
+		merged := false
+		if operation.Type == OperationPut {
+			value, timestamp, merged = kvs.merge(operation.Key, value, timestamp)
+		}
+		if merged && value == kvs.data[operation.Key] || !merged && kvs.supersedes(operation.Key, timestamp) {
+			superseded[i] = true
+			continue
+		}
+		timestamp, err := kvs.stamp(timestamp)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		values[i] = value
+		timestamps[i] = timestamp
+		// A put earlier in the batch may create the key, a delete that
+		// turns out to miss only leaves its tombstone on replay
+		writes = append(writes, walOperation{delete: operation.Type == OperationDelete, key: operation.Key, value: value, timestamp: timestamp})
+	}
+	commit, err := kvs.log(writes...)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs, nil
+	}
+
+	for i, operation := range operations {
+		if errs[i] != nil {
+			continue
+		}
+		kvs.rangeUsage.recordOperation(operation.Key)
+		if superseded[i] {
+			kvs.logger.Debug("Dropped a write older than the stored one", "key", operation.Key, "timestamp", operation.Timestamp)
+			continue
+		}
+		if operation.Type == OperationPut {
+			kvs.put(operation.Key, values[i], timestamps[i])
+			kvs.notifyWatchers(WatchEvent{Type: WatchEventPut, Key: operation.Key, Value: values[i]})
+			continue
+		}
+		_, exist := kvs.data[operation.Key]
+		kvs.remove(operation.Key, timestamps[i])
+		if !exist {
+			errs[i] = fmt.Errorf("Key doesn't exist. Failed to delete the key %s: %w", operation.Key, ErrKeyNotFound)
+			continue
+		}
+		kvs.notifyWatchers(WatchEvent{Type: WatchEventDelete, Key: operation.Key})
+	}
+	kvs.logger.Debug("Applied writes", "operations", len(operations))
+	return errs, commit
+}
+
+// Scan returns the key value pairs in [startKey, endKey) in key order. An empty
+// endKey means the scan is unbounded and a limit <= 0 returns every match.
+func (kvs *KeyValueStore) Scan(startKey string, endKey string, limit int) ([]KeyValue, error) {
+	kvs.mu.RLock()
+	defer kvs.mu.RUnlock()
+
+	kvs.logger.Info("Scan Request", "startKey", startKey, "endKey", endKey, "limit", limit)
+	if kvs.closed {
+		return nil, ErrStoreClosed
+	}
+	if endKey != "" && endKey < startKey {
+		kvs.logger.Error("Invalid scan bounds", "startKey", startKey, "endKey", endKey)
+		return nil, fmt.Errorf("Invalid scan bounds: start key %s is after end key %s", startKey, endKey)
+	}
+
+	keys := kvs.sortedKeys(startKey, endKey)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	result := make([]KeyValue, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, KeyValue{Key: key, Value: kvs.data[key], Timestamp: kvs.timestamps[key]})
+	}
+	return result, nil
+}
+
+// Close ends every watch and rejects any later operation. Writes still
+// waiting for a group commit are flushed to the log first.
+func (kvs *KeyValueStore) Close() error {
+	kvs.mu.Lock()
+	if kvs.closed {
+		kvs.mu.Unlock()
+		return nil
+	}
+	kvs.cancelAllWatches()
+	kvs.closed = true
+	// The log takes the store lock to roll back a last commit that fails
+	kvs.mu.Unlock()
+
+	if kvs.wal != nil {
+		if err := kvs.wal.close(); err != nil {
+			kvs.logger.Error("Failed to close the write ahead log", "error", err)
+			return err
+		}
+	}
+	kvs.logger.Info("Closed the key value store")
 	return nil
 }
+
+// log appends operations to the write ahead log ahead of applying them and
+// expects the caller to hold the write lock. What the keys hold before is kept
+// so the writes can be rolled back if the log fails to commit them.
+func (kvs *KeyValueStore) log(operations ...walOperation) (*walCommit, error) {
+	if kvs.wal == nil || len(operations) == 0 {
+		return nil, nil
+	}
+	undo := make([]walOperation, len(operations))
+	for i, operation := range operations {
+		value, exists := kvs.data[operation.key]
+		timestamp, _ := kvs.current(operation.key)
+		undo[i] = walOperation{delete: !exists, key: operation.key, value: value, timestamp: timestamp}
+	}
+	return kvs.wal.append(operations, undo)
+}
+
+// rollback undoes the writes of commits that never reached the log, given
+// newest first, so readers stop seeing them. It expects the caller to hold the
+// write lock.
+func (kvs *KeyValueStore) rollback(commits ...*walCommit) {
+	undone := 0
+	for _, commit := range commits {
+		for i := len(commit.undo) - 1; i >= 0; i-- {
+			previous := commit.undo[i]
+			if previous.delete {
+				_, exists := kvs.data[previous.key]
+				kvs.remove(previous.key, previous.timestamp)
+				if exists {
+					kvs.notifyWatchers(WatchEvent{Type: WatchEventDelete, Key: previous.key})
+				}
+			} else {
+				kvs.put(previous.key, previous.value, previous.timestamp)
+				kvs.notifyWatchers(WatchEvent{Type: WatchEventPut, Key: previous.key, Value: previous.value})
+			}
+			undone++
+		}
+	}
+	kvs.logger.Warn("Rolled back writes the wal failed to commit", "writes", undone)
+}
+
+// stamp returns the timestamp a write is stored with. A timestamp the write
+// carries was taken on another node, the clock moves past it so later writes
+// here are ordered after it. A timestamp too far ahead of the local clock
+// fails with hlc.ErrClockSkew, storing it would order every later write to
+// the key before it.
+func (kvs *KeyValueStore) stamp(timestamp int64) (int64, error) {
+	if timestamp == 0 {
+		return kvs.clock.Now(), nil
+	}
+	if err := kvs.clock.Update(timestamp); err != nil {
+		kvs.logger.Warn("Rejected a write stamped by a skewed clock", "error", err)
+		return 0, err
+	}
+	return timestamp, nil
+}
+
+// current returns the timestamp of the write or the tombstone stored for key.
+// It expects the caller to hold the lock.
+func (kvs *KeyValueStore) current(key string) (int64, bool) {
+	if timestamp, exists := kvs.timestamps[key]; exists {
+		return timestamp, true
+	}
+	timestamp, exists := kvs.tombstones[key]
+	return timestamp, exists
+}
+
+// supersedes reports whether the write or tombstone stored for key is at
+// least as new as a write stamped timestamp, 0 being a write still to be
+// stamped. It expects the caller to hold the lock.
+func (kvs *KeyValueStore) supersedes(key string, timestamp int64) bool {
+	if timestamp == 0 {
+		return false
+	}
+	current, exists := kvs.current(key)
+	return exists && current >= timestamp
+}
+
+// merge returns the value and timestamp a put of value stamped timestamp is
+// stored with, merged with the value stored for key when the merge function
+// accepts both. A merged value carries the later of the two timestamps. It
+// expects the caller to hold the lock.
+func (kvs *KeyValueStore) merge(key string, value string, timestamp int64) (string, int64, bool) {
+	if kvs.mergeValues == nil || timestamp == 0 {
+		return value, timestamp, false
+	}
+	current, exists := kvs.data[key]
+	if !exists {
+		return value, timestamp, false
+	}
+	merged, ok := kvs.mergeValues(current, value)
+	if !ok {
+		return value, timestamp, false
+	}
+	return merged, max(timestamp, kvs.timestamps[key]), true
+}
+
+// put and remove expect the caller to hold the write lock
+func (kvs *KeyValueStore) put(key string, value string, timestamp int64) {
+	if current, exists := kvs.data[key]; exists {
+		kvs.merkle.toggle(key, current)
+	}
+	kvs.data[key] = value
+	kvs.timestamps[key] = timestamp
+	delete(kvs.tombstones, key)
+	kvs.merkle.toggle(key, value)
+}
+
+// remove leaves a tombstone stamped timestamp, 0 removes the key without one
+// for a delete whose time is unknown
+func (kvs *KeyValueStore) remove(key string, timestamp int64) {
+	if current, exists := kvs.data[key]; exists {
+		kvs.merkle.toggle(key, current)
+		delete(kvs.data, key)
+		delete(kvs.timestamps, key)
+	}
+	if timestamp != 0 {
+		kvs.tombstones[key] = timestamp
+	} else {
+		delete(kvs.tombstones, key)
+	}
+}
+
+// dropTombstones forgets the tombstones stamped before cutoff. A write older
+// than one of them can no longer arrive once it was retained long enough.
+// It expects the caller to hold the write lock.
+func (kvs *KeyValueStore) dropTombstones(cutoff int64) int {
+	dropped := 0
+	for key, timestamp := range kvs.tombstones {
+		if timestamp < cutoff {
+			delete(kvs.tombstones, key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// sortedKeys expects the caller to hold the read lock
+func (kvs *KeyValueStore) sortedKeys(startKey string, endKey string) []string {
+	var keys []string
+	for key := range kvs.data {
+		if key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
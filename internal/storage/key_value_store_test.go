@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+)
+
+func testLogger() slog.Logger {
+	return *slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestApplyKeepsTheNewestWrite(t *testing.T) {
+	kvs := NewKeyValueStore(testLogger())
+	newer := kvs.clock.Now()
+	older := newer - 1
+
+	// The newer write arrives first, the older one must not replace it
+	errs := kvs.Apply([]TxnOperation{{Type: OperationPut, Key: "key", Value: "newer", Timestamp: newer}})
+	if errs[0] != nil {
+		t.Fatalf("applying the newer write failed: %v", errs[0])
+	}
+	errs = kvs.Apply([]TxnOperation{{Type: OperationPut, Key: "key", Value: "older", Timestamp: older}})
+	if errs[0] != nil {
+		t.Fatalf("applying the older write failed: %v", errs[0])
+	}
+	if value, err := kvs.Get("key"); err != nil || value != "newer" {
+		t.Fatalf("expected the newer write to win, got %q, %v", value, err)
+	}
+
+	newest := kvs.clock.Now()
+	kvs.Apply([]TxnOperation{{Type: OperationPut, Key: "key", Value: "newest", Timestamp: newest}})
+	if value, _ := kvs.Get("key"); value != "newest" {
+		t.Fatalf("expected a later write to replace the value, got %q", value)
+	}
+}
+
+func TestSetStampedOrdersWritesToAKey(t *testing.T) {
+	kvs := NewKeyValueStore(testLogger())
+	first, err := kvs.SetStamped("key", "first")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	second, err := kvs.SetStamped("key", "second")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if second <= first {
+		t.Fatalf("expected the second write to be stamped after the first, got %d and %d", first, second)
+	}
+}
+
+func TestDeleteLeavesTombstoneThatDropsOlderWrites(t *testing.T) {
+	kvs := NewKeyValueStore(testLogger())
+	written, err := kvs.SetStamped("key", "value")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	deleted, err := kvs.DeleteStamped("key")
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if deleted <= written {
+		t.Fatalf("expected the delete to be stamped after the write, got %d and %d", written, deleted)
+	}
+
+	// A put the delete overtook arrives late and must not bring the key back
+	errs := kvs.Apply([]TxnOperation{{Type: OperationPut, Key: "key", Value: "late", Timestamp: deleted - 1}})
+	if errs[0] != nil {
+		t.Fatalf("applying the late put failed: %v", errs[0])
+	}
+	if value, err := kvs.Get("key"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected the key to stay deleted, got %q, %v", value, err)
+	}
+
+	// A delete that arrives before the put it follows leaves a tombstone too
+	newer := kvs.clock.Now()
+	errs = kvs.Apply([]TxnOperation{{Type: OperationDelete, Key: "other", Timestamp: newer}})
+	if !errors.Is(errs[0], ErrKeyNotFound) {
+		t.Fatalf("expected the delete of a missing key to fail with ErrKeyNotFound, got %v", errs[0])
+	}
+	kvs.Apply([]TxnOperation{{Type: OperationPut, Key: "other", Value: "older", Timestamp: newer - 1}})
+	if value, err := kvs.Get("other"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected the older put to be dropped, got %q, %v", value, err)
+	}
+	kvs.Apply([]TxnOperation{{Type: OperationPut, Key: "other", Value: "newer", Timestamp: kvs.clock.Now()}})
+	if value, _ := kvs.Get("other"); value != "newer" {
+		t.Fatalf("expected a put after the delete to recreate the key, got %q", value)
+	}
+}
+
+func TestApplyRejectsSkewedWrites(t *testing.T) {
+	kvs := NewKeyValueStore(testLogger())
+	skewed := hlc.FromTime(time.Now().Add(time.Hour))
+	errs := kvs.Apply([]TxnOperation{
+		{Type: OperationPut, Key: "skewed", Value: "value", Timestamp: skewed},
+		{Type: OperationPut, Key: "fine", Value: "value", Timestamp: kvs.clock.Now()},
+	})
+	if !errors.Is(errs[0], hlc.ErrClockSkew) {
+		t.Fatalf("expected the skewed write to fail with ErrClockSkew, got %v", errs[0])
+	}
+	if errs[1] != nil {
+		t.Fatalf("expected the other write to apply, got %v", errs[1])
+	}
+	if value, err := kvs.Get("skewed"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected the skewed write not to be stored, got %q, %v", value, err)
+	}
+	if now := kvs.clock.Now(); now >= skewed {
+		t.Fatalf("expected the skewed write to leave the clock alone")
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+const (
+	// MerkleDepth is the number of levels below the root of the Merkle tree
+	MerkleDepth = 10
+	// MerkleLeaves is the number of key ranges the tree tells apart. A leaf
+	// covers the keys whose hash falls in its slice of the hash space, so
+	// keys spread evenly whatever their prefixes.
+	MerkleLeaves = 1 << MerkleDepth
+	// MerkleNodes bounds the node indexes of the tree. The root is node 1,
+	// the children of node i are 2i and 2i+1 and the leaves are the nodes
+	// from MerkleLeaves on.
+	MerkleNodes = 2 * MerkleLeaves
+)
+
+// merkleLeaves holds the hash of every leaf. A leaf hash is the XOR of the
+// hashes of the key value pairs in its range, so a write updates it in
+// place and stores holding the same pairs agree on it regardless of the
+// order they were written in.
+type merkleLeaves [MerkleLeaves]uint64
+
+// toggle adds a pair to its leaf or takes it out again
+func (leaves *merkleLeaves) toggle(key string, value string) {
+	leaves[MerkleLeaf(key)] ^= pairHash(key, value)
+}
+
+// MerkleLeaf returns the leaf whose range holds key
+func MerkleLeaf(key string) int {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	// FNV barely changes the high bits for keys that differ in their last
+	// bytes, the finalizer of MurmurHash3 spreads them
+	mixed := hash.Sum64()
+	mixed ^= mixed >> 33
+	mixed *= 0xff51afd7ed558ccd
+	mixed ^= mixed >> 33
+	mixed *= 0xc4ceb9fe1a85ec53
+	mixed ^= mixed >> 33
+	return int(mixed >> (64 - MerkleDepth))
+}
+
+func pairHash(key string, value string) uint64 {
+	hash := sha256.New()
+	var length [binary.MaxVarintLen64]byte
+	hash.Write(length[:binary.PutUvarint(length[:], uint64(len(key)))])
+	hash.Write([]byte(key))
+	hash.Write([]byte(value))
+	return binary.LittleEndian.Uint64(hash.Sum(nil))
+}
+
+// MerkleTree returns the hash of every node of the tree indexed as described
+// for MerkleNodes. Index 0 is unused. An empty leaf hashes to 0 and so does
+// a node whose children both do.
+func (kvs *KeyValueStore) MerkleTree() ([]uint64, error) {
+	kvs.mu.RLock()
+	defer kvs.mu.RUnlock()
+
+	if kvs.closed {
+		return nil, ErrStoreClosed
+	}
+	tree := make([]uint64, MerkleNodes)
+	copy(tree[MerkleLeaves:], kvs.merkle[:])
+	for node := MerkleLeaves - 1; node >= 1; node-- {
+		left, right := tree[2*node], tree[2*node+1]
+		if left == 0 && right == 0 {
+			continue
+		}
+		hash := fnv.New64a()
+		var children [16]byte
+		binary.LittleEndian.PutUint64(children[:8], left)
+		binary.LittleEndian.PutUint64(children[8:], right)
+		hash.Write(children[:])
+		tree[node] = hash.Sum64()
+	}
+	return tree, nil
+}
+
+// ScanMerkleLeaves returns the key value pairs and the tombstones in the
+// ranges of leaves in key order
+func (kvs *KeyValueStore) ScanMerkleLeaves(leaves []int) ([]KeyValue, error) {
+	kvs.mu.RLock()
+	defer kvs.mu.RUnlock()
+
+	if kvs.closed {
+		return nil, ErrStoreClosed
+	}
+	inLeaves, err := leafSet(leaves)
+	if err != nil {
+		return nil, err
+	}
+	var result []KeyValue
+	for key, value := range kvs.data {
+		if inLeaves[MerkleLeaf(key)] {
+			result = append(result, KeyValue{Key: key, Value: value, Timestamp: kvs.timestamps[key]})
+		}
+	}
+	for key, timestamp := range kvs.tombstones {
+		if inLeaves[MerkleLeaf(key)] {
+			result = append(result, KeyValue{Key: key, Timestamp: timestamp, Deleted: true})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// RepairMerkleLeaves replaces what the store holds in the ranges of leaves
+// with keyValues, keeping the keys in preserved as they are, and returns how
+// many keys it changed. A key keeps its value or tombstone if it was stamped
+// later than the one in keyValues and merged values hold writes the sender
+// lacks, those keys are returned so the sender can take them over. It is
+// Restore confined to a few ranges.
+func (kvs *KeyValueStore) RepairMerkleLeaves(leaves []int, keyValues []KeyValue, preserved map[string]bool) (int, []KeyValue, error) {
+	inLeaves, err := leafSet(leaves)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, keyValue := range keyValues {
+		if !inLeaves[MerkleLeaf(keyValue.Key)] {
+			return 0, nil, fmt.Errorf("Key %q is outside the repaired ranges", keyValue.Key)
+		}
+	}
+	changed, newer, commit, err := kvs.restore(keyValues, preserved, func(key string) bool {
+		return inLeaves[MerkleLeaf(key)]
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return changed, newer, commit.wait()
+}
+
+func leafSet(leaves []int) (map[int]bool, error) {
+	inLeaves := make(map[int]bool, len(leaves))
+	for _, leaf := range leaves {
+		if leaf < 0 || leaf >= MerkleLeaves {
+			return nil, fmt.Errorf("Merkle leaf %d out of range", leaf)
+		}
+		inLeaves[leaf] = true
+	}
+	return inLeaves, nil
+}
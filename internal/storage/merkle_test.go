@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"testing"
+)
+
+// divergentLeaves returns the leaves whose hash differs between two trees
+func divergentLeaves(left []uint64, right []uint64) []int {
+	var leaves []int
+	for leaf := 0; leaf < MerkleLeaves; leaf++ {
+		if left[MerkleLeaves+leaf] != right[MerkleLeaves+leaf] {
+			leaves = append(leaves, leaf)
+		}
+	}
+	return leaves
+}
+
+func merkleTree(t *testing.T, kvs *KeyValueStore) []uint64 {
+	t.Helper()
+	tree, err := kvs.MerkleTree()
+	if err != nil {
+		t.Fatalf("building the merkle tree failed: %v", err)
+	}
+	return tree[:]
+}
+
+func TestMerkleTreeFindsDivergentLeaves(t *testing.T) {
+	primary, peer := NewKeyValueStore(testLogger()), NewKeyValueStore(testLogger())
+	for _, key := range []string{"a", "b", "c", "d"} {
+		primary.Set(key, "1")
+		peer.Set(key, "1")
+	}
+	// The same pairs written in another order hash the same
+	primary.Set("e", "1")
+	primary.Set("f", "1")
+	peer.Set("f", "1")
+	peer.Set("e", "1")
+	if primaryTree, peerTree := merkleTree(t, primary), merkleTree(t, peer); primaryTree[1] != peerTree[1] {
+		t.Fatalf("expected stores holding the same pairs to agree on the root")
+	}
+
+	// The peer misses a write and a new key
+	primary.Set("b", "2")
+	primary.Set("g", "1")
+	primaryTree, peerTree := merkleTree(t, primary), merkleTree(t, peer)
+	if primaryTree[1] == peerTree[1] {
+		t.Fatalf("expected diverged stores to disagree on the root")
+	}
+	expected := map[int]bool{MerkleLeaf("b"): true, MerkleLeaf("g"): true}
+	leaves := divergentLeaves(primaryTree, peerTree)
+	if len(leaves) != len(expected) {
+		t.Fatalf("expected the leaves %v to diverge, got %v", expected, leaves)
+	}
+	for _, leaf := range leaves {
+		if !expected[leaf] {
+			t.Fatalf("leaf %d diverged although its keys agree", leaf)
+		}
+	}
+
+	keyValues, err := primary.ScanMerkleLeaves(leaves)
+	if err != nil {
+		t.Fatalf("scanning the divergent leaves failed: %v", err)
+	}
+	if _, _, err := peer.RepairMerkleLeaves(leaves, keyValues, nil); err != nil {
+		t.Fatalf("repairing the divergent leaves failed: %v", err)
+	}
+	if primaryTree, peerTree := merkleTree(t, primary), merkleTree(t, peer); primaryTree[1] != peerTree[1] {
+		t.Fatalf("expected the repaired peer to agree with the primary, leaves %v still differ", divergentLeaves(primaryTree, peerTree))
+	}
+}
+
+func TestRepairMerkleLeavesKeepsNewerValues(t *testing.T) {
+	primary, peer := NewKeyValueStore(testLogger()), NewKeyValueStore(testLogger())
+	stamp := primary.clock.Now()
+	primary.Apply([]TxnOperation{
+		{Type: OperationPut, Key: "older", Value: "primary", Timestamp: stamp + 1},
+		{Type: OperationPut, Key: "newer", Value: "primary", Timestamp: stamp + 1},
+	})
+	peer.Apply([]TxnOperation{
+		{Type: OperationPut, Key: "older", Value: "peer", Timestamp: stamp},
+		{Type: OperationPut, Key: "newer", Value: "peer", Timestamp: stamp + 2},
+	})
+
+	leaves := []int{MerkleLeaf("older"), MerkleLeaf("newer")}
+	keyValues, err := primary.ScanMerkleLeaves(leaves)
+	if err != nil {
+		t.Fatalf("scanning failed: %v", err)
+	}
+	changed, newer, err := peer.RepairMerkleLeaves(leaves, keyValues, nil)
+	if err != nil {
+		t.Fatalf("repairing failed: %v", err)
+	}
+	expectValue(t, peer, "older", "primary")
+	expectValue(t, peer, "newer", "peer")
+	if changed != 1 {
+		t.Fatalf("expected the repair to change just the older key, it changed %d", changed)
+	}
+	if len(newer) != 1 || newer[0] != (KeyValue{Key: "newer", Value: "peer", Timestamp: stamp + 2}) {
+		t.Fatalf("expected the repair to return the newer value the peer kept, got %+v", newer)
+	}
+}
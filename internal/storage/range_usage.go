@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RangeUsage summarises the load a contiguous key range puts on the store.
+// Operations were counted over Period. SplitKey is the median key of the range
+// and is empty when the range holds fewer than two keys.
+type RangeUsage struct {
+	StartKey   string
+	EndKey     string
+	KeyCount   int64
+	SizeBytes  int64
+	Operations int64
+	Period     time.Duration
+	SplitKey   string
+}
+
+type rangeUsageTracker struct {
+	startKeys  []string
+	operations []int64
+	// countingSince is when the operation counters were last reset
+	countingSince time.Time
+	mu            sync.Mutex
+}
+
+func (tracker *rangeUsageTracker) recordOperation(key string) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	index := rangeIndexForKey(tracker.startKeys, key)
+	if index < 0 {
+		return
+	}
+	tracker.operations[index]++
+}
+
+// resetOperations returns the operation counters along with the time they
+// were counted over
+func (tracker *rangeUsageTracker) resetOperations() ([]string, []int64, time.Duration) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	now := time.Now()
+	operations := tracker.operations
+	period := now.Sub(tracker.countingSince)
+	tracker.operations = make([]int64, len(tracker.startKeys))
+	tracker.countingSince = now
+	return tracker.startKeys, operations, period
+}
+
+// rangeIndexForKey returns the index of the range whose start key is the
+// greatest one that is <= key, or -1 when key sorts before every range
+func rangeIndexForKey(startKeys []string, key string) int {
+	return sort.Search(len(startKeys), func(i int) bool {
+		return startKeys[i] > key
+	}) - 1
+}
+
+// SetRangeBoundaries tells the store which ranges to account operations
+// against. The boundaries are the start keys of the ranges; each range ends
+// where the next one starts and the last range is unbounded. Operation counts
+// are kept when the boundaries have not changed.
+func (kvs *KeyValueStore) SetRangeBoundaries(startKeys []string) {
+	sortedStartKeys := slices.Clone(startKeys)
+	sort.Strings(sortedStartKeys)
+
+	kvs.rangeUsage.mu.Lock()
+	defer kvs.rangeUsage.mu.Unlock()
+
+	if slices.Equal(kvs.rangeUsage.startKeys, sortedStartKeys) {
+		return
+	}
+	kvs.logger.Info("Updating range boundaries", "ranges", len(sortedStartKeys))
+	kvs.rangeUsage.startKeys = sortedStartKeys
+	kvs.rangeUsage.operations = make([]int64, len(sortedStartKeys))
+	kvs.rangeUsage.countingSince = time.Now()
+}
+
+// CollectRangeUsage returns the usage of every range set through
+// SetRangeBoundaries and resets the operation counters
+func (kvs *KeyValueStore) CollectRangeUsage() []RangeUsage {
+	startKeys, operations, period := kvs.rangeUsage.resetOperations()
+	if len(startKeys) == 0 {
+		return nil
+	}
+
+	kvs.mu.RLock()
+	defer kvs.mu.RUnlock()
+
+	usage := make([]RangeUsage, len(startKeys))
+	rangeKeys := make([][]string, len(startKeys))
+	for index, startKey := range startKeys {
+		usage[index].StartKey = startKey
+		usage[index].Operations = operations[index]
+		usage[index].Period = period
+		if index+1 < len(startKeys) {
+			usage[index].EndKey = startKeys[index+1]
+		}
+	}
+
+	for _, key := range kvs.sortedKeys("", "") {
+		index := rangeIndexForKey(startKeys, key)
+		if index < 0 {
+			continue
+		}
+		usage[index].KeyCount++
+		usage[index].SizeBytes += int64(len(key) + len(kvs.data[key]))
+		rangeKeys[index] = append(rangeKeys[index], key)
+	}
+
+	for index, keys := range rangeKeys {
+		if len(keys) >= 2 {
+			usage[index].SplitKey = keys[len(keys)/2]
+		}
+	}
+	return usage
+}
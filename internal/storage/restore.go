@@ -0,0 +1,104 @@
+package storage
+
+import "fmt"
+
+// Restore replaces the contents of the store with keyValues, typically a
+// snapshot received from another node. Keys in preserved keep their current
+// value or absence because they were written after the snapshot was taken,
+// keys whose current value is stamped later than the restored one keep it.
+// Watchers see a put for every key whose value changed and a delete for every
+// key that is gone. The changes are logged in a single record.
+func (kvs *KeyValueStore) Restore(keyValues []KeyValue, preserved map[string]bool) error {
+	_, _, commit, err := kvs.restore(keyValues, preserved, nil)
+	if err != nil {
+		return err
+	}
+	return commit.wait()
+}
+
+// restore replaces the keys for which inScope holds, or every key when it is
+// nil, and returns how many keys it changed along with the keys it kept
+// because they hold a newer write or tombstone than the restored one. A value
+// the merge function accepts is merged with the stored one instead, and kept
+// as newer when the merge holds writes the restored value lacks. A restored
+// tombstone deletes the key unless it was written later, a key that is neither
+// restored nor has a tombstone is deleted without one.
+func (kvs *KeyValueStore) restore(keyValues []KeyValue, preserved map[string]bool, inScope func(key string) bool) (int, []KeyValue, *walCommit, error) {
+	kvs.mu.Lock()
+	defer kvs.mu.Unlock()
+
+	kvs.logger.Info("Restoring store from snapshot", "keys", len(keyValues), "preserved", len(preserved))
+	if kvs.closed {
+		return 0, nil, nil, ErrStoreClosed
+	}
+
+	restored := make(map[string]KeyValue, len(keyValues))
+	for _, keyValue := range keyValues {
+		restored[keyValue.Key] = keyValue
+	}
+	var changes []walOperation
+	var newer []KeyValue
+	for key := range kvs.data {
+		if _, exists := restored[key]; exists || preserved[key] {
+			continue
+		}
+		if inScope != nil && !inScope(key) {
+			continue
+		}
+		changes = append(changes, walOperation{delete: true, key: key})
+	}
+	for key, keyValue := range restored {
+		if preserved[key] {
+			continue
+		}
+		current, exists := kvs.data[key]
+		if exists && !keyValue.Deleted && current == keyValue.Value {
+			continue
+		}
+		if value, timestamp, merged := kvs.merge(key, keyValue.Value, keyValue.Timestamp); exists && !keyValue.Deleted && merged {
+			if value != keyValue.Value {
+				newer = append(newer, KeyValue{Key: key, Value: value, Timestamp: timestamp})
+			}
+			if value == current {
+				continue
+			}
+			timestamp, err := kvs.stamp(timestamp)
+			if err != nil {
+				return 0, nil, nil, fmt.Errorf("Failed to restore the key %s: %w", key, err)
+			}
+			changes = append(changes, walOperation{key: key, value: value, timestamp: timestamp})
+			continue
+		}
+		timestamp, stored := kvs.current(key)
+		if !exists && keyValue.Deleted && stored && timestamp >= keyValue.Timestamp {
+			continue
+		}
+		if stored && timestamp > keyValue.Timestamp {
+			newer = append(newer, KeyValue{Key: key, Value: current, Timestamp: timestamp, Deleted: !exists})
+			continue
+		}
+		timestamp, err := kvs.stamp(keyValue.Timestamp)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("Failed to restore the key %s: %w", key, err)
+		}
+		changes = append(changes, walOperation{delete: keyValue.Deleted, key: key, value: keyValue.Value, timestamp: timestamp})
+	}
+	commit, err := kvs.log(changes...)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	for _, change := range changes {
+		if change.delete {
+			_, exists := kvs.data[change.key]
+			kvs.remove(change.key, change.timestamp)
+			if exists {
+				kvs.notifyWatchers(WatchEvent{Type: WatchEventDelete, Key: change.key})
+			}
+			continue
+		}
+		kvs.put(change.key, change.value, change.timestamp)
+		kvs.notifyWatchers(WatchEvent{Type: WatchEventPut, Key: change.key, Value: change.value})
+	}
+	return len(changes), newer, commit, nil
+}
@@ -0,0 +1,155 @@
+package storage
+
+import "fmt"
+
+type CompareType int
+
+const (
+	CompareValueEquals CompareType = iota
+	CompareExists
+	CompareNotExists
+)
+
+type OperationType int
+
+const (
+	OperationGet OperationType = iota
+	OperationPut
+	OperationDelete
+)
+
+type TxnCompare struct {
+	Key   string
+	Type  CompareType
+	Value string
+}
+
+// TxnOperation is a read or a write. A write is stored with Timestamp, or
+// stamped by the store when it is 0.
+type TxnOperation struct {
+	Type      OperationType
+	Key       string
+	Value     string
+	Timestamp int64
+}
+
+// TxnOperationResult carries the timestamp a write was stamped with
+type TxnOperationResult struct {
+	Key       string
+	Value     string
+	Found     bool
+	Timestamp int64
+}
+
+type TxnResult struct {
+	Succeeded bool
+	Results   []TxnOperationResult
+}
+
+// Txn evaluates every compare and applies the success operations when all of
+// them hold and the failure operations otherwise. The compares and the
+// operations run under a single write lock so no other write can interleave.
+// The writes are logged in a single record and the result is returned once
+// they are durable.
+func (kvs *KeyValueStore) Txn(compares []TxnCompare, success []TxnOperation, failure []TxnOperation) (TxnResult, error) {
+	result, commit, err := kvs.txn(compares, success, failure)
+	if err != nil {
+		return TxnResult{}, err
+	}
+	if err := commit.wait(); err != nil {
+		return TxnResult{}, err
+	}
+	return result, nil
+}
+
+func (kvs *KeyValueStore) txn(compares []TxnCompare, success []TxnOperation, failure []TxnOperation) (TxnResult, *walCommit, error) {
+	kvs.mu.Lock()
+	defer kvs.mu.Unlock()
+
+	kvs.logger.Info("Txn Request", "compares", len(compares), "success", len(success), "failure", len(failure))
+	if kvs.closed {
+		return TxnResult{}, nil, ErrStoreClosed
+	}
+	succeeded := true
+	for _, compare := range compares {
+		holds, err := kvs.evaluateCompare(compare)
+		if err != nil {
+			return TxnResult{}, nil, err
+		}
+		if !holds {
+			succeeded = false
+			break
+		}
+	}
+
+	operations := failure
+	if succeeded {
+		operations = success
+	}
+
+	// Deleting a missing key only leaves its tombstone on replay, so every
+	// write is logged as is
+	var writes []walOperation
+	timestamps := make([]int64, len(operations))
+	for i, operation := range operations {
+		switch operation.Type {
+		case OperationGet:
+			continue
+		case OperationPut, OperationDelete:
+		default:
+			kvs.logger.Error("Unknown txn operation", "type", operation.Type)
+			return TxnResult{}, nil, fmt.Errorf("Unknown txn operation %d", operation.Type)
+		}
+		timestamp, err := kvs.stamp(operation.Timestamp)
+		if err != nil {
+			return TxnResult{}, nil, err
+		}
+		timestamps[i] = timestamp
+		writes = append(writes, walOperation{delete: operation.Type == OperationDelete, key: operation.Key, value: operation.Value, timestamp: timestamp})
+	}
+	commit, err := kvs.log(writes...)
+	if err != nil {
+		return TxnResult{}, nil, err
+	}
+
+	result := TxnResult{Succeeded: succeeded}
+	for i, operation := range operations {
+		kvs.rangeUsage.recordOperation(operation.Key)
+		value, found := kvs.data[operation.Key]
+		switch operation.Type {
+		case OperationGet:
+		case OperationPut:
+			kvs.put(operation.Key, operation.Value, timestamps[i])
+			kvs.notifyWatchers(WatchEvent{Type: WatchEventPut, Key: operation.Key, Value: operation.Value})
+			value, found = operation.Value, true
+		case OperationDelete:
+			kvs.remove(operation.Key, timestamps[i])
+			if found {
+				kvs.notifyWatchers(WatchEvent{Type: WatchEventDelete, Key: operation.Key})
+			}
+		}
+		result.Results = append(result.Results, TxnOperationResult{
+			Key:       operation.Key,
+			Value:     value,
+			Found:     found,
+			Timestamp: timestamps[i],
+		})
+	}
+	kvs.logger.Info("Txn applied", "succeeded", succeeded)
+	return result, commit, nil
+}
+
+func (kvs *KeyValueStore) evaluateCompare(compare TxnCompare) (bool, error) {
+	value, exists := kvs.data[compare.Key]
+	switch compare.Type {
+	case CompareValueEquals:
+		return exists && value == compare.Value, nil
+	case CompareExists:
+		return exists, nil
+	case CompareNotExists:
+		return !exists, nil
+	default:
+		kvs.logger.Error("Unknown txn compare", "type", compare.Type)
+		return false, fmt.Errorf("Unknown txn compare %d", compare.Type)
+	}
+}
@@ -0,0 +1,594 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+)
+
+const (
+	DefaultGroupCommitWindow = time.Millisecond
+	DefaultGroupCommitBytes  = 1 << 20
+	DefaultCompactBytes      = 64 << 20
+	// DefaultTombstoneRetention outlasts the hints kept for a peer that is
+	// down, the oldest writes that may still arrive
+	DefaultTombstoneRetention = 24 * time.Hour
+
+	walFileName = "wal.log"
+	// walHeaderSize is the record length followed by its checksum
+	walHeaderSize = 8
+	// maxWALRecordSize guards against allocating for a corrupt length
+	maxWALRecordSize = 1 << 30
+)
+
+// Puts logged before writes carried a timestamp are walOperationPut, they are
+// stamped when the log is opened. walOperationDelete removes a key without
+// leaving a tombstone.
+const (
+	walOperationPut byte = iota + 1
+	walOperationDelete
+	walOperationTimestampedPut
+	walOperationTimestampedDelete
+)
+
+var ErrCorruptWAL = errors.New("write ahead log is corrupt")
+
+var walCastagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// WALConfig makes the store durable. Every write is appended to a log in Dir
+// and acknowledged once the log was fsynced. Writes arriving within
+// GroupCommitWindow of the first one waiting, or until GroupCommitBytes are
+// buffered, share a single fsync. Once the log grows past CompactBytes it is
+// rewritten with just the live keys and tombstones, 0 only rewrites it on
+// open. Tombstones older than TombstoneRetention are dropped by the rewrite,
+// 0 keeps them. An empty Dir keeps the store in memory.
+type WALConfig struct {
+	Dir                string
+	GroupCommitWindow  time.Duration
+	GroupCommitBytes   int
+	CompactBytes       int64
+	TombstoneRetention time.Duration
+}
+
+func DefaultWALConfig(dir string) WALConfig {
+	return WALConfig{
+		Dir:                dir,
+		GroupCommitWindow:  DefaultGroupCommitWindow,
+		GroupCommitBytes:   DefaultGroupCommitBytes,
+		CompactBytes:       DefaultCompactBytes,
+		TombstoneRetention: DefaultTombstoneRetention,
+	}
+}
+
+// walOperation is a single change carried by a log record
+type walOperation struct {
+	delete    bool
+	key       string
+	value     string
+	timestamp int64
+}
+
+// walCommit is a group of records that are fsynced together. undo holds
+// what the keys they change held before, in the order they were logged.
+type walCommit struct {
+	undo []walOperation
+	done chan struct{}
+	err  error
+}
+
+// wait blocks until the records of the commit are durable. A nil commit
+// belongs to a store without a log and is durable right away.
+func (commit *walCommit) wait() error {
+	if commit == nil {
+		return nil
+	}
+	<-commit.done
+	return commit.err
+}
+
+type writeAheadLog struct {
+	// store is locked while failed writes are rolled back and while the log
+	// is compacted, the store lock is always taken before mu
+	store  *KeyValueStore
+	path   string
+	file   *os.File
+	config WALConfig
+	// size is how many bytes the log file holds
+	size int64
+	mu   sync.Mutex
+	// buffer holds the records of commit that were not written yet
+	buffer []byte
+	commit *walCommit
+	// failed is the first write or fsync error, the log accepts no record
+	// after it since it cannot tell what reached the disk
+	failed error
+	closed bool
+	// pending wakes the committer when the first record is buffered and
+	// full when GroupCommitBytes are
+	pending chan struct{}
+	full    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+	logger  slog.Logger
+}
+
+// OpenKeyValueStore opens a store that persists its writes to a log in
+// config.Dir and restores whatever the log holds. The log is rewritten with
+// just the live keys and the retained tombstones on open and whenever it grows
+// past config.CompactBytes. Writes are stamped with clock, which is moved past
+// every timestamp in the log.
+func OpenKeyValueStore(config WALConfig, clock *hlc.Clock, logger slog.Logger) (*KeyValueStore, error) {
+	kvs := newKeyValueStore(clock, logger)
+	if config.Dir == "" {
+		return kvs, nil
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Failed to create the wal directory: %w", err)
+	}
+
+	path := filepath.Join(config.Dir, walFileName)
+	records, err := replayWAL(path, kvs.data, kvs.timestamps, kvs.tombstones, logger)
+	if err != nil {
+		return nil, err
+	}
+	// The log was written by this clock, it may have run ahead of the wall
+	// clock and is not checked for skew
+	for key, value := range kvs.data {
+		kvs.merkle.toggle(key, value)
+		if kvs.timestamps[key] == 0 {
+			kvs.timestamps[key] = clock.Now()
+		}
+		clock.Advance(kvs.timestamps[key])
+	}
+	for _, timestamp := range kvs.tombstones {
+		clock.Advance(timestamp)
+	}
+	kvs.dropTombstones(tombstoneCutoff(config))
+	size, err := rewriteWAL(path, kvs.data, kvs.timestamps, kvs.tombstones)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the wal: %w", err)
+	}
+
+	kvs.wal = &writeAheadLog{
+		store:   kvs,
+		path:    path,
+		file:    file,
+		config:  config,
+		size:    size,
+		pending: make(chan struct{}, 1),
+		full:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		logger:  logger,
+	}
+	go kvs.wal.run()
+	logger.Info("Opened write ahead log", "path", path, "records", records, "keys", len(kvs.data), "tombstones", len(kvs.tombstones))
+	return kvs, nil
+}
+
+// tombstoneCutoff returns the timestamp the tombstones retained by config are
+// stamped after, 0 when every tombstone is retained
+func tombstoneCutoff(config WALConfig) int64 {
+	if config.TombstoneRetention <= 0 {
+		return 0
+	}
+	return hlc.FromTime(time.Now().Add(-config.TombstoneRetention))
+}
+
+// replayWAL applies every intact record of the log at path to data,
+// timestamps and tombstones and returns how many it applied. A torn record at
+// the end, left by a crash in the middle of a write, is dropped.
+func replayWAL(path string, data map[string]string, timestamps map[string]int64, tombstones map[string]int64, logger slog.Logger) (int, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Failed to open the wal: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header := make([]byte, walHeaderSize)
+	records := 0
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err != io.EOF {
+				logger.Warn("Dropping torn record at the end of the wal", "records", records)
+			}
+			return records, nil
+		}
+		length := binary.LittleEndian.Uint32(header)
+		if length > maxWALRecordSize {
+			return records, fmt.Errorf("Record %d is %d bytes long: %w", records+1, length, ErrCorruptWAL)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			logger.Warn("Dropping torn record at the end of the wal", "records", records)
+			return records, nil
+		}
+		if crc32.Checksum(payload, walCastagnoli) != binary.LittleEndian.Uint32(header[4:]) {
+			// Only the last record can be torn, anything after a bad
+			// one would be lost silently
+			if _, err := reader.Peek(1); err == io.EOF {
+				logger.Warn("Dropping torn record at the end of the wal", "records", records)
+				return records, nil
+			}
+			return records, fmt.Errorf("Checksum mismatch in record %d: %w", records+1, ErrCorruptWAL)
+		}
+		operations, err := decodeWALRecord(payload)
+		if err != nil {
+			return records, fmt.Errorf("Record %d: %w", records+1, err)
+		}
+		for _, operation := range operations {
+			if operation.delete {
+				delete(data, operation.key)
+				delete(timestamps, operation.key)
+				if operation.timestamp != 0 {
+					tombstones[operation.key] = operation.timestamp
+				} else {
+					delete(tombstones, operation.key)
+				}
+			} else {
+				data[operation.key] = operation.value
+				timestamps[operation.key] = operation.timestamp
+				delete(tombstones, operation.key)
+			}
+		}
+		records++
+	}
+}
+
+// rewriteWAL replaces the log at path with a single record holding data and
+// tombstones and returns its size
+func rewriteWAL(path string, data map[string]string, timestamps map[string]int64, tombstones map[string]int64) (int64, error) {
+	keys := make([]string, 0, len(data)+len(tombstones))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	for key := range tombstones {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	operations := make([]walOperation, len(keys))
+	for i, key := range keys {
+		if timestamp, deleted := tombstones[key]; deleted {
+			operations[i] = walOperation{delete: true, key: key, timestamp: timestamp}
+			continue
+		}
+		operations[i] = walOperation{key: key, value: data[key], timestamp: timestamps[key]}
+	}
+
+	temporaryPath := path + ".tmp"
+	file, err := os.Create(temporaryPath)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to rewrite the wal: %w", err)
+	}
+	var record []byte
+	if len(operations) > 0 {
+		record = encodeWALRecord(nil, operations)
+		_, err = file.Write(record)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporaryPath, path)
+	}
+	if err == nil {
+		err = syncDir(filepath.Dir(path))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Failed to rewrite the wal: %w", err)
+	}
+	return int64(len(record)), nil
+}
+
+func syncDir(dir string) error {
+	directory, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer directory.Close()
+	return directory.Sync()
+}
+
+// append buffers a record for the next group commit along with what the keys
+// it changes held before. The caller holds the store lock, so records are
+// logged in the order they are applied.
+func (log *writeAheadLog) append(operations []walOperation, undo []walOperation) (*walCommit, error) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if log.failed != nil {
+		return nil, log.failed
+	}
+	if log.closed {
+		return nil, ErrStoreClosed
+	}
+	if log.commit == nil {
+		log.commit = &walCommit{done: make(chan struct{})}
+		notify(log.pending)
+	}
+	log.buffer = encodeWALRecord(log.buffer, operations)
+	log.commit.undo = append(log.commit.undo, undo...)
+	if len(log.buffer) >= log.config.GroupCommitBytes {
+		notify(log.full)
+	}
+	return log.commit, nil
+}
+
+// run flushes a group commit GroupCommitWindow after its first record was
+// buffered, or earlier once it holds GroupCommitBytes
+func (log *writeAheadLog) run() {
+	defer close(log.stopped)
+	window := time.NewTimer(0)
+	<-window.C
+	for {
+		select {
+		case <-log.stop:
+			log.flush()
+			return
+		case <-log.pending:
+		}
+		window.Reset(log.config.GroupCommitWindow)
+		select {
+		case <-window.C:
+		case <-log.full:
+			window.Stop()
+		case <-log.stop:
+			window.Stop()
+			log.flush()
+			return
+		}
+		log.flush()
+		if log.config.CompactBytes > 0 && log.size > log.config.CompactBytes {
+			log.compact()
+		}
+	}
+}
+
+// flush writes and fsyncs the buffered records and completes their commit.
+// Records buffered meanwhile go to the next commit.
+func (log *writeAheadLog) flush() {
+	buffer, commit := log.take()
+	if commit == nil {
+		return
+	}
+	if err := log.write(buffer); err != nil {
+		log.store.mu.Lock()
+		log.fail(commit, err)
+		log.store.mu.Unlock()
+		return
+	}
+	close(commit.done)
+}
+
+// take hands over the buffered records and their commit
+func (log *writeAheadLog) take() ([]byte, *walCommit) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	buffer, commit := log.buffer, log.commit
+	log.buffer, log.commit = nil, nil
+	// A stale signal would cut the next window short
+	select {
+	case <-log.full:
+	default:
+	}
+	return buffer, commit
+}
+
+func (log *writeAheadLog) write(buffer []byte) error {
+	_, err := log.file.Write(buffer)
+	if err == nil {
+		err = log.file.Sync()
+	}
+	if err == nil {
+		log.size += int64(len(buffer))
+	}
+	return err
+}
+
+// fail refuses every later record and rolls back the writes of commit and of
+// the records buffered after it, which were applied but never reach the log.
+// It expects the caller to hold the store lock, so nothing is applied on top
+// of them meanwhile.
+func (log *writeAheadLog) fail(commit *walCommit, err error) {
+	log.logger.Error("Failed to commit to the wal", "error", err)
+	log.mu.Lock()
+	if log.failed == nil {
+		log.failed = fmt.Errorf("Failed to commit to the wal: %w", err)
+	}
+	failed := []*walCommit{commit}
+	if log.commit != nil {
+		failed = append([]*walCommit{log.commit}, failed...)
+	}
+	log.buffer, log.commit = nil, nil
+	err = log.failed
+	log.mu.Unlock()
+
+	log.store.rollback(failed...)
+	for _, failedCommit := range failed {
+		failedCommit.err = err
+		close(failedCommit.done)
+	}
+}
+
+// compact rewrites the log with just the live keys and the tombstones still
+// retained. Writes wait meanwhile, the records still buffered are flushed
+// first so the rewrite only holds durable writes. A failed rewrite leaves the
+// log as it was.
+func (log *writeAheadLog) compact() {
+	log.store.mu.Lock()
+	defer log.store.mu.Unlock()
+
+	if buffer, commit := log.take(); commit != nil {
+		if err := log.write(buffer); err != nil {
+			log.fail(commit, err)
+			return
+		}
+		close(commit.done)
+	}
+	previousSize := log.size
+	dropped := log.store.dropTombstones(tombstoneCutoff(log.config))
+	size, err := rewriteWAL(log.path, log.store.data, log.store.timestamps, log.store.tombstones)
+	if err != nil {
+		log.logger.Warn("Failed to compact the wal", "error", err)
+	}
+	// The rename may have gone through even if the rewrite failed after it,
+	// so the log file is reopened either way
+	file, openErr := os.OpenFile(log.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if openErr != nil {
+		log.mu.Lock()
+		log.failed = fmt.Errorf("Failed to reopen the wal: %w", openErr)
+		log.mu.Unlock()
+		log.logger.Error("Failed to reopen the wal after compacting it", "error", openErr)
+		return
+	}
+	log.file.Close()
+	log.file = file
+	if err != nil {
+		if info, statErr := file.Stat(); statErr == nil {
+			log.size = info.Size()
+		}
+		return
+	}
+	log.size = size
+	log.logger.Info("Compacted the wal",
+		"fromBytes", previousSize,
+		"toBytes", size,
+		"keys", len(log.store.data),
+		"tombstones", len(log.store.tombstones),
+		"droppedTombstones", dropped)
+}
+
+// close flushes what is buffered and closes the log file
+func (log *writeAheadLog) close() error {
+	log.mu.Lock()
+	if log.closed {
+		log.mu.Unlock()
+		return nil
+	}
+	log.closed = true
+	log.mu.Unlock()
+
+	close(log.stop)
+	<-log.stopped
+	return log.file.Close()
+}
+
+func notify(signal chan struct{}) {
+	select {
+	case signal <- struct{}{}:
+	default:
+	}
+}
+
+// encodeWALRecord appends a record carrying operations to buffer. A record is
+// applied as a whole on replay, so the writes of a transaction share one.
+func encodeWALRecord(buffer []byte, operations []walOperation) []byte {
+	start := len(buffer)
+	buffer = append(buffer, make([]byte, walHeaderSize)...)
+	buffer = binary.AppendUvarint(buffer, uint64(len(operations)))
+	for _, operation := range operations {
+		if operation.delete && operation.timestamp == 0 {
+			buffer = append(buffer, walOperationDelete)
+			buffer = binary.AppendUvarint(buffer, uint64(len(operation.key)))
+			buffer = append(buffer, operation.key...)
+			continue
+		}
+		if operation.delete {
+			buffer = append(buffer, walOperationTimestampedDelete)
+			buffer = binary.AppendUvarint(buffer, uint64(len(operation.key)))
+			buffer = append(buffer, operation.key...)
+			buffer = binary.AppendUvarint(buffer, uint64(operation.timestamp))
+			continue
+		}
+		buffer = append(buffer, walOperationTimestampedPut)
+		buffer = binary.AppendUvarint(buffer, uint64(len(operation.key)))
+		buffer = append(buffer, operation.key...)
+		buffer = binary.AppendUvarint(buffer, uint64(len(operation.value)))
+		buffer = append(buffer, operation.value...)
+		buffer = binary.AppendUvarint(buffer, uint64(operation.timestamp))
+	}
+	payload := buffer[start+walHeaderSize:]
+	binary.LittleEndian.PutUint32(buffer[start:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buffer[start+4:], crc32.Checksum(payload, walCastagnoli))
+	return buffer
+}
+
+func decodeWALRecord(payload []byte) ([]walOperation, error) {
+	count, read := binary.Uvarint(payload)
+	if read <= 0 {
+		return nil, ErrCorruptWAL
+	}
+	payload = payload[read:]
+	readString := func() (string, bool) {
+		length, read := binary.Uvarint(payload)
+		if read <= 0 || uint64(len(payload)-read) < length {
+			return "", false
+		}
+		value := string(payload[read : read+int(length)])
+		payload = payload[read+int(length):]
+		return value, true
+	}
+
+	var operations []walOperation
+	for range count {
+		if len(payload) == 0 {
+			return nil, ErrCorruptWAL
+		}
+		kind := payload[0]
+		payload = payload[1:]
+		key, ok := readString()
+		if !ok {
+			return nil, ErrCorruptWAL
+		}
+		switch kind {
+		case walOperationDelete:
+			operations = append(operations, walOperation{delete: true, key: key})
+		case walOperationTimestampedDelete:
+			timestamp, read := binary.Uvarint(payload)
+			if read <= 0 {
+				return nil, ErrCorruptWAL
+			}
+			payload = payload[read:]
+			operations = append(operations, walOperation{delete: true, key: key, timestamp: int64(timestamp)})
+		case walOperationPut, walOperationTimestampedPut:
+			value, ok := readString()
+			if !ok {
+				return nil, ErrCorruptWAL
+			}
+			var timestamp uint64
+			if kind == walOperationTimestampedPut {
+				if timestamp, read = binary.Uvarint(payload); read <= 0 {
+					return nil, ErrCorruptWAL
+				}
+				payload = payload[read:]
+			}
+			operations = append(operations, walOperation{key: key, value: value, timestamp: int64(timestamp)})
+		default:
+			return nil, ErrCorruptWAL
+		}
+	}
+	return operations, nil
+}
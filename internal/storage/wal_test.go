@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+)
+
+func openTestStore(t *testing.T, config WALConfig) *KeyValueStore {
+	t.Helper()
+	kvs, err := OpenKeyValueStore(config, hlc.NewClock(hlc.DefaultMaxSkew, testLogger()), testLogger())
+	if err != nil {
+		t.Fatalf("opening the store failed: %v", err)
+	}
+	return kvs
+}
+
+func expectValue(t *testing.T, kvs *KeyValueStore, key string, expected string) {
+	t.Helper()
+	if value, err := kvs.Get(key); err != nil || value != expected {
+		t.Fatalf("expected %q for %q, got %q, %v", expected, key, value, err)
+	}
+}
+
+func expectMissing(t *testing.T, kvs *KeyValueStore, key string) {
+	t.Helper()
+	if value, err := kvs.Get(key); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected %q to be missing, got %q, %v", key, value, err)
+	}
+}
+
+func TestWALReplaysWritesAfterReopen(t *testing.T) {
+	config := DefaultWALConfig(t.TempDir())
+	kvs := openTestStore(t, config)
+	if err := kvs.Set("a", "1"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if err := kvs.Set("b", "2"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if err := kvs.Set("a", "3"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if err := kvs.Delete("b"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	stamp := kvs.timestamps["a"]
+	kvs.Close()
+
+	reopened := openTestStore(t, config)
+	defer reopened.Close()
+	expectValue(t, reopened, "a", "3")
+	expectMissing(t, reopened, "b")
+	if reopened.timestamps["a"] != stamp {
+		t.Fatalf("expected the timestamp of a to survive a restart, got %d and %d", stamp, reopened.timestamps["a"])
+	}
+	if now := reopened.clock.Now(); now <= stamp {
+		t.Fatalf("expected the clock to move past the logged timestamps, got %d after %d", now, stamp)
+	}
+}
+
+// writeTestWAL writes one record per operation and returns where each record
+// starts
+func writeTestWAL(t *testing.T, dir string, operations ...walOperation) []int {
+	t.Helper()
+	var buffer []byte
+	offsets := make([]int, len(operations))
+	for i, operation := range operations {
+		offsets[i] = len(buffer)
+		buffer = encodeWALRecord(buffer, []walOperation{operation})
+	}
+	if err := os.WriteFile(filepath.Join(dir, walFileName), buffer, 0o644); err != nil {
+		t.Fatalf("writing the wal failed: %v", err)
+	}
+	return offsets
+}
+
+func TestWALDropsTornTail(t *testing.T) {
+	for _, torn := range []struct {
+		name string
+		tear func(path string, lastRecord int, size int)
+	}{
+		{"short header", func(path string, lastRecord int, size int) { os.Truncate(path, int64(lastRecord+walHeaderSize/2)) }},
+		{"short payload", func(path string, lastRecord int, size int) { os.Truncate(path, int64(size-1)) }},
+		{"bad checksum", func(path string, lastRecord int, size int) { flipByte(t, path, size-1) }},
+	} {
+		t.Run(torn.name, func(t *testing.T) {
+			config := DefaultWALConfig(t.TempDir())
+			path := filepath.Join(config.Dir, walFileName)
+			offsets := writeTestWAL(t, config.Dir,
+				walOperation{key: "a", value: "1", timestamp: 1 << 16},
+				walOperation{key: "b", value: "2", timestamp: 2 << 16},
+			)
+			info, _ := os.Stat(path)
+			torn.tear(path, offsets[1], int(info.Size()))
+
+			kvs := openTestStore(t, config)
+			defer kvs.Close()
+			expectValue(t, kvs, "a", "1")
+			expectMissing(t, kvs, "b")
+		})
+	}
+}
+
+func TestWALRejectsCorruptionBeforeTheTail(t *testing.T) {
+	config := DefaultWALConfig(t.TempDir())
+	path := filepath.Join(config.Dir, walFileName)
+	offsets := writeTestWAL(t, config.Dir,
+		walOperation{key: "a", value: "1", timestamp: 1 << 16},
+		walOperation{key: "b", value: "2", timestamp: 2 << 16},
+	)
+	flipByte(t, path, offsets[1]-1)
+
+	_, err := OpenKeyValueStore(config, hlc.NewClock(hlc.DefaultMaxSkew, testLogger()), testLogger())
+	if !errors.Is(err, ErrCorruptWAL) {
+		t.Fatalf("expected a bad record followed by others to fail with ErrCorruptWAL, got %v", err)
+	}
+}
+
+func flipByte(t *testing.T, path string, offset int) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the wal failed: %v", err)
+	}
+	content[offset] ^= 0xff
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("writing the wal failed: %v", err)
+	}
+}
+
+func TestWALRollsBackWritesItFailedToCommit(t *testing.T) {
+	config := DefaultWALConfig(t.TempDir())
+	kvs := openTestStore(t, config)
+	defer kvs.Close()
+	if err := kvs.Set("a", "1"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	stamp := kvs.timestamps["a"]
+
+	// Every later write to the log fails
+	kvs.wal.file.Close()
+	if err := kvs.Set("a", "2"); err == nil {
+		t.Fatalf("expected a write the log failed to commit to fail")
+	}
+	if err := kvs.Set("b", "3"); err == nil {
+		t.Fatalf("expected writes after a failed commit to fail")
+	}
+	expectValue(t, kvs, "a", "1")
+	expectMissing(t, kvs, "b")
+	if kvs.timestamps["a"] != stamp {
+		t.Fatalf("expected the timestamp of a to be rolled back, got %d instead of %d", kvs.timestamps["a"], stamp)
+	}
+}
+
+func TestWALCompactsPastCompactBytes(t *testing.T) {
+	config := DefaultWALConfig(t.TempDir())
+	config.CompactBytes = 4 << 10
+	path := filepath.Join(config.Dir, walFileName)
+	kvs := openTestStore(t, config)
+	for i := 0; i < 1000; i++ {
+		if err := kvs.Set("key", string(rune('a'+i%26))); err != nil {
+			t.Fatalf("set failed: %v", err)
+		}
+	}
+	if err := kvs.Set("other", "value"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	// Compaction runs after the commit that crossed the threshold
+	deadline := time.Now().Add(time.Second)
+	for {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat of the wal failed: %v", err)
+		}
+		if info.Size() <= config.CompactBytes {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the wal to be compacted below %d bytes, it holds %d", config.CompactBytes, info.Size())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := kvs.Set("last", "write"); err != nil {
+		t.Fatalf("set after compacting failed: %v", err)
+	}
+	kvs.Close()
+
+	reopened := openTestStore(t, config)
+	defer reopened.Close()
+	expectValue(t, reopened, "key", string(rune('a'+999%26)))
+	expectValue(t, reopened, "other", "value")
+	expectValue(t, reopened, "last", "write")
+}
+
+func TestWALKeepsTombstonesForTheRetention(t *testing.T) {
+	config := DefaultWALConfig(t.TempDir())
+	kvs := openTestStore(t, config)
+	if err := kvs.Set("key", "value"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	deleted, err := kvs.DeleteStamped("key")
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	kvs.Close()
+
+	reopened := openTestStore(t, config)
+	reopened.Apply([]TxnOperation{{Type: OperationPut, Key: "key", Value: "late", Timestamp: deleted - 1}})
+	expectMissing(t, reopened, "key")
+	reopened.Close()
+
+	// Once the retention passed the tombstone is dropped
+	config.TombstoneRetention = time.Millisecond
+	time.Sleep(2 * config.TombstoneRetention)
+	expired := openTestStore(t, config)
+	defer expired.Close()
+	if _, retained := expired.tombstones["key"]; retained {
+		t.Fatalf("expected the tombstone to be dropped after the retention")
+	}
+}
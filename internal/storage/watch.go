@@ -0,0 +1,124 @@
+package storage
+
+import "sync"
+
+const watchEventBufferSize = 128
+
+type WatchEventType int
+
+const (
+	WatchEventPut WatchEventType = iota
+	WatchEventDelete
+)
+
+type WatchEvent struct {
+	Type  WatchEventType
+	Key   string
+	Value string
+}
+
+// Watcher receives an event for every change to a key in [startKey, endKey).
+// A watcher that falls more than watchEventBufferSize events behind is
+// dropped and its Events channel closed, so callers have to re-read the keys
+// they care about after the channel closes. The channel is also closed when
+// the store shuts down, Dropped tells the two cases apart.
+type Watcher struct {
+	Events   chan WatchEvent
+	startKey string
+	endKey   string
+	dropped  bool
+	once     sync.Once
+}
+
+// Dropped reports whether the watcher was closed for falling behind. It is
+// only meaningful once Events has been closed.
+func (watcher *Watcher) Dropped() bool {
+	return watcher.dropped
+}
+
+func (watcher *Watcher) matches(key string) bool {
+	return key >= watcher.startKey && (watcher.endKey == "" || key < watcher.endKey)
+}
+
+func (watcher *Watcher) close() {
+	watcher.once.Do(func() {
+		close(watcher.Events)
+	})
+}
+
+type watcherRegistry struct {
+	watchers map[*Watcher]struct{}
+	closed   bool
+	mu       sync.Mutex
+}
+
+// Watch registers a watcher for [startKey, endKey). An empty endKey watches
+// every key from startKey onwards.
+func (kvs *KeyValueStore) Watch(startKey string, endKey string) *Watcher {
+	watcher := &Watcher{
+		Events:   make(chan WatchEvent, watchEventBufferSize),
+		startKey: startKey,
+		endKey:   endKey,
+	}
+
+	kvs.watchers.mu.Lock()
+	defer kvs.watchers.mu.Unlock()
+	if kvs.watchers.watchers == nil {
+		kvs.watchers.watchers = make(map[*Watcher]struct{})
+	}
+	if kvs.watchers.closed {
+		watcher.close()
+		return watcher
+	}
+	kvs.watchers.watchers[watcher] = struct{}{}
+	kvs.logger.Info("Registered watcher", "startKey", startKey, "endKey", endKey)
+	return watcher
+}
+
+func (kvs *KeyValueStore) CancelWatch(watcher *Watcher) {
+	kvs.watchers.mu.Lock()
+	defer kvs.watchers.mu.Unlock()
+
+	delete(kvs.watchers.watchers, watcher)
+	watcher.close()
+}
+
+// notifyWatchers expects the caller to hold the write lock so events are
+// delivered in the order the changes were applied
+func (kvs *KeyValueStore) notifyWatchers(event WatchEvent) {
+	kvs.watchers.mu.Lock()
+	defer kvs.watchers.mu.Unlock()
+
+	for watcher := range kvs.watchers.watchers {
+		if !watcher.matches(event.Key) {
+			continue
+		}
+		select {
+		case watcher.Events <- event:
+		default:
+			kvs.logger.Warn("Dropping slow watcher", "startKey", watcher.startKey, "endKey", watcher.endKey)
+			delete(kvs.watchers.watchers, watcher)
+			watcher.dropped = true
+			watcher.close()
+		}
+	}
+}
+
+// CancelWatches ends every watch and refuses new ones while the store keeps
+// serving reads and writes
+func (kvs *KeyValueStore) CancelWatches() {
+	kvs.cancelAllWatches()
+}
+
+// cancelAllWatches closes every watcher and refuses new ones
+func (kvs *KeyValueStore) cancelAllWatches() {
+	kvs.watchers.mu.Lock()
+	defer kvs.watchers.mu.Unlock()
+
+	kvs.logger.Info("Cancelling all watchers", "count", len(kvs.watchers.watchers))
+	for watcher := range kvs.watchers.watchers {
+		watcher.close()
+	}
+	kvs.watchers.watchers = nil
+	kvs.watchers.closed = true
+}
@@ -0,0 +1,422 @@
+// Package antientropy finds replicas that drifted from the primary and repairs
+// them. Every store keeps a Merkle tree whose leaves cover ranges of keys.
+// The primary periodically walks the tree of each peer from the root down,
+// descending only into the subtrees whose hashes differ from its own, until
+// it is left with the leaves that differ. It streams its keys in just those
+// ranges and the peer replaces what it holds in them, except for keys whose
+// value the peer stamped later. Those are sent back and the primary keeps the
+// newer value as well. Writes the peer applies while a repair runs keep their
+// value, as with snapshots, since they are newer than the keys the primary
+// streams.
+package antientropy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultInterval is how often the primary compares every peer
+	DefaultInterval = 30 * time.Second
+	// maxMessageBytes bounds the entries sent in a single repair message
+	maxMessageBytes = 1 << 20
+	// repairTimeout bounds comparing and repairing a single peer
+	repairTimeout = time.Minute
+)
+
+var (
+	ErrRepairInProgress = errors.New("Another repair is in progress")
+	ErrInvalidNode      = errors.New("Merkle tree node out of range")
+)
+
+type Config struct {
+	Interval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval: DefaultInterval,
+	}
+}
+
+// Transport reaches the control plane of a peer
+type Transport interface {
+	GetMerkleHashes(ctx context.Context, address string, request *pb.MerkleHashesRequest) (*pb.MerkleHashesResponse, error)
+	RepairRanges(ctx context.Context, address string) (grpc.BidiStreamingClient[pb.RepairMessage, pb.RepairResponse], error)
+}
+
+// Stats counts what the repairs of a node found since it started. A range
+// written while it was compared can be counted as divergent, repairing it
+// changes nothing.
+type Stats struct {
+	Comparisons     int64
+	DivergentRanges int64
+	RepairedKeys    int64
+}
+
+// Repairer compares the peers with the primary and repairs them
+type Repairer struct {
+	config          Config
+	store           *storage.KeyValueStore
+	lease           *lease.Lease
+	nodeData        *data.NodeData
+	comparisons     atomic.Int64
+	divergentRanges atomic.Int64
+	repairedKeys    atomic.Int64
+	logger          slog.Logger
+}
+
+func NewRepairer(config Config, store *storage.KeyValueStore, readLease *lease.Lease, nodeData *data.NodeData, logger slog.Logger) *Repairer {
+	return &Repairer{
+		config:   config,
+		store:    store,
+		lease:    readLease,
+		nodeData: nodeData,
+		logger:   logger,
+	}
+}
+
+// Run compares every peer every interval while this node is the primary.
+// Peers that missed writes are skipped, the snapshot sender catches them up.
+func (repairer *Repairer) Run(ctx context.Context, transport Transport) {
+	repairer.logger.Info("Starting anti-entropy repairer", "interval", repairer.config.Interval)
+	ticker := time.NewTicker(repairer.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			repairer.logger.Info("Stopping anti-entropy repairer")
+			return
+		case <-ticker.C:
+		}
+		if !repairer.lease.IsPrimary() {
+			continue
+		}
+		outOfSync := make(map[string]bool)
+		for _, nodeID := range repairer.lease.OutOfSyncPeers() {
+			outOfSync[nodeID] = true
+		}
+		for _, nodeID := range repairer.peers() {
+			if outOfSync[nodeID] {
+				continue
+			}
+			if err := repairer.RepairPeer(ctx, transport, nodeID); err != nil && ctx.Err() == nil {
+				repairer.logger.Warn("Failed to repair peer", "nodeId", nodeID, "error", err)
+			}
+		}
+	}
+}
+
+// Stats returns the counts since the node started
+func (repairer *Repairer) Stats() Stats {
+	return Stats{
+		Comparisons:     repairer.comparisons.Load(),
+		DivergentRanges: repairer.divergentRanges.Load(),
+		RepairedKeys:    repairer.repairedKeys.Load(),
+	}
+}
+
+// RepairPeer compares the tree of a peer with the local one and repairs the
+// ranges that differ
+func (repairer *Repairer) RepairPeer(ctx context.Context, transport Transport, nodeID string) error {
+	repairer.nodeData.Mu.RLock()
+	peer, exists := repairer.nodeData.PeerNodes[nodeID]
+	repairer.nodeData.Mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("Peer %s is not known to this node", nodeID)
+	}
+	address := peer.NodeIP + ":" + peer.NodeControlPort
+
+	ctx, cancel := context.WithTimeout(ctx, repairTimeout)
+	defer cancel()
+	leaves, err := repairer.divergentLeaves(ctx, transport, address)
+	if err != nil {
+		return err
+	}
+	repairer.comparisons.Add(1)
+	if len(leaves) == 0 {
+		repairer.logger.Debug("Peer is in sync", "nodeId", nodeID)
+		return nil
+	}
+
+	repairer.divergentRanges.Add(int64(len(leaves)))
+	repairer.logger.Warn("Peer diverged", "nodeId", nodeID, "divergentRanges", len(leaves))
+	repaired, err := repairer.repair(ctx, transport, address, leaves)
+	if err != nil {
+		return err
+	}
+	repairer.repairedKeys.Add(repaired)
+	stats := repairer.Stats()
+	repairer.logger.Info("Repaired divergent ranges",
+		"nodeId", nodeID,
+		"divergentRanges", len(leaves),
+		"repairedKeys", repaired,
+		"totalDivergentRanges", stats.DivergentRanges,
+		"totalRepairedKeys", stats.RepairedKeys)
+	return nil
+}
+
+// peers returns the node ids of every peer in a stable order
+func (repairer *Repairer) peers() []string {
+	repairer.nodeData.Mu.RLock()
+	defer repairer.nodeData.Mu.RUnlock()
+
+	nodeIDs := make([]string, 0, len(repairer.nodeData.PeerNodes))
+	for nodeID := range repairer.nodeData.PeerNodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+	return nodeIDs
+}
+
+// divergentLeaves walks the tree of a peer a level at a time and returns the
+// leaves whose hash differs from the local one
+func (repairer *Repairer) divergentLeaves(ctx context.Context, transport Transport, address string) ([]int, error) {
+	local, err := repairer.store.MerkleTree()
+	if err != nil {
+		return nil, err
+	}
+	var leaves []int
+	nodes := []uint32{1}
+	for len(nodes) > 0 {
+		response, err := transport.GetMerkleHashes(ctx, address, &pb.MerkleHashesRequest{Nodes: nodes})
+		if err != nil {
+			return nil, err
+		}
+		if len(response.Hashes) != len(nodes) {
+			return nil, fmt.Errorf("Peer returned %d hashes for %d nodes", len(response.Hashes), len(nodes))
+		}
+		var next []uint32
+		for i, node := range nodes {
+			if response.Hashes[i] == local[node] {
+				continue
+			}
+			if node >= storage.MerkleLeaves {
+				leaves = append(leaves, int(node)-storage.MerkleLeaves)
+				continue
+			}
+			next = append(next, 2*node, 2*node+1)
+		}
+		nodes = next
+	}
+	return leaves, nil
+}
+
+// repair streams the keys in the ranges of leaves to the peer and returns how
+// many keys the peer changed
+func (repairer *Repairer) repair(ctx context.Context, transport Transport, address string, leaves []int) (int64, error) {
+	stream, err := transport.RepairRanges(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	repairID := fmt.Sprintf("%s-%d", repairer.nodeData.NodeDetails.NodeID, time.Now().UnixNano())
+	wireLeaves := make([]uint32, len(leaves))
+	for i, leaf := range leaves {
+		wireLeaves[i] = uint32(leaf)
+	}
+	if err := stream.Send(&pb.RepairMessage{RepairId: repairID, Leaves: wireLeaves}); err != nil {
+		return 0, err
+	}
+	response, err := stream.Recv()
+	if err != nil {
+		return 0, err
+	}
+	if !response.Ready {
+		return 0, fmt.Errorf("Peer is not ready for repair %s", repairID)
+	}
+
+	// The peer keeps the keys it applies a write to from here on, every
+	// write it applied before was applied here first and is in the scan
+	keyValues, err := repairer.store.ScanMerkleLeaves(leaves)
+	if err != nil {
+		return 0, err
+	}
+	message := &pb.RepairMessage{RepairId: repairID}
+	size := 0
+	for _, keyValue := range keyValues {
+		message.Entries = append(message.Entries, snapshotEntry(keyValue))
+		size += len(keyValue.Key) + len(keyValue.Value)
+		if size < maxMessageBytes {
+			continue
+		}
+		if err := stream.Send(message); err != nil {
+			return 0, err
+		}
+		message = &pb.RepairMessage{RepairId: repairID}
+		size = 0
+	}
+	message.Last = true
+	if err := stream.Send(message); err != nil {
+		return 0, err
+	}
+	response, err = stream.Recv()
+	if err != nil {
+		return 0, err
+	}
+	if err := repairer.takeNewer(response.Newer); err != nil {
+		return 0, err
+	}
+	return response.RepairedKeys, stream.CloseSend()
+}
+
+// takeNewer applies the keys and tombstones a peer kept because they were
+// stamped later than the local ones. A key written here since is left alone
+// as it is newer still.
+func (repairer *Repairer) takeNewer(entries []*pb.SnapshotEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	operations := make([]storage.TxnOperation, len(entries))
+	for i, entry := range entries {
+		operations[i] = storage.TxnOperation{Type: storage.OperationPut, Key: entry.Key, Value: entry.Value, Timestamp: entry.Timestamp}
+		if entry.Deleted {
+			operations[i].Type = storage.OperationDelete
+		}
+	}
+	for _, err := range repairer.store.Apply(operations) {
+		// A tombstone for a key this node never held is still recorded
+		if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
+			return fmt.Errorf("Failed to take over newer keys from the peer: %w", err)
+		}
+	}
+	repairer.logger.Info("Took over newer keys from the peer", "keys", len(entries))
+	return nil
+}
+
+type repair struct {
+	id     string
+	leaves []int
+	// touched holds the keys written by replication since the repair
+	// started
+	touched map[string]bool
+}
+
+// Receiver answers the comparisons of the primary and applies its repairs
+type Receiver struct {
+	store *storage.KeyValueStore
+	// active is the repair being received, a peer repairs one at a time
+	active *repair
+	mu     sync.Mutex
+	logger slog.Logger
+}
+
+func NewReceiver(store *storage.KeyValueStore, logger slog.Logger) *Receiver {
+	return &Receiver{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// MerkleHashes returns the hash of every requested node of the local tree
+func (receiver *Receiver) MerkleHashes(request *pb.MerkleHashesRequest) (*pb.MerkleHashesResponse, error) {
+	tree, err := receiver.store.MerkleTree()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]uint64, len(request.Nodes))
+	for i, node := range request.Nodes {
+		if node == 0 || node >= storage.MerkleNodes {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidNode, node)
+		}
+		hashes[i] = tree[node]
+	}
+	return &pb.MerkleHashesResponse{Hashes: hashes}, nil
+}
+
+// Touched records a replicated write to key. It has to be called before the
+// write is applied so a repair running concurrently cannot overwrite it.
+func (receiver *Receiver) Touched(key string) {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if receiver.active != nil {
+		receiver.active.touched[key] = true
+	}
+}
+
+// HandleStream receives a repair and applies it once the primary sent every
+// entry. The ranges are left alone if the stream ends before that.
+func (receiver *Receiver) HandleStream(stream grpc.BidiStreamingServer[pb.RepairMessage, pb.RepairResponse]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	leaves := make([]int, len(first.Leaves))
+	for i, leaf := range first.Leaves {
+		if leaf >= storage.MerkleLeaves {
+			return status.Error(codes.InvalidArgument, fmt.Sprintf("Merkle leaf %d out of range", leaf))
+		}
+		leaves[i] = int(leaf)
+	}
+
+	current := &repair{id: first.RepairId, leaves: leaves, touched: make(map[string]bool)}
+	receiver.mu.Lock()
+	if receiver.active != nil {
+		receiver.mu.Unlock()
+		return status.Error(codes.Aborted, ErrRepairInProgress.Error())
+	}
+	receiver.active = current
+	receiver.mu.Unlock()
+	defer func() {
+		receiver.mu.Lock()
+		receiver.active = nil
+		receiver.mu.Unlock()
+	}()
+
+	if err := stream.Send(&pb.RepairResponse{Ready: true}); err != nil {
+		return err
+	}
+	var keyValues []storage.KeyValue
+	for message := first; !message.Last; {
+		message, err = stream.Recv()
+		if err != nil {
+			receiver.logger.Warn("Repair stream ended early", "repairId", current.id, "error", err)
+			return err
+		}
+		for _, entry := range message.Entries {
+			keyValues = append(keyValues, storage.KeyValue{Key: entry.Key, Value: entry.Value, Timestamp: entry.Timestamp, Deleted: entry.Deleted})
+		}
+	}
+
+	// The lock is held so no write slips in between reading the touched
+	// keys and repairing the ranges
+	receiver.mu.Lock()
+	repaired, newer, err := receiver.store.RepairMerkleLeaves(current.leaves, keyValues, current.touched)
+	preserved := len(current.touched)
+	receiver.mu.Unlock()
+	if err != nil {
+		receiver.logger.Error("Failed to repair ranges", "repairId", current.id, "error", err)
+		return err
+	}
+	receiver.logger.Info("Repaired ranges",
+		"repairId", current.id,
+		"ranges", len(current.leaves),
+		"keys", len(keyValues),
+		"repairedKeys", repaired,
+		"newerKeys", len(newer),
+		"preserved", preserved)
+	response := &pb.RepairResponse{RepairedKeys: int64(repaired)}
+	for _, keyValue := range newer {
+		response.Newer = append(response.Newer, snapshotEntry(keyValue))
+	}
+	return stream.Send(response)
+}
+
+func snapshotEntry(keyValue storage.KeyValue) *pb.SnapshotEntry {
+	return &pb.SnapshotEntry{Key: keyValue.Key, Value: keyValue.Value, Timestamp: keyValue.Timestamp, Deleted: keyValue.Deleted}
+}
@@ -0,0 +1,25 @@
+package clients
+
+import (
+	"context"
+
+	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/grpc"
+)
+
+// GetMerkleHashes makes ClusterClient the antientropy.Transport
+func (clusterClient *ClusterClient) GetMerkleHashes(ctx context.Context, address string, request *pb_contol_plane.MerkleHashesRequest) (*pb_contol_plane.MerkleHashesResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.GetMerkleHashes(ctx, request)
+}
+
+func (clusterClient *ClusterClient) RepairRanges(ctx context.Context, address string) (grpc.BidiStreamingClient[pb_contol_plane.RepairMessage, pb_contol_plane.RepairResponse], error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.RepairRanges(ctx)
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 
 	clientcommon "github.com/Vahsek/distrokv/internal/common/client_common"
+	"github.com/Vahsek/distrokv/internal/common/hlc"
 	pb_node_control_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
 	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
 	"google.golang.org/grpc"
@@ -13,19 +14,28 @@ import (
 
 type ClusterClient struct {
 	factory *clientcommon.ClientFactory
+	clock   *hlc.Clock
 	logger  slog.Logger
 }
 
-func InitializeClusterClient(logger slog.Logger) *ClusterClient {
+// InitializeClusterClient returns a client that propagates clock on every
+// call to the registry and the peers
+func InitializeClusterClient(clock *hlc.Clock, logger slog.Logger) *ClusterClient {
 	return &ClusterClient{
 		factory: clientcommon.InitializeClientFactory(logger),
+		clock:   clock,
 		logger:  logger,
 	}
 }
 
+// Close closes every pooled connection to the registry and the peers
+func (clusterClient *ClusterClient) Close() error {
+	return clusterClient.factory.Close()
+}
+
 // createRegistryClient creates a gRPC client for registry communication
 func (clusterClient *ClusterClient) createRegistryClient(registryServerAddress string) (pb_registry.RegistryServiceClient, error) {
-	grpcDialOption := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	grpcDialOption := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, clusterClient.clock.DialOptions()...)
 	registryBuilder := clientcommon.NewGrpcBuilder(registryServerAddress, clusterClient.logger).
 		SetGrpcDialOptions(grpcDialOption...)
 	registryClientConstructor := func(conn *grpc.ClientConn) pb_registry.RegistryServiceClient {
@@ -45,7 +55,7 @@ func (clusterClient *ClusterClient) createRegistryClient(registryServerAddress s
 }
 
 func (clusterClient *ClusterClient) createPeerClientConnection(peerAddress string) (pb_node_control_plane.NodeControlPlaneServiceClient, error) {
-	grpcDialOption := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	grpcDialOption := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, clusterClient.clock.DialOptions()...)
 	peerClientBuilder := clientcommon.NewGrpcBuilder(peerAddress, clusterClient.logger).
 		SetGrpcDialOptions(grpcDialOption...)
 	peerClientConstructor := func(conn *grpc.ClientConn) pb_node_control_plane.NodeControlPlaneServiceClient {
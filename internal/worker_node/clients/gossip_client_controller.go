@@ -0,0 +1,25 @@
+package clients
+
+import (
+	"context"
+
+	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+// Ping and PingReq make ClusterClient the membership.Transport
+
+func (clusterClient *ClusterClient) Ping(ctx context.Context, address string, request *pb_contol_plane.PingRequest) (*pb_contol_plane.PingResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.Ping(ctx, request)
+}
+
+func (clusterClient *ClusterClient) PingReq(ctx context.Context, address string, request *pb_contol_plane.PingReqRequest) (*pb_contol_plane.PingResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.PingReq(ctx, request)
+}
@@ -0,0 +1,32 @@
+package clients
+
+import (
+	"context"
+
+	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+// ReplicaRead makes ClusterClient the leaderless.Transport
+func (clusterClient *ClusterClient) ReplicaRead(ctx context.Context, address string, request *pb_contol_plane.ReplicaReadRequest) (*pb_contol_plane.ReplicaReadResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.ReplicaRead(ctx, request)
+}
+
+func (clusterClient *ClusterClient) ReplicaWrite(ctx context.Context, address string, request *pb_contol_plane.ReplicaWriteRequest) (*pb_contol_plane.ReplicaWriteResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.ReplicaWrite(ctx, request)
+}
+
+func (clusterClient *ClusterClient) ReplicaUpdateCRDT(ctx context.Context, address string, request *pb_contol_plane.ReplicaUpdateCRDTRequest) (*pb_contol_plane.ReplicaUpdateCRDTResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.ReplicaUpdateCRDT(ctx, request)
+}
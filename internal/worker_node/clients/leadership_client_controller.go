@@ -0,0 +1,40 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+// TimeoutNow makes ClusterClient the leadership.Transport
+func (clusterClient *ClusterClient) TimeoutNow(ctx context.Context, address string, request *pb_contol_plane.TimeoutNowRequest) (*pb_contol_plane.TimeoutNowResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.TimeoutNow(ctx, request)
+}
+
+// TransferPrimary asks the registry to hand the primary role from
+// fromNodeId to toNodeId and returns the primary the registry settled on
+func (clusterClient *ClusterClient) TransferPrimary(ctx context.Context, nodeData *data.NodeData, fromNodeId string, toNodeId string) (string, error) {
+	registryClient, err := clusterClient.createRegistryClient(nodeData.RegistryServerAddress)
+	if err != nil {
+		clusterClient.logger.Error("Error creating registry client", "error", err)
+		return "", err
+	}
+
+	response, err := registryClient.TransferPrimary(ctx, &pb_registry.TransferPrimaryRequest{
+		FromNodeId: fromNodeId,
+		ToNodeId:   toNodeId,
+	})
+	if err != nil {
+		clusterClient.logger.Error("Failed to transfer the primary", "error", err)
+		return "", fmt.Errorf("primary transfer failed: %w", err)
+	}
+	setPrimaryNode(nodeData, response.PrimaryNodeId, clusterClient)
+	return response.PrimaryNodeId, nil
+}
@@ -0,0 +1,16 @@
+package clients
+
+import (
+	"context"
+
+	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+// RequestLease makes ClusterClient the lease.Transport
+func (clusterClient *ClusterClient) RequestLease(ctx context.Context, address string, request *pb_contol_plane.LeaseRequest) (*pb_contol_plane.LeaseResponse, error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.RequestLease(ctx, request)
+}
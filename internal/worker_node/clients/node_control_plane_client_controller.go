@@ -2,41 +2,138 @@ package clients
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/storage"
 	"github.com/Vahsek/distrokv/internal/worker_node/data"
 	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
 )
 
-func (clusterClient *ClusterClient) RegisterNodeWithPeers(nodeData *data.NodeData) error {
-	nodeData.Mu.RLock()
-	defer nodeData.Mu.RUnlock()
+// RegisterNodeWithPeers announces the node to the peers it learned about from
+// the registry. Announcing is best effort, a peer that cannot be reached
+// learns about the node through gossip instead.
+func (clusterClient *ClusterClient) RegisterNodeWithPeers(nodeData *data.NodeData, incarnation int64) {
 	logger := clusterClient.logger
 	logger.Info("Sending alive message to peers")
-	for key, value := range nodeData.PeerNodes {
-		logger.Info("Sending alive message to Peer: ", "PeerHostname", key)
-		request := &pb_contol_plane.NewServerAddRequest{
-			Hostname:         nodeData.NodeDetails.NodeHostname,
-			IpAddress:        nodeData.NodeDetails.NodeIP,
-			ControlPlanePort: nodeData.NodeDetails.NodeControlPort,
-			DataPlanePort:    nodeData.NodeDetails.NodeDataPort,
+	request := &pb_contol_plane.NewServerAddRequest{
+		NodeId:           nodeData.NodeDetails.NodeID,
+		Hostname:         nodeData.NodeDetails.NodeHostname,
+		IpAddress:        nodeData.NodeDetails.NodeIP,
+		ControlPlanePort: nodeData.NodeDetails.NodeControlPort,
+		DataPlanePort:    nodeData.NodeDetails.NodeDataPort,
+		Incarnation:      incarnation,
+		Learner:          nodeData.NodeDetails.Role == nodecommon.RoleLearner,
+	}
+	clusterClient.fanOutToPeers(nodeData, func(ctx context.Context, peerClient pb_contol_plane.NodeControlPlaneServiceClient) error {
+		response, err := peerClient.RegisterNewPeerServer(ctx, request)
+		if err == nil {
+			logger.Info("Peer Response", "Response", response)
 		}
-		peerAddress := value.NodeIP + ":" + value.NodeControlPort
+		return err
+	})
+}
+
+// RemoveNodeFromPeers tells every peer that this node is leaving so they stop
+// replicating to it
+func (clusterClient *ClusterClient) RemoveNodeFromPeers(nodeData *data.NodeData) {
+	request := &pb_contol_plane.RemovePeerRequest{
+		NodeId:           nodeData.NodeDetails.NodeID,
+		Hostname:         nodeData.NodeDetails.NodeHostname,
+		IpAddress:        nodeData.NodeDetails.NodeIP,
+		ControlPlanePort: nodeData.NodeDetails.NodeControlPort,
+	}
+	clusterClient.fanOutToPeers(nodeData, func(ctx context.Context, peerClient pb_contol_plane.NodeControlPlaneServiceClient) error {
+		_, err := peerClient.RemovePeerServer(ctx, request)
+		return err
+	})
+}
+
+// HandOffDataToPeers copies every key to every peer. Unlike regular
+// replication a failed write is returned so a draining node does not leave
+// before its data is safe.
+func (clusterClient *ClusterClient) HandOffDataToPeers(nodeData *data.NodeData, keyValues []storage.KeyValue) error {
+	nodeData.Mu.RLock()
+	peerAddresses := make([]string, 0, len(nodeData.PeerNodes))
+	for _, peer := range nodeData.PeerNodes {
+		peerAddresses = append(peerAddresses, peer.NodeIP+":"+peer.NodeControlPort)
+	}
+	nodeData.Mu.RUnlock()
+
+	if len(peerAddresses) == 0 && len(keyValues) > 0 {
+		clusterClient.logger.Error("No peers to hand the data off to")
+		return fmt.Errorf("No peers to hand %d keys off to", len(keyValues))
+	}
 
-		peerNodeClient, err := clusterClient.createPeerClientConnection(peerAddress)
+	clusterClient.logger.Info("Handing data off to peers", "keys", len(keyValues), "peers", len(peerAddresses))
+	var errs []error
+	for _, peerAddress := range peerAddresses {
+		peerClient, err := clusterClient.createPeerClientConnection(peerAddress)
 		if err != nil {
-			logger.Error("Error in creating peer client")
-			return fmt.Errorf("Error in creating peer client")
+			errs = append(errs, fmt.Errorf("peer %s: %w", peerAddress, err))
+			continue
 		}
-
-		response, peerError := peerNodeClient.RegisterNewPeerServer(context.Background(), request)
-		logger.Info("Peer Response", "Response", response)
-		if peerError != nil {
-			logger.Error("Network Error in registering the peer client")
-			return peerError
+		for _, keyValue := range keyValues {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			_, err := peerClient.ReplicateSetRequest(ctx, &pb_contol_plane.SetReplicationRequest{
+				Key:       keyValue.Key,
+				Value:     keyValue.Value,
+				Timestamp: keyValue.Timestamp,
+			})
+			cancel()
+			if err != nil {
+				clusterClient.logger.Error("Failed to hand key off to peer", "peerAddress", peerAddress, "key", keyValue.Key, "error", err)
+				errs = append(errs, fmt.Errorf("peer %s: %w", peerAddress, err))
+				break
+			}
 		}
+	}
+	return errors.Join(errs...)
+}
+
+// fanOutToPeers calls every known peer in parallel and returns the node ids of
+// the peers the call failed on
+func (clusterClient *ClusterClient) fanOutToPeers(nodeData *data.NodeData, call func(context.Context, pb_contol_plane.NodeControlPlaneServiceClient) error) []string {
+	nodeData.Mu.RLock()
+	peerAddresses := make(map[string]string, len(nodeData.PeerNodes))
+	for nodeID, peer := range nodeData.PeerNodes {
+		peerAddresses[nodeID] = peer.NodeIP + ":" + peer.NodeControlPort
+	}
+	nodeData.Mu.RUnlock()
 
-		logger.Info("Successfully registered peer client")
+	var failed []string
+	var failedMu sync.Mutex
+	var wg sync.WaitGroup
+	for nodeID, peerAddress := range peerAddresses {
+		wg.Add(1)
+		go func(nodeID string, peerAddress string) {
+			defer wg.Done()
+			err := clusterClient.callPeer(peerAddress, call)
+			if err != nil {
+				failedMu.Lock()
+				failed = append(failed, nodeID)
+				failedMu.Unlock()
+			}
+		}(nodeID, peerAddress)
+	}
+	wg.Wait()
+	return failed
+}
+
+func (clusterClient *ClusterClient) callPeer(peerAddress string, call func(context.Context, pb_contol_plane.NodeControlPlaneServiceClient) error) error {
+	peerClient, err := clusterClient.createPeerClientConnection(peerAddress)
+	if err != nil {
+		clusterClient.logger.Error("Error in creating peer client", "peerAddress", peerAddress)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := call(ctx, peerClient); err != nil {
+		clusterClient.logger.Error("Failed to reach peer", "peerAddress", peerAddress, "error", err)
+		return err
 	}
 	return nil
 }
@@ -6,10 +6,17 @@ import (
 	"time"
 
 	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/antientropy"
+	"github.com/Vahsek/distrokv/internal/worker_node/controllers"
 	"github.com/Vahsek/distrokv/internal/worker_node/data"
 	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
 )
 
+// DefaultHeartbeatInterval is how often a node reports to the registry, the
+// registry's failure detector expects the same interval
+const DefaultHeartbeatInterval = 10 * time.Second
+
 func retrieveAllNodesFromRegistry(nodeData *data.NodeData, registryClient pb_registry.RegistryServiceClient, clusterClient *ClusterClient) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -29,14 +36,16 @@ func retrieveAllNodesFromRegistry(nodeData *data.NodeData, registryClient pb_reg
 		nodeName := node.NodeHostname
 		nodeIP := node.NodeIP
 		nodeControlPort := node.NodeControlPort
+		nodeDataPort := node.NodeDataPort
 
 		// Skip self
-		if nodeName == nodeData.NodeDetails.NodeHostname && nodeIP == nodeData.NodeDetails.NodeIP && nodeControlPort == nodeData.NodeDetails.NodeControlPort {
+		if node.NodeId == nodeData.NodeDetails.NodeID {
 			clusterClient.logger.Info("Found Self node skipping")
 			continue
 		}
-		peerNode := nodecommon.InitializeNode(nodeName, nodeIP, nodeControlPort, "", 1)
-		nodeData.PeerNodes[nodeName] = *peerNode
+		peerNode := nodecommon.InitializeNode(node.NodeId, nodeName, nodeIP, nodeControlPort, nodeDataPort, 1)
+		peerNode.Role = nodecommon.NodeRole(node.Role)
+		nodeData.PeerNodes[node.NodeId] = *peerNode
 
 		clusterClient.logger.Info("Added peer node",
 			"hostname", nodeName,
@@ -59,9 +68,12 @@ func (clusterClient *ClusterClient) RegisterNodeWithRegistry(nodeData *data.Node
 	}
 
 	request := &pb_registry.RegisterNodeRequest{
-		Hostname:   nodeData.NodeDetails.NodeHostname,
-		IpAddress:  nodeData.NodeDetails.NodeIP,
-		PortNumber: nodeData.NodeDetails.NodeControlPort,
+		NodeId:        nodeData.NodeDetails.NodeID,
+		Hostname:      nodeData.NodeDetails.NodeHostname,
+		IpAddress:     nodeData.NodeDetails.NodeIP,
+		PortNumber:    nodeData.NodeDetails.NodeControlPort,
+		DataPlanePort: nodeData.NodeDetails.NodeDataPort,
+		Role:          pb_registry.NodeRole(nodeData.NodeDetails.Role),
 	}
 
 	// Add timeout for registration
@@ -78,6 +90,9 @@ func (clusterClient *ClusterClient) RegisterNodeWithRegistry(nodeData *data.Node
 	clusterClient.logger.Info("Successfully registered with registry",
 		"status", response.Status,
 		"message", response.Message)
+	setPrimaryNode(nodeData, response.PrimaryNodeId, clusterClient)
+	setNodeRole(nodeData, nodecommon.NodeRole(response.Role), clusterClient)
+	setConfiguration(nodeData, response.Configuration, clusterClient)
 
 	// Handle error from node retrieval
 	if err := retrieveAllNodesFromRegistry(nodeData, registryClient, clusterClient); err != nil {
@@ -88,7 +103,44 @@ func (clusterClient *ClusterClient) RegisterNodeWithRegistry(nodeData *data.Node
 	return nil
 }
 
-func (clusterClient *ClusterClient) SendRegularNodeHeartBeat(nodeData *data.NodeData) {
+// DeregisterNodeFromRegistry removes the node from the registry so clients and
+// new peers stop routing to it
+func (clusterClient *ClusterClient) DeregisterNodeFromRegistry(nodeData *data.NodeData) error {
+	clusterClient.logger.Info("Deregistering node from registry")
+
+	registryClient, err := clusterClient.createRegistryClient(nodeData.RegistryServerAddress)
+	if err != nil {
+		clusterClient.logger.Error("Error creating registry client", "error", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := registryClient.DeregisterNode(ctx, &pb_registry.DeregisterNodeRequest{
+		NodeId:     nodeData.NodeDetails.NodeID,
+		Hostname:   nodeData.NodeDetails.NodeHostname,
+		IpAddress:  nodeData.NodeDetails.NodeIP,
+		PortNumber: nodeData.NodeDetails.NodeControlPort,
+	})
+	if err != nil {
+		clusterClient.logger.Error("Failed to deregister node from registry", "error", err)
+		return fmt.Errorf("deregistration failed: %w", err)
+	}
+
+	clusterClient.logger.Info("Successfully deregistered from registry",
+		"status", response.Status,
+		"message", response.Message)
+	return nil
+}
+
+// SendRegularNodeHeartBeat sends a heartbeat every heartbeatInterval until ctx
+// is done. onDrain is called whenever the registry reports the node as
+// draining and onRoleChange when the registry reports a new role, as after a
+// promotion.
+// steppedDown tells the registry whether the node gave up being the primary
+// and antiEntropy what the repairs the node ran found.
+func (clusterClient *ClusterClient) SendRegularNodeHeartBeat(ctx context.Context, heartbeatInterval time.Duration, nodeData *data.NodeData, store *storage.KeyValueStore, onDrain func(), onRoleChange func(nodecommon.NodeRole), steppedDown func() bool, antiEntropy func() antientropy.Stats) {
 	clusterClient.logger.Info("Starting heartbeat service")
 
 	// Create client once and reuse
@@ -98,23 +150,40 @@ func (clusterClient *ClusterClient) SendRegularNodeHeartBeat(nodeData *data.Node
 		return
 	}
 
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(heartbeatInterval)
 	defer ticker.Stop()
 
-	heartbeatRequest := &pb_registry.HeartBeatRequest{
-		Hostname:   nodeData.NodeDetails.NodeHostname,
-		IpAddress:  nodeData.NodeDetails.NodeIP,
-		PortNumber: nodeData.NodeDetails.NodeControlPort,
-	}
+	for {
+		select {
+		case <-ctx.Done():
+			clusterClient.logger.Info("Stopping heartbeat service")
+			return
+		case <-ticker.C:
+		}
 
-	for range ticker.C {
 		// Add timeout for each heartbeat
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		heartbeatCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+		antiEntropyStats := antiEntropy()
+		heartbeatRequest := &pb_registry.HeartBeatRequest{
+			NodeId:        nodeData.NodeDetails.NodeID,
+			Hostname:      nodeData.NodeDetails.NodeHostname,
+			IpAddress:     nodeData.NodeDetails.NodeIP,
+			PortNumber:    nodeData.NodeDetails.NodeControlPort,
+			RangeStats:    controllers.CollectRangeStats(nodeData, store),
+			ConfigVersion: configurationVersion(nodeData),
+			SteppedDown:   steppedDown(),
+			AntiEntropy: &pb_registry.AntiEntropyStats{
+				Comparisons:     antiEntropyStats.Comparisons,
+				DivergentRanges: antiEntropyStats.DivergentRanges,
+				RepairedKeys:    antiEntropyStats.RepairedKeys,
+			},
+		}
 
 		clusterClient.logger.Debug("Sending heartbeat to registry server")
 
 		// Handle heartbeat response and errors
-		response, err := registryClient.NodeHeartBeat(ctx, heartbeatRequest)
+		response, err := registryClient.NodeHeartBeat(heartbeatCtx, heartbeatRequest)
 		if err != nil {
 			clusterClient.logger.Error("Failed to send heartbeat", "error", err)
 			cancel()
@@ -127,8 +196,73 @@ func (clusterClient *ClusterClient) SendRegularNodeHeartBeat(nodeData *data.Node
 				"message", response.Message)
 		} else {
 			clusterClient.logger.Debug("Successfully sent heartbeat")
+			controllers.UpdateRanges(response.Ranges, nodeData, store, &clusterClient.logger)
+			setPrimaryNode(nodeData, response.PrimaryNodeId, clusterClient)
+			setConfiguration(nodeData, response.Configuration, clusterClient)
+			if setNodeRole(nodeData, nodecommon.NodeRole(response.Role), clusterClient) {
+				onRoleChange(nodecommon.NodeRole(response.Role))
+			}
+			if response.Draining {
+				clusterClient.logger.Info("Registry reported the node as draining")
+				onDrain()
+			}
 		}
 
 		cancel()
 	}
 }
+
+func setPrimaryNode(nodeData *data.NodeData, primaryNodeID string, clusterClient *ClusterClient) {
+	nodeData.Mu.Lock()
+	defer nodeData.Mu.Unlock()
+
+	if nodeData.PrimaryNodeID == primaryNodeID {
+		return
+	}
+	clusterClient.logger.Info("Primary node changed", "from", nodeData.PrimaryNodeID, "to", primaryNodeID)
+	nodeData.PrimaryNodeID = primaryNodeID
+}
+
+// setNodeRole records the role the registry reports for the node and returns
+// whether it changed
+func setNodeRole(nodeData *data.NodeData, role nodecommon.NodeRole, clusterClient *ClusterClient) bool {
+	nodeData.Mu.Lock()
+	defer nodeData.Mu.Unlock()
+
+	if nodeData.NodeDetails.Role == role {
+		return false
+	}
+	clusterClient.logger.Info("Node role changed", "from", nodeData.NodeDetails.Role, "to", role)
+	nodeData.NodeDetails.Role = role
+	return true
+}
+
+// setConfiguration applies a newer voter configuration, the version is
+// acknowledged with the next heartbeat
+func setConfiguration(nodeData *data.NodeData, configuration *pb_registry.ClusterConfiguration, clusterClient *ClusterClient) {
+	if configuration == nil {
+		return
+	}
+	nodeData.Mu.Lock()
+	defer nodeData.Mu.Unlock()
+
+	if nodeData.Configuration != nil && nodeData.Configuration.Version >= configuration.Version {
+		return
+	}
+	clusterClient.logger.Info("Applying cluster configuration",
+		"version", configuration.Version,
+		"voters", configuration.Voters,
+		"newVoters", configuration.NewVoters,
+		"joint", configuration.Joint)
+	nodeData.Configuration = configuration
+}
+
+func configurationVersion(nodeData *data.NodeData) int64 {
+	nodeData.Mu.RLock()
+	defer nodeData.Mu.RUnlock()
+
+	if nodeData.Configuration == nil {
+		return 0
+	}
+	return nodeData.Configuration.Version
+}
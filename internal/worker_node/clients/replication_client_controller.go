@@ -0,0 +1,17 @@
+package clients
+
+import (
+	"context"
+
+	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/grpc"
+)
+
+// Replicate makes ClusterClient the replication.Transport
+func (clusterClient *ClusterClient) Replicate(ctx context.Context, address string) (grpc.BidiStreamingClient[pb_contol_plane.ReplicationBatch, pb_contol_plane.ReplicationAck], error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.Replicate(ctx)
+}
@@ -0,0 +1,17 @@
+package clients
+
+import (
+	"context"
+
+	pb_contol_plane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/grpc"
+)
+
+// InstallSnapshot makes ClusterClient the snapshot.Transport
+func (clusterClient *ClusterClient) InstallSnapshot(ctx context.Context, address string) (grpc.ClientStreamingClient[pb_contol_plane.SnapshotChunk, pb_contol_plane.InstallSnapshotResponse], error) {
+	peerClient, err := clusterClient.createPeerClientConnection(address)
+	if err != nil {
+		return nil, err
+	}
+	return peerClient.InstallSnapshot(ctx)
+}
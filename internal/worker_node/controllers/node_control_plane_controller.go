@@ -5,35 +5,43 @@ import (
 	"log/slog"
 
 	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
-	"github.com/Vahsek/distrokv/internal/common/util"
-	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/membership"
 	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
 )
 
-func RegisterNewPeerNode(request *pb.NewServerAddRequest, nodeData *data.NodeData, logger *slog.Logger) error {
-	hostname := request.Hostname
-	ipAddress := request.IpAddress
-	controlPlanePort := request.ControlPlanePort
-	dataPlanePort := request.DataPlanePort
-	nodeHash := util.GenerateHash(hostname + ipAddress + controlPlanePort)
-	logger.Info("Started registering the node: ", "NodeName: ", hostname, "NodeIP: ", ipAddress)
-	nodeData.Mu.Lock()
-	defer nodeData.Mu.Unlock()
-
-	_, exists := nodeData.PeerNodes[nodeHash]
-
-	if exists {
-		logger.Error("Node Already Exists")
-		return fmt.Errorf("Node Already Exists")
+// RegisterNewPeerNode records a peer that announced itself. A known peer that
+// restarted, possibly on a new address, comes back with a newer incarnation.
+func RegisterNewPeerNode(request *pb.NewServerAddRequest, peerMembership *membership.Membership, logger *slog.Logger) error {
+	logger.Info("Started registering the node: ", "NodeId: ", request.NodeId, "NodeName: ", request.Hostname, "NodeIP: ", request.IpAddress)
+	if request.NodeId == "" {
+		logger.Error("Peer registered without a node id")
+		return fmt.Errorf("Peer %s registered without a node id", request.Hostname)
 	}
 
-	nodeData.PeerNodes[nodeHash] = *nodecommon.InitializeNode(
-		hostname,
-		ipAddress,
-		controlPlanePort,
-		dataPlanePort,
+	peer := *nodecommon.InitializeNode(
+		request.NodeId,
+		request.Hostname,
+		request.IpAddress,
+		request.ControlPlanePort,
+		request.DataPlanePort,
 		1)
+	if request.Learner {
+		peer.Role = nodecommon.RoleLearner
+	}
+	peerMembership.Join(peer, request.Incarnation)
 
 	logger.Info("Node Registered Successfully")
 	return nil
 }
+
+// RemovePeerNode marks a peer that left the cluster so nothing is replicated
+// to it anymore. The departure is gossiped to the other members.
+func RemovePeerNode(request *pb.RemovePeerRequest, peerMembership *membership.Membership, logger *slog.Logger) error {
+	logger.Info("Removing peer node", "nodeId", request.NodeId, "hostname", request.Hostname)
+	if !peerMembership.Leave(request.NodeId) {
+		logger.Error("Peer node doesn't exist")
+		return fmt.Errorf("Peer node %s doesn't exist", request.NodeId)
+	}
+	logger.Info("Peer node removed successfully")
+	return nil
+}
@@ -1 +1,117 @@
 package controllers
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	pb "github.com/Vahsek/distrokv/pkg/node/dataplane"
+)
+
+// ExecuteTxn runs a data plane transaction against the store and returns the
+// response along with the writes it applied, so they can be replicated. Every
+// put carries the timestamp the store stamped it with.
+func ExecuteTxn(request *pb.TxnRequest, store *storage.KeyValueStore, logger *slog.Logger) (*pb.TxnResponse, []storage.TxnOperation, error) {
+	compares := make([]storage.TxnCompare, 0, len(request.Compares))
+	for _, compare := range request.Compares {
+		compareType, err := toStorageCompareType(compare.Type)
+		if err != nil {
+			logger.Error("Invalid txn compare", "key", compare.Key)
+			return nil, nil, err
+		}
+		compares = append(compares, storage.TxnCompare{
+			Key:   compare.Key,
+			Type:  compareType,
+			Value: compare.Value,
+		})
+	}
+	success, err := toStorageOperations(request.Success)
+	if err != nil {
+		logger.Error("Invalid txn success operations")
+		return nil, nil, err
+	}
+	failure, err := toStorageOperations(request.Failure)
+	if err != nil {
+		logger.Error("Invalid txn failure operations")
+		return nil, nil, err
+	}
+
+	result, err := store.Txn(compares, success, failure)
+	if err != nil {
+		logger.Error("Failed to apply txn", "error", err)
+		return nil, nil, err
+	}
+
+	applied := failure
+	if result.Succeeded {
+		applied = success
+	}
+	var writes []storage.TxnOperation
+	for i, operation := range applied {
+		if operation.Type != storage.OperationGet {
+			operation.Timestamp = result.Results[i].Timestamp
+			writes = append(writes, operation)
+		}
+	}
+
+	response := &pb.TxnResponse{
+		Succeeded: result.Succeeded,
+		Status:    true,
+	}
+	for _, operationResult := range result.Results {
+		response.Results = append(response.Results, &pb.TxnOperationResult{
+			Key:   operationResult.Key,
+			Value: operationResult.Value,
+			Found: operationResult.Found,
+		})
+	}
+	return response, writes, nil
+}
+
+func toStorageCompareType(compareType pb.TxnCompare_CompareType) (storage.CompareType, error) {
+	switch compareType {
+	case pb.TxnCompare_VALUE_EQUALS:
+		return storage.CompareValueEquals, nil
+	case pb.TxnCompare_EXISTS:
+		return storage.CompareExists, nil
+	case pb.TxnCompare_NOT_EXISTS:
+		return storage.CompareNotExists, nil
+	}
+	return 0, fmt.Errorf("Unknown compare type %s", compareType)
+}
+
+func toStorageOperations(operations []*pb.TxnOperation) ([]storage.TxnOperation, error) {
+	converted := make([]storage.TxnOperation, 0, len(operations))
+	for _, operation := range operations {
+		var operationType storage.OperationType
+		switch operation.Type {
+		case pb.TxnOperation_GET:
+			operationType = storage.OperationGet
+		case pb.TxnOperation_PUT:
+			operationType = storage.OperationPut
+		case pb.TxnOperation_DELETE:
+			operationType = storage.OperationDelete
+		default:
+			return nil, fmt.Errorf("Unknown operation type %s", operation.Type)
+		}
+		converted = append(converted, storage.TxnOperation{
+			Type:  operationType,
+			Key:   operation.Key,
+			Value: operation.Value,
+		})
+	}
+	return converted, nil
+}
+
+// ToWatchEvent converts a store change into the event streamed to watchers
+func ToWatchEvent(event storage.WatchEvent) *pb.WatchEvent {
+	eventType := pb.WatchEvent_PUT
+	if event.Type == storage.WatchEventDelete {
+		eventType = pb.WatchEvent_DELETE
+	}
+	return &pb.WatchEvent{
+		Type:  eventType,
+		Key:   event.Key,
+		Value: event.Value,
+	}
+}
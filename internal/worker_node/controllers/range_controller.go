@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"log/slog"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+// UpdateRanges replaces the range descriptors known to the node with the ones
+// handed out by the registry and points the store's usage accounting at them
+func UpdateRanges(ranges []*pb_registry.RangeDescriptor, nodeData *data.NodeData, store *storage.KeyValueStore, logger *slog.Logger) {
+	nodeData.Mu.Lock()
+	defer nodeData.Mu.Unlock()
+
+	startKeys := make([]string, 0, len(ranges))
+	for _, rangeDescriptor := range ranges {
+		startKeys = append(startKeys, rangeDescriptor.StartKey)
+	}
+	if len(ranges) != len(nodeData.Ranges) {
+		logger.Info("Range layout changed", "ranges", len(ranges))
+	}
+	nodeData.Ranges = ranges
+	store.SetRangeBoundaries(startKeys)
+}
+
+// CollectRangeStats reports the size and load of every range known to the
+// node. The query rate is averaged over the time since the last collection.
+func CollectRangeStats(nodeData *data.NodeData, store *storage.KeyValueStore) []*pb_registry.RangeStats {
+	nodeData.Mu.RLock()
+	descriptorByStartKey := make(map[string]*pb_registry.RangeDescriptor, len(nodeData.Ranges))
+	for _, rangeDescriptor := range nodeData.Ranges {
+		descriptorByStartKey[rangeDescriptor.StartKey] = rangeDescriptor
+	}
+	nodeData.Mu.RUnlock()
+
+	var stats []*pb_registry.RangeStats
+	for _, usage := range store.CollectRangeUsage() {
+		rangeDescriptor, exists := descriptorByStartKey[usage.StartKey]
+		if !exists {
+			continue
+		}
+		var queriesPerSecond float64
+		if usage.Period > 0 {
+			queriesPerSecond = float64(usage.Operations) / usage.Period.Seconds()
+		}
+		stats = append(stats, &pb_registry.RangeStats{
+			RangeId:          rangeDescriptor.RangeId,
+			Generation:       rangeDescriptor.Generation,
+			KeyCount:         usage.KeyCount,
+			SizeBytes:        usage.SizeBytes,
+			QueriesPerSecond: queriesPerSecond,
+			SplitKey:         usage.SplitKey,
+		})
+	}
+	return stats
+}
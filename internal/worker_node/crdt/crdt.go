@@ -0,0 +1,342 @@
+// Package crdt implements the conflict free replicated data types a key can
+// hold. Every update is applied by a single node under its own node id, and
+// any two states merge into one that contains the updates of both whatever
+// order they are merged in, so replicas converge without coordinating.
+//
+// A PN-counter keeps the sum of the increments and of the decrements of every
+// node. An observed-remove set tags every add with a dot and a remove
+// tombstones the dots of the adds it has seen, an add concurrent with a remove
+// survives it. A last writer wins register keeps the value with the newest
+// timestamp, the node id breaking ties.
+package crdt
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/protobuf/proto"
+)
+
+// encodedPrefix marks the values of CRDT keys in the store so a plain value
+// is never taken for one. The state follows in base64 since values travel in
+// string fields, which must be valid UTF-8.
+const encodedPrefix = "\x00crdt\x00"
+
+// maxUpdateAttempts bounds how often an update is retried when the key changed
+// between reading and replacing it
+const maxUpdateAttempts = 16
+
+var (
+	ErrWrongType        = errors.New("Key holds a value of another type")
+	ErrInvalidOperation = errors.New("Invalid CRDT operation")
+)
+
+// Apply returns state with operation applied as nodeID at timestamp, taken
+// from the node's hybrid logical clock. A nil state is a key without a value,
+// it takes the type of the operation.
+func Apply(state *pb.CRDTState, operation *pb.CRDTOperation, nodeID string, timestamp int64) (*pb.CRDTState, error) {
+	if state != nil {
+		state = proto.Clone(state).(*pb.CRDTState)
+	}
+	switch op := operation.GetOperation().(type) {
+	case *pb.CRDTOperation_Increment:
+		counter, err := counterOf(state)
+		if err != nil {
+			return nil, err
+		}
+		if op.Increment >= 0 {
+			counter.Increments[nodeID] += op.Increment
+		} else {
+			counter.Decrements[nodeID] -= op.Increment
+		}
+		return &pb.CRDTState{State: &pb.CRDTState_Counter{Counter: counter}}, nil
+	case *pb.CRDTOperation_SetAdd:
+		set, err := setOf(state)
+		if err != nil {
+			return nil, err
+		}
+		element := set.Elements[op.SetAdd]
+		if element == nil {
+			element = &pb.ORSetElement{}
+			set.Elements[op.SetAdd] = element
+		}
+		// The dot of an add must be unique for the node, the timestamp
+		// bumped past the node's last add is
+		counter := timestamp
+		for _, dot := range element.Added {
+			if dot.NodeId == nodeID && dot.Counter >= counter {
+				counter = dot.Counter + 1
+			}
+		}
+		element.Added = mergeDots(element.Added, []*pb.Dot{{NodeId: nodeID, Counter: counter}})
+		return &pb.CRDTState{State: &pb.CRDTState_Set{Set: set}}, nil
+	case *pb.CRDTOperation_SetRemove:
+		set, err := setOf(state)
+		if err != nil {
+			return nil, err
+		}
+		if element := set.Elements[op.SetRemove]; element != nil {
+			element.Removed = mergeDots(element.Removed, element.Added)
+		}
+		return &pb.CRDTState{State: &pb.CRDTState_Set{Set: set}}, nil
+	case *pb.CRDTOperation_RegisterSet:
+		if state != nil && state.GetRegister() == nil {
+			return nil, ErrWrongType
+		}
+		// A value set after the current one has to win over it even if the
+		// clock of this node is behind the one that set it
+		if current := state.GetRegister(); current != nil && current.Timestamp >= timestamp {
+			timestamp = current.Timestamp + 1
+		}
+		register := &pb.LWWRegister{Value: op.RegisterSet, Timestamp: timestamp, NodeId: nodeID}
+		return &pb.CRDTState{State: &pb.CRDTState_Register{Register: register}}, nil
+	}
+	return nil, fmt.Errorf("%w: no operation given", ErrInvalidOperation)
+}
+
+// Merge returns the state holding the updates of both a and b. Either may be
+// nil.
+func Merge(a *pb.CRDTState, b *pb.CRDTState) (*pb.CRDTState, error) {
+	if a == nil {
+		return b, nil
+	}
+	if b == nil {
+		return a, nil
+	}
+	switch {
+	case a.GetCounter() != nil && b.GetCounter() != nil:
+		counter := &pb.PNCounter{
+			Increments: mergeCounts(a.GetCounter().Increments, b.GetCounter().Increments),
+			Decrements: mergeCounts(a.GetCounter().Decrements, b.GetCounter().Decrements),
+		}
+		return &pb.CRDTState{State: &pb.CRDTState_Counter{Counter: counter}}, nil
+	case a.GetSet() != nil && b.GetSet() != nil:
+		set := &pb.ORSet{Elements: make(map[string]*pb.ORSetElement)}
+		for _, from := range []*pb.ORSet{a.GetSet(), b.GetSet()} {
+			for name, element := range from.Elements {
+				merged := set.Elements[name]
+				if merged == nil {
+					merged = &pb.ORSetElement{}
+					set.Elements[name] = merged
+				}
+				merged.Added = mergeDots(merged.Added, element.Added)
+				merged.Removed = mergeDots(merged.Removed, element.Removed)
+			}
+		}
+		return &pb.CRDTState{State: &pb.CRDTState_Set{Set: set}}, nil
+	case a.GetRegister() != nil && b.GetRegister() != nil:
+		if newerRegister(b.GetRegister(), a.GetRegister()) {
+			return b, nil
+		}
+		return a, nil
+	}
+	return nil, ErrWrongType
+}
+
+// Counter returns the value of a counter, 0 for any other type
+func Counter(state *pb.CRDTState) int64 {
+	var value int64
+	for _, increment := range state.GetCounter().GetIncrements() {
+		value += increment
+	}
+	for _, decrement := range state.GetCounter().GetDecrements() {
+		value -= decrement
+	}
+	return value
+}
+
+// Elements returns the elements of a set in order, none for any other type
+func Elements(state *pb.CRDTState) []string {
+	var elements []string
+	for name, element := range state.GetSet().GetElements() {
+		removed := make(map[string]bool, len(element.Removed))
+		for _, dot := range element.Removed {
+			removed[dotKey(dot)] = true
+		}
+		for _, dot := range element.Added {
+			if !removed[dotKey(dot)] {
+				elements = append(elements, name)
+				break
+			}
+		}
+	}
+	sort.Strings(elements)
+	return elements
+}
+
+// Register returns the value of a register, "" for any other type
+func Register(state *pb.CRDTState) string {
+	return state.GetRegister().GetValue()
+}
+
+// Encode turns state into the value stored for a CRDT key
+func Encode(state *pb.CRDTState) (string, error) {
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	return encodedPrefix + base64.RawStdEncoding.EncodeToString(encoded), nil
+}
+
+// Decode is the inverse of Encode, a plain value is ErrWrongType
+func Decode(value string) (*pb.CRDTState, error) {
+	encoded, found := strings.CutPrefix(value, encodedPrefix)
+	if !found {
+		return nil, ErrWrongType
+	}
+	decoded, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("Corrupt CRDT value: %w", err)
+	}
+	var state pb.CRDTState
+	if err := proto.Unmarshal(decoded, &state); err != nil {
+		return nil, fmt.Errorf("Corrupt CRDT value: %w", err)
+	}
+	return &state, nil
+}
+
+// MergeValues merges two stored CRDT values of the same type, it is the
+// storage.MergeFunc that keeps updates coordinated by different nodes when
+// their states are replicated
+func MergeValues(stored string, incoming string) (string, bool) {
+	a, err := Decode(stored)
+	if err != nil {
+		return "", false
+	}
+	b, err := Decode(incoming)
+	if err != nil {
+		return "", false
+	}
+	merged, err := Merge(a, b)
+	if err != nil {
+		return "", false
+	}
+	encoded, err := Encode(merged)
+	if err != nil {
+		return "", false
+	}
+	return encoded, true
+}
+
+// Update applies operation as nodeID at timestamp to the state stored under
+// key and returns the new state, its encoding and the timestamp the store
+// stamped it with. The replacement is a transaction on the value it read, so
+// a concurrent update is never overwritten.
+func Update(store *storage.KeyValueStore, key string, operation *pb.CRDTOperation, nodeID string, timestamp int64) (*pb.CRDTState, string, int64, error) {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		current, err := store.Get(key)
+		found := err == nil
+		if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
+			return nil, "", 0, err
+		}
+		var state *pb.CRDTState
+		if found {
+			if state, err = Decode(current); err != nil {
+				return nil, "", 0, err
+			}
+		}
+		if state, err = Apply(state, operation, nodeID, timestamp); err != nil {
+			return nil, "", 0, err
+		}
+		encoded, err := Encode(state)
+		if err != nil {
+			return nil, "", 0, err
+		}
+
+		compare := storage.TxnCompare{Key: key, Type: storage.CompareNotExists}
+		if found {
+			compare = storage.TxnCompare{Key: key, Type: storage.CompareValueEquals, Value: current}
+		}
+		result, err := store.Txn(
+			[]storage.TxnCompare{compare},
+			[]storage.TxnOperation{{Type: storage.OperationPut, Key: key, Value: encoded}},
+			nil)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if result.Succeeded {
+			return state, encoded, result.Results[0].Timestamp, nil
+		}
+	}
+	return nil, "", 0, fmt.Errorf("Key %q kept changing while it was updated", key)
+}
+
+func counterOf(state *pb.CRDTState) (*pb.PNCounter, error) {
+	if state == nil {
+		return &pb.PNCounter{Increments: make(map[string]int64), Decrements: make(map[string]int64)}, nil
+	}
+	counter := state.GetCounter()
+	if counter == nil {
+		return nil, ErrWrongType
+	}
+	if counter.Increments == nil {
+		counter.Increments = make(map[string]int64)
+	}
+	if counter.Decrements == nil {
+		counter.Decrements = make(map[string]int64)
+	}
+	return counter, nil
+}
+
+func setOf(state *pb.CRDTState) (*pb.ORSet, error) {
+	if state == nil {
+		return &pb.ORSet{Elements: make(map[string]*pb.ORSetElement)}, nil
+	}
+	set := state.GetSet()
+	if set == nil {
+		return nil, ErrWrongType
+	}
+	if set.Elements == nil {
+		set.Elements = make(map[string]*pb.ORSetElement)
+	}
+	return set, nil
+}
+
+func mergeCounts(a map[string]int64, b map[string]int64) map[string]int64 {
+	merged := make(map[string]int64, max(len(a), len(b)))
+	for nodeID, count := range a {
+		merged[nodeID] = count
+	}
+	for nodeID, count := range b {
+		merged[nodeID] = max(merged[nodeID], count)
+	}
+	return merged
+}
+
+// mergeDots returns the union of both in a fixed order, so equal states
+// encode the same
+func mergeDots(a []*pb.Dot, b []*pb.Dot) []*pb.Dot {
+	seen := make(map[string]bool, len(a)+len(b))
+	merged := make([]*pb.Dot, 0, len(a)+len(b))
+	for _, dots := range [][]*pb.Dot{a, b} {
+		for _, dot := range dots {
+			if !seen[dotKey(dot)] {
+				seen[dotKey(dot)] = true
+				merged = append(merged, dot)
+			}
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].NodeId != merged[j].NodeId {
+			return merged[i].NodeId < merged[j].NodeId
+		}
+		return merged[i].Counter < merged[j].Counter
+	})
+	return merged
+}
+
+func dotKey(dot *pb.Dot) string {
+	return fmt.Sprintf("%s/%d", dot.NodeId, dot.Counter)
+}
+
+func newerRegister(register *pb.LWWRegister, current *pb.LWWRegister) bool {
+	if register.Timestamp != current.Timestamp {
+		return register.Timestamp > current.Timestamp
+	}
+	return register.NodeId > current.NodeId
+}
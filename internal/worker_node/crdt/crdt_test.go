@@ -0,0 +1,218 @@
+package crdt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/protobuf/proto"
+)
+
+// replicaStates applies random operations to a few replicas that never
+// exchange their states, so they diverge
+func replicaStates(t *testing.T, random *rand.Rand, operation func(random *rand.Rand) *pb.CRDTOperation) []*pb.CRDTState {
+	t.Helper()
+	states := make([]*pb.CRDTState, 3)
+	for step := range 30 {
+		replica := random.Intn(len(states))
+		state, err := Apply(states[replica], operation(random), fmt.Sprintf("n%d", replica), int64(step))
+		if err != nil {
+			t.Fatalf("applying an operation failed: %v", err)
+		}
+		states[replica] = state
+	}
+	return states
+}
+
+func counterOperation(random *rand.Rand) *pb.CRDTOperation {
+	return &pb.CRDTOperation{Operation: &pb.CRDTOperation_Increment{Increment: int64(random.Intn(11) - 5)}}
+}
+
+func setOperation(random *rand.Rand) *pb.CRDTOperation {
+	element := fmt.Sprintf("e%d", random.Intn(4))
+	if random.Intn(3) == 0 {
+		return &pb.CRDTOperation{Operation: &pb.CRDTOperation_SetRemove{SetRemove: element}}
+	}
+	return &pb.CRDTOperation{Operation: &pb.CRDTOperation_SetAdd{SetAdd: element}}
+}
+
+func registerOperation(random *rand.Rand) *pb.CRDTOperation {
+	return &pb.CRDTOperation{Operation: &pb.CRDTOperation_RegisterSet{RegisterSet: fmt.Sprintf("v%d", random.Intn(100))}}
+}
+
+func mustMerge(t *testing.T, a *pb.CRDTState, b *pb.CRDTState) *pb.CRDTState {
+	t.Helper()
+	merged, err := Merge(a, b)
+	if err != nil {
+		t.Fatalf("merging failed: %v", err)
+	}
+	return merged
+}
+
+func expectEqual(t *testing.T, law string, got *pb.CRDTState, expected *pb.CRDTState) {
+	t.Helper()
+	if !proto.Equal(got, expected) {
+		t.Fatalf("%s does not hold: got %v, expected %v", law, got, expected)
+	}
+	gotEncoded, _ := Encode(got)
+	expectedEncoded, _ := Encode(expected)
+	if gotEncoded != expectedEncoded {
+		t.Fatalf("%s does not hold for the encoding", law)
+	}
+}
+
+func TestMergeLaws(t *testing.T) {
+	for _, crdtType := range []struct {
+		name      string
+		operation func(random *rand.Rand) *pb.CRDTOperation
+	}{
+		{"counter", counterOperation},
+		{"set", setOperation},
+		{"register", registerOperation},
+	} {
+		t.Run(crdtType.name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(1))
+			for range 50 {
+				states := replicaStates(t, random, crdtType.operation)
+				if slices.Contains(states, nil) {
+					continue
+				}
+				a, b, c := states[0], states[1], states[2]
+				expectEqual(t, "commutativity", mustMerge(t, a, b), mustMerge(t, b, a))
+				expectEqual(t, "associativity", mustMerge(t, mustMerge(t, a, b), c), mustMerge(t, a, mustMerge(t, b, c)))
+				expectEqual(t, "idempotence", mustMerge(t, a, a), a)
+				expectEqual(t, "absorption", mustMerge(t, mustMerge(t, a, b), b), mustMerge(t, a, b))
+				expectEqual(t, "identity", mustMerge(t, a, nil), a)
+			}
+		})
+	}
+}
+
+func TestMergedCounterSumsEveryNode(t *testing.T) {
+	increment := func(state *pb.CRDTState, delta int64, nodeID string) *pb.CRDTState {
+		state, err := Apply(state, &pb.CRDTOperation{Operation: &pb.CRDTOperation_Increment{Increment: delta}}, nodeID, 1)
+		if err != nil {
+			t.Fatalf("incrementing failed: %v", err)
+		}
+		return state
+	}
+	left := increment(increment(nil, 5, "n1"), -2, "n1")
+	right := increment(nil, 4, "n2")
+	merged := mustMerge(t, left, right)
+	if value := Counter(merged); value != 7 {
+		t.Fatalf("expected the merged counter to be 7, got %d", value)
+	}
+	// Merging a replica's older state again does not count it twice
+	if value := Counter(mustMerge(t, merged, increment(nil, 5, "n1"))); value != 7 {
+		t.Fatalf("expected a merged older state not to be counted again, got %d", value)
+	}
+}
+
+func TestConcurrentAddSurvivesRemove(t *testing.T) {
+	operation := func(state *pb.CRDTState, operation *pb.CRDTOperation, nodeID string, timestamp int64) *pb.CRDTState {
+		state, err := Apply(state, operation, nodeID, timestamp)
+		if err != nil {
+			t.Fatalf("applying failed: %v", err)
+		}
+		return state
+	}
+	add := &pb.CRDTOperation{Operation: &pb.CRDTOperation_SetAdd{SetAdd: "element"}}
+	remove := &pb.CRDTOperation{Operation: &pb.CRDTOperation_SetRemove{SetRemove: "element"}}
+
+	shared := operation(nil, add, "n1", 1)
+	// n1 removes the element it saw while n2 adds it again concurrently
+	removed := operation(shared, remove, "n1", 2)
+	readded := operation(shared, add, "n2", 3)
+	if elements := Elements(mustMerge(t, removed, readded)); !slices.Equal(elements, []string{"element"}) {
+		t.Fatalf("expected the concurrent add to survive the remove, got %v", elements)
+	}
+	// A remove that saw every add wins
+	if elements := Elements(operation(mustMerge(t, removed, readded), remove, "n1", 4)); len(elements) != 0 {
+		t.Fatalf("expected the element to be removed, got %v", elements)
+	}
+}
+
+func TestRegisterKeepsTheNewestValue(t *testing.T) {
+	set := func(value string, nodeID string, timestamp int64) *pb.CRDTState {
+		state, err := Apply(nil, &pb.CRDTOperation{Operation: &pb.CRDTOperation_RegisterSet{RegisterSet: value}}, nodeID, timestamp)
+		if err != nil {
+			t.Fatalf("setting failed: %v", err)
+		}
+		return state
+	}
+	if value := Register(mustMerge(t, set("newer", "n1", 2), set("older", "n2", 1))); value != "newer" {
+		t.Fatalf("expected the newest value to win, got %q", value)
+	}
+	if value := Register(mustMerge(t, set("n1", "n1", 1), set("n2", "n2", 1))); value != "n2" {
+		t.Fatalf("expected the node id to break a tie, got %q", value)
+	}
+}
+
+func TestMergeRejectsDifferentTypes(t *testing.T) {
+	counter, _ := Apply(nil, &pb.CRDTOperation{Operation: &pb.CRDTOperation_Increment{Increment: 1}}, "n1", 1)
+	set, _ := Apply(nil, &pb.CRDTOperation{Operation: &pb.CRDTOperation_SetAdd{SetAdd: "element"}}, "n1", 1)
+	if _, err := Merge(counter, set); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("expected ErrWrongType, got %v", err)
+	}
+}
+
+func TestReplicatedIncrementsFromTwoCoordinatorsSurvive(t *testing.T) {
+	newStore := func() *storage.KeyValueStore {
+		store := storage.NewKeyValueStore(*slog.New(slog.NewTextHandler(io.Discard, nil)))
+		store.SetMergeFunc(MergeValues)
+		return store
+	}
+	increment := &pb.CRDTOperation{Operation: &pb.CRDTOperation_Increment{Increment: 1}}
+	counter := func(store *storage.KeyValueStore) int64 {
+		value, err := store.Get("key")
+		if err != nil {
+			t.Fatalf("reading the counter failed: %v", err)
+		}
+		state, err := Decode(value)
+		if err != nil {
+			t.Fatalf("decoding the counter failed: %v", err)
+		}
+		return Counter(state)
+	}
+
+	// Both nodes hold the counter and each coordinates an increment of it
+	// before seeing the other's, as a primary and a node writing in its
+	// place would
+	stores := []*storage.KeyValueStore{newStore(), newStore()}
+	updates := make([]storage.TxnOperation, len(stores))
+	for i, store := range stores {
+		_, encoded, timestamp, err := Update(store, "key", increment, fmt.Sprintf("n%d", i+1), 0)
+		if err != nil {
+			t.Fatalf("incrementing on n%d failed: %v", i+1, err)
+		}
+		updates[i] = storage.TxnOperation{Type: storage.OperationPut, Key: "key", Value: encoded, Timestamp: timestamp}
+	}
+	// Each replicates its state to the other, in whichever timestamp order
+	for i, store := range stores {
+		if errs := store.Apply([]storage.TxnOperation{updates[1-i]}); errs[0] != nil {
+			t.Fatalf("applying the replicated update on n%d failed: %v", i+1, errs[0])
+		}
+		if value := counter(store); value != 2 {
+			t.Fatalf("expected both increments on n%d, got %d", i+1, value)
+		}
+	}
+
+	// A snapshot holding only the other increment merges the same way
+	restored := newStore()
+	if errs := restored.Apply(updates[:1]); errs[0] != nil {
+		t.Fatalf("applying the update failed: %v", errs[0])
+	}
+	snapshot := []storage.KeyValue{{Key: "key", Value: updates[1].Value, Timestamp: updates[1].Timestamp}}
+	if err := restored.Restore(snapshot, nil); err != nil {
+		t.Fatalf("restoring the snapshot failed: %v", err)
+	}
+	if value := counter(restored); value != 2 {
+		t.Fatalf("expected the restored counter to keep both increments, got %d", value)
+	}
+}
@@ -5,11 +5,16 @@ import (
 	"sync"
 
 	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
 )
 
 type NodeData struct {
-	NodeDetails           nodecommon.Node
-	PeerNodes             map[string]nodecommon.Node
+	NodeDetails   nodecommon.Node
+	PeerNodes     map[string]nodecommon.Node
+	Ranges        []*pb_registry.RangeDescriptor
+	PrimaryNodeID string
+	// Configuration is the latest voter configuration the registry published
+	Configuration         *pb_registry.ClusterConfiguration
 	RegistryServerAddress string
 	Logger                slog.Logger
 	Mu                    sync.RWMutex
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Vahsek/distrokv/internal/common/util"
+)
+
+const nodeIDFile = "node-id"
+
+// LoadOrCreateNodeID returns the node id kept in dataDir, generating and
+// persisting a new one on the first start. The id identifies the node to the
+// registry and its peers for as long as the data directory is kept, even if
+// the node comes back on a different address.
+func LoadOrCreateNodeID(dataDir string) (string, error) {
+	nodeIDPath := filepath.Join(dataDir, nodeIDFile)
+	contents, err := os.ReadFile(nodeIDPath)
+	if err == nil {
+		nodeID := strings.TrimSpace(string(contents))
+		if nodeID == "" {
+			return "", fmt.Errorf("Node id file %s is empty", nodeIDPath)
+		}
+		return nodeID, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("Failed to read node id: %w", err)
+	}
+
+	nodeID, err := util.GenerateUUID()
+	if err != nil {
+		return "", fmt.Errorf("Failed to generate node id: %w", err)
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return "", fmt.Errorf("Failed to create data directory %s: %w", dataDir, err)
+	}
+
+	// Written to a temporary file first so a crash never leaves a partial id
+	tempFile, err := os.CreateTemp(dataDir, nodeIDFile+".*")
+	if err != nil {
+		return "", fmt.Errorf("Failed to create node id file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.WriteString(nodeID + "\n"); err != nil {
+		tempFile.Close()
+		return "", fmt.Errorf("Failed to write node id: %w", err)
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return "", fmt.Errorf("Failed to sync node id: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return "", fmt.Errorf("Failed to close node id file: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), nodeIDPath); err != nil {
+		return "", fmt.Errorf("Failed to persist node id: %w", err)
+	}
+	return nodeID, nil
+}
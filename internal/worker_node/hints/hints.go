@@ -0,0 +1,273 @@
+// Package hints keeps the writes a peer missed while it was unreachable so
+// the primary can replay them once the peer is back instead of sending it a
+// whole snapshot. The hints for a peer are appended to a file of their own
+// and read back in order. A peer whose hints outgrow the size cap loses them
+// and is caught up with a snapshot instead.
+//
+// Hints do not survive a restart. A restarted node no longer knows which of
+// its peers are out of sync, so the files left by its previous run are
+// removed when the store is opened.
+package hints
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultMaxBytesPerPeer = 64 << 20
+
+	fileSuffix = ".hints"
+	// headerSize is the record length followed by its checksum
+	headerSize = 8
+)
+
+var (
+	ErrNoHints  = errors.New("No hints are kept for the peer")
+	ErrOverflow = errors.New("Hints for the peer outgrew the size cap")
+	ErrCorrupt  = errors.New("Hint file is corrupt")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type Config struct {
+	Dir string
+	// MaxBytesPerPeer caps the size of the hints kept for a single peer
+	MaxBytesPerPeer int64
+}
+
+func DefaultConfig(dir string) Config {
+	return Config{
+		Dir:             dir,
+		MaxBytesPerPeer: DefaultMaxBytesPerPeer,
+	}
+}
+
+type hintFile struct {
+	file *os.File
+	size int64
+}
+
+// Store holds the hints of every peer. Appends to the hints of a peer are
+// read back in the order they were made.
+type Store struct {
+	config Config
+	files  map[string]*hintFile
+	closed bool
+	mu     sync.Mutex
+	logger slog.Logger
+}
+
+// Open creates the hint directory and removes the hints left by a previous
+// run
+func Open(config Config, logger slog.Logger) (*Store, error) {
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("Failed to create the hint directory: %w", err)
+	}
+	stale, err := filepath.Glob(filepath.Join(config.Dir, "*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("Failed to remove stale hints: %w", err)
+		}
+	}
+	if len(stale) > 0 {
+		logger.Info("Removed hints left by a previous run", "peers", len(stale))
+	}
+	return &Store{
+		config: config,
+		files:  make(map[string]*hintFile),
+		logger: logger,
+	}, nil
+}
+
+// Start begins keeping hints for a peer. It is a no-op for a peer that has
+// hints already.
+func (store *Store) Start(nodeID string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.closed {
+		return ErrNoHints
+	}
+	if _, exists := store.files[nodeID]; exists {
+		return nil
+	}
+	file, err := os.OpenFile(store.path(nodeID), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("Failed to create hints for %s: %w", nodeID, err)
+	}
+	store.files[nodeID] = &hintFile{file: file}
+	store.logger.Info("Keeping hints for peer", "nodeId", nodeID)
+	return nil
+}
+
+// Append adds entries to the hints of a peer as a single record. The hints
+// are dropped when they would outgrow the size cap.
+func (store *Store) Append(nodeID string, entries []*pb.ReplicationEntry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	hints, exists := store.files[nodeID]
+	if !exists {
+		return ErrNoHints
+	}
+	payload, err := proto.Marshal(&pb.ReplicationBatch{Entries: entries})
+	if err != nil {
+		return err
+	}
+	if hints.size+headerSize+int64(len(payload)) > store.config.MaxBytesPerPeer {
+		store.logger.Warn("Dropping hints that outgrew the size cap", "nodeId", nodeID, "bytes", hints.size)
+		store.drop(nodeID)
+		return ErrOverflow
+	}
+
+	record := make([]byte, headerSize, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(record, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:], crc32.Checksum(payload, castagnoli))
+	record = append(record, payload...)
+	if _, err := hints.file.WriteAt(record, hints.size); err != nil {
+		store.logger.Error("Failed to append hints, dropping them", "nodeId", nodeID, "error", err)
+		store.drop(nodeID)
+		return err
+	}
+	hints.size += int64(len(record))
+	return nil
+}
+
+// Read returns the entries of the records from offset on, up to about
+// maxBytes of them, along with the offset of the next record
+func (store *Store) Read(nodeID string, offset int64, maxBytes int) ([]*pb.ReplicationEntry, int64, error) {
+	store.mu.Lock()
+	hints, exists := store.files[nodeID]
+	var size int64
+	if exists {
+		size = hints.size
+	}
+	store.mu.Unlock()
+	if !exists {
+		return nil, offset, ErrNoHints
+	}
+
+	// Records are never rewritten, so reading below size needs no lock
+	var entries []*pb.ReplicationEntry
+	header := make([]byte, headerSize)
+	read := 0
+	for offset < size && (read == 0 || read < maxBytes) {
+		if _, err := hints.file.ReadAt(header, offset); err != nil {
+			return nil, offset, readError(err)
+		}
+		length := int64(binary.LittleEndian.Uint32(header))
+		if offset+headerSize+length > size {
+			return nil, offset, ErrCorrupt
+		}
+		payload := make([]byte, length)
+		if _, err := hints.file.ReadAt(payload, offset+headerSize); err != nil {
+			return nil, offset, readError(err)
+		}
+		if crc32.Checksum(payload, castagnoli) != binary.LittleEndian.Uint32(header[4:]) {
+			return nil, offset, ErrCorrupt
+		}
+		var batch pb.ReplicationBatch
+		if err := proto.Unmarshal(payload, &batch); err != nil {
+			return nil, offset, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		entries = append(entries, batch.Entries...)
+		offset += headerSize + length
+		read += int(length)
+	}
+	return entries, offset, nil
+}
+
+// Has reports whether hints are kept for a peer
+func (store *Store) Has(nodeID string) bool {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	_, exists := store.files[nodeID]
+	return exists
+}
+
+// Peers returns the node ids of the peers hints are kept for
+func (store *Store) Peers() []string {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	nodeIDs := make([]string, 0, len(store.files))
+	for nodeID := range store.files {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	return nodeIDs
+}
+
+// DropIfReplayed drops the hints of a peer when every record up to offset
+// was replayed and nothing was appended since. It reports whether it did.
+func (store *Store) DropIfReplayed(nodeID string, offset int64) bool {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	hints, exists := store.files[nodeID]
+	if !exists || hints.size != offset {
+		return false
+	}
+	store.drop(nodeID)
+	return true
+}
+
+// Drop stops keeping hints for a peer and removes the ones kept
+func (store *Store) Drop(nodeID string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.drop(nodeID)
+}
+
+// Close removes every hint, they would not be trusted after a restart anyway
+func (store *Store) Close() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.closed = true
+	for nodeID := range store.files {
+		store.drop(nodeID)
+	}
+	return nil
+}
+
+// drop expects the caller to hold the lock
+func (store *Store) drop(nodeID string) {
+	hints, exists := store.files[nodeID]
+	if !exists {
+		return
+	}
+	delete(store.files, nodeID)
+	hints.file.Close()
+	if err := os.Remove(store.path(nodeID)); err != nil {
+		store.logger.Warn("Failed to remove hints", "nodeId", nodeID, "error", err)
+	}
+}
+
+func (store *Store) path(nodeID string) string {
+	// Node ids are uuids, anything else is kept from escaping the directory
+	return filepath.Join(store.config.Dir, strings.ReplaceAll(nodeID, string(filepath.Separator), "_")+fileSuffix)
+}
+
+func readError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return ErrCorrupt
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package leaderless
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Vahsek/distrokv/internal/worker_node/crdt"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrInvalidContext = errors.New("Invalid causal context")
+
+// merge folds version into versions, the versions of a key that do not
+// replace each other, and reports whether version was kept. Under last writer
+// wins the newest version replaces every other. With vector clocks a version
+// replaces the ones its clock covers and is dropped when one of them covers
+// it, so concurrent versions stay as siblings. CRDT versions of the same type
+// are joined in either mode.
+func merge(versions []*pb.VersionedValue, version *pb.VersionedValue, vectorClocks bool) ([]*pb.VersionedValue, bool) {
+	if version.Crdt != nil {
+		if merged, kept, joined := mergeCRDT(versions, version); joined {
+			return merged, kept
+		}
+	}
+	if !vectorClocks {
+		if current := newest(versions); current != nil && !Newer(version, current) {
+			return versions, false
+		}
+		return []*pb.VersionedValue{version}, true
+	}
+
+	for _, current := range versions {
+		if sameWrite(current, version) || Covers(current, version) {
+			return versions, false
+		}
+	}
+	kept := make([]*pb.VersionedValue, 0, len(versions)+1)
+	for _, current := range versions {
+		if !Covers(version, current) {
+			kept = append(kept, current)
+		}
+	}
+	kept = append(kept, version)
+	sort.Slice(kept, func(i, j int) bool {
+		return Newer(kept[j], kept[i])
+	})
+	return kept, true
+}
+
+// mergeCRDT joins version into the CRDT version among versions and reports
+// whether that changed it. joined is false when there is no CRDT version of
+// the same type to join with.
+func mergeCRDT(versions []*pb.VersionedValue, version *pb.VersionedValue) (merged []*pb.VersionedValue, kept bool, joined bool) {
+	for i, current := range versions {
+		if current.Crdt == nil {
+			continue
+		}
+		state, err := crdt.Merge(current.Crdt, version.Crdt)
+		if err != nil {
+			return nil, false, false
+		}
+		if proto.Equal(state, current.Crdt) {
+			return versions, false, true
+		}
+		// The joined version takes the identity of the newer one so it
+		// still wins over the writes both replaced
+		newer := current
+		if Newer(version, current) {
+			newer = version
+		}
+		merged = slices.Clone(versions)
+		merged[i] = &pb.VersionedValue{Crdt: state, Timestamp: newer.Timestamp, NodeId: newer.NodeId, Clock: newer.Clock}
+		return merged, true, true
+	}
+	return nil, false, false
+}
+
+// Covers reports whether version was written by a client that had seen other,
+// so version replaces it
+func Covers(version *pb.VersionedValue, other *pb.VersionedValue) bool {
+	return version.Clock[other.NodeId] >= other.Timestamp
+}
+
+// sameWrite reports whether both versions come from the same write, the node
+// id and timestamp of a write are unique
+func sameWrite(version *pb.VersionedValue, other *pb.VersionedValue) bool {
+	return version.NodeId == other.NodeId && version.Timestamp == other.Timestamp
+}
+
+func newest(versions []*pb.VersionedValue) *pb.VersionedValue {
+	var newest *pb.VersionedValue
+	for _, version := range versions {
+		if Newer(version, newest) {
+			newest = version
+		}
+	}
+	return newest
+}
+
+// causalContext is the clock that covers every one of versions
+func causalContext(versions []*pb.VersionedValue) map[string]int64 {
+	clock := make(map[string]int64)
+	for _, version := range versions {
+		for nodeID, timestamp := range version.Clock {
+			clock[nodeID] = max(clock[nodeID], timestamp)
+		}
+		clock[version.NodeId] = max(clock[version.NodeId], version.Timestamp)
+	}
+	return clock
+}
+
+// EncodeContext turns a clock into the opaque context handed to clients
+func EncodeContext(clock map[string]int64) (string, error) {
+	if len(clock) == 0 {
+		return "", nil
+	}
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(&pb.CausalContext{Clock: clock})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// DecodeContext is the inverse of EncodeContext, an empty context is an empty
+// clock
+func DecodeContext(context string) (map[string]int64, error) {
+	if context == "" {
+		return nil, nil
+	}
+	encoded, err := base64.RawURLEncoding.DecodeString(context)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContext, err)
+	}
+	var causalContext pb.CausalContext
+	if err := proto.Unmarshal(encoded, &causalContext); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidContext, err)
+	}
+	return causalContext.Clock, nil
+}
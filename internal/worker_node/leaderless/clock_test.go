@@ -0,0 +1,167 @@
+package leaderless
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"testing"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"github.com/Vahsek/distrokv/internal/storage"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+func version(value string, nodeID string, timestamp int64, clock map[string]int64) *pb.VersionedValue {
+	return &pb.VersionedValue{Value: value, NodeId: nodeID, Timestamp: timestamp, Clock: clock}
+}
+
+func values(versions []*pb.VersionedValue) []string {
+	result := make([]string, len(versions))
+	for i, version := range versions {
+		result[i] = version.Value
+	}
+	sort.Strings(result)
+	return result
+}
+
+func mergeAll(vectorClocks bool, versions ...*pb.VersionedValue) []*pb.VersionedValue {
+	var merged []*pb.VersionedValue
+	for _, version := range versions {
+		merged, _ = merge(merged, version, vectorClocks)
+	}
+	return merged
+}
+
+func expectValues(t *testing.T, versions []*pb.VersionedValue, expected ...string) {
+	t.Helper()
+	got := values(versions)
+	sort.Strings(expected)
+	if len(got) != len(expected) {
+		t.Fatalf("expected the versions %v, got %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("expected the versions %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestConcurrentWritesBecomeSiblings(t *testing.T) {
+	// Both clients started from the same read of the first write
+	first := version("first", "n1", 10, nil)
+	left := version("left", "n1", 20, map[string]int64{"n1": 10})
+	right := version("right", "n2", 15, map[string]int64{"n1": 10})
+
+	siblings := mergeAll(true, first, left, right)
+	expectValues(t, siblings, "left", "right")
+
+	// A write made after reading both siblings replaces them
+	resolved := version("resolved", "n2", 30, causalContext(siblings))
+	merged, kept := merge(siblings, resolved, true)
+	if !kept {
+		t.Fatalf("expected the resolving write to be kept")
+	}
+	expectValues(t, merged, "resolved")
+}
+
+func TestWriteReplacesOnlyTheSiblingsItSaw(t *testing.T) {
+	left := version("left", "n1", 20, nil)
+	right := version("right", "n2", 15, nil)
+	siblings := mergeAll(true, left, right)
+
+	// The client only read left before writing
+	update := version("update", "n1", 25, map[string]int64{"n1": 20})
+	merged, kept := merge(siblings, update, true)
+	if !kept {
+		t.Fatalf("expected the update to be kept")
+	}
+	expectValues(t, merged, "right", "update")
+}
+
+func TestStaleAndDuplicateWritesAreDropped(t *testing.T) {
+	older := version("older", "n1", 10, nil)
+	newer := version("newer", "n2", 20, map[string]int64{"n1": 10})
+	siblings := mergeAll(true, newer)
+
+	if merged, kept := merge(siblings, older, true); kept {
+		t.Fatalf("expected a write the stored one covers to be dropped, got %v", values(merged))
+	}
+	duplicate := version("newer", "n2", 20, map[string]int64{"n1": 10})
+	if merged, kept := merge(siblings, duplicate, true); kept || len(merged) != 1 {
+		t.Fatalf("expected a write seen before to be dropped, got %v", values(merged))
+	}
+}
+
+func TestSiblingsDoNotDependOnArrivalOrder(t *testing.T) {
+	writes := []*pb.VersionedValue{
+		version("a", "n1", 10, nil),
+		version("b", "n2", 12, nil),
+		version("c", "n3", 14, map[string]int64{"n1": 10}),
+		version("d", "n1", 16, map[string]int64{"n1": 10, "n2": 12}),
+	}
+	expected := values(mergeAll(true, writes...))
+	for _, order := range [][]int{{3, 2, 1, 0}, {1, 3, 0, 2}, {2, 0, 3, 1}} {
+		reordered := make([]*pb.VersionedValue, len(order))
+		for i, index := range order {
+			reordered[i] = writes[index]
+		}
+		got := mergeAll(true, reordered...)
+		expectValues(t, got, expected...)
+		// Siblings are kept oldest first
+		for i := 1; i < len(got); i++ {
+			if Newer(got[i-1], got[i]) {
+				t.Fatalf("expected the siblings oldest first, got %v before %v", got[i-1].Value, got[i].Value)
+			}
+		}
+	}
+	expectValues(t, mergeAll(true, writes...), "c", "d")
+}
+
+func TestLastWriterWinsKeepsOneVersion(t *testing.T) {
+	merged := mergeAll(false,
+		version("left", "n1", 20, nil),
+		version("right", "n2", 15, nil),
+		version("tie", "n3", 20, nil),
+	)
+	expectValues(t, merged, "tie")
+}
+
+func TestCausalContextRoundTrip(t *testing.T) {
+	clock := map[string]int64{"n1": 10, "n2": 20}
+	encoded, err := EncodeContext(clock)
+	if err != nil {
+		t.Fatalf("encoding the context failed: %v", err)
+	}
+	decoded, err := DecodeContext(encoded)
+	if err != nil {
+		t.Fatalf("decoding the context failed: %v", err)
+	}
+	if len(decoded) != len(clock) || decoded["n1"] != 10 || decoded["n2"] != 20 {
+		t.Fatalf("expected %v back, got %v", clock, decoded)
+	}
+	if _, err := DecodeContext("not a context!"); !errors.Is(err, ErrInvalidContext) {
+		t.Fatalf("expected ErrInvalidContext, got %v", err)
+	}
+}
+
+func TestReplicaStoresSiblings(t *testing.T) {
+	logger := *slog.New(slog.NewTextHandler(io.Discard, nil))
+	replica := NewReplica(storage.NewKeyValueStore(logger), "n1", true, hlc.NewClock(hlc.DefaultMaxSkew, logger), logger)
+	kept, err := replica.Write([]*pb.VersionedEntry{
+		{Key: "key", Version: version("left", "n1", 20, nil)},
+		{Key: "key", Version: version("right", "n2", 15, nil)},
+		{Key: "key", Version: version("left", "n1", 20, nil)},
+	})
+	if err != nil {
+		t.Fatalf("writing failed: %v", err)
+	}
+	if kept != 2 {
+		t.Fatalf("expected the repeated write to be dropped, kept %d", kept)
+	}
+	versions, err := replica.Read("key")
+	if err != nil {
+		t.Fatalf("reading failed: %v", err)
+	}
+	expectValues(t, versions, "left", "right")
+}
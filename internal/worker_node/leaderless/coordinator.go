@@ -0,0 +1,526 @@
+// Package leaderless replicates keys Dynamo style as an alternative to the
+// primary. Every key is stored on the N nodes that follow it on a consistent
+// hash ring and any node coordinates a request for it. A write is stamped
+// with the coordinator's hybrid logical clock and sent to all N replicas, it succeeds once W
+// of them kept it. A read asks all N replicas and answers with the newest
+// version among the first R to reply, last writer wins. Replicas that
+// answered with an older version, or that answer later, are brought up to
+// date in the background, which is read repair. R + W > N makes every read
+// see the latest acknowledged write, smaller quorums trade that for
+// availability.
+//
+// Last writer wins loses one of two concurrent writes. With vector clocks a
+// write carries the causal context of the read it is based on instead and
+// only replaces the versions that read returned. Concurrent writes are kept
+// as siblings and returned together until a client writes back a value that
+// resolves them.
+//
+// CRDT keys need neither. An update is applied by one replica of the key and
+// merged into the others, and the versions replicas hold are merged on reads
+// and repairs.
+package leaderless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/crdt"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultReplicas    = 3
+	DefaultReadQuorum  = 2
+	DefaultWriteQuorum = 2
+	DefaultTimeout     = 5 * time.Second
+	// handOffBatchSize bounds the entries sent to a replica in one request
+	// while a node hands its keys off
+	handOffBatchSize = 256
+)
+
+var (
+	ErrInvalidQuorum     = errors.New("Quorum must be between 1 and the number of replicas")
+	ErrQuorumUnavailable = errors.New("Not enough replicas answered")
+)
+
+type Config struct {
+	// Enabled switches the node from the primary to leaderless mode, every
+	// node of a cluster has to agree on it
+	Enabled bool
+	// Replicas is N, the number of nodes every key is stored on
+	Replicas int
+	// ReadQuorum and WriteQuorum are the R and W used by requests that do
+	// not ask for their own
+	ReadQuorum  int
+	WriteQuorum int
+	// VectorClocks keeps concurrent writes as siblings instead of letting
+	// the last writer win, every node of a cluster has to agree on it
+	VectorClocks bool
+	// Timeout bounds a single request to a replica
+	Timeout      time.Duration
+	VirtualNodes int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Replicas:     DefaultReplicas,
+		ReadQuorum:   DefaultReadQuorum,
+		WriteQuorum:  DefaultWriteQuorum,
+		Timeout:      DefaultTimeout,
+		VirtualNodes: DefaultVirtualNodes,
+	}
+}
+
+// Transport reaches the replicas on other nodes
+type Transport interface {
+	ReplicaRead(ctx context.Context, address string, request *pb.ReplicaReadRequest) (*pb.ReplicaReadResponse, error)
+	ReplicaWrite(ctx context.Context, address string, request *pb.ReplicaWriteRequest) (*pb.ReplicaWriteResponse, error)
+	ReplicaUpdateCRDT(ctx context.Context, address string, request *pb.ReplicaUpdateCRDTRequest) (*pb.ReplicaUpdateCRDTResponse, error)
+}
+
+type Coordinator struct {
+	config    Config
+	nodeData  *data.NodeData
+	replica   *Replica
+	transport Transport
+	clock     *hlc.Clock
+	// mu guards the ring, rebuilt when the members change
+	mu          sync.Mutex
+	ring        *Ring
+	ringMembers string
+	logger      slog.Logger
+}
+
+func NewCoordinator(config Config, nodeData *data.NodeData, replica *Replica, transport Transport, clock *hlc.Clock, logger slog.Logger) *Coordinator {
+	return &Coordinator{
+		config:    config,
+		nodeData:  nodeData,
+		replica:   replica,
+		transport: transport,
+		clock:     clock,
+		logger:    logger,
+	}
+}
+
+// Value is what a read found. Siblings holds the newest value under last
+// writer wins and every concurrent value with vector clocks, Context is the
+// causal context to write back with.
+type Value struct {
+	Siblings []string
+	Context  string
+}
+
+// Get returns the value of key among readQuorum of its replicas, or
+// storage.ErrKeyNotFound when none of them holds one. A readQuorum of 0 uses
+// the configured one.
+func (coordinator *Coordinator) Get(ctx context.Context, key string, readQuorum int) (*Value, error) {
+	versions, err := coordinator.read(ctx, key, readQuorum)
+	if err != nil {
+		return nil, err
+	}
+
+	// A tombstone concurrent with values leaves the values visible, CRDT
+	// keys are read with GetCRDT
+	var value Value
+	for _, version := range versions {
+		if !version.Deleted && version.Crdt == nil {
+			value.Siblings = append(value.Siblings, version.Value)
+		}
+	}
+	if len(value.Siblings) == 0 {
+		return nil, fmt.Errorf("The Key %s doesn't exists: %w", key, storage.ErrKeyNotFound)
+	}
+	if coordinator.config.VectorClocks {
+		if value.Context, err = EncodeContext(causalContext(versions)); err != nil {
+			return nil, err
+		}
+	}
+	return &value, nil
+}
+
+// GetCRDT returns the CRDT of key merged from readQuorum of its replicas
+func (coordinator *Coordinator) GetCRDT(ctx context.Context, key string, readQuorum int) (*pb.CRDTState, error) {
+	versions, err := coordinator.read(ctx, key, readQuorum)
+	if err != nil {
+		return nil, err
+	}
+	var state *pb.CRDTState
+	for _, version := range versions {
+		if version.Crdt == nil {
+			if !version.Deleted {
+				return nil, crdt.ErrWrongType
+			}
+			continue
+		}
+		if state, err = crdt.Merge(state, version.Crdt); err != nil {
+			return nil, err
+		}
+	}
+	if state == nil {
+		return nil, fmt.Errorf("The Key %s doesn't exists: %w", key, storage.ErrKeyNotFound)
+	}
+	return state, nil
+}
+
+// read returns the versions of key merged from readQuorum of its replicas
+// and repairs the replicas in the background
+func (coordinator *Coordinator) read(ctx context.Context, key string, readQuorum int) ([]*pb.VersionedValue, error) {
+	replicas, quorum, err := coordinator.replicasFor(key, readQuorum, coordinator.config.ReadQuorum)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replicas are asked apart from ctx so the ones that answer after the
+	// quorum still take part in read repair
+	results := make(chan replicaVersions, len(replicas))
+	for _, node := range replicas {
+		go func() {
+			versions, err := coordinator.readFrom(node, key)
+			results <- replicaVersions{node: node, versions: versions, err: err}
+		}()
+	}
+
+	var answered []replicaVersions
+	failed := 0
+	for len(answered) < quorum {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case result := <-results:
+			if result.err != nil {
+				coordinator.logger.Warn("Replica failed to answer read", "key", key, "nodeId", result.node.NodeID, "error", result.err)
+				failed++
+				if len(replicas)-failed < quorum {
+					return nil, fmt.Errorf("%w: %d of %d replicas answered the read, %d needed", ErrQuorumUnavailable, len(answered), len(replicas), quorum)
+				}
+				continue
+			}
+			answered = append(answered, result)
+		}
+	}
+
+	go coordinator.readRepair(key, answered, results, len(replicas)-len(answered)-failed)
+	return coordinator.mergeVersions(answered), nil
+}
+
+// Put stores value under key on its replicas and returns once writeQuorum of
+// them kept it. With vector clocks the value replaces the versions covered by
+// causalContext, the context of an earlier read, and is a sibling of the
+// others.
+func (coordinator *Coordinator) Put(ctx context.Context, key string, value string, writeQuorum int, causalContext string) error {
+	return coordinator.write(ctx, key, &pb.VersionedValue{Value: value}, writeQuorum, causalContext)
+}
+
+// Delete leaves a tombstone for key on its replicas, whether or not it exists
+func (coordinator *Coordinator) Delete(ctx context.Context, key string, writeQuorum int, causalContext string) error {
+	return coordinator.write(ctx, key, &pb.VersionedValue{Deleted: true}, writeQuorum, causalContext)
+}
+
+func (coordinator *Coordinator) write(ctx context.Context, key string, version *pb.VersionedValue, writeQuorum int, causalContext string) error {
+	replicas, quorum, err := coordinator.replicasFor(key, writeQuorum, coordinator.config.WriteQuorum)
+	if err != nil {
+		return err
+	}
+	if coordinator.config.VectorClocks {
+		if version.Clock, err = DecodeContext(causalContext); err != nil {
+			return err
+		}
+	}
+	version.NodeId = coordinator.nodeData.NodeDetails.NodeID
+	// The timestamp has to be past the one of the write's own node in the
+	// context, or the write would be covered by its own context
+	if version.Timestamp, err = coordinator.nextTimestamp(version.Clock[version.NodeId]); err != nil {
+		return err
+	}
+	return coordinator.replicate(ctx, key, []*pb.VersionedEntry{{Key: key, Version: version}}, replicas, quorum)
+}
+
+// UpdateCRDT applies operation to the CRDT of key and returns the CRDT once
+// writeQuorum replicas hold the update. The first replica that answers
+// applies it as itself, so the updates counted for a node never race each
+// other, and the result is merged into the other replicas.
+func (coordinator *Coordinator) UpdateCRDT(ctx context.Context, key string, operation *pb.CRDTOperation, writeQuorum int) (*pb.CRDTState, error) {
+	replicas, quorum, err := coordinator.replicasFor(key, writeQuorum, coordinator.config.WriteQuorum)
+	if err != nil {
+		return nil, err
+	}
+	for i, node := range replicas {
+		version, err := coordinator.updateOn(node, key, operation)
+		if errors.Is(err, crdt.ErrWrongType) || errors.Is(err, crdt.ErrInvalidOperation) {
+			return nil, err
+		}
+		if err != nil {
+			coordinator.logger.Warn("Replica failed to apply CRDT update", "key", key, "nodeId", node.NodeID, "error", err)
+			continue
+		}
+		others := slices.Delete(slices.Clone(replicas), i, i+1)
+		if err := coordinator.replicate(ctx, key, []*pb.VersionedEntry{{Key: key, Version: version}}, others, quorum-1); err != nil {
+			return nil, err
+		}
+		return version.Crdt, nil
+	}
+	return nil, fmt.Errorf("%w: no replica applied the update", ErrQuorumUnavailable)
+}
+
+// replicate writes entries to replicas and returns once quorum of them kept
+// them. Replicas that did not acknowledge in time keep the entries if they
+// reach them later.
+func (coordinator *Coordinator) replicate(ctx context.Context, key string, entries []*pb.VersionedEntry, replicas []nodecommon.Node, quorum int) error {
+	results := make(chan error, len(replicas))
+	for _, node := range replicas {
+		go func() {
+			results <- coordinator.writeTo(node, entries)
+		}()
+	}
+
+	acknowledged, failed := 0, 0
+	for acknowledged < quorum {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-results:
+			if err != nil {
+				coordinator.logger.Warn("Replica failed to acknowledge write", "key", key, "error", err)
+				failed++
+				if len(replicas)-failed < quorum {
+					return fmt.Errorf("%w: %d of %d replicas acknowledged the write, %d needed", ErrQuorumUnavailable, acknowledged, len(replicas), quorum)
+				}
+				continue
+			}
+			acknowledged++
+		}
+	}
+	return nil
+}
+
+// HandOff copies every version this node holds to the replicas the key has
+// without this node, so the node can leave without losing writes only it
+// kept
+func (coordinator *Coordinator) HandOff() error {
+	entries, err := coordinator.replica.Entries()
+	if err != nil {
+		return err
+	}
+	self := coordinator.nodeData.NodeDetails.NodeID
+	ring := NewRing(coordinator.members()[1:], coordinator.config.VirtualNodes)
+
+	batches := make(map[string][]*pb.VersionedEntry)
+	nodes := make(map[string]nodecommon.Node)
+	for _, entry := range entries {
+		for _, node := range ring.Replicas(entry.Key, coordinator.config.Replicas) {
+			if node.NodeID == self {
+				continue
+			}
+			nodes[node.NodeID] = node
+			batches[node.NodeID] = append(batches[node.NodeID], entry)
+		}
+	}
+	if len(entries) > 0 && len(nodes) == 0 {
+		return errors.New("No peer to hand the keys off to")
+	}
+
+	var errs []error
+	for nodeID, batch := range batches {
+		for start := 0; start < len(batch); start += handOffBatchSize {
+			end := min(start+handOffBatchSize, len(batch))
+			if err := coordinator.writeTo(nodes[nodeID], batch[start:end]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", nodeID, err))
+				break
+			}
+		}
+	}
+	if len(errs) == 0 {
+		coordinator.logger.Info("Handed keys off to their replicas", "keys", len(entries), "replicas", len(nodes))
+	}
+	return errors.Join(errs...)
+}
+
+type replicaVersions struct {
+	node     nodecommon.Node
+	versions []*pb.VersionedValue
+	err      error
+}
+
+// readRepair waits for the replicas that did not answer the read yet and
+// writes the merged versions to every replica that misses one of them
+func (coordinator *Coordinator) readRepair(key string, answered []replicaVersions, results <-chan replicaVersions, pending int) {
+	// Every replica request is bounded by the timeout, so this ends
+	for ; pending > 0; pending-- {
+		result := <-results
+		if result.err == nil {
+			answered = append(answered, result)
+		}
+	}
+	versions := coordinator.mergeVersions(answered)
+
+	repaired := 0
+	for _, result := range answered {
+		entries := missing(key, versions, result.versions)
+		if len(entries) == 0 {
+			continue
+		}
+		if err := coordinator.writeTo(result.node, entries); err != nil {
+			coordinator.logger.Warn("Failed to read repair replica", "key", key, "nodeId", result.node.NodeID, "error", err)
+			continue
+		}
+		repaired++
+	}
+	if repaired > 0 {
+		coordinator.logger.Info("Read repaired replicas", "key", key, "replicas", repaired)
+	}
+}
+
+// replicasFor returns the replicas of key and the quorum to wait for,
+// requested or the configured one when it is 0
+func (coordinator *Coordinator) replicasFor(key string, requested int, configured int) ([]nodecommon.Node, int, error) {
+	quorum := requested
+	if quorum == 0 {
+		quorum = configured
+	}
+	if quorum < 1 || quorum > coordinator.config.Replicas {
+		return nil, 0, fmt.Errorf("%w, got %d of %d", ErrInvalidQuorum, quorum, coordinator.config.Replicas)
+	}
+	replicas := coordinator.currentRing().Replicas(key, coordinator.config.Replicas)
+	if len(replicas) < quorum {
+		return nil, 0, fmt.Errorf("%w: only %d replicas are known, %d needed", ErrQuorumUnavailable, len(replicas), quorum)
+	}
+	return replicas, quorum, nil
+}
+
+// currentRing returns the ring of this node and its peers, rebuilding it when
+// a member joined, left or moved
+func (coordinator *Coordinator) currentRing() *Ring {
+	nodes := coordinator.members()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].NodeID < nodes[j].NodeID
+	})
+	var members strings.Builder
+	for _, node := range nodes {
+		members.WriteString(node.NodeID + "@" + node.NodeIP + ":" + node.NodeControlPort + ",")
+	}
+
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
+
+	if coordinator.ring == nil || coordinator.ringMembers != members.String() {
+		coordinator.ring = NewRing(nodes, coordinator.config.VirtualNodes)
+		coordinator.ringMembers = members.String()
+		coordinator.logger.Info("Rebuilt hash ring", "nodes", len(nodes))
+	}
+	return coordinator.ring
+}
+
+// members returns this node followed by its peers
+func (coordinator *Coordinator) members() []nodecommon.Node {
+	coordinator.nodeData.Mu.RLock()
+	defer coordinator.nodeData.Mu.RUnlock()
+
+	members := make([]nodecommon.Node, 0, len(coordinator.nodeData.PeerNodes)+1)
+	members = append(members, coordinator.nodeData.NodeDetails)
+	for _, peer := range coordinator.nodeData.PeerNodes {
+		members = append(members, peer)
+	}
+	return members
+}
+
+// nextTimestamp returns a timestamp of the clock past after. The clock never
+// hands out a timestamp twice, so writes coordinated here never tie.
+func (coordinator *Coordinator) nextTimestamp(after int64) (int64, error) {
+	if err := coordinator.clock.Update(after); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidContext, err)
+	}
+	return coordinator.clock.Now(), nil
+}
+
+func (coordinator *Coordinator) readFrom(node nodecommon.Node, key string) ([]*pb.VersionedValue, error) {
+	if node.NodeID == coordinator.nodeData.NodeDetails.NodeID {
+		return coordinator.replica.Read(key)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), coordinator.config.Timeout)
+	defer cancel()
+	response, err := coordinator.transport.ReplicaRead(ctx, node.NodeIP+":"+node.NodeControlPort, &pb.ReplicaReadRequest{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return response.Versions, nil
+}
+
+func (coordinator *Coordinator) updateOn(node nodecommon.Node, key string, operation *pb.CRDTOperation) (*pb.VersionedValue, error) {
+	if node.NodeID == coordinator.nodeData.NodeDetails.NodeID {
+		return coordinator.replica.UpdateCRDT(key, operation)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), coordinator.config.Timeout)
+	defer cancel()
+	response, err := coordinator.transport.ReplicaUpdateCRDT(ctx, node.NodeIP+":"+node.NodeControlPort, &pb.ReplicaUpdateCRDTRequest{Key: key, Operation: operation})
+	// A rejected update is rejected by every replica, there is no point
+	// asking the next
+	if status.Code(err) == codes.InvalidArgument {
+		return nil, fmt.Errorf("%w: %s", crdt.ErrInvalidOperation, status.Convert(err).Message())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response.Version, nil
+}
+
+func (coordinator *Coordinator) writeTo(node nodecommon.Node, entries []*pb.VersionedEntry) error {
+	if node.NodeID == coordinator.nodeData.NodeDetails.NodeID {
+		_, err := coordinator.replica.Write(entries)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), coordinator.config.Timeout)
+	defer cancel()
+	response, err := coordinator.transport.ReplicaWrite(ctx, node.NodeIP+":"+node.NodeControlPort, &pb.ReplicaWriteRequest{Entries: entries})
+	if err != nil {
+		return err
+	}
+	if !response.Status {
+		return fmt.Errorf("Replica %s failed to write: %s", node.NodeID, response.Error)
+	}
+	return nil
+}
+
+// mergeVersions folds the versions every replica answered with into the
+// versions of the key none of them replaces
+func (coordinator *Coordinator) mergeVersions(results []replicaVersions) []*pb.VersionedValue {
+	var versions []*pb.VersionedValue
+	for _, result := range results {
+		for _, version := range result.versions {
+			versions, _ = merge(versions, version, coordinator.config.VectorClocks)
+		}
+	}
+	return versions
+}
+
+// missing returns the entries of the versions a replica holding held lacks
+func missing(key string, versions []*pb.VersionedValue, held []*pb.VersionedValue) []*pb.VersionedEntry {
+	var entries []*pb.VersionedEntry
+	for _, version := range versions {
+		found := false
+		for _, current := range held {
+			// Joined CRDTs keep the identity of one of their writes
+			if sameWrite(version, current) && proto.Equal(version.Crdt, current.Crdt) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			entries = append(entries, &pb.VersionedEntry{Key: key, Version: version})
+		}
+	}
+	return entries
+}
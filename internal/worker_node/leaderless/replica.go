@@ -0,0 +1,238 @@
+package leaderless
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/crdt"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxMergeAttempts bounds how often a write is retried when the key changed
+// between reading and replacing it
+const maxMergeAttempts = 16
+
+var ErrCorruptVersion = errors.New("Stored value is not a set of versions")
+
+// Replica keeps the versions of the keys this node holds. Every key in the
+// store maps to encoded Siblings.
+type Replica struct {
+	store        *storage.KeyValueStore
+	nodeID       string
+	vectorClocks bool
+	clock        *hlc.Clock
+	logger       slog.Logger
+}
+
+func NewReplica(store *storage.KeyValueStore, nodeID string, vectorClocks bool, clock *hlc.Clock, logger slog.Logger) *Replica {
+	return &Replica{
+		store:        store,
+		nodeID:       nodeID,
+		vectorClocks: vectorClocks,
+		clock:        clock,
+		logger:       logger,
+	}
+}
+
+// Read returns the versions of key this replica holds, none when it holds no
+// version
+func (replica *Replica) Read(key string) ([]*pb.VersionedValue, error) {
+	versions, _, err := replica.read(key)
+	return versions, err
+}
+
+// read also returns the encoded versions so they can be compared against
+func (replica *Replica) read(key string) ([]*pb.VersionedValue, string, error) {
+	encoded, err := replica.store.Get(key)
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	versions, err := decodeVersions(encoded)
+	if err != nil {
+		return nil, "", fmt.Errorf("Key %q: %w", key, err)
+	}
+	return versions, encoded, nil
+}
+
+// Write keeps every entry no version this replica holds replaces and returns
+// how many it kept
+func (replica *Replica) Write(entries []*pb.VersionedEntry) (int, error) {
+	kept := 0
+	for _, entry := range entries {
+		written, err := replica.merge(entry)
+		if err != nil {
+			return kept, err
+		}
+		if written {
+			kept++
+		}
+	}
+	return kept, nil
+}
+
+// merge folds the version of an entry into the versions of its key. The
+// replacement is a transaction on the versions it read, so a concurrent write
+// is never overwritten.
+func (replica *Replica) merge(entry *pb.VersionedEntry) (bool, error) {
+	if entry.Version == nil {
+		return false, fmt.Errorf("Key %q has no version", entry.Key)
+	}
+	for attempt := 0; attempt < maxMergeAttempts; attempt++ {
+		current, currentEncoded, err := replica.read(entry.Key)
+		if err != nil {
+			return false, err
+		}
+		versions, kept := merge(current, entry.Version, replica.vectorClocks)
+		if !kept {
+			return false, nil
+		}
+		encoded, err := proto.Marshal(&pb.Siblings{Versions: versions})
+		if err != nil {
+			return false, err
+		}
+		compare := storage.TxnCompare{Key: entry.Key, Type: storage.CompareNotExists}
+		if currentEncoded != "" {
+			compare = storage.TxnCompare{Key: entry.Key, Type: storage.CompareValueEquals, Value: currentEncoded}
+		}
+		result, err := replica.store.Txn(
+			[]storage.TxnCompare{compare},
+			[]storage.TxnOperation{{Type: storage.OperationPut, Key: entry.Key, Value: string(encoded)}},
+			nil)
+		if err != nil {
+			return false, err
+		}
+		if result.Succeeded {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("Key %q kept changing while it was written", entry.Key)
+}
+
+// UpdateCRDT applies operation to the CRDT key holds as this node and returns
+// the version it stored. Tombstones are dropped, a CRDT updated after a delete
+// starts over.
+func (replica *Replica) UpdateCRDT(key string, operation *pb.CRDTOperation) (*pb.VersionedValue, error) {
+	for attempt := 0; attempt < maxMergeAttempts; attempt++ {
+		current, currentEncoded, err := replica.read(key)
+		if err != nil {
+			return nil, err
+		}
+		var state *pb.CRDTState
+		var latest int64
+		for _, version := range current {
+			latest = max(latest, version.Timestamp)
+			if version.Crdt != nil {
+				state = version.Crdt
+			} else if !version.Deleted {
+				return nil, crdt.ErrWrongType
+			}
+		}
+		// The update replaces every version the replica holds
+		if err := replica.clock.Update(latest); err != nil {
+			replica.logger.Warn("A version of the key was stamped by a skewed clock", "key", key, "error", err)
+		}
+		timestamp := max(replica.clock.Now(), latest+1)
+		if state, err = crdt.Apply(state, operation, replica.nodeID, timestamp); err != nil {
+			return nil, err
+		}
+		version := &pb.VersionedValue{
+			Crdt:      state,
+			Timestamp: timestamp,
+			NodeId:    replica.nodeID,
+		}
+		encoded, err := proto.Marshal(&pb.Siblings{Versions: []*pb.VersionedValue{version}})
+		if err != nil {
+			return nil, err
+		}
+
+		compare := storage.TxnCompare{Key: key, Type: storage.CompareNotExists}
+		if currentEncoded != "" {
+			compare = storage.TxnCompare{Key: key, Type: storage.CompareValueEquals, Value: currentEncoded}
+		}
+		result, err := replica.store.Txn(
+			[]storage.TxnCompare{compare},
+			[]storage.TxnOperation{{Type: storage.OperationPut, Key: key, Value: string(encoded)}},
+			nil)
+		if err != nil {
+			return nil, err
+		}
+		if result.Succeeded {
+			return version, nil
+		}
+	}
+	return nil, fmt.Errorf("Key %q kept changing while it was updated", key)
+}
+
+// Entries returns every version this replica holds, tombstones included
+func (replica *Replica) Entries() ([]*pb.VersionedEntry, error) {
+	keyValues, err := replica.store.Scan("", "", 0)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*pb.VersionedEntry, 0, len(keyValues))
+	for _, keyValue := range keyValues {
+		versions, err := decodeVersions(keyValue.Value)
+		if err != nil {
+			replica.logger.Warn("Skipping key that is not versioned", "key", keyValue.Key, "error", err)
+			continue
+		}
+		for _, version := range versions {
+			entries = append(entries, &pb.VersionedEntry{Key: keyValue.Key, Version: version})
+		}
+	}
+	return entries, nil
+}
+
+func (replica *Replica) HandleRead(request *pb.ReplicaReadRequest) (*pb.ReplicaReadResponse, error) {
+	versions, err := replica.Read(request.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReplicaReadResponse{Versions: versions}, nil
+}
+
+func (replica *Replica) HandleUpdateCRDT(request *pb.ReplicaUpdateCRDTRequest) (*pb.ReplicaUpdateCRDTResponse, error) {
+	version, err := replica.UpdateCRDT(request.Key, request.Operation)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReplicaUpdateCRDTResponse{Version: version}, nil
+}
+
+func (replica *Replica) HandleWrite(request *pb.ReplicaWriteRequest) *pb.ReplicaWriteResponse {
+	if _, err := replica.Write(request.Entries); err != nil {
+		replica.logger.Error("Failed to write replica entries", "entries", len(request.Entries), "error", err)
+		return &pb.ReplicaWriteResponse{Status: false, Error: err.Error()}
+	}
+	return &pb.ReplicaWriteResponse{Status: true}
+}
+
+// Newer reports whether version wins over current under last writer wins.
+// Any version is newer than none.
+func Newer(version *pb.VersionedValue, current *pb.VersionedValue) bool {
+	if current == nil {
+		return version != nil
+	}
+	if version == nil {
+		return false
+	}
+	if version.Timestamp != current.Timestamp {
+		return version.Timestamp > current.Timestamp
+	}
+	return version.NodeId > current.NodeId
+}
+
+func decodeVersions(encoded string) ([]*pb.VersionedValue, error) {
+	var siblings pb.Siblings
+	if err := proto.Unmarshal([]byte(encoded), &siblings); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptVersion, err)
+	}
+	return siblings.Versions, nil
+}
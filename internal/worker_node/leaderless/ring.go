@@ -0,0 +1,86 @@
+package leaderless
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+)
+
+// DefaultVirtualNodes is the number of tokens every node gets on the ring.
+// More tokens spread the keys of a node that leaves over more of the others.
+const DefaultVirtualNodes = 64
+
+type token struct {
+	hash   uint64
+	nodeID string
+}
+
+// Ring places nodes on a consistent hash ring. The replicas of a key are the
+// first distinct nodes found walking clockwise from the hash of the key, so a
+// node joining or leaving only moves the keys next to its tokens.
+type Ring struct {
+	tokens []token
+	nodes  map[string]nodecommon.Node
+}
+
+func NewRing(nodes []nodecommon.Node, virtualNodes int) *Ring {
+	ring := &Ring{
+		tokens: make([]token, 0, len(nodes)*virtualNodes),
+		nodes:  make(map[string]nodecommon.Node, len(nodes)),
+	}
+	for _, node := range nodes {
+		ring.nodes[node.NodeID] = node
+		for i := 0; i < virtualNodes; i++ {
+			ring.tokens = append(ring.tokens, token{hash: hashKey(node.NodeID + "#" + strconv.Itoa(i)), nodeID: node.NodeID})
+		}
+	}
+	sort.Slice(ring.tokens, func(i, j int) bool {
+		if ring.tokens[i].hash != ring.tokens[j].hash {
+			return ring.tokens[i].hash < ring.tokens[j].hash
+		}
+		return ring.tokens[i].nodeID < ring.tokens[j].nodeID
+	})
+	return ring
+}
+
+// Replicas returns the n nodes that hold key in preference order, or every
+// node when the ring has fewer
+func (ring *Ring) Replicas(key string, n int) []nodecommon.Node {
+	if n > len(ring.nodes) {
+		n = len(ring.nodes)
+	}
+	if n == 0 {
+		return nil
+	}
+	hash := hashKey(key)
+	start := sort.Search(len(ring.tokens), func(i int) bool {
+		return ring.tokens[i].hash >= hash
+	})
+	replicas := make([]nodecommon.Node, 0, n)
+	seen := make(map[string]bool, n)
+	for i := 0; len(replicas) < n; i++ {
+		nodeID := ring.tokens[(start+i)%len(ring.tokens)].nodeID
+		if seen[nodeID] {
+			continue
+		}
+		seen[nodeID] = true
+		replicas = append(replicas, ring.nodes[nodeID])
+	}
+	return replicas
+}
+
+func hashKey(key string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	// The finalizer of MurmurHash3 spreads keys that only differ in their
+	// last bytes, FNV alone leaves them next to each other
+	mixed := hash.Sum64()
+	mixed ^= mixed >> 33
+	mixed *= 0xff51afd7ed558ccd
+	mixed ^= mixed >> 33
+	mixed *= 0xc4ceb9fe1a85ec53
+	mixed ^= mixed >> 33
+	return mixed
+}
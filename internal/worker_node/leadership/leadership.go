@@ -0,0 +1,148 @@
+// Package leadership hands the primary role to another voter without waiting
+// for the lease to run out. The primary stops taking writes and waits for the
+// ones in flight to be replicated, catches the target up with a snapshot if it
+// missed writes, releases the lease promises its peers made to it and then
+// tells the target to take over. The target records itself as the primary with
+// the registry and acquires the lease right away. A transfer that fails before
+// the target took over leaves the old primary in charge.
+package leadership
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	"github.com/Vahsek/distrokv/internal/worker_node/snapshot"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+// drainTimeout bounds the wait for writes in flight when a transfer starts
+const drainTimeout = 5 * time.Second
+
+var (
+	ErrNotPrimary         = errors.New("Node is not the primary")
+	ErrUnknownTarget      = errors.New("Target is not a voter known to this node")
+	ErrTransferInProgress = errors.New("Another leadership transfer is in progress")
+)
+
+// Transport reaches the peers and the registry
+type Transport interface {
+	lease.Transport
+	snapshot.Transport
+	TimeoutNow(ctx context.Context, address string, request *pb.TimeoutNowRequest) (*pb.TimeoutNowResponse, error)
+	TransferPrimary(ctx context.Context, nodeData *data.NodeData, fromNodeId string, toNodeId string) (string, error)
+}
+
+type Transferer struct {
+	nodeData       *data.NodeData
+	lease          *lease.Lease
+	snapshotSender *snapshot.Sender
+	// mu is held for the whole of a transfer so only one runs at a time
+	mu     sync.Mutex
+	logger slog.Logger
+}
+
+func NewTransferer(nodeData *data.NodeData, readLease *lease.Lease, snapshotSender *snapshot.Sender, logger slog.Logger) *Transferer {
+	return &Transferer{
+		nodeData:       nodeData,
+		lease:          readLease,
+		snapshotSender: snapshotSender,
+		logger:         logger,
+	}
+}
+
+// Transfer hands the primary role to targetNodeID and returns once the target
+// took over
+func (transferer *Transferer) Transfer(ctx context.Context, transport Transport, targetNodeID string) error {
+	if !transferer.mu.TryLock() {
+		return ErrTransferInProgress
+	}
+	defer transferer.mu.Unlock()
+
+	if !transferer.lease.IsPrimary() {
+		return ErrNotPrimary
+	}
+	target, err := transferer.target(targetNodeID)
+	if err != nil {
+		return err
+	}
+
+	transferer.logger.Info("Transferring leadership", "target", targetNodeID)
+	drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+	err = transferer.lease.BeginTransfer(drainCtx)
+	cancel()
+	if err != nil {
+		transferer.lease.EndTransfer()
+		return fmt.Errorf("Writes in flight did not finish: %w", err)
+	}
+
+	if slices.Contains(transferer.lease.OutOfSyncPeers(), targetNodeID) {
+		if err := transferer.snapshotSender.SendTo(ctx, transport, targetNodeID); err != nil {
+			transferer.lease.EndTransfer()
+			return fmt.Errorf("Failed to catch up the target: %w", err)
+		}
+	}
+
+	transferer.lease.Release(ctx, transport)
+	self := transferer.nodeData.NodeDetails.NodeID
+	response, err := transport.TimeoutNow(ctx, target.NodeIP+":"+target.NodeControlPort, &pb.TimeoutNowRequest{FromNodeId: self})
+	if err != nil || !response.TookOver {
+		// The lease is taken again with the next renewal
+		transferer.lease.EndTransfer()
+		if err == nil {
+			err = errors.New("Target did not take over")
+		}
+		return fmt.Errorf("Failed to hand over leadership: %w", err)
+	}
+
+	transferer.nodeData.Mu.Lock()
+	transferer.nodeData.PrimaryNodeID = targetNodeID
+	transferer.nodeData.Mu.Unlock()
+	transferer.lease.EndTransfer()
+	transferer.logger.Info("Leadership transferred", "primaryNodeId", targetNodeID)
+	return nil
+}
+
+// TakeOver makes this node the primary on behalf of fromNodeID, which stopped
+// serving as one
+func (transferer *Transferer) TakeOver(ctx context.Context, transport Transport, fromNodeID string) error {
+	transferer.logger.Info("Taking over leadership", "from", fromNodeID)
+	self := transferer.nodeData.NodeDetails.NodeID
+	primaryNodeID, err := transport.TransferPrimary(ctx, transferer.nodeData, fromNodeID, self)
+	if err != nil {
+		return err
+	}
+	if primaryNodeID != self {
+		return fmt.Errorf("Registry kept %q as the primary", primaryNodeID)
+	}
+
+	// Reads fall back to quorum rounds until a lease is held, so a failure
+	// here only costs latency
+	if err := transferer.lease.Acquire(ctx, transport); err != nil {
+		transferer.logger.Warn("Failed to acquire the lease after taking over", "error", err)
+	}
+	return nil
+}
+
+// target looks up a voter among the peers
+func (transferer *Transferer) target(nodeID string) (nodecommon.Node, error) {
+	transferer.nodeData.Mu.RLock()
+	defer transferer.nodeData.Mu.RUnlock()
+
+	peer, exists := transferer.nodeData.PeerNodes[nodeID]
+	if !exists || peer.Role != nodecommon.RoleVoter {
+		return nodecommon.Node{}, fmt.Errorf("%w: %s", ErrUnknownTarget, nodeID)
+	}
+	configuration := transferer.nodeData.Configuration
+	if configuration != nil && !slices.Contains(configuration.Voters, nodeID) {
+		return nodecommon.Node{}, fmt.Errorf("%w: %s", ErrUnknownTarget, nodeID)
+	}
+	return peer, nil
+}
@@ -0,0 +1,682 @@
+// Package lease lets the primary serve linearizable reads from its local store.
+// The primary asks its peers for a time-bound lease and every peer that grants
+// it promises not to grant a lease to anyone else until it runs out. Once a
+// quorum granted, no other node can gather a quorum before the lease expires,
+// so the primary knows it still leads and reads locally. The primary measures
+// the lease from before it asked and shortens it by the clock drift bound
+// while the peers measure it from when they granted, so the primary always
+// gives up first. Without a valid lease a read falls back to a ReadIndex style
+// round that confirms leadership with a quorum before reading. Quorums follow
+// the voter configuration published by the registry and take a majority of
+// both the old and the new voters while the configuration changes.
+//
+// The lease requests also tell followers how far behind they are. A request
+// is marked in sync when every write the primary acknowledged reached the
+// follower, which then knows its data is at least as fresh as the request,
+// give or take RequestTimeout. Freshness is measured on the follower's own
+// clock so clock skew between nodes does not matter.
+//
+// A node that does not hold the lease runs a pre-vote round before asking
+// for it. Peers answer a pre-vote with whether they would grant, without
+// promising anything, so a node that cannot win, like one that rejoins after
+// a partition with a stale view of the primary, never takes promises away
+// from the primary the rest of the cluster follows. The primary also checks
+// it can still reach a quorum. Once no round reached one for a whole lease
+// Duration it steps down, stops taking writes and tells the registry so
+// another voter can take over.
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+var (
+	ErrNotLeader = errors.New("Node could not confirm its leadership with a quorum")
+	ErrTooStale  = errors.New("Node is too far behind the primary")
+	ErrStepDown  = errors.New("Node is handing leadership to another node")
+	ErrNoQuorum  = errors.New("Primary lost contact with a quorum and stepped down")
+)
+
+type Config struct {
+	// Duration is how long a granted lease lasts
+	Duration time.Duration
+	// RenewInterval is how often the primary renews its lease ahead of
+	// expiry, it has to be well below Duration
+	RenewInterval time.Duration
+	// MaxClockDrift bounds the relative drift between the clocks of any two
+	// nodes, 0.01 tolerates one clock running 1% faster than another
+	MaxClockDrift float64
+	// RequestTimeout bounds a single round of lease requests
+	RequestTimeout time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Duration:       3 * time.Second,
+		RenewInterval:  time.Second,
+		MaxClockDrift:  0.01,
+		RequestTimeout: 500 * time.Millisecond,
+	}
+}
+
+// Transport sends lease requests to the control plane of a peer
+type Transport interface {
+	RequestLease(ctx context.Context, address string, request *pb.LeaseRequest) (*pb.LeaseResponse, error)
+}
+
+type Lease struct {
+	config   Config
+	nodeData *data.NodeData
+	// validUntil is when the lease held by this node runs out
+	validUntil time.Time
+	// grantedTo and grantExpiry are the promise this node made, possibly
+	// to itself
+	grantedTo   string
+	grantExpiry time.Time
+	// outOfSync maps the peers that missed a write this node acknowledged
+	// to the number of writes acknowledged when they last missed one
+	outOfSync map[string]int64
+	writes    int64
+	// freshAsOf is the local time this node's data is known to be current
+	// as of, freshened is closed and replaced whenever it moves
+	freshAsOf time.Time
+	freshened chan struct{}
+	// transferring is set while leadership is handed to another node, no
+	// writes are accepted and no lease is taken meanwhile. writesIdle is
+	// closed and replaced whenever the last write in flight finishes.
+	transferring   bool
+	inFlightWrites int
+	writesIdle     chan struct{}
+	// quorumAsOf is when a round last reached quorum or, while this node is
+	// not the primary, the current time. steppedDown is set once it is more
+	// than a lease Duration old.
+	quorumAsOf  time.Time
+	steppedDown bool
+	mu          sync.Mutex
+	// roundMu lets a single round run at a time, reads that queued behind
+	// it reuse its result
+	roundMu sync.Mutex
+	logger  slog.Logger
+}
+
+func NewLease(config Config, nodeData *data.NodeData, logger slog.Logger) *Lease {
+	return &Lease{
+		config:     config,
+		nodeData:   nodeData,
+		outOfSync:  make(map[string]int64),
+		freshened:  make(chan struct{}),
+		writesIdle: make(chan struct{}),
+		quorumAsOf: time.Now(),
+		logger:     logger,
+	}
+}
+
+// Valid reports whether this node holds an unexpired lease
+func (lease *Lease) Valid() bool {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	return time.Now().Before(lease.validUntil)
+}
+
+// ConfirmRead returns nil once a local read is linearizable, either right away
+// because the lease is valid or after a quorum confirmed the leadership
+func (lease *Lease) ConfirmRead(ctx context.Context, transport Transport) error {
+	if lease.Valid() {
+		return nil
+	}
+	lease.roundMu.Lock()
+	defer lease.roundMu.Unlock()
+
+	if lease.Valid() {
+		return nil
+	}
+	lease.logger.Debug("Lease expired, confirming leadership with a quorum")
+	return lease.acquire(ctx, transport)
+}
+
+// Acquire runs a round of lease requests right away instead of waiting for
+// the next renewal
+func (lease *Lease) Acquire(ctx context.Context, transport Transport) error {
+	lease.roundMu.Lock()
+	defer lease.roundMu.Unlock()
+
+	return lease.acquire(ctx, transport)
+}
+
+// BeginWrite admits a write unless leadership is being handed off. Every
+// admitted write has to call EndWrite once it was replicated.
+func (lease *Lease) BeginWrite() error {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	if lease.transferring {
+		return ErrStepDown
+	}
+	if lease.steppedDown {
+		return ErrNoQuorum
+	}
+	lease.inFlightWrites++
+	return nil
+}
+
+func (lease *Lease) EndWrite() {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	lease.inFlightWrites--
+	if lease.inFlightWrites == 0 {
+		close(lease.writesIdle)
+		lease.writesIdle = make(chan struct{})
+	}
+}
+
+// BeginTransfer stops serving linearizable reads and accepting writes ahead of
+// a leadership transfer and waits for the writes in flight to finish
+func (lease *Lease) BeginTransfer(ctx context.Context) error {
+	// Waiting for a running round keeps it from reinstating the lease
+	lease.roundMu.Lock()
+	lease.mu.Lock()
+	lease.transferring = true
+	lease.validUntil = time.Time{}
+	lease.mu.Unlock()
+	lease.roundMu.Unlock()
+
+	for {
+		lease.mu.Lock()
+		inFlightWrites, writesIdle := lease.inFlightWrites, lease.writesIdle
+		lease.mu.Unlock()
+		if inFlightWrites == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-writesIdle:
+		}
+	}
+}
+
+// EndTransfer accepts writes again, the lease is taken again with the next
+// renewal if the node is still the primary
+func (lease *Lease) EndTransfer() {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	lease.transferring = false
+}
+
+// Release gives up the promises the peers made to this node so another node
+// can take the lease without waiting for them to run out. It is only safe
+// while a transfer keeps this node from taking the lease again.
+func (lease *Lease) Release(ctx context.Context, transport Transport) {
+	self := lease.nodeData.NodeDetails.NodeID
+	lease.mu.Lock()
+	if lease.grantedTo == self {
+		lease.grantExpiry = time.Now()
+	}
+	lease.mu.Unlock()
+
+	lease.nodeData.Mu.RLock()
+	addresses := make([]string, 0, len(lease.nodeData.PeerNodes))
+	for _, peer := range lease.nodeData.PeerNodes {
+		addresses = append(addresses, peer.NodeIP+":"+peer.NodeControlPort)
+	}
+	lease.nodeData.Mu.RUnlock()
+
+	requestCtx, cancel := context.WithTimeout(ctx, lease.config.RequestTimeout)
+	defer cancel()
+	var pending sync.WaitGroup
+	for _, address := range addresses {
+		pending.Add(1)
+		go func(address string) {
+			defer pending.Done()
+			if _, err := transport.RequestLease(requestCtx, address, &pb.LeaseRequest{NodeId: self}); err != nil {
+				lease.logger.Debug("Failed to release lease", "address", address, "error", err)
+			}
+		}(address)
+	}
+	pending.Wait()
+	lease.logger.Info("Released lease")
+}
+
+// RecordWrite records a write this node acknowledged along with the peers
+// that missed it. Lease requests stop telling those peers they are in sync.
+func (lease *Lease) RecordWrite(missedNodeIDs ...string) {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	lease.writes++
+	for _, nodeID := range missedNodeIDs {
+		lease.markOutOfSync(nodeID)
+	}
+}
+
+// RecordPeerJoined records a peer that joined or restarted, it is out of sync
+// if it missed any write acknowledged before
+func (lease *Lease) RecordPeerJoined(nodeID string) {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	if lease.writes > 0 {
+		lease.markOutOfSync(nodeID)
+	}
+}
+
+// OutOfSyncPeers returns the node ids of the peers that missed writes
+func (lease *Lease) OutOfSyncPeers() []string {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	nodeIDs := make([]string, 0, len(lease.outOfSync))
+	for nodeID := range lease.outOfSync {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	return nodeIDs
+}
+
+// InSync reports whether a peer received every write acknowledged so far
+func (lease *Lease) InSync(nodeID string) bool {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	return !lease.isOutOfSync(nodeID)
+}
+
+// SyncPoint returns the number of writes acknowledged so far. A peer brought
+// up to date with every write up to a sync point is passed to MarkInSync.
+func (lease *Lease) SyncPoint() int64 {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	return lease.writes
+}
+
+// MarkInSync records that a peer caught up to syncPoint. It stays out of sync
+// if it missed another write since.
+func (lease *Lease) MarkInSync(nodeID string, syncPoint int64) {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	missedAt, exists := lease.outOfSync[nodeID]
+	if !exists || missedAt > syncPoint {
+		return
+	}
+	lease.logger.Info("Peer caught up and is in sync again", "nodeId", nodeID)
+	delete(lease.outOfSync, nodeID)
+}
+
+// markOutOfSync expects the caller to hold the lock
+func (lease *Lease) markOutOfSync(nodeID string) {
+	if _, exists := lease.outOfSync[nodeID]; !exists {
+		lease.logger.Warn("Peer missed writes and is out of sync", "nodeId", nodeID)
+	}
+	lease.outOfSync[nodeID] = lease.writes
+}
+
+// Staleness returns how far this node may be behind the primary. A node
+// holding the lease is never behind.
+func (lease *Lease) Staleness() time.Duration {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	return lease.staleness(time.Now())
+}
+
+// WaitFresh returns nil once this node is at most maxStaleness behind the
+// primary. A node that is further behind waits up to one renew interval for
+// the primary's next lease request before giving up with ErrTooStale.
+func (lease *Lease) WaitFresh(ctx context.Context, maxStaleness time.Duration) error {
+	timer := time.NewTimer(lease.config.RenewInterval)
+	defer timer.Stop()
+
+	for {
+		lease.mu.Lock()
+		staleness := lease.staleness(time.Now())
+		freshened := lease.freshened
+		lease.mu.Unlock()
+		if staleness <= maxStaleness {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			if staleness == time.Duration(math.MaxInt64) {
+				return fmt.Errorf("%w: it has not been in sync since it started", ErrTooStale)
+			}
+			return fmt.Errorf("%w: it is %s behind, more than the %s allowed", ErrTooStale, staleness.Round(time.Millisecond), maxStaleness)
+		case <-freshened:
+		}
+	}
+}
+
+// staleness expects the caller to hold the lock
+func (lease *Lease) staleness(now time.Time) time.Duration {
+	if now.Before(lease.validUntil) {
+		return 0
+	}
+	if lease.freshAsOf.IsZero() {
+		return time.Duration(math.MaxInt64)
+	}
+	return now.Sub(lease.freshAsOf)
+}
+
+// Run renews the lease every RenewInterval while this node is the primary and
+// checks the primary still has a quorum in between
+func (lease *Lease) Run(ctx context.Context, transport Transport) {
+	lease.logger.Info("Starting lease renewal")
+	ticker := time.NewTicker(lease.config.RenewInterval)
+	defer ticker.Stop()
+
+	var quorumChecks sync.WaitGroup
+	quorumChecks.Add(1)
+	go func() {
+		defer quorumChecks.Done()
+		lease.runQuorumChecks(ctx)
+	}()
+	defer quorumChecks.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			lease.logger.Info("Stopping lease renewal")
+			return
+		case <-ticker.C:
+		}
+		if !lease.IsPrimary() {
+			lease.standBy()
+			continue
+		}
+		lease.roundMu.Lock()
+		err := lease.acquire(ctx, transport)
+		lease.roundMu.Unlock()
+		if err != nil && ctx.Err() == nil {
+			lease.logger.Warn("Failed to renew lease", "error", err)
+		}
+	}
+}
+
+// runQuorumChecks runs checkQuorum on a timer of its own while this node is
+// the primary, so a round stuck on unreachable peers or a lease that can no
+// longer be renewed does not delay stepping down
+func (lease *Lease) runQuorumChecks(ctx context.Context) {
+	ticker := time.NewTicker(max(lease.config.RenewInterval/4, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if lease.IsPrimary() {
+			lease.checkQuorum()
+		}
+	}
+}
+
+// SteppedDown reports whether this node stepped down as the primary because
+// it lost contact with a quorum
+func (lease *Lease) SteppedDown() bool {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	return lease.steppedDown
+}
+
+// checkQuorum steps down once no round reached quorum for a lease Duration
+func (lease *Lease) checkQuorum() {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	if lease.steppedDown || time.Since(lease.quorumAsOf) < lease.config.Duration {
+		return
+	}
+	lease.logger.Warn("Stepping down, no quorum reached", "since", lease.quorumAsOf)
+	lease.steppedDown = true
+}
+
+// standBy gives a node that becomes the primary a whole lease Duration to
+// reach a quorum before it steps down
+func (lease *Lease) standBy() {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	lease.quorumAsOf = time.Now()
+	lease.steppedDown = false
+}
+
+// HandleRequest grants the lease unless a lease granted to another node is
+// still running
+func (lease *Lease) HandleRequest(request *pb.LeaseRequest) *pb.LeaseResponse {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+
+	if request.PreVote {
+		return &pb.LeaseResponse{Granted: lease.wouldGrant(request.NodeId), HolderNodeId: lease.grantedTo}
+	}
+	if request.DurationMs == 0 {
+		if lease.grantedTo == request.NodeId {
+			lease.grantExpiry = time.Now()
+		}
+		return &pb.LeaseResponse{Granted: false}
+	}
+	if !lease.grant(request.NodeId, time.Duration(request.DurationMs)*time.Millisecond) {
+		return &pb.LeaseResponse{Granted: false, HolderNodeId: lease.grantedTo}
+	}
+	if request.InSync {
+		// The request left the primary at most RequestTimeout ago
+		freshAsOf := time.Now().Add(-lease.config.RequestTimeout)
+		if freshAsOf.After(lease.freshAsOf) {
+			lease.freshAsOf = freshAsOf
+			close(lease.freshened)
+			lease.freshened = make(chan struct{})
+		}
+	}
+	return &pb.LeaseResponse{Granted: true, HolderNodeId: request.NodeId}
+}
+
+// acquire runs one round of lease requests and expects the caller to hold
+// roundMu
+func (lease *Lease) acquire(ctx context.Context, transport Transport) error {
+	self := lease.nodeData.NodeDetails.NodeID
+	start := time.Now()
+
+	lease.mu.Lock()
+	if lease.transferring {
+		lease.mu.Unlock()
+		return ErrStepDown
+	}
+	preVote := !start.Before(lease.validUntil)
+	selfWouldGrant := lease.wouldGrant(self)
+	lease.mu.Unlock()
+	if !selfWouldGrant {
+		return ErrNotLeader
+	}
+
+	lease.nodeData.Mu.RLock()
+	peers := make([]nodecommon.Node, 0, len(lease.nodeData.PeerNodes))
+	for _, peer := range lease.nodeData.PeerNodes {
+		peers = append(peers, peer)
+	}
+	quorum := newQuorum(lease.nodeData.Configuration, self, peers)
+	lease.nodeData.Mu.RUnlock()
+
+	if preVote && !lease.requestFromPeers(ctx, transport, peers, self, quorum, true) {
+		lease.logger.Debug("Pre-vote did not reach quorum")
+		return ErrNotLeader
+	}
+
+	lease.mu.Lock()
+	selfGranted := lease.grant(self, lease.config.Duration)
+	lease.mu.Unlock()
+	if !selfGranted {
+		return ErrNotLeader
+	}
+	// Learners are asked as well so they learn how fresh they are, but only
+	// voters count toward the quorum
+	if !lease.requestFromPeers(ctx, transport, peers, self, quorum, false) {
+		return ErrNotLeader
+	}
+
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+	drift := time.Duration(float64(lease.config.Duration) * lease.config.MaxClockDrift)
+	lease.validUntil = start.Add(lease.config.Duration - drift)
+	lease.quorumAsOf = start
+	if lease.steppedDown {
+		lease.logger.Info("Reached quorum again, taking writes")
+		lease.steppedDown = false
+	}
+	return nil
+}
+
+// requestFromPeers asks every peer for the lease, or only whether they would
+// grant it for a pre-vote, and reports whether the grants, along with this
+// node's own, reached quorum. It returns as soon as they do or every peer
+// answered. The remaining requests still complete in the background so
+// followers keep learning how fresh they are.
+func (lease *Lease) requestFromPeers(ctx context.Context, transport Transport, peers []nodecommon.Node, self string, quorum quorum, preVote bool) bool {
+	requestCtx, cancel := context.WithTimeout(ctx, lease.config.RequestTimeout)
+	var pending sync.WaitGroup
+	pending.Add(len(peers))
+	go func() {
+		pending.Wait()
+		cancel()
+	}()
+
+	lease.mu.Lock()
+	requests := make([]*pb.LeaseRequest, len(peers))
+	for i, peer := range peers {
+		requests[i] = &pb.LeaseRequest{
+			NodeId:     self,
+			DurationMs: lease.config.Duration.Milliseconds(),
+			InSync:     !preVote && !lease.isOutOfSync(peer.NodeID),
+			PreVote:    preVote,
+		}
+	}
+	lease.mu.Unlock()
+
+	// grants carries the id of a peer that granted and an empty id for one
+	// that did not
+	grants := make(chan string, len(peers))
+	for i, peer := range peers {
+		address := peer.NodeIP + ":" + peer.NodeControlPort
+		go func(nodeID string, address string, request *pb.LeaseRequest) {
+			defer pending.Done()
+			response, err := transport.RequestLease(requestCtx, address, request)
+			if err != nil {
+				lease.logger.Debug("Lease request failed", "address", address, "error", err)
+				grants <- ""
+				return
+			}
+			if !response.Granted {
+				lease.logger.Debug("Peer refused the lease", "address", address, "holder", response.HolderNodeId)
+				grants <- ""
+				return
+			}
+			grants <- nodeID
+		}(peer.NodeID, address, requests[i])
+	}
+
+	granted := map[string]bool{self: true}
+	for range peers {
+		if quorum.reached(granted) {
+			return true
+		}
+		if nodeID := <-grants; nodeID != "" {
+			granted[nodeID] = true
+		}
+	}
+	return quorum.reached(granted)
+}
+
+// quorum decides whether a set of grants is enough to hold the lease. Under a
+// joint configuration it takes a majority of the old and of the new voters.
+type quorum struct {
+	voters    []string
+	newVoters []string
+	joint     bool
+}
+
+// newQuorum follows the configuration published by the registry. Until there
+// is one, the roles gossiped by the peers decide who votes.
+func newQuorum(configuration *pb_registry.ClusterConfiguration, self string, peers []nodecommon.Node) quorum {
+	if configuration != nil && len(configuration.Voters) > 0 {
+		return quorum{
+			voters:    configuration.Voters,
+			newVoters: configuration.NewVoters,
+			joint:     configuration.Joint,
+		}
+	}
+	voters := []string{self}
+	for _, peer := range peers {
+		if peer.Role == nodecommon.RoleVoter {
+			voters = append(voters, peer.NodeID)
+		}
+	}
+	return quorum{voters: voters}
+}
+
+func (quorum quorum) reached(granted map[string]bool) bool {
+	return majority(quorum.voters, granted) && (!quorum.joint || majority(quorum.newVoters, granted))
+}
+
+func majority(voters []string, granted map[string]bool) bool {
+	votes := 0
+	for _, nodeID := range voters {
+		if granted[nodeID] {
+			votes++
+		}
+	}
+	return votes > len(voters)/2
+}
+
+// wouldGrant reports whether a promise to nodeID can be made and expects the
+// caller to hold the lock
+func (lease *Lease) wouldGrant(nodeID string) bool {
+	return lease.grantedTo == nodeID || !time.Now().Before(lease.grantExpiry)
+}
+
+// grant records a promise to nodeID and expects the caller to hold the lock
+func (lease *Lease) grant(nodeID string, duration time.Duration) bool {
+	now := time.Now()
+	if !lease.wouldGrant(nodeID) {
+		return false
+	}
+	if lease.grantedTo != nodeID {
+		lease.logger.Info("Granting lease", "nodeId", nodeID)
+		// Granting to another node ends any lease this node held
+		lease.validUntil = time.Time{}
+	}
+	lease.grantedTo = nodeID
+	lease.grantExpiry = now.Add(duration)
+	return true
+}
+
+// IsPrimary reports whether the registry last named this node the primary
+func (lease *Lease) IsPrimary() bool {
+	lease.nodeData.Mu.RLock()
+	defer lease.nodeData.Mu.RUnlock()
+
+	return lease.nodeData.PrimaryNodeID == lease.nodeData.NodeDetails.NodeID
+}
+
+// isOutOfSync expects the caller to hold the lock
+func (lease *Lease) isOutOfSync(nodeID string) bool {
+	_, exists := lease.outOfSync[nodeID]
+	return exists
+}
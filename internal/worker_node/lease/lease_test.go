@@ -0,0 +1,199 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+var errPartitioned = errors.New("partitioned")
+
+func testConfig() Config {
+	return Config{
+		Duration:       300 * time.Millisecond,
+		RenewInterval:  50 * time.Millisecond,
+		MaxClockDrift:  0.01,
+		RequestTimeout: 50 * time.Millisecond,
+	}
+}
+
+// testCluster wires the leases of a few nodes to each other in process, lease
+// requests between two nodes fail while they are partitioned
+type testCluster struct {
+	leases map[string]*Lease
+	nodes  map[string]*data.NodeData
+	cut    map[[2]string]bool
+	mu     sync.Mutex
+}
+
+func newTestCluster(t *testing.T, primary string, nodeIDs ...string) *testCluster {
+	t.Helper()
+	logger := *slog.New(slog.NewTextHandler(io.Discard, nil))
+	cluster := &testCluster{
+		leases: make(map[string]*Lease),
+		nodes:  make(map[string]*data.NodeData),
+		cut:    make(map[[2]string]bool),
+	}
+	configuration := &pb_registry.ClusterConfiguration{Version: 1, Voters: nodeIDs}
+	for _, nodeID := range nodeIDs {
+		nodeData := &data.NodeData{
+			NodeDetails:   testNode(nodeID),
+			PeerNodes:     make(map[string]nodecommon.Node),
+			PrimaryNodeID: primary,
+			Configuration: configuration,
+		}
+		for _, peerID := range nodeIDs {
+			if peerID != nodeID {
+				nodeData.PeerNodes[peerID] = testNode(peerID)
+			}
+		}
+		cluster.nodes[nodeID] = nodeData
+		cluster.leases[nodeID] = NewLease(testConfig(), nodeData, logger)
+	}
+	return cluster
+}
+
+// testNode uses the node id as the host so an address leads back to the node
+func testNode(nodeID string) nodecommon.Node {
+	return nodecommon.Node{NodeID: nodeID, NodeIP: nodeID, NodeControlPort: "1", Role: nodecommon.RoleVoter}
+}
+
+// partition cuts every link between the nodes in side and the other nodes
+func (cluster *testCluster) partition(side ...string) {
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+
+	inSide := make(map[string]bool)
+	for _, nodeID := range side {
+		inSide[nodeID] = true
+	}
+	for from := range cluster.leases {
+		for to := range cluster.leases {
+			if inSide[from] != inSide[to] {
+				cluster.cut[[2]string{from, to}] = true
+			}
+		}
+	}
+}
+
+// cutLink cuts the link between two nodes in both directions
+func (cluster *testCluster) cutLink(left string, right string) {
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+
+	cluster.cut[[2]string{left, right}] = true
+	cluster.cut[[2]string{right, left}] = true
+}
+
+func (cluster *testCluster) transport(from string) Transport {
+	return testTransport{cluster: cluster, from: from}
+}
+
+type testTransport struct {
+	cluster *testCluster
+	from    string
+}
+
+func (transport testTransport) RequestLease(ctx context.Context, address string, request *pb.LeaseRequest) (*pb.LeaseResponse, error) {
+	to := address[:len(address)-len(":1")]
+	transport.cluster.mu.Lock()
+	cut := transport.cluster.cut[[2]string{transport.from, to}]
+	transport.cluster.mu.Unlock()
+	if cut {
+		<-ctx.Done()
+		return nil, errPartitioned
+	}
+	return transport.cluster.leases[to].HandleRequest(request), nil
+}
+
+func TestPartitionedFollowerPreVoteKeepsLeaseholder(t *testing.T) {
+	cluster := newTestCluster(t, "n1", "n1", "n2", "n3")
+	leader, follower, partitioned := cluster.leases["n1"], cluster.leases["n2"], cluster.leases["n3"]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := leader.Acquire(ctx, cluster.transport("n1")); err != nil {
+		t.Fatalf("leader failed to acquire the lease: %v", err)
+	}
+	running := make(chan struct{})
+	go func() {
+		defer close(running)
+		leader.Run(ctx, cluster.transport("n1"))
+	}()
+
+	// n3 only loses the leader, the leader keeps its quorum through n2. Once
+	// its promise to the leader ran out n3 believes it is the primary.
+	cluster.cutLink("n1", "n3")
+	time.Sleep(testConfig().Duration + testConfig().RenewInterval)
+	cluster.nodes["n3"].Mu.Lock()
+	cluster.nodes["n3"].PrimaryNodeID = "n3"
+	cluster.nodes["n3"].Mu.Unlock()
+
+	if err := partitioned.Acquire(ctx, cluster.transport("n3")); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("expected the pre-vote of the partitioned follower to fail with ErrNotLeader, got %v", err)
+	}
+	if partitioned.Valid() {
+		t.Fatalf("partitioned follower took the lease")
+	}
+	follower.mu.Lock()
+	grantedTo := follower.grantedTo
+	follower.mu.Unlock()
+	if grantedTo != "n1" {
+		t.Fatalf("pre-vote moved the promise of n2 to %q", grantedTo)
+	}
+	if err := leader.Acquire(ctx, cluster.transport("n1")); err != nil {
+		t.Fatalf("leaseholder lost its quorum after the pre-vote: %v", err)
+	}
+	if !leader.Valid() || leader.SteppedDown() {
+		t.Fatalf("leaseholder was disturbed by the pre-vote")
+	}
+
+	cancel()
+	<-running
+}
+
+func TestIsolatedLeaderStepsDown(t *testing.T) {
+	cluster := newTestCluster(t, "n1", "n1", "n2", "n3")
+	leader := cluster.leases["n1"]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := leader.Acquire(ctx, cluster.transport("n1")); err != nil {
+		t.Fatalf("leader failed to acquire the lease: %v", err)
+	}
+	running := make(chan struct{})
+	go func() {
+		defer close(running)
+		leader.Run(ctx, cluster.transport("n1"))
+	}()
+	if leader.SteppedDown() {
+		t.Fatalf("leader stepped down while it had a quorum")
+	}
+
+	cluster.partition("n1")
+	deadline := time.Now().Add(3 * testConfig().Duration)
+	for !leader.SteppedDown() {
+		if time.Now().After(deadline) {
+			t.Fatalf("leader cut off from the majority did not step down")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := leader.BeginWrite(); !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("expected writes to fail with ErrNoQuorum, got %v", err)
+	}
+	if leader.Valid() {
+		t.Fatalf("leader still holds a valid lease after stepping down")
+	}
+
+	cancel()
+	<-running
+}
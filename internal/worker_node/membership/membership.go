@@ -0,0 +1,421 @@
+// Package membership implements SWIM style gossip membership among worker
+// nodes. Every protocol period a node pings one peer over the control plane,
+// asks a few other peers to ping it on its behalf when the direct ping fails
+// and suspects the peer when neither gets an answer. A suspect that does not
+// refute the suspicion in time is declared dead. Membership updates are
+// piggybacked on the pings so NodeData.PeerNodes converges without the
+// registry, which only provides the initial list of peers. Every message from
+// a peer counts as a heartbeat for a phi accrual failure detector, and a failed
+// probe only raises a suspicion once the peer's phi crosses SuspectPhi, so a
+// peer that is merely slow is not suspected on its first missed ack.
+package membership
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	failuredetector "github.com/Vahsek/distrokv/internal/common/failure_detector"
+	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+type Config struct {
+	// ProtocolPeriod is how often a peer is probed
+	ProtocolPeriod time.Duration
+	// PingTimeout bounds the direct ping, the indirect pings get the rest
+	// of the protocol period
+	PingTimeout time.Duration
+	// IndirectProbes is the number of peers asked to ping a target that did
+	// not answer the direct ping
+	IndirectProbes int
+	// SuspicionMultiplier scales how many protocol periods a suspect has to
+	// refute the suspicion, the timeout grows with the log of the cluster size
+	SuspicionMultiplier int
+	// RetransmitMultiplier scales how many messages an update is
+	// piggybacked on, again growing with the log of the cluster size
+	RetransmitMultiplier int
+	// MaxPiggybackedUpdates caps the updates carried by a single message
+	MaxPiggybackedUpdates int
+	// DeadMemberRetention is how long dead and departed members are
+	// remembered so stale updates about them are ignored
+	DeadMemberRetention time.Duration
+	// SuspectPhi is the phi a member that failed a probe must have reached
+	// before it is suspected
+	SuspectPhi float64
+	// AcceptableHeartbeatPause is the silence tolerated on top of the usual
+	// interval between messages before phi starts to climb
+	AcceptableHeartbeatPause time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ProtocolPeriod:           time.Second,
+		PingTimeout:              400 * time.Millisecond,
+		IndirectProbes:           3,
+		SuspicionMultiplier:      5,
+		RetransmitMultiplier:     3,
+		MaxPiggybackedUpdates:    8,
+		DeadMemberRetention:      5 * time.Minute,
+		SuspectPhi:               8,
+		AcceptableHeartbeatPause: time.Second,
+	}
+}
+
+type memberState struct {
+	node           nodecommon.Node
+	state          pb.MemberUpdate_State
+	incarnation    int64
+	stateChangedAt time.Time
+}
+
+type queuedUpdate struct {
+	update    *pb.MemberUpdate
+	transmits int
+}
+
+type Membership struct {
+	config      Config
+	nodeData    *data.NodeData
+	self        nodecommon.Node
+	incarnation int64
+	members     map[string]*memberState
+	updates     map[string]*queuedUpdate
+	probeOrder  []string
+	probeIndex  int
+	detector    *failuredetector.Detector
+	// stateChanged is told about every member that changed state
+	stateChanged func(nodeID string, state pb.MemberUpdate_State)
+	mu           sync.Mutex
+	logger       slog.Logger
+}
+
+func NewMembership(config Config, nodeData *data.NodeData, logger slog.Logger) *Membership {
+	detectorConfig := failuredetector.DefaultConfig(config.ProtocolPeriod)
+	detectorConfig.AcceptableHeartbeatPause = config.AcceptableHeartbeatPause
+	membership := &Membership{
+		config:   config,
+		nodeData: nodeData,
+		self:     nodeData.NodeDetails,
+		// Starting from the clock instead of zero lets a restarted node
+		// override what the cluster remembers about its previous run
+		incarnation: time.Now().UnixNano(),
+		members:     make(map[string]*memberState),
+		updates:     make(map[string]*queuedUpdate),
+		detector:    failuredetector.NewDetector(detectorConfig),
+		logger:      logger,
+	}
+	membership.queue(membership.selfUpdate())
+	return membership
+}
+
+func (membership *Membership) Incarnation() int64 {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	return membership.incarnation
+}
+
+// OnStateChange registers handler to be called whenever a member is learned
+// about or changes state. It runs with the membership lock held, after the
+// peer list was updated, and must not block.
+func (membership *Membership) OnStateChange(handler func(nodeID string, state pb.MemberUpdate_State)) {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	membership.stateChanged = handler
+}
+
+// SetRole gossips a new role for this node. The incarnation is bumped so the
+// update overrides what the members remember about the node.
+func (membership *Membership) SetRole(role nodecommon.NodeRole) {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	if membership.self.Role == role {
+		return
+	}
+	membership.self.Role = role
+	membership.incarnation++
+	membership.logger.Info("Gossiping new role", "role", role, "incarnation", membership.incarnation)
+	membership.queue(membership.selfUpdate())
+}
+
+// Seed adds the peers already in NodeData.PeerNodes, normally the node list
+// returned by the registry, as alive members
+func (membership *Membership) Seed() {
+	membership.nodeData.Mu.RLock()
+	peers := make([]nodecommon.Node, 0, len(membership.nodeData.PeerNodes))
+	for _, peer := range membership.nodeData.PeerNodes {
+		peers = append(peers, peer)
+	}
+	membership.nodeData.Mu.RUnlock()
+
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+	for _, peer := range peers {
+		if _, exists := membership.members[peer.NodeID]; exists || peer.NodeID == membership.self.NodeID {
+			continue
+		}
+		membership.members[peer.NodeID] = &memberState{
+			node:           peer,
+			state:          pb.MemberUpdate_ALIVE,
+			stateChangedAt: time.Now(),
+		}
+		membership.detector.Heartbeat(peer.NodeID)
+	}
+	membership.logger.Info("Seeded gossip membership", "members", len(membership.members))
+}
+
+// Join records a peer that announced itself directly
+func (membership *Membership) Join(peer nodecommon.Node, incarnation int64) {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	membership.applyUpdate(toMemberUpdate(peer, pb.MemberUpdate_ALIVE, incarnation))
+}
+
+// Leave records that a peer left the cluster. It reports false when the peer
+// is not a known member.
+func (membership *Membership) Leave(nodeID string) bool {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	member, exists := membership.members[nodeID]
+	if !exists {
+		return false
+	}
+	membership.applyUpdate(toMemberUpdate(member.node, pb.MemberUpdate_LEFT, member.incarnation))
+	return true
+}
+
+// HandlePing applies the updates carried by a ping and returns the ack
+func (membership *Membership) HandlePing(request *pb.PingRequest) *pb.PingResponse {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	membership.heardFrom(request.Source)
+	membership.applyUpdates(request.Updates)
+	return &pb.PingResponse{
+		Source:  membership.selfUpdate(),
+		Updates: membership.piggyback(),
+	}
+}
+
+func (membership *Membership) handleAck(response *pb.PingResponse) {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	membership.heardFrom(response.Source)
+	membership.applyUpdates(response.Updates)
+}
+
+// heardFrom applies the update a member sent about itself and records the
+// message as a heartbeat. It expects the caller to hold the lock.
+func (membership *Membership) heardFrom(source *pb.MemberUpdate) {
+	if source == nil || source.NodeId == "" {
+		return
+	}
+	// A member declared dead that still talks to us missed the notice, it
+	// is gossiped again so the member hears it and refutes
+	if member, exists := membership.members[source.NodeId]; exists && member.state == pb.MemberUpdate_DEAD && source.Incarnation <= member.incarnation {
+		membership.queue(toMemberUpdate(member.node, pb.MemberUpdate_DEAD, member.incarnation))
+	}
+	membership.applyUpdate(source)
+	if source.NodeId != membership.self.NodeID {
+		membership.detector.Heartbeat(source.NodeId)
+	}
+}
+
+func (membership *Membership) newPingRequest() *pb.PingRequest {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	return &pb.PingRequest{
+		Source:  membership.selfUpdate(),
+		Updates: membership.piggyback(),
+	}
+}
+
+func (membership *Membership) applyUpdates(updates []*pb.MemberUpdate) {
+	for _, update := range updates {
+		membership.applyUpdate(update)
+	}
+}
+
+// applyUpdate merges an update using the SWIM precedence rules and expects
+// the caller to hold the lock. Alive overrides a suspicion only with a newer
+// incarnation, a suspicion overrides alive of the same incarnation and dead or
+// left override both.
+func (membership *Membership) applyUpdate(update *pb.MemberUpdate) {
+	if update == nil || update.NodeId == "" {
+		return
+	}
+	if update.NodeId == membership.self.NodeID {
+		membership.refute(update)
+		return
+	}
+
+	member, exists := membership.members[update.NodeId]
+	if !exists {
+		membership.members[update.NodeId] = &memberState{
+			node:           toNode(update),
+			state:          update.State,
+			incarnation:    update.Incarnation,
+			stateChangedAt: time.Now(),
+		}
+		membership.logger.Info("Learned about new member", "nodeId", update.NodeId, "hostname", update.Hostname, "state", update.State)
+		if isActive(update.State) {
+			membership.detector.Heartbeat(update.NodeId)
+		}
+		membership.queue(update)
+		membership.syncPeer(update.NodeId)
+		membership.notifyStateChange(update.NodeId, update.State)
+		return
+	}
+
+	overrides := false
+	switch update.State {
+	case pb.MemberUpdate_ALIVE:
+		overrides = update.Incarnation > member.incarnation
+	case pb.MemberUpdate_SUSPECT:
+		overrides = (member.state == pb.MemberUpdate_ALIVE && update.Incarnation >= member.incarnation) ||
+			(member.state == pb.MemberUpdate_SUSPECT && update.Incarnation > member.incarnation)
+	case pb.MemberUpdate_DEAD, pb.MemberUpdate_LEFT:
+		overrides = isActive(member.state) && update.Incarnation >= member.incarnation
+	}
+	if !overrides {
+		return
+	}
+
+	if update.State == pb.MemberUpdate_ALIVE {
+		// The member may have come back on a different address
+		member.node = toNode(update)
+		if !isActive(member.state) {
+			// Its silence while it was gone says nothing about its
+			// heartbeats from now on
+			membership.detector.Remove(update.NodeId)
+			membership.detector.Heartbeat(update.NodeId)
+		}
+	}
+	changed := member.state != update.State
+	if changed {
+		membership.logger.Info("Member changed state", "nodeId", update.NodeId, "hostname", member.node.NodeHostname, "from", member.state, "to", update.State)
+		member.stateChangedAt = time.Now()
+	}
+	member.state = update.State
+	member.incarnation = update.Incarnation
+	membership.queue(update)
+	membership.syncPeer(update.NodeId)
+	if changed {
+		membership.notifyStateChange(update.NodeId, update.State)
+	}
+}
+
+// notifyStateChange expects the caller to hold the lock
+func (membership *Membership) notifyStateChange(nodeID string, state pb.MemberUpdate_State) {
+	if membership.stateChanged != nil {
+		membership.stateChanged(nodeID, state)
+	}
+}
+
+// refute answers a suspicion or death notice about this node by bumping its
+// incarnation past the one in the notice
+func (membership *Membership) refute(update *pb.MemberUpdate) {
+	if update.State != pb.MemberUpdate_SUSPECT && update.State != pb.MemberUpdate_DEAD {
+		return
+	}
+	if update.Incarnation < membership.incarnation {
+		return
+	}
+	membership.incarnation = update.Incarnation + 1
+	membership.logger.Warn("Refuting membership notice about this node", "state", update.State, "incarnation", membership.incarnation)
+	membership.queue(membership.selfUpdate())
+}
+
+// syncPeer mirrors the member into NodeData.PeerNodes. Suspects stay peers so
+// they keep receiving writes until they are declared dead.
+func (membership *Membership) syncPeer(nodeID string) {
+	member := membership.members[nodeID]
+	membership.nodeData.Mu.Lock()
+	defer membership.nodeData.Mu.Unlock()
+
+	if isActive(member.state) {
+		membership.nodeData.PeerNodes[nodeID] = member.node
+	} else {
+		delete(membership.nodeData.PeerNodes, nodeID)
+	}
+}
+
+func (membership *Membership) selfUpdate() *pb.MemberUpdate {
+	return toMemberUpdate(membership.self, pb.MemberUpdate_ALIVE, membership.incarnation)
+}
+
+// queue schedules an update for dissemination, replacing any older update
+// about the same member
+func (membership *Membership) queue(update *pb.MemberUpdate) {
+	membership.updates[update.NodeId] = &queuedUpdate{update: update}
+}
+
+// piggyback picks the updates sent the fewest times so far and drops those
+// that have been sent often enough to have reached every member
+func (membership *Membership) piggyback() []*pb.MemberUpdate {
+	queued := make([]*queuedUpdate, 0, len(membership.updates))
+	for _, update := range membership.updates {
+		queued = append(queued, update)
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].transmits < queued[j].transmits
+	})
+	if len(queued) > membership.config.MaxPiggybackedUpdates {
+		queued = queued[:membership.config.MaxPiggybackedUpdates]
+	}
+
+	limit := membership.retransmitLimit()
+	updates := make([]*pb.MemberUpdate, 0, len(queued))
+	for _, update := range queued {
+		updates = append(updates, update.update)
+		update.transmits++
+		if update.transmits >= limit {
+			delete(membership.updates, update.update.NodeId)
+		}
+	}
+	return updates
+}
+
+func (membership *Membership) retransmitLimit() int {
+	return membership.config.RetransmitMultiplier * int(math.Ceil(math.Log10(float64(len(membership.members)+2))))
+}
+
+func (membership *Membership) suspicionTimeout() time.Duration {
+	scale := math.Max(1, math.Log10(float64(len(membership.members)+1)))
+	return time.Duration(float64(membership.config.SuspicionMultiplier) * scale * float64(membership.config.ProtocolPeriod))
+}
+
+func isActive(state pb.MemberUpdate_State) bool {
+	return state == pb.MemberUpdate_ALIVE || state == pb.MemberUpdate_SUSPECT
+}
+
+func toMemberUpdate(node nodecommon.Node, state pb.MemberUpdate_State, incarnation int64) *pb.MemberUpdate {
+	return &pb.MemberUpdate{
+		NodeId:           node.NodeID,
+		Hostname:         node.NodeHostname,
+		IpAddress:        node.NodeIP,
+		ControlPlanePort: node.NodeControlPort,
+		DataPlanePort:    node.NodeDataPort,
+		State:            state,
+		Incarnation:      incarnation,
+		Learner:          node.Role == nodecommon.RoleLearner,
+	}
+}
+
+func toNode(update *pb.MemberUpdate) nodecommon.Node {
+	node := *nodecommon.InitializeNode(update.NodeId, update.Hostname, update.IpAddress, update.ControlPlanePort, update.DataPlanePort, 1)
+	if update.Learner {
+		node.Role = nodecommon.RoleLearner
+	}
+	return node
+}
@@ -0,0 +1,218 @@
+package membership
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+// Transport sends gossip messages to the control plane of a peer
+type Transport interface {
+	Ping(ctx context.Context, address string, request *pb.PingRequest) (*pb.PingResponse, error)
+	PingReq(ctx context.Context, address string, request *pb.PingReqRequest) (*pb.PingResponse, error)
+}
+
+type probeTarget struct {
+	nodeID      string
+	address     string
+	incarnation int64
+}
+
+// Run probes one member every protocol period until ctx is done
+func (membership *Membership) Run(ctx context.Context, transport Transport) {
+	membership.logger.Info("Starting gossip membership")
+	ticker := time.NewTicker(membership.config.ProtocolPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			membership.logger.Info("Stopping gossip membership")
+			return
+		case <-ticker.C:
+		}
+		membership.probe(ctx, transport)
+		membership.expireSuspects()
+		membership.pruneDeparted()
+	}
+}
+
+func (membership *Membership) probe(ctx context.Context, transport Transport) {
+	target, exists := membership.nextProbeTarget()
+	if !exists {
+		return
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, membership.config.PingTimeout)
+	response, err := transport.Ping(pingCtx, target.address, membership.newPingRequest())
+	cancel()
+	if err == nil {
+		membership.handleAck(response)
+		return
+	}
+	membership.logger.Debug("Direct ping failed, trying indirect probes", "nodeId", target.nodeID, "error", err)
+
+	if response, acked := membership.probeIndirectly(ctx, transport, target); acked {
+		membership.handleAck(response)
+		return
+	}
+	if ctx.Err() == nil {
+		membership.suspect(target)
+	}
+}
+
+// probeIndirectly asks up to IndirectProbes other members to ping the target
+// and returns the first ack any of them relays
+func (membership *Membership) probeIndirectly(ctx context.Context, transport Transport, target probeTarget) (*pb.PingResponse, bool) {
+	helpers := membership.randomMembers(membership.config.IndirectProbes, target.nodeID)
+	if len(helpers) == 0 {
+		return nil, false
+	}
+
+	indirectCtx, cancel := context.WithTimeout(ctx, membership.config.ProtocolPeriod-membership.config.PingTimeout)
+	defer cancel()
+
+	acks := make(chan *pb.PingResponse, len(helpers))
+	for _, helper := range helpers {
+		request := membership.newPingRequest()
+		go func(helper probeTarget) {
+			response, err := transport.PingReq(indirectCtx, helper.address, &pb.PingReqRequest{
+				Source:        request.Source,
+				Updates:       request.Updates,
+				TargetNodeId:  target.nodeID,
+				TargetAddress: target.address,
+			})
+			if err != nil {
+				acks <- nil
+				return
+			}
+			acks <- response
+		}(helper)
+	}
+
+	for range helpers {
+		if response := <-acks; response != nil {
+			return response, true
+		}
+	}
+	return nil, false
+}
+
+// HandlePingReq pings a target on behalf of another member and relays the ack
+func (membership *Membership) HandlePingReq(ctx context.Context, transport Transport, request *pb.PingReqRequest) (*pb.PingResponse, error) {
+	membership.mu.Lock()
+	membership.heardFrom(request.Source)
+	membership.applyUpdates(request.Updates)
+	membership.mu.Unlock()
+
+	pingCtx, cancel := context.WithTimeout(ctx, membership.config.PingTimeout)
+	defer cancel()
+	response, err := transport.Ping(pingCtx, request.TargetAddress, membership.newPingRequest())
+	if err != nil {
+		return nil, err
+	}
+	membership.handleAck(response)
+	return response, nil
+}
+
+// nextProbeTarget walks the active members in a random order and reshuffles
+// after every full pass, so each member is probed once per pass
+func (membership *Membership) nextProbeTarget() (probeTarget, bool) {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	for attempts := 0; attempts < 2; attempts++ {
+		for membership.probeIndex < len(membership.probeOrder) {
+			nodeID := membership.probeOrder[membership.probeIndex]
+			membership.probeIndex++
+			member, exists := membership.members[nodeID]
+			if exists && isActive(member.state) {
+				return membership.toProbeTarget(nodeID, member), true
+			}
+		}
+
+		membership.probeOrder = membership.probeOrder[:0]
+		for nodeID, member := range membership.members {
+			if isActive(member.state) {
+				membership.probeOrder = append(membership.probeOrder, nodeID)
+			}
+		}
+		rand.Shuffle(len(membership.probeOrder), func(i, j int) {
+			membership.probeOrder[i], membership.probeOrder[j] = membership.probeOrder[j], membership.probeOrder[i]
+		})
+		membership.probeIndex = 0
+	}
+	return probeTarget{}, false
+}
+
+func (membership *Membership) randomMembers(count int, excludeNodeID string) []probeTarget {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	var candidates []probeTarget
+	for nodeID, member := range membership.members {
+		if nodeID != excludeNodeID && member.state == pb.MemberUpdate_ALIVE {
+			candidates = append(candidates, membership.toProbeTarget(nodeID, member))
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+	return candidates
+}
+
+func (membership *Membership) toProbeTarget(nodeID string, member *memberState) probeTarget {
+	return probeTarget{
+		nodeID:      nodeID,
+		address:     member.node.NodeIP + ":" + member.node.NodeControlPort,
+		incarnation: member.incarnation,
+	}
+}
+
+// suspect marks a member that missed both the direct and the indirect probes
+// once its phi reached SuspectPhi. Nothing happens if the member refuted in the
+// meantime.
+func (membership *Membership) suspect(target probeTarget) {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	member, exists := membership.members[target.nodeID]
+	if !exists || member.state != pb.MemberUpdate_ALIVE || member.incarnation != target.incarnation {
+		return
+	}
+	if phi := membership.detector.Phi(target.nodeID); phi < membership.config.SuspectPhi {
+		membership.logger.Debug("Member missed a probe but is not suspected yet", "nodeId", target.nodeID, "phi", phi)
+		return
+	}
+	membership.applyUpdate(toMemberUpdate(member.node, pb.MemberUpdate_SUSPECT, member.incarnation))
+}
+
+// expireSuspects declares dead every suspect that did not refute in time
+func (membership *Membership) expireSuspects() {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	timeout := membership.suspicionTimeout()
+	for _, member := range membership.members {
+		if member.state == pb.MemberUpdate_SUSPECT && time.Since(member.stateChangedAt) > timeout {
+			membership.applyUpdate(toMemberUpdate(member.node, pb.MemberUpdate_DEAD, member.incarnation))
+		}
+	}
+}
+
+func (membership *Membership) pruneDeparted() {
+	membership.mu.Lock()
+	defer membership.mu.Unlock()
+
+	for nodeID, member := range membership.members {
+		if !isActive(member.state) && time.Since(member.stateChangedAt) > membership.config.DeadMemberRetention {
+			delete(membership.members, nodeID)
+			membership.detector.Remove(nodeID)
+		}
+	}
+}
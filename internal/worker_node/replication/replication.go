@@ -0,0 +1,967 @@
+// Package replication streams writes from the primary to its peers. Every peer
+// gets a long lived bidirectional stream. Writes queued for a peer are packed
+// into batches of up to MaxBatchEntries entries or MaxBatchBytes bytes, and up
+// to MaxInFlightBatches batches are sent ahead of their acknowledgements, so
+// write throughput is no longer bounded by one round trip per write. The
+// window is kept per peer, a slow peer only holds back its own stream. A
+// stream that breaks fails the batches in flight on it and is reopened for the
+// next batch. Replication stays best effort, writes that did not reach a peer
+// are reported as missed by it.
+//
+// A peer that is suspected or unreachable while it is in sync gets hinted
+// handoff. Its failed batches and every write after them are kept as hints
+// in order, and replayed once the failure detector sees the peer alive again
+// or every HintReplayInterval. Live replication resumes when the hints ran
+// out, and the peer is in sync again without a snapshot. Hints that outgrow
+// their cap or miss a write are dropped and the snapshot sender takes over.
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/antientropy"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/hints"
+	"github.com/Vahsek/distrokv/internal/worker_node/session"
+	"github.com/Vahsek/distrokv/internal/worker_node/snapshot"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultMaxBatchEntries    = 256
+	DefaultMaxBatchBytes      = 1 << 20
+	DefaultMaxInFlightBatches = 8
+	DefaultAckTimeout         = 5 * time.Second
+	DefaultHintReplayInterval = 5 * time.Second
+	// queueSize bounds the writes waiting to be batched for a single peer
+	queueSize = 1024
+	// maxPendingAcks bounds the batches a receiver applied but did not
+	// acknowledge yet, the primary keeps fewer in flight
+	maxPendingAcks = 64
+)
+
+var (
+	ErrStopped      = errors.New("Replication to peer stopped")
+	errStreamBroken = errors.New("Replication stream broke before the batch was sent")
+	errSuspected    = errors.New("Peer is suspected to be down")
+	errHinted       = errors.New("Peer is unreachable, the write was kept as a hint")
+	errNotHinted    = errors.New("Peer is unreachable and its hints were dropped")
+)
+
+type Config struct {
+	// MaxBatchEntries and MaxBatchBytes bound a single batch, a write larger
+	// than MaxBatchBytes is still sent in a batch of its own
+	MaxBatchEntries int
+	MaxBatchBytes   int
+	// MaxInFlightBatches is the window of batches sent to a peer but not
+	// acknowledged yet
+	MaxInFlightBatches int
+	// AckTimeout bounds the wait for a peer to acknowledge a write
+	AckTimeout time.Duration
+	// HintReplayInterval is how often hints are replayed to a peer the
+	// failure detector did not see come back
+	HintReplayInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxBatchEntries:    DefaultMaxBatchEntries,
+		MaxBatchBytes:      DefaultMaxBatchBytes,
+		MaxInFlightBatches: DefaultMaxInFlightBatches,
+		AckTimeout:         DefaultAckTimeout,
+		HintReplayInterval: DefaultHintReplayInterval,
+	}
+}
+
+// Transport opens replication streams to the control plane of a peer
+type Transport interface {
+	Replicate(ctx context.Context, address string) (grpc.BidiStreamingClient[pb.ReplicationBatch, pb.ReplicationAck], error)
+}
+
+// SyncState tracks the peers that missed writes, the lease implements it.
+// Hints are only kept for a peer that was in sync when they started, so
+// replaying them brings it back in sync.
+type SyncState interface {
+	InSync(nodeID string) bool
+	SyncPoint() int64
+	MarkInSync(nodeID string, syncPoint int64)
+}
+
+type Replicator struct {
+	config    Config
+	nodeData  *data.NodeData
+	transport Transport
+	hints     *hints.Store
+	syncState SyncState
+	peers     map[string]*peer
+	closed    bool
+	// stateChanges queues the peer state changes the membership reported
+	// until followStates handles them, in order
+	stateChanges []stateChange
+	changed      chan struct{}
+	stopped      chan struct{}
+	mu           sync.Mutex
+	logger       slog.Logger
+}
+
+type stateChange struct {
+	nodeID string
+	state  pb.MemberUpdate_State
+}
+
+func NewReplicator(config Config, nodeData *data.NodeData, transport Transport, hintStore *hints.Store, syncState SyncState, logger slog.Logger) *Replicator {
+	replicator := &Replicator{
+		config:    config,
+		nodeData:  nodeData,
+		transport: transport,
+		hints:     hintStore,
+		syncState: syncState,
+		peers:     make(map[string]*peer),
+		changed:   make(chan struct{}, 1),
+		stopped:   make(chan struct{}),
+		logger:    logger,
+	}
+	go replicator.followStates()
+	return replicator
+}
+
+// Replicate sends the entries to every known peer and waits until each peer
+// acknowledged them or AckTimeout passed. The entries reach a peer in a single
+// batch and in the order given. The node ids of the peers that missed them are
+// returned, including the ones that only got them as hints.
+func (replicator *Replicator) Replicate(entries ...*pb.ReplicationEntry) []string {
+	peers := replicator.currentPeers()
+	size := 0
+	for _, entry := range entries {
+		size += proto.Size(entry)
+	}
+
+	// Unlike a timer, the context stays done for every peer after the first
+	// one that timed out
+	ctx, cancel := context.WithTimeout(context.Background(), replicator.config.AckTimeout)
+	defer cancel()
+	writes := make([]*write, len(peers))
+	var missed []string
+	for i, peer := range peers {
+		writes[i] = &write{entries: entries, size: size, done: make(chan error, 1)}
+		select {
+		case peer.queue <- writes[i]:
+		case <-peer.stopped:
+			writes[i] = nil
+			replicator.hintAbsent(peer.nodeID, entries)
+		case <-ctx.Done():
+			writes[i] = nil
+			// The write never reaches the peer, so its hints fall short
+			peer.abandonHints(errors.New("A write could not be queued"))
+		}
+	}
+	for i, peer := range peers {
+		if writes[i] == nil {
+			missed = append(missed, peer.nodeID)
+			continue
+		}
+		var err error
+		select {
+		case err = <-writes[i].done:
+		case <-peer.stopped:
+			err = ErrStopped
+		case <-ctx.Done():
+			err = ctx.Err()
+			// The write is still queued or in flight, a peer that does not
+			// answer in time is treated as suspected so it ends up a hint
+			peer.suspect()
+		}
+		if errors.Is(err, ErrStopped) {
+			replicator.hintAbsent(peer.nodeID, entries)
+		}
+		if errors.Is(err, errHinted) {
+			replicator.logger.Debug("Kept write as a hint for peer", "nodeId", peer.nodeID)
+			missed = append(missed, peer.nodeID)
+		} else if err != nil {
+			replicator.logger.Error("Failed to replicate to peer", "nodeId", peer.nodeID, "error", err)
+			missed = append(missed, peer.nodeID)
+		}
+	}
+
+	// Peers that went away while hinted keep collecting hints until they
+	// are back
+	current := make(map[string]bool, len(peers))
+	for _, peer := range peers {
+		current[peer.nodeID] = true
+	}
+	for _, nodeID := range replicator.hints.Peers() {
+		if !current[nodeID] {
+			replicator.hintAbsent(nodeID, entries)
+			missed = append(missed, nodeID)
+		}
+	}
+	return missed
+}
+
+// hintAbsent keeps entries as hints for a peer no goroutine replicates to
+// anymore, when hints are kept for it
+func (replicator *Replicator) hintAbsent(nodeID string, entries []*pb.ReplicationEntry) {
+	err := replicator.hints.Append(nodeID, entries)
+	if err != nil && !errors.Is(err, hints.ErrNoHints) {
+		replicator.logger.Warn("Failed to keep hint for absent peer", "nodeId", nodeID, "error", err)
+	}
+}
+
+// PeerStateChanged is told by the membership about every state change of a
+// peer. A suspected peer gets its writes hinted, a peer that is alive again
+// gets its hints replayed and a peer that left loses them. It is called with
+// the membership lock held, so it only queues the change and the hint files
+// are touched by followStates.
+func (replicator *Replicator) PeerStateChanged(nodeID string, state pb.MemberUpdate_State) {
+	replicator.mu.Lock()
+	replicator.stateChanges = append(replicator.stateChanges, stateChange{nodeID: nodeID, state: state})
+	replicator.mu.Unlock()
+
+	select {
+	case replicator.changed <- struct{}{}:
+	default:
+	}
+}
+
+// followStates handles the queued peer state changes until the replicator is
+// closed
+func (replicator *Replicator) followStates() {
+	for {
+		select {
+		case <-replicator.stopped:
+			return
+		case <-replicator.changed:
+		}
+		replicator.mu.Lock()
+		changes := replicator.stateChanges
+		replicator.stateChanges = nil
+		replicator.mu.Unlock()
+
+		for _, change := range changes {
+			replicator.applyStateChange(change.nodeID, change.state)
+		}
+	}
+}
+
+func (replicator *Replicator) applyStateChange(nodeID string, state pb.MemberUpdate_State) {
+	switch state {
+	case pb.MemberUpdate_SUSPECT, pb.MemberUpdate_DEAD:
+		if peer := replicator.peer(nodeID); peer != nil {
+			peer.suspect()
+		}
+	case pb.MemberUpdate_ALIVE:
+		if !replicator.hints.Has(nodeID) {
+			return
+		}
+		// The peer may have just been added back to the peer list
+		replicator.currentPeers()
+		if peer := replicator.peer(nodeID); peer != nil {
+			peer.wake()
+		}
+	case pb.MemberUpdate_LEFT:
+		replicator.DropHints(nodeID)
+	}
+}
+
+// DropHints discards the hints kept for a peer, it is caught up with a
+// snapshot instead
+func (replicator *Replicator) DropHints(nodeID string) {
+	if !replicator.hints.Has(nodeID) {
+		return
+	}
+	replicator.hints.Drop(nodeID)
+	replicator.logger.Info("Dropped hints for peer", "nodeId", nodeID)
+}
+
+// Close stops replicating to every peer
+func (replicator *Replicator) Close() {
+	replicator.mu.Lock()
+	defer replicator.mu.Unlock()
+
+	if replicator.closed {
+		return
+	}
+	replicator.closed = true
+	close(replicator.stopped)
+	for nodeID, peer := range replicator.peers {
+		peer.stop()
+		delete(replicator.peers, nodeID)
+	}
+}
+
+func (replicator *Replicator) peer(nodeID string) *peer {
+	replicator.mu.Lock()
+	defer replicator.mu.Unlock()
+
+	return replicator.peers[nodeID]
+}
+
+// currentPeers returns a peer for every node in the peer list, starting the
+// ones that joined and stopping the ones that left or moved
+func (replicator *Replicator) currentPeers() []*peer {
+	replicator.nodeData.Mu.RLock()
+	addresses := make(map[string]string, len(replicator.nodeData.PeerNodes))
+	for nodeID, node := range replicator.nodeData.PeerNodes {
+		addresses[nodeID] = node.NodeIP + ":" + node.NodeControlPort
+	}
+	replicator.nodeData.Mu.RUnlock()
+
+	replicator.mu.Lock()
+	defer replicator.mu.Unlock()
+
+	if replicator.closed {
+		return nil
+	}
+	for nodeID, peer := range replicator.peers {
+		if addresses[nodeID] != peer.address {
+			peer.stop()
+			delete(replicator.peers, nodeID)
+		}
+	}
+	peers := make([]*peer, 0, len(addresses))
+	for nodeID, address := range addresses {
+		existing, exists := replicator.peers[nodeID]
+		if !exists {
+			existing = newPeer(nodeID, address, replicator.config, replicator.transport, replicator.hints, replicator.syncState, replicator.logger)
+			replicator.peers[nodeID] = existing
+			go existing.run()
+			go existing.replayHints()
+		}
+		peers = append(peers, existing)
+	}
+	return peers
+}
+
+// write is a call to Replicate as seen by a single peer
+type write struct {
+	entries []*pb.ReplicationEntry
+	size    int
+	done    chan error
+}
+
+type peer struct {
+	nodeID    string
+	address   string
+	config    Config
+	transport Transport
+	hints     *hints.Store
+	syncState SyncState
+	queue     chan *write
+	// window holds a token for every batch in flight
+	window    chan struct{}
+	recovered chan struct{}
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	// mu guards the current stream and the batches in flight on it, and
+	// whether writes are kept as hints
+	mu          sync.Mutex
+	stream      *stream
+	nextBatchID int64
+	hinting     bool
+	logger      slog.Logger
+}
+
+type stream struct {
+	client   grpc.BidiStreamingClient[pb.ReplicationBatch, pb.ReplicationAck]
+	cancel   context.CancelFunc
+	inFlight map[int64][]*write
+	broken   bool
+}
+
+func newPeer(nodeID string, address string, config Config, transport Transport, hintStore *hints.Store, syncState SyncState, logger slog.Logger) *peer {
+	return &peer{
+		nodeID:    nodeID,
+		address:   address,
+		config:    config,
+		transport: transport,
+		hints:     hintStore,
+		syncState: syncState,
+		queue:     make(chan *write, queueSize),
+		window:    make(chan struct{}, config.MaxInFlightBatches),
+		recovered: make(chan struct{}, 1),
+		stopped:   make(chan struct{}),
+		// A peer that came back keeps collecting hints until they are
+		// replayed
+		hinting: hintStore.Has(nodeID),
+		logger:  logger,
+	}
+}
+
+func (peer *peer) stop() {
+	peer.stopOnce.Do(func() {
+		close(peer.stopped)
+	})
+}
+
+// run batches the queued writes and sends them while the window has room
+func (peer *peer) run() {
+	var carried *write
+	for {
+		next := carried
+		carried = nil
+		if next == nil {
+			select {
+			case <-peer.stopped:
+				peer.closeStream()
+				peer.drain(nil)
+				return
+			case next = <-peer.queue:
+			}
+		}
+		batch := []*write{next}
+		entries, size := len(next.entries), next.size
+	collect:
+		for {
+			select {
+			case queued := <-peer.queue:
+				if entries+len(queued.entries) > peer.config.MaxBatchEntries || size+queued.size > peer.config.MaxBatchBytes {
+					carried = queued
+					break collect
+				}
+				batch = append(batch, queued)
+				entries += len(queued.entries)
+				size += queued.size
+			default:
+				break collect
+			}
+		}
+
+		select {
+		case peer.window <- struct{}{}:
+		case <-peer.stopped:
+			peer.closeStream()
+			if carried != nil {
+				batch = append(batch, carried)
+			}
+			peer.drain(batch)
+			return
+		}
+		peer.send(batch)
+	}
+}
+
+// drain hands the writes that were not sent when the peer stopped to its
+// hints, if it has any, after the ones that were in flight
+func (peer *peer) drain(batch []*write) {
+	for {
+		select {
+		case queued := <-peer.queue:
+			batch = append(batch, queued)
+			continue
+		default:
+		}
+		break
+	}
+	if len(batch) == 0 {
+		return
+	}
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	peer.divert([][]*write{batch}, ErrStopped, false)
+}
+
+// send sends a batch on the current stream, opening one if needed. The caller
+// took a window token for the batch.
+func (peer *peer) send(batch []*write) {
+	peer.mu.Lock()
+	if peer.hinting {
+		peer.divert([][]*write{batch}, errNotHinted, false)
+		peer.mu.Unlock()
+		<-peer.window
+		return
+	}
+	peer.mu.Unlock()
+
+	current, err := peer.openStream()
+	if err != nil {
+		<-peer.window
+		peer.logger.Error("Failed to open replication stream", "nodeId", peer.nodeID, "error", err)
+		peer.mu.Lock()
+		peer.divert([][]*write{batch}, err, true)
+		peer.mu.Unlock()
+		return
+	}
+
+	message := &pb.ReplicationBatch{}
+	for _, pending := range batch {
+		message.Entries = append(message.Entries, pending.entries...)
+	}
+	peer.mu.Lock()
+	// Hints may have started while the stream was opened, the batch has to
+	// queue up behind them
+	if current.broken || peer.hinting {
+		peer.divert([][]*write{batch}, errStreamBroken, true)
+		peer.mu.Unlock()
+		<-peer.window
+		return
+	}
+	peer.nextBatchID++
+	message.BatchId = peer.nextBatchID
+	current.inFlight[message.BatchId] = batch
+	peer.mu.Unlock()
+
+	if err := current.client.Send(message); err != nil {
+		peer.breakStream(current, err)
+	}
+}
+
+func (peer *peer) openStream() (*stream, error) {
+	peer.mu.Lock()
+	current := peer.stream
+	peer.mu.Unlock()
+	if current != nil {
+		return current, nil
+	}
+
+	// The stream outlives the call, so opening it is bounded separately
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := peer.dial(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	current = &stream{client: client, cancel: cancel, inFlight: make(map[int64][]*write)}
+	peer.mu.Lock()
+	peer.stream = current
+	peer.mu.Unlock()
+	go peer.receive(current)
+	peer.logger.Info("Opened replication stream", "nodeId", peer.nodeID, "address", peer.address)
+	return current, nil
+}
+
+// dial opens a replication stream that lives as long as ctx, giving up after
+// AckTimeout
+func (peer *peer) dial(ctx context.Context) (grpc.BidiStreamingClient[pb.ReplicationBatch, pb.ReplicationAck], error) {
+	ctx, cancel := context.WithCancel(ctx)
+	openTimer := time.AfterFunc(peer.config.AckTimeout, cancel)
+	client, err := peer.transport.Replicate(ctx, peer.address)
+	if !openTimer.Stop() && err == nil {
+		err = fmt.Errorf("Timed out opening replication stream to %s", peer.address)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return client, nil
+}
+
+// receive completes the batches acknowledged on a stream until it breaks
+func (peer *peer) receive(current *stream) {
+	for {
+		ack, err := current.client.Recv()
+		if err != nil {
+			peer.breakStream(current, err)
+			return
+		}
+		peer.mu.Lock()
+		batch, exists := current.inFlight[ack.BatchId]
+		delete(current.inFlight, ack.BatchId)
+		peer.mu.Unlock()
+		if !exists {
+			continue
+		}
+		<-peer.window
+		var result error
+		if !ack.Status {
+			result = fmt.Errorf("Peer failed to apply batch: %s", ack.Error)
+		}
+		for _, pending := range batch {
+			pending.done <- result
+		}
+	}
+}
+
+// breakStream fails the batches in flight on a stream, the next batch opens a
+// new one. The batches are kept as hints when the peer is in sync.
+func (peer *peer) breakStream(current *stream, err error) {
+	peer.mu.Lock()
+	broke := peer.breakStreamLocked(current, err)
+	peer.mu.Unlock()
+	if broke {
+		current.cancel()
+	}
+}
+
+// breakStreamLocked expects the caller to hold the lock and to cancel the
+// stream when it reports that it broke it
+func (peer *peer) breakStreamLocked(current *stream, err error) bool {
+	if current.broken {
+		return false
+	}
+	current.broken = true
+	if peer.stream == current {
+		peer.stream = nil
+	}
+	if len(current.inFlight) == 0 {
+		return true
+	}
+
+	peer.logger.Warn("Replication stream broke", "nodeId", peer.nodeID, "batches", len(current.inFlight), "error", err)
+	// Hints have to keep the order the batches were sent in
+	batchIDs := make([]int64, 0, len(current.inFlight))
+	for batchID := range current.inFlight {
+		batchIDs = append(batchIDs, batchID)
+	}
+	sort.Slice(batchIDs, func(i, j int) bool {
+		return batchIDs[i] < batchIDs[j]
+	})
+	batches := make([][]*write, len(batchIDs))
+	for i, batchID := range batchIDs {
+		batches[i] = current.inFlight[batchID]
+		<-peer.window
+	}
+	current.inFlight = make(map[int64][]*write)
+	peer.divert(batches, err, !errors.Is(err, ErrStopped))
+	return true
+}
+
+func (peer *peer) closeStream() {
+	peer.mu.Lock()
+	current := peer.stream
+	peer.mu.Unlock()
+	if current != nil {
+		peer.breakStream(current, ErrStopped)
+	}
+}
+
+// suspect starts keeping the writes for the peer as hints and fails over the
+// batches in flight to them, so writes no longer wait on a peer that is down
+func (peer *peer) suspect() {
+	peer.mu.Lock()
+	peer.startHints()
+	current := peer.stream
+	broke := peer.hinting && current != nil && peer.breakStreamLocked(current, errSuspected)
+	peer.mu.Unlock()
+	if broke {
+		current.cancel()
+	}
+}
+
+// wake replays the hints to the peer right away
+func (peer *peer) wake() {
+	select {
+	case peer.recovered <- struct{}{}:
+	default:
+	}
+}
+
+// startHints expects the caller to hold the lock. Hints only bring a peer
+// back in sync when they hold every write it missed, so they are not started
+// for a peer that is out of sync already.
+func (peer *peer) startHints() {
+	if peer.hinting || !peer.syncState.InSync(peer.nodeID) {
+		return
+	}
+	if err := peer.hints.Start(peer.nodeID); err != nil {
+		peer.logger.Error("Failed to start hints for peer", "nodeId", peer.nodeID, "error", err)
+		return
+	}
+	peer.hinting = true
+}
+
+// divert expects the caller to hold the lock. It keeps the batches as hints in
+// order, starting the hints first when start holds, and fails the writes it
+// could not keep with cause.
+func (peer *peer) divert(batches [][]*write, cause error, start bool) {
+	if start {
+		peer.startHints()
+	}
+	for _, batch := range batches {
+		if !peer.hinting {
+			fail(batch, cause)
+			continue
+		}
+		var entries []*pb.ReplicationEntry
+		for _, pending := range batch {
+			entries = append(entries, pending.entries...)
+		}
+		if err := peer.hints.Append(peer.nodeID, entries); err != nil {
+			peer.abandonHintsLocked(err)
+			fail(batch, cause)
+			continue
+		}
+		fail(batch, errHinted)
+	}
+}
+
+func (peer *peer) abandonHints(cause error) {
+	peer.mu.Lock()
+	defer peer.mu.Unlock()
+
+	peer.abandonHintsLocked(cause)
+}
+
+// abandonHintsLocked expects the caller to hold the lock. The peer is left to
+// the snapshot sender and gets its writes live again.
+func (peer *peer) abandonHintsLocked(cause error) {
+	if !peer.hinting {
+		return
+	}
+	peer.hinting = false
+	peer.hints.Drop(peer.nodeID)
+	peer.logger.Warn("Abandoned hints for peer, a snapshot catches it up instead", "nodeId", peer.nodeID, "error", cause)
+}
+
+// replayHints replays the hints whenever the peer is seen alive again and
+// every HintReplayInterval until they ran out
+func (peer *peer) replayHints() {
+	ticker := time.NewTicker(peer.config.HintReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-peer.stopped:
+			return
+		case <-peer.recovered:
+		case <-ticker.C:
+		}
+		peer.mu.Lock()
+		hinting := peer.hinting
+		peer.mu.Unlock()
+		if !hinting {
+			continue
+		}
+		if err := peer.replay(); err != nil {
+			peer.logger.Warn("Failed to replay hints to peer", "nodeId", peer.nodeID, "error", err)
+		}
+	}
+}
+
+// replay sends the hints in order on a stream of its own, each batch waiting
+// for its acknowledgement. Writes keep being added as hints meanwhile, once
+// none are left the peer is in sync and gets its writes live again.
+func (peer *peer) replay() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-peer.stopped:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	client, err := peer.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.CloseSend()
+
+	peer.logger.Info("Replaying hints to peer", "nodeId", peer.nodeID)
+	var offset, batchID int64
+	replayed := 0
+	for {
+		// Every write counted by the sync point was kept as a hint before
+		// it was counted, so it is among the hints read next
+		syncPoint := peer.syncState.SyncPoint()
+		entries, next, err := peer.hints.Read(peer.nodeID, offset, peer.config.MaxBatchBytes)
+		if err != nil {
+			// Hints that were dropped meanwhile leave the peer to a snapshot
+			peer.abandonHints(err)
+			if errors.Is(err, hints.ErrNoHints) {
+				return nil
+			}
+			return err
+		}
+		if len(entries) == 0 {
+			peer.mu.Lock()
+			done := peer.hints.DropIfReplayed(peer.nodeID, offset)
+			if done {
+				peer.hinting = false
+			}
+			peer.mu.Unlock()
+			if !done {
+				continue
+			}
+			peer.logger.Info("Replayed hints to peer", "nodeId", peer.nodeID, "entries", replayed)
+			peer.syncState.MarkInSync(peer.nodeID, syncPoint)
+			return nil
+		}
+
+		batchID++
+		if err := client.Send(&pb.ReplicationBatch{BatchId: batchID, Entries: entries}); err != nil {
+			return err
+		}
+		ackTimer := time.AfterFunc(peer.config.AckTimeout, cancel)
+		ack, err := client.Recv()
+		ackTimer.Stop()
+		if err != nil {
+			return err
+		}
+		if !ack.Status {
+			return fmt.Errorf("Peer failed to apply hints: %s", ack.Error)
+		}
+		offset = next
+		replayed += len(entries)
+	}
+}
+
+func fail(batch []*write, err error) {
+	for _, pending := range batch {
+		pending.done <- err
+	}
+}
+
+// Receiver applies the writes the primary replicates to this node
+type Receiver struct {
+	store     *storage.KeyValueStore
+	sessions  *session.Tracker
+	snapshots *snapshot.Receiver
+	repairs   *antientropy.Receiver
+	closing   chan struct{}
+	closeOnce sync.Once
+	logger    slog.Logger
+}
+
+func NewReceiver(store *storage.KeyValueStore, sessions *session.Tracker, snapshots *snapshot.Receiver, repairs *antientropy.Receiver, logger slog.Logger) *Receiver {
+	return &Receiver{
+		store:     store,
+		sessions:  sessions,
+		snapshots: snapshots,
+		repairs:   repairs,
+		closing:   make(chan struct{}),
+		logger:    logger,
+	}
+}
+
+// Apply applies a single replicated write
+func (receiver *Receiver) Apply(entry *pb.ReplicationEntry) error {
+	return receiver.applyBatch([]*pb.ReplicationEntry{entry})()
+}
+
+// applyBatch applies the entries of a batch together so they share a single
+// wal fsync. The returned wait blocks until they are durable and returns the
+// first error. Entries are independent writes, one failing does not hold back
+// the rest. A write older than what the key holds or its tombstone is dropped,
+// writes to a key coordinated concurrently can reach this node in either
+// order.
+func (receiver *Receiver) applyBatch(entries []*pb.ReplicationEntry) (wait func() error) {
+	operations := make([]storage.TxnOperation, len(entries))
+	for i, entry := range entries {
+		receiver.snapshots.Touched(entry.Key)
+		receiver.repairs.Touched(entry.Key)
+		operations[i] = storage.TxnOperation{Type: storage.OperationPut, Key: entry.Key, Value: entry.Value, Timestamp: entry.Timestamp}
+		if entry.Delete {
+			operations[i] = storage.TxnOperation{Type: storage.OperationDelete, Key: entry.Key, Timestamp: entry.Timestamp}
+		}
+	}
+
+	errs, durable := receiver.store.ApplyAsync(operations)
+	return func() error {
+		if err := durable(); err != nil {
+			receiver.logger.Error("Failed to persist replicated writes", "entries", len(entries), "error", err)
+			return err
+		}
+		var firstErr error
+		for i, err := range errs {
+			// Deletes are idempotent, a replica that never saw the key is already in sync
+			if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
+				receiver.logger.Error("Failed to apply replicated write", "key", entries[i].Key, "delete", entries[i].Delete, "error", err)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			receiver.sessions.Applied(entries[i].Position)
+		}
+		return firstErr
+	}
+}
+
+// Close ends every open stream so the server can stop gracefully, the
+// primary reopens them against another incarnation of this node
+func (receiver *Receiver) Close() {
+	receiver.closeOnce.Do(func() {
+		close(receiver.closing)
+	})
+}
+
+// pendingAck is a batch that was applied and waits to be durable before it
+// is acknowledged
+type pendingAck struct {
+	batchID int64
+	wait    func() error
+}
+
+// HandleStream applies the batches received on a replication stream in order
+// and acknowledges each once all of its entries were applied and are durable.
+// The next batch is applied while earlier ones wait for their group commit,
+// commits complete in order so the acknowledgements keep the batch order.
+func (receiver *Receiver) HandleStream(stream grpc.BidiStreamingServer[pb.ReplicationBatch, pb.ReplicationAck]) error {
+	// Receiving runs apart so the stream can be ended while it waits
+	batches := make(chan *pb.ReplicationBatch)
+	received := make(chan error, 1)
+	go func() {
+		for {
+			batch, err := stream.Recv()
+			if err != nil {
+				received <- err
+				return
+			}
+			select {
+			case batches <- batch:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	// The handler must not return while acknowledgements are still sent
+	pending := make(chan pendingAck, maxPendingAcks)
+	acked := make(chan error, 1)
+	go func() {
+		defer close(acked)
+		for applied := range pending {
+			ack := &pb.ReplicationAck{BatchId: applied.batchID, Status: true}
+			if err := applied.wait(); err != nil {
+				ack.Status = false
+				ack.Error = err.Error()
+			}
+			if err := stream.Send(ack); err != nil {
+				receiver.logger.Error("Failed to acknowledge replication batch", "batchId", applied.batchID, "error", err)
+				acked <- err
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(pending)
+		<-acked
+	}()
+
+	for {
+		var batch *pb.ReplicationBatch
+		select {
+		case <-receiver.closing:
+			return status.Error(codes.Unavailable, "Node is shutting down")
+		case err := <-received:
+			if err == io.EOF {
+				return nil
+			}
+			receiver.logger.Debug("Replication stream closed", "error", err)
+			return err
+		case err := <-acked:
+			return err
+		case batch = <-batches:
+		}
+
+		select {
+		case pending <- pendingAck{batchID: batch.BatchId, wait: receiver.applyBatch(batch.Entries)}:
+		case err := <-acked:
+			return err
+		}
+	}
+}
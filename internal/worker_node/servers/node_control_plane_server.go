@@ -2,28 +2,72 @@ package servers
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net"
 
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/antientropy"
 	"github.com/Vahsek/distrokv/internal/worker_node/clients"
 	"github.com/Vahsek/distrokv/internal/worker_node/controllers"
+	"github.com/Vahsek/distrokv/internal/worker_node/crdt"
 	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/hints"
+	"github.com/Vahsek/distrokv/internal/worker_node/leaderless"
+	"github.com/Vahsek/distrokv/internal/worker_node/leadership"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	"github.com/Vahsek/distrokv/internal/worker_node/membership"
+	"github.com/Vahsek/distrokv/internal/worker_node/replication"
+	"github.com/Vahsek/distrokv/internal/worker_node/session"
+	"github.com/Vahsek/distrokv/internal/worker_node/snapshot"
 	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (controlPlaneServer *NodeControlPlaneServer) ReplicateSetRequest(ctx context.Context, request *pb.SetReplicationRequest) (*pb.SetReplicationResponse, error) {
-	return nil, nil
+	err := controlPlaneServer.Replication.Apply(&pb.ReplicationEntry{Key: request.Key, Value: request.Value, Position: request.Position, Timestamp: request.Timestamp})
+	if err != nil {
+		return &pb.SetReplicationResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.SetReplicationResponse{
+		Key:    request.Key,
+		Status: true,
+	}, nil
 }
 
 func (controlPlaneServer *NodeControlPlaneServer) ReplicateDeleteRequest(ctx context.Context, request *pb.DeleteReplicationRequest) (*pb.DeleteReplicationResponse, error) {
-	return nil, nil
+	err := controlPlaneServer.Replication.Apply(&pb.ReplicationEntry{Key: request.Key, Delete: true, Position: request.Position, Timestamp: request.Timestamp})
+	if err != nil {
+		return &pb.DeleteReplicationResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.DeleteReplicationResponse{
+		Key:    request.Key,
+		Status: true,
+	}, nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) Replicate(stream grpc.BidiStreamingServer[pb.ReplicationBatch, pb.ReplicationAck]) error {
+	return controlPlaneServer.Replication.HandleStream(stream)
 }
 
 func (controlPlaneServer *NodeControlPlaneServer) RegisterNewPeerServer(ctx context.Context, request *pb.NewServerAddRequest) (*pb.NewServerAddResponse, error) {
 	controlPlaneServer.logger.Info("Registeration request from peer")
 	controlPlaneServer.logger.Info(request.String())
-	err := controllers.RegisterNewPeerNode(request, controlPlaneServer.NodeData, &controlPlaneServer.logger)
+	// A restarted node may have lost what it had, it is caught up with a
+	// snapshot rather than with hints
+	controlPlaneServer.Hints.Drop(request.NodeId)
+	err := controllers.RegisterNewPeerNode(request, controlPlaneServer.Membership, &controlPlaneServer.logger)
 	if err != nil {
 		controlPlaneServer.logger.Error("Error in registering new peer node")
 		return &pb.NewServerAddResponse{
@@ -31,25 +75,157 @@ func (controlPlaneServer *NodeControlPlaneServer) RegisterNewPeerServer(ctx cont
 			Message: "Failed to register new server",
 		}, err
 	}
+	// A node joining or restarting has none of the writes acknowledged
+	// while it was away
+	controlPlaneServer.Lease.RecordPeerJoined(request.NodeId)
 	return &pb.NewServerAddResponse{
 		Status:  "Success",
 		Message: "Successfully registerd the new peer server",
 	}, nil
 }
 
-func StartNodeControlPlaneServer(controlPlanePortNumber string, logger slog.Logger, client *clients.ClusterClient, nodeData *data.NodeData) {
+func (controlPlaneServer *NodeControlPlaneServer) RemovePeerServer(ctx context.Context, request *pb.RemovePeerRequest) (*pb.RemovePeerResponse, error) {
+	controlPlaneServer.logger.Info("Removal request from peer")
+	err := controllers.RemovePeerNode(request, controlPlaneServer.Membership, &controlPlaneServer.logger)
+	if err != nil {
+		controlPlaneServer.logger.Error("Error in removing peer node")
+		return &pb.RemovePeerResponse{
+			Status:  "Failed",
+			Message: "Failed to remove the peer server",
+		}, status.Error(codes.NotFound, err.Error())
+	}
+	return &pb.RemovePeerResponse{
+		Status:  "Success",
+		Message: "Successfully removed the peer server",
+	}, nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) Ping(ctx context.Context, request *pb.PingRequest) (*pb.PingResponse, error) {
+	return controlPlaneServer.Membership.HandlePing(request), nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) PingReq(ctx context.Context, request *pb.PingReqRequest) (*pb.PingResponse, error) {
+	response, err := controlPlaneServer.Membership.HandlePingReq(ctx, controlPlaneServer.ClusterClient, request)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return response, nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) RequestLease(ctx context.Context, request *pb.LeaseRequest) (*pb.LeaseResponse, error) {
+	return controlPlaneServer.Lease.HandleRequest(request), nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) InstallSnapshot(stream grpc.ClientStreamingServer[pb.SnapshotChunk, pb.InstallSnapshotResponse]) error {
+	return controlPlaneServer.Snapshots.HandleStream(stream)
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) TransferLeadership(ctx context.Context, request *pb.TransferLeadershipRequest) (*pb.TransferLeadershipResponse, error) {
+	controlPlaneServer.logger.Info("Leadership transfer requested", "target", request.TargetNodeId)
+	err := controlPlaneServer.Leadership.Transfer(ctx, controlPlaneServer.ClusterClient, request.TargetNodeId)
+	if err != nil {
+		controlPlaneServer.logger.Error("Leadership transfer failed", "target", request.TargetNodeId, "error", err)
+		code := codes.Unavailable
+		switch {
+		case errors.Is(err, leadership.ErrNotPrimary):
+			code = codes.FailedPrecondition
+		case errors.Is(err, leadership.ErrUnknownTarget):
+			code = codes.InvalidArgument
+		case errors.Is(err, leadership.ErrTransferInProgress):
+			code = codes.Aborted
+		}
+		controlPlaneServer.NodeData.Mu.RLock()
+		primaryNodeId := controlPlaneServer.NodeData.PrimaryNodeID
+		controlPlaneServer.NodeData.Mu.RUnlock()
+		return &pb.TransferLeadershipResponse{
+			Status:        "Failed",
+			Message:       err.Error(),
+			PrimaryNodeId: primaryNodeId,
+		}, status.Error(code, err.Error())
+	}
+	return &pb.TransferLeadershipResponse{
+		Status:        "Success",
+		Message:       "Leadership transferred",
+		PrimaryNodeId: request.TargetNodeId,
+	}, nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) TimeoutNow(ctx context.Context, request *pb.TimeoutNowRequest) (*pb.TimeoutNowResponse, error) {
+	if err := controlPlaneServer.Leadership.TakeOver(ctx, controlPlaneServer.ClusterClient, request.FromNodeId); err != nil {
+		controlPlaneServer.logger.Error("Failed to take over leadership", "from", request.FromNodeId, "error", err)
+		return &pb.TimeoutNowResponse{TookOver: false}, nil
+	}
+	return &pb.TimeoutNowResponse{TookOver: true}, nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) GetMerkleHashes(ctx context.Context, request *pb.MerkleHashesRequest) (*pb.MerkleHashesResponse, error) {
+	response, err := controlPlaneServer.Repairs.MerkleHashes(request)
+	if errors.Is(err, antientropy.ErrInvalidNode) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return response, nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) RepairRanges(stream grpc.BidiStreamingServer[pb.RepairMessage, pb.RepairResponse]) error {
+	return controlPlaneServer.Repairs.HandleStream(stream)
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) ReplicaRead(ctx context.Context, request *pb.ReplicaReadRequest) (*pb.ReplicaReadResponse, error) {
+	if controlPlaneServer.Replica == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Node is not in leaderless mode")
+	}
+	response, err := controlPlaneServer.Replica.HandleRead(request)
+	if errors.Is(err, leaderless.ErrCorruptVersion) {
+		return nil, status.Error(codes.DataLoss, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return response, nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) ReplicaWrite(ctx context.Context, request *pb.ReplicaWriteRequest) (*pb.ReplicaWriteResponse, error) {
+	if controlPlaneServer.Replica == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Node is not in leaderless mode")
+	}
+	return controlPlaneServer.Replica.HandleWrite(request), nil
+}
+
+func (controlPlaneServer *NodeControlPlaneServer) ReplicaUpdateCRDT(ctx context.Context, request *pb.ReplicaUpdateCRDTRequest) (*pb.ReplicaUpdateCRDTResponse, error) {
+	if controlPlaneServer.Replica == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Node is not in leaderless mode")
+	}
+	response, err := controlPlaneServer.Replica.HandleUpdateCRDT(request)
+	switch {
+	case errors.Is(err, crdt.ErrWrongType), errors.Is(err, crdt.ErrInvalidOperation):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, leaderless.ErrCorruptVersion):
+		return nil, status.Error(codes.DataLoss, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return response, nil
+}
+
+// StartNodeControlPlaneServer starts serving in the background and returns the
+// server so the caller can stop it
+func StartNodeControlPlaneServer(controlPlanePortNumber string, logger slog.Logger, clock *hlc.Clock, client *clients.ClusterClient, nodeData *data.NodeData, store *storage.KeyValueStore, peerMembership *membership.Membership, readLease *lease.Lease, sessions *session.Tracker, snapshots *snapshot.Receiver, replicationReceiver *replication.Receiver, transferer *leadership.Transferer, repairs *antientropy.Receiver, hintStore *hints.Store, replica *leaderless.Replica) (*grpc.Server, error) {
 	logger.Info("Creating TCP Socket on port" + controlPlanePortNumber)
 	lis, err := net.Listen("tcp", controlPlanePortNumber)
 	if err != nil {
 		logger.Error("Error in Creating TCP socket")
-		return
+		return nil, err
 	}
-	nodeCPServer := grpc.NewServer()
+	nodeCPServer := grpc.NewServer(clock.ServerOptions()...)
 	logger.Info("Initializing GRPC service for node control plane")
-	pb.RegisterNodeControlPlaneServiceServer(nodeCPServer, InitializeControlPlaneServer(logger, client, nodeData))
-	if err := nodeCPServer.Serve(lis); err != nil {
-		logger.Info("Failed to initialize GRPC server for node control plane")
-	} else {
-		logger.Info("Successfully initialized GRPC server")
-	}
+	pb.RegisterNodeControlPlaneServiceServer(nodeCPServer, InitializeControlPlaneServer(logger, client, nodeData, store, peerMembership, readLease, sessions, snapshots, replicationReceiver, transferer, repairs, hintStore, replica))
+	go func() {
+		if err := nodeCPServer.Serve(lis); err != nil {
+			logger.Error("GRPC server for node control plane stopped", "error", err)
+		}
+	}()
+	return nodeCPServer, nil
 }
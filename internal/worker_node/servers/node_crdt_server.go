@@ -0,0 +1,151 @@
+package servers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Vahsek/distrokv/internal/worker_node/crdt"
+	"github.com/Vahsek/distrokv/internal/worker_node/session"
+	pbControlPlane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	pb "github.com/Vahsek/distrokv/pkg/node/dataplane"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (dataplaneServer *NodeDataPlaneServer) Increment(ctx context.Context, request *pb.IncrementRequest) (*pb.CRDTResponse, error) {
+	operation := &pbControlPlane.CRDTOperation{Operation: &pbControlPlane.CRDTOperation_Increment{Increment: request.Delta}}
+	return dataplaneServer.updateCRDT(ctx, request.Key, operation, request.WriteQuorum)
+}
+
+func (dataplaneServer *NodeDataPlaneServer) SetAdd(ctx context.Context, request *pb.SetElementRequest) (*pb.CRDTResponse, error) {
+	operation := &pbControlPlane.CRDTOperation{Operation: &pbControlPlane.CRDTOperation_SetAdd{SetAdd: request.Element}}
+	return dataplaneServer.updateCRDT(ctx, request.Key, operation, request.WriteQuorum)
+}
+
+func (dataplaneServer *NodeDataPlaneServer) SetRemove(ctx context.Context, request *pb.SetElementRequest) (*pb.CRDTResponse, error) {
+	operation := &pbControlPlane.CRDTOperation{Operation: &pbControlPlane.CRDTOperation_SetRemove{SetRemove: request.Element}}
+	return dataplaneServer.updateCRDT(ctx, request.Key, operation, request.WriteQuorum)
+}
+
+func (dataplaneServer *NodeDataPlaneServer) RegisterSet(ctx context.Context, request *pb.RegisterSetRequest) (*pb.CRDTResponse, error) {
+	operation := &pbControlPlane.CRDTOperation{Operation: &pbControlPlane.CRDTOperation_RegisterSet{RegisterSet: request.Value}}
+	return dataplaneServer.updateCRDT(ctx, request.Key, operation, request.WriteQuorum)
+}
+
+func (dataplaneServer *NodeDataPlaneServer) GetCRDT(ctx context.Context, request *pb.GetCRDTRequest) (*pb.CRDTResponse, error) {
+	var state *pbControlPlane.CRDTState
+	var err error
+	if dataplaneServer.Leaderless != nil {
+		state, err = dataplaneServer.Leaderless.GetCRDT(ctx, request.Key, int(request.ReadQuorum))
+	} else {
+		if err := dataplaneServer.confirmRead(ctx, &pb.GetRequest{Key: request.Key, Consistency: request.Consistency, MaxStalenessMs: request.MaxStalenessMs}); err != nil {
+			return &pb.CRDTResponse{
+				Key:    request.Key,
+				Status: false,
+				Error:  err.Error(),
+			}, status.Error(codes.Unavailable, err.Error())
+		}
+		state, err = dataplaneServer.readCRDT(request.Key)
+	}
+	if err != nil {
+		return &pb.CRDTResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, crdtError(err)
+	}
+	return crdtResponse(request.Key, state), nil
+}
+
+// updateCRDT applies operation on the primary and replicates the resulting
+// state like any other write. In leaderless mode any node coordinates the
+// update.
+func (dataplaneServer *NodeDataPlaneServer) updateCRDT(ctx context.Context, key string, operation *pbControlPlane.CRDTOperation, writeQuorum int32) (*pb.CRDTResponse, error) {
+	if dataplaneServer.Leaderless != nil {
+		state, err := dataplaneServer.Leaderless.UpdateCRDT(ctx, key, operation, int(writeQuorum))
+		if err != nil {
+			dataplaneServer.logger.Error("Failed to update CRDT", "key", key, "error", err)
+			return &pb.CRDTResponse{
+				Key:    key,
+				Status: false,
+				Error:  err.Error(),
+			}, crdtError(err)
+		}
+		return crdtResponse(key, state), nil
+	}
+
+	if err := dataplaneServer.checkRangeOwner(key); err != nil {
+		return &pb.CRDTResponse{
+			Key:    key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Unavailable, err.Error())
+	}
+	if err := dataplaneServer.Lease.BeginWrite(); err != nil {
+		return &pb.CRDTResponse{
+			Key:    key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Unavailable, err.Error())
+	}
+	defer dataplaneServer.Lease.EndWrite()
+
+	state, encoded, timestamp, err := crdt.Update(dataplaneServer.Storage, key, operation, dataplaneServer.NodeData.NodeDetails.NodeID, dataplaneServer.Clock.Now())
+	if err != nil {
+		dataplaneServer.logger.Error("Failed to update CRDT", "key", key, "error", err)
+		return &pb.CRDTResponse{
+			Key:    key,
+			Status: false,
+			Error:  err.Error(),
+		}, crdtError(err)
+	}
+	position := dataplaneServer.Sessions.Next()
+	missed := dataplaneServer.Replicator.Replicate(&pbControlPlane.ReplicationEntry{
+		Key:       key,
+		Value:     encoded,
+		Position:  session.ToWritePosition(position),
+		Timestamp: timestamp,
+	})
+	dataplaneServer.Lease.RecordWrite(missed...)
+	if err := dataplaneServer.checkPrimaryAcknowledged(missed); err != nil {
+		return &pb.CRDTResponse{
+			Key:    key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Aborted, err.Error())
+	}
+	response := crdtResponse(key, state)
+	response.SessionToken = session.EncodeToken(position)
+	return response, nil
+}
+
+func (dataplaneServer *NodeDataPlaneServer) readCRDT(key string) (*pbControlPlane.CRDTState, error) {
+	value, err := dataplaneServer.Storage.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return crdt.Decode(value)
+}
+
+func crdtResponse(key string, state *pbControlPlane.CRDTState) *pb.CRDTResponse {
+	response := &pb.CRDTResponse{Key: key, Status: true}
+	switch state.GetState().(type) {
+	case *pbControlPlane.CRDTState_Counter:
+		response.Type = pb.CRDTType_COUNTER
+		response.Counter = crdt.Counter(state)
+	case *pbControlPlane.CRDTState_Set:
+		response.Type = pb.CRDTType_SET
+		response.Elements = crdt.Elements(state)
+	case *pbControlPlane.CRDTState_Register:
+		response.Type = pb.CRDTType_REGISTER
+		response.Register = crdt.Register(state)
+	}
+	return response
+}
+
+func crdtError(err error) error {
+	if errors.Is(err, crdt.ErrWrongType) || errors.Is(err, crdt.ErrInvalidOperation) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return leaderlessError(err)
+}
@@ -0,0 +1,83 @@
+package servers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Vahsek/distrokv/internal/worker_node/leaderless"
+	pb "github.com/Vahsek/distrokv/pkg/node/dataplane"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// In leaderless mode every node coordinates reads and writes of any key, the
+// lease, the sessions and the primary's replication are not used
+
+func (dataplaneServer *NodeDataPlaneServer) leaderlessGet(ctx context.Context, request *pb.GetRequest) (*pb.GetResponse, error) {
+	value, err := dataplaneServer.Leaderless.Get(ctx, request.Key, int(request.ReadQuorum))
+	if err != nil {
+		return &pb.GetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, leaderlessError(err)
+	}
+	response := &pb.GetResponse{
+		Key:     request.Key,
+		Value:   value.Siblings[0],
+		Status:  true,
+		Context: value.Context,
+	}
+	// Only reads with vector clocks have a context and can find siblings
+	if value.Context != "" {
+		response.Siblings = value.Siblings
+	}
+	return response, nil
+}
+
+func (dataplaneServer *NodeDataPlaneServer) leaderlessSet(ctx context.Context, request *pb.SetRequest) (*pb.SetResponse, error) {
+	if err := dataplaneServer.Leaderless.Put(ctx, request.Key, request.Value, int(request.WriteQuorum), request.Context); err != nil {
+		dataplaneServer.logger.Error("Failed to set key", "key", request.Key, "error", err)
+		return &pb.SetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, leaderlessError(err)
+	}
+	return &pb.SetResponse{
+		Key:    request.Key,
+		Status: true,
+	}, nil
+}
+
+func (dataplaneServer *NodeDataPlaneServer) leaderlessDelete(ctx context.Context, request *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := dataplaneServer.Leaderless.Delete(ctx, request.Key, int(request.WriteQuorum), request.Context); err != nil {
+		dataplaneServer.logger.Error("Failed to delete key", "key", request.Key, "error", err)
+		return &pb.DeleteResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, leaderlessError(err)
+	}
+	return &pb.DeleteResponse{
+		Key:    request.Key,
+		Status: true,
+	}, nil
+}
+
+// errLeaderlessUnsupported rejects the requests that need a single order of
+// writes, which leaderless mode does not have
+var errLeaderlessUnsupported = status.Error(codes.Unimplemented, "Not supported in leaderless mode")
+
+func leaderlessError(err error) error {
+	if errors.Is(err, leaderless.ErrInvalidQuorum) || errors.Is(err, leaderless.ErrInvalidContext) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, leaderless.ErrQuorumUnavailable) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+	return storageError(err)
+}
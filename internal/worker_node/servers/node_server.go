@@ -2,38 +2,373 @@ package servers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net"
+	"slices"
+	"time"
 
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/clients"
+	"github.com/Vahsek/distrokv/internal/worker_node/controllers"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/leaderless"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	"github.com/Vahsek/distrokv/internal/worker_node/replication"
+	"github.com/Vahsek/distrokv/internal/worker_node/session"
+	pbControlPlane "github.com/Vahsek/distrokv/pkg/node/controlplane"
 	pb "github.com/Vahsek/distrokv/pkg/node/dataplane"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func (dataplaneServer *NodeDataPlaneServer) GetKey(ctx context.Context, request *pb.GetRequest) (*pb.GetResponse, error) {
-	return nil, nil
+	if dataplaneServer.Leaderless != nil {
+		return dataplaneServer.leaderlessGet(ctx, request)
+	}
+	if err := dataplaneServer.confirmRead(ctx, request); err != nil {
+		code := codes.Unavailable
+		if errors.Is(err, session.ErrInvalidToken) {
+			code = codes.InvalidArgument
+		}
+		return &pb.GetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(code, err.Error())
+	}
+	value, err := dataplaneServer.Storage.Get(request.Key)
+	if err != nil {
+		dataplaneServer.logger.Error("Failed to get key", "key", request.Key)
+		return &pb.GetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, storageError(err)
+	}
+	return &pb.GetResponse{
+		Key:    request.Key,
+		Value:  value,
+		Status: true,
+	}, nil
 }
 
 func (dataplaneServer *NodeDataPlaneServer) SetKey(ctx context.Context, request *pb.SetRequest) (*pb.SetResponse, error) {
-	return nil, nil
+	if dataplaneServer.Leaderless != nil {
+		return dataplaneServer.leaderlessSet(ctx, request)
+	}
+	if err := dataplaneServer.checkRangeOwner(request.Key); err != nil {
+		return &pb.SetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Unavailable, err.Error())
+	}
+	if err := dataplaneServer.Lease.BeginWrite(); err != nil {
+		return &pb.SetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Unavailable, err.Error())
+	}
+	defer dataplaneServer.Lease.EndWrite()
+
+	timestamp, err := dataplaneServer.Storage.SetStamped(request.Key, request.Value)
+	if err != nil {
+		dataplaneServer.logger.Error("Failed to set key", "key", request.Key)
+		return &pb.SetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, storageError(err)
+	}
+	position := dataplaneServer.Sessions.Next()
+	missed := dataplaneServer.Replicator.Replicate(&pbControlPlane.ReplicationEntry{
+		Key:       request.Key,
+		Value:     request.Value,
+		Position:  session.ToWritePosition(position),
+		Timestamp: timestamp,
+	})
+	dataplaneServer.Lease.RecordWrite(missed...)
+	if err := dataplaneServer.checkPrimaryAcknowledged(missed); err != nil {
+		return &pb.SetResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Aborted, err.Error())
+	}
+	return &pb.SetResponse{
+		Key:          request.Key,
+		Status:       true,
+		SessionToken: session.EncodeToken(position),
+	}, nil
+}
+
+func (dataplaneServer *NodeDataPlaneServer) DeleteKey(ctx context.Context, request *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if dataplaneServer.Leaderless != nil {
+		return dataplaneServer.leaderlessDelete(ctx, request)
+	}
+	if err := dataplaneServer.checkRangeOwner(request.Key); err != nil {
+		return &pb.DeleteResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Unavailable, err.Error())
+	}
+	if err := dataplaneServer.Lease.BeginWrite(); err != nil {
+		return &pb.DeleteResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Unavailable, err.Error())
+	}
+	defer dataplaneServer.Lease.EndWrite()
+
+	timestamp, err := dataplaneServer.Storage.DeleteStamped(request.Key)
+	if err != nil {
+		dataplaneServer.logger.Error("Failed to delete key", "key", request.Key)
+		return &pb.DeleteResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, storageError(err)
+	}
+	position := dataplaneServer.Sessions.Next()
+	missed := dataplaneServer.Replicator.Replicate(&pbControlPlane.ReplicationEntry{
+		Key:       request.Key,
+		Delete:    true,
+		Position:  session.ToWritePosition(position),
+		Timestamp: timestamp,
+	})
+	dataplaneServer.Lease.RecordWrite(missed...)
+	if err := dataplaneServer.checkPrimaryAcknowledged(missed); err != nil {
+		return &pb.DeleteResponse{
+			Key:    request.Key,
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Aborted, err.Error())
+	}
+	return &pb.DeleteResponse{
+		Key:          request.Key,
+		Status:       true,
+		SessionToken: session.EncodeToken(position),
+	}, nil
+}
+
+func (dataplaneServer *NodeDataPlaneServer) ScanKeys(ctx context.Context, request *pb.ScanRequest) (*pb.ScanResponse, error) {
+	if dataplaneServer.Leaderless != nil {
+		return &pb.ScanResponse{Status: false, Error: "Not supported in leaderless mode"}, errLeaderlessUnsupported
+	}
+	keyValues, err := dataplaneServer.Storage.Scan(request.StartKey, request.EndKey, int(request.Limit))
+	if err != nil {
+		dataplaneServer.logger.Error("Failed to scan keys", "startKey", request.StartKey, "endKey", request.EndKey)
+		return &pb.ScanResponse{
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response := &pb.ScanResponse{Status: true}
+	for _, keyValue := range keyValues {
+		response.KeyValues = append(response.KeyValues, &pb.KeyValue{
+			Key:   keyValue.Key,
+			Value: keyValue.Value,
+		})
+	}
+	return response, nil
+}
+
+func (dataplaneServer *NodeDataPlaneServer) WatchKeys(request *pb.WatchRequest, stream grpc.ServerStreamingServer[pb.WatchEvent]) error {
+	if dataplaneServer.Leaderless != nil {
+		return errLeaderlessUnsupported
+	}
+	watcher := dataplaneServer.Storage.Watch(request.StartKey, request.EndKey)
+	defer dataplaneServer.Storage.CancelWatch(watcher)
+
+	// Sending the headers tells the client the watch is registered
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		dataplaneServer.logger.Error("Failed to acknowledge watch", "error", err)
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			dataplaneServer.logger.Info("Watch stream closed by client")
+			return nil
+		case event, open := <-watcher.Events:
+			if !open && watcher.Dropped() {
+				return status.Error(codes.ResourceExhausted, "Watcher fell behind and was dropped")
+			}
+			if !open {
+				return status.Error(codes.Unavailable, "Node is shutting down")
+			}
+			if err := stream.Send(controllers.ToWatchEvent(event)); err != nil {
+				dataplaneServer.logger.Error("Failed to send watch event", "error", err)
+				return err
+			}
+		}
+	}
 }
 
-func (dataPlaneServe *NodeControlPlaneServer) DeleteKey(ctx context.Context, request *pb.DeleteRequest) (*pb.DeleteResponse, error) {
-	return nil, nil
+func (dataplaneServer *NodeDataPlaneServer) Txn(ctx context.Context, request *pb.TxnRequest) (*pb.TxnResponse, error) {
+	if dataplaneServer.Leaderless != nil {
+		return &pb.TxnResponse{Status: false, Error: "Not supported in leaderless mode"}, errLeaderlessUnsupported
+	}
+	if err := dataplaneServer.Lease.BeginWrite(); err != nil {
+		return &pb.TxnResponse{
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Unavailable, err.Error())
+	}
+	defer dataplaneServer.Lease.EndWrite()
+
+	response, writes, err := controllers.ExecuteTxn(request, dataplaneServer.Storage, &dataplaneServer.logger)
+	if err != nil {
+		return &pb.TxnResponse{
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if len(writes) == 0 {
+		return response, nil
+	}
+	// The writes of a transaction reach every peer in a single batch
+	entries := make([]*pbControlPlane.ReplicationEntry, len(writes))
+	for i, write := range writes {
+		position := dataplaneServer.Sessions.Next()
+		entries[i] = &pbControlPlane.ReplicationEntry{
+			Key:       write.Key,
+			Value:     write.Value,
+			Delete:    write.Type != storage.OperationPut,
+			Position:  session.ToWritePosition(position),
+			Timestamp: write.Timestamp,
+		}
+		response.SessionToken = session.EncodeToken(position)
+	}
+	missed := dataplaneServer.Replicator.Replicate(entries...)
+	for range writes {
+		dataplaneServer.Lease.RecordWrite(missed...)
+	}
+	if err := dataplaneServer.checkPrimaryAcknowledged(missed); err != nil {
+		return &pb.TxnResponse{
+			Status: false,
+			Error:  err.Error(),
+		}, status.Error(codes.Aborted, err.Error())
+	}
+	return response, nil
+}
+
+// checkPrimaryAcknowledged fails a write the node coordinated as the owner of
+// a range when the primary missed it. Linearizable reads are served by the
+// primary, so a write is only acknowledged once the primary has it. The write
+// is already applied here and on the peers that acknowledged it, callers
+// report the failure as aborted so the client does not blindly retry it.
+func (dataplaneServer *NodeDataPlaneServer) checkPrimaryAcknowledged(missed []string) error {
+	dataplaneServer.NodeData.Mu.RLock()
+	primaryNodeID := dataplaneServer.NodeData.PrimaryNodeID
+	dataplaneServer.NodeData.Mu.RUnlock()
+
+	if !slices.Contains(missed, primaryNodeID) {
+		return nil
+	}
+	dataplaneServer.logger.Warn("Primary missed a write coordinated by this node", "primaryNodeId", primaryNodeID)
+	return fmt.Errorf("Outcome of the write is unknown, primary %s did not acknowledge it", primaryNodeID)
 }
 
-func StartNodeDataPlaneServer(dataPlanePortNumber string, logger slog.Logger) {
+// checkRangeOwner rejects a write to a key in a range owned by another node
+// before anything is applied, callers report it as unavailable so the client
+// refreshes its topology and retries on the owner. A range without an owner
+// is written through whichever node the client falls back to.
+func (dataplaneServer *NodeDataPlaneServer) checkRangeOwner(key string) error {
+	dataplaneServer.NodeData.Mu.RLock()
+	defer dataplaneServer.NodeData.Mu.RUnlock()
+
+	nodeID := dataplaneServer.NodeData.NodeDetails.NodeID
+	for _, rangeDescriptor := range dataplaneServer.NodeData.Ranges {
+		if key < rangeDescriptor.StartKey || (rangeDescriptor.EndKey != "" && key >= rangeDescriptor.EndKey) {
+			continue
+		}
+		if rangeDescriptor.OwnerNodeId == "" || rangeDescriptor.OwnerNodeId == nodeID {
+			return nil
+		}
+		dataplaneServer.logger.Info("Rejecting write to a range owned by another node", "key", key, "rangeId", rangeDescriptor.RangeId, "ownerNodeId", rangeDescriptor.OwnerNodeId)
+		return fmt.Errorf("Range %d holding the key is owned by node %s", rangeDescriptor.RangeId, rangeDescriptor.OwnerNodeId)
+	}
+	return nil
+}
+
+// confirmRead checks the node may serve a read at the requested consistency.
+// Linearizable reads are served by the primary, locally while the lease holds
+// and after a quorum round otherwise. Bounded staleness reads are served by
+// any node close enough behind the primary. A read carrying a session token
+// first waits for the node to apply the session's writes. A read the node
+// cannot serve is rejected as unavailable so the client retries it on the
+// primary.
+func (dataplaneServer *NodeDataPlaneServer) confirmRead(ctx context.Context, request *pb.GetRequest) error {
+	if request.SessionToken != "" {
+		if err := dataplaneServer.Sessions.WaitFor(ctx, request.SessionToken); err != nil {
+			dataplaneServer.logger.Info("Rejecting read the node has not caught up for", "key", request.Key, "error", err)
+			return err
+		}
+	}
+
+	switch request.Consistency {
+	case pb.ReadConsistency_ANY:
+		return nil
+	case pb.ReadConsistency_BOUNDED_STALENESS:
+		maxStaleness := time.Duration(request.MaxStalenessMs) * time.Millisecond
+		if err := dataplaneServer.Lease.WaitFresh(ctx, maxStaleness); err != nil {
+			dataplaneServer.logger.Info("Rejecting bounded staleness read", "key", request.Key, "error", err)
+			return err
+		}
+		return nil
+	}
+
+	if !dataplaneServer.Lease.IsPrimary() {
+		return fmt.Errorf("Node is not the primary, linearizable reads are served by the primary")
+	}
+	if err := dataplaneServer.Lease.ConfirmRead(ctx, dataplaneServer.ClusterClient); err != nil {
+		dataplaneServer.logger.Warn("Primary could not confirm its leadership for a read", "error", err)
+		return err
+	}
+	return nil
+}
+
+func storageError(err error) error {
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, storage.ErrStoreClosed) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if errors.Is(err, hlc.ErrClockSkew) {
+		return hlc.SkewError(err)
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// StartNodeDataPlaneServer starts serving in the background and returns the
+// server so the caller can stop it
+func StartNodeDataPlaneServer(dataPlanePortNumber string, logger slog.Logger, clock *hlc.Clock, store *storage.KeyValueStore, client *clients.ClusterClient, nodeData *data.NodeData, readLease *lease.Lease, sessions *session.Tracker, replicator *replication.Replicator, coordinator *leaderless.Coordinator) (*grpc.Server, error) {
 	logger.Info("Creating TCP Socket on port" + dataPlanePortNumber)
 	lis, err := net.Listen("tcp", dataPlanePortNumber)
 	if err != nil {
 		logger.Error("Error in Creating TCP socket")
-		return
+		return nil, err
 	}
-	nodeDPServer := grpc.NewServer()
+	nodeDPServer := grpc.NewServer(clock.ServerOptions()...)
 	logger.Info("Initializing GRPC service for node data plane")
-	pb.RegisterNodeKeyValueServiceServer(nodeDPServer, InitializeDataPlaneServer(logger))
-	if err := nodeDPServer.Serve(lis); err != nil {
-		logger.Info("Failed to initialize GRPC server for data plane")
-	} else {
-		logger.Info("Successfully initialized GRPC server")
-	}
+	pb.RegisterNodeKeyValueServiceServer(nodeDPServer, InitializeDataPlaneServer(logger, clock, store, client, nodeData, readLease, sessions, replicator, coordinator))
+	go func() {
+		if err := nodeDPServer.Serve(lis); err != nil {
+			logger.Error("GRPC server for data plane stopped", "error", err)
+		}
+	}()
+	return nodeDPServer, nil
 }
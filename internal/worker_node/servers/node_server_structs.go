@@ -3,8 +3,19 @@ package servers
 import (
 	"log/slog"
 
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/antientropy"
 	"github.com/Vahsek/distrokv/internal/worker_node/clients"
 	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/hints"
+	"github.com/Vahsek/distrokv/internal/worker_node/leaderless"
+	"github.com/Vahsek/distrokv/internal/worker_node/leadership"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	"github.com/Vahsek/distrokv/internal/worker_node/membership"
+	"github.com/Vahsek/distrokv/internal/worker_node/replication"
+	"github.com/Vahsek/distrokv/internal/worker_node/session"
+	"github.com/Vahsek/distrokv/internal/worker_node/snapshot"
 	pbControlPlane "github.com/Vahsek/distrokv/pkg/node/controlplane"
 	pbDataPlane "github.com/Vahsek/distrokv/pkg/node/dataplane"
 )
@@ -13,24 +24,60 @@ type NodeControlPlaneServer struct {
 	pbControlPlane.UnimplementedNodeControlPlaneServiceServer
 	ClusterClient *clients.ClusterClient
 	NodeData      *data.NodeData
+	Storage       *storage.KeyValueStore
+	Membership    *membership.Membership
+	Lease         *lease.Lease
+	Sessions      *session.Tracker
+	Snapshots     *snapshot.Receiver
+	Replication   *replication.Receiver
+	Leadership    *leadership.Transferer
+	Repairs       *antientropy.Receiver
+	Hints         *hints.Store
+	Replica       *leaderless.Replica // nil unless the node runs in leaderless mode
 	logger        slog.Logger
 }
 
 type NodeDataPlaneServer struct {
 	pbDataPlane.UnimplementedNodeKeyValueServiceServer
-	logger slog.Logger
+	Clock         *hlc.Clock
+	ClusterClient *clients.ClusterClient
+	NodeData      *data.NodeData
+	Storage       *storage.KeyValueStore
+	Lease         *lease.Lease
+	Sessions      *session.Tracker
+	Replicator    *replication.Replicator
+	Leaderless    *leaderless.Coordinator // nil unless the node runs in leaderless mode
+	logger        slog.Logger
 }
 
-func InitializeControlPlaneServer(logger slog.Logger, client *clients.ClusterClient, nodeData *data.NodeData) *NodeControlPlaneServer {
+func InitializeControlPlaneServer(logger slog.Logger, client *clients.ClusterClient, nodeData *data.NodeData, store *storage.KeyValueStore, peerMembership *membership.Membership, readLease *lease.Lease, sessions *session.Tracker, snapshots *snapshot.Receiver, replicationReceiver *replication.Receiver, transferer *leadership.Transferer, repairs *antientropy.Receiver, hintStore *hints.Store, replica *leaderless.Replica) *NodeControlPlaneServer {
 	return &NodeControlPlaneServer{
 		ClusterClient: client,
 		NodeData:      nodeData,
+		Storage:       store,
+		Membership:    peerMembership,
+		Lease:         readLease,
+		Sessions:      sessions,
+		Snapshots:     snapshots,
+		Replication:   replicationReceiver,
+		Leadership:    transferer,
+		Repairs:       repairs,
+		Hints:         hintStore,
+		Replica:       replica,
 		logger:        logger,
 	}
 }
 
-func InitializeDataPlaneServer(logger slog.Logger) *NodeDataPlaneServer {
+func InitializeDataPlaneServer(logger slog.Logger, clock *hlc.Clock, store *storage.KeyValueStore, client *clients.ClusterClient, nodeData *data.NodeData, readLease *lease.Lease, sessions *session.Tracker, replicator *replication.Replicator, coordinator *leaderless.Coordinator) *NodeDataPlaneServer {
 	return &NodeDataPlaneServer{
-		logger: logger,
+		Clock:         clock,
+		ClusterClient: client,
+		NodeData:      nodeData,
+		Storage:       store,
+		Lease:         readLease,
+		Sessions:      sessions,
+		Replicator:    replicator,
+		Leaderless:    coordinator,
+		logger:        logger,
 	}
 }
@@ -1,85 +1,228 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/Vahsek/distrokv/internal/common/hlc"
 	nodecommon "github.com/Vahsek/distrokv/internal/common/node_common"
 	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/antientropy"
 	"github.com/Vahsek/distrokv/internal/worker_node/clients"
+	"github.com/Vahsek/distrokv/internal/worker_node/crdt"
 	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/hints"
+	"github.com/Vahsek/distrokv/internal/worker_node/leaderless"
+	"github.com/Vahsek/distrokv/internal/worker_node/leadership"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	"github.com/Vahsek/distrokv/internal/worker_node/membership"
+	"github.com/Vahsek/distrokv/internal/worker_node/replication"
 	"github.com/Vahsek/distrokv/internal/worker_node/servers"
+	"github.com/Vahsek/distrokv/internal/worker_node/session"
+	"github.com/Vahsek/distrokv/internal/worker_node/snapshot"
+	"google.golang.org/grpc"
 )
 
+const handOffRetryInterval = 5 * time.Second
+
 type WorkerNodeService struct {
-	NodeConfig      *nodecommon.Node
-	NodeData        *data.NodeData
-	ClusterClient   *clients.ClusterClient
-	Storage         *storage.KeyValueStore
-	RegistryAddress string
-	logger          slog.Logger
+	NodeConfig        *nodecommon.Node
+	NodeData          *data.NodeData
+	Clock             *hlc.Clock
+	ClusterClient     *clients.ClusterClient
+	Storage           *storage.KeyValueStore
+	Membership        *membership.Membership
+	Lease             *lease.Lease
+	Sessions          *session.Tracker
+	SnapshotSender    *snapshot.Sender
+	Snapshots         *snapshot.Receiver
+	Replicator        *replication.Replicator
+	Replication       *replication.Receiver
+	Hints             *hints.Store
+	Leadership        *leadership.Transferer
+	AntiEntropy       *antientropy.Repairer
+	Repairs           *antientropy.Receiver
+	Replica           *leaderless.Replica     // nil unless the node runs in leaderless mode
+	Leaderless        *leaderless.Coordinator // nil unless the node runs in leaderless mode
+	RegistryAddress   string
+	HeartbeatInterval time.Duration
+	logger            slog.Logger
+
+	controlPlaneServer *grpc.Server
+	dataPlaneServer    *grpc.Server
+	drainRequested     chan struct{}
+	drainOnce          sync.Once
+	// background tracks the goroutines BootstrapWorkerNode starts
+	background sync.WaitGroup
 }
 
-func InitializeNewNodeService(hostname, ip, controlPort, dataPort string, nodeType int, registryAddress string, logger slog.Logger) *WorkerNodeService {
-	nodeConfig := nodecommon.InitializeNode(hostname, ip, controlPort, dataPort, nodeType)
+// Config describes the node and configures the components it runs
+type Config struct {
+	NodeID            string
+	Hostname          string
+	IP                string
+	ControlPort       string
+	DataPort          string
+	NodeType          int
+	Role              nodecommon.NodeRole
+	RegistryAddress   string
+	HeartbeatInterval time.Duration
+	Membership        membership.Config
+	Lease             lease.Config
+	WAL               storage.WALConfig
+	AntiEntropy       antientropy.Config
+	Hints             hints.Config
+	Leaderless        leaderless.Config
+	MaxClockSkew      time.Duration
+}
+
+func InitializeNewNodeService(config Config, logger slog.Logger) (*WorkerNodeService, error) {
+	nodeConfig := nodecommon.InitializeNode(config.NodeID, config.Hostname, config.IP, config.ControlPort, config.DataPort, config.NodeType)
+	nodeConfig.Role = config.Role
 	nodeData := &data.NodeData{
 		NodeDetails:           *nodeConfig,
 		PeerNodes:             make(map[string]nodecommon.Node),
-		RegistryServerAddress: registryAddress,
+		RegistryServerAddress: config.RegistryAddress,
 		Logger:                logger,
 	}
-	return &WorkerNodeService{
-		NodeConfig:      nodeConfig,
-		NodeData:        nodeData,
-		ClusterClient:   clients.InitializeClusterClient(logger),
-		Storage:         storage.NewKeyValueStore(logger),
-		RegistryAddress: registryAddress,
-		logger:          logger,
+	// Every write the node stores and every RPC it makes or serves is
+	// stamped by the same clock
+	clock := hlc.NewClock(config.MaxClockSkew, logger)
+	store, err := storage.OpenKeyValueStore(config.WAL, clock, logger)
+	if err != nil {
+		return nil, err
+	}
+	// CRDT states coordinated by different nodes are merged wherever they
+	// meet, not replaced by the newest
+	store.SetMergeFunc(crdt.MergeValues)
+	hintStore, err := hints.Open(config.Hints, logger)
+	if err != nil {
+		return nil, errors.Join(err, store.Close())
 	}
+	readLease := lease.NewLease(config.Lease, nodeData, logger)
+	sessions := session.NewTracker(config.NodeID, session.DefaultMaxWait, logger)
+	snapshotSender := snapshot.NewSender(store, sessions, readLease, hintStore, nodeData, logger)
+	snapshots := snapshot.NewReceiver(store, sessions, logger)
+	repairs := antientropy.NewReceiver(store, logger)
+	clusterClient := clients.InitializeClusterClient(clock, logger)
+	peerMembership := membership.NewMembership(config.Membership, nodeData, logger)
+	replicator := replication.NewReplicator(replication.DefaultConfig(), nodeData, clusterClient, hintStore, readLease, logger)
+	// The failure detector decides when a peer gets hints and when they are
+	// replayed to it
+	peerMembership.OnStateChange(replicator.PeerStateChanged)
+	var replica *leaderless.Replica
+	var coordinator *leaderless.Coordinator
+	if config.Leaderless.Enabled {
+		replica = leaderless.NewReplica(store, config.NodeID, config.Leaderless.VectorClocks, clock, logger)
+		coordinator = leaderless.NewCoordinator(config.Leaderless, nodeData, replica, clusterClient, clock, logger)
+	}
+	return &WorkerNodeService{
+		NodeConfig:        nodeConfig,
+		NodeData:          nodeData,
+		Clock:             clock,
+		ClusterClient:     clusterClient,
+		Storage:           store,
+		Membership:        peerMembership,
+		Lease:             readLease,
+		Sessions:          sessions,
+		SnapshotSender:    snapshotSender,
+		Snapshots:         snapshots,
+		Replicator:        replicator,
+		Replication:       replication.NewReceiver(store, sessions, snapshots, repairs, logger),
+		Hints:             hintStore,
+		Leadership:        leadership.NewTransferer(nodeData, readLease, snapshotSender, logger),
+		AntiEntropy:       antientropy.NewRepairer(config.AntiEntropy, store, readLease, nodeData, logger),
+		Repairs:           repairs,
+		Replica:           replica,
+		Leaderless:        coordinator,
+		RegistryAddress:   config.RegistryAddress,
+		HeartbeatInterval: config.HeartbeatInterval,
+		logger:            logger,
+		drainRequested:    make(chan struct{}),
+	}, nil
 }
 
-func (nodeService *WorkerNodeService) BootStrapControlPlaneServer(ready chan bool) {
+func (nodeService *WorkerNodeService) BootStrapControlPlaneServer() error {
 	nodeService.logger.Info("Bootstrapping control plane server")
-	defer func() {
-		if r := recover(); r != nil {
-			nodeService.logger.Error("Control Plane server panicked", "error", r)
-			ready <- false
-		}
-	}()
-
-	ready <- true
-	servers.StartNodeControlPlaneServer(
+	controlPlaneServer, err := servers.StartNodeControlPlaneServer(
 		":"+nodeService.NodeConfig.NodeControlPort,
 		nodeService.logger,
+		nodeService.Clock,
 		nodeService.ClusterClient,
-		nodeService.NodeData)
+		nodeService.NodeData,
+		nodeService.Storage,
+		nodeService.Membership,
+		nodeService.Lease,
+		nodeService.Sessions,
+		nodeService.Snapshots,
+		nodeService.Replication,
+		nodeService.Leadership,
+		nodeService.Repairs,
+		nodeService.Hints,
+		nodeService.Replica)
+	if err != nil {
+		return err
+	}
+	nodeService.controlPlaneServer = controlPlaneServer
+	return nil
 }
 
-func (nodeService *WorkerNodeService) BootStrapDataPlaneServer(ready chan bool) {
+func (nodeService *WorkerNodeService) BootStrapDataPlaneServer() error {
 	nodeService.logger.Info("Bootstrapping data plane server")
-	defer func() {
-		if r := recover(); r != nil {
-			nodeService.logger.Error("Control Plane server panicked", "error", r)
-			ready <- false
-		}
-	}()
-
-	ready <- true
-
-	servers.StartNodeDataPlaneServer(
+	dataPlaneServer, err := servers.StartNodeDataPlaneServer(
 		":"+nodeService.NodeConfig.NodeDataPort,
-		nodeService.logger)
+		nodeService.logger,
+		nodeService.Clock,
+		nodeService.Storage,
+		nodeService.ClusterClient,
+		nodeService.NodeData,
+		nodeService.Lease,
+		nodeService.Sessions,
+		nodeService.Replicator,
+		nodeService.Leaderless)
+	if err != nil {
+		return err
+	}
+	nodeService.dataPlaneServer = dataPlaneServer
+	return nil
 }
 
-func (nodeService *WorkerNodeService) BootStrapHeartBeat() {
+func (nodeService *WorkerNodeService) BootStrapHeartBeat(ctx context.Context) {
 	defer func() {
 		if r := recover(); r != nil {
 			nodeService.logger.Error("Heartbeat service panicked", "error", r)
 		}
 	}()
 	nodeService.ClusterClient.SendRegularNodeHeartBeat(
-		nodeService.NodeData)
+		ctx,
+		nodeService.HeartbeatInterval,
+		nodeService.NodeData,
+		nodeService.Storage,
+		nodeService.requestDrain,
+		nodeService.Membership.SetRole,
+		nodeService.Lease.SteppedDown,
+		nodeService.AntiEntropy.Stats)
+}
+
+func (nodeService *WorkerNodeService) requestDrain() {
+	nodeService.drainOnce.Do(func() {
+		close(nodeService.drainRequested)
+	})
 }
-func (nodeService *WorkerNodeService) BootstrapWorkerNode() {
+
+// BootstrapWorkerNode registers the node, starts its servers and blocks until
+// ctx is done or the registry asks the node to drain, after which the node is
+// shut down
+func (nodeService *WorkerNodeService) BootstrapWorkerNode(ctx context.Context, shutdownTimeout time.Duration) error {
+	// The background goroutines are stopped before Shutdown closes what they
+	// use, a drain does not cancel the caller's ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Registering with registry
 	nodeService.logger.Info("Bootstrapping the worker node")
 	nodeService.logger.Info("Registering with the registry server")
@@ -87,35 +230,179 @@ func (nodeService *WorkerNodeService) BootstrapWorkerNode() {
 		nodeService.NodeData)
 	if err != nil {
 		nodeService.logger.Error("Error in registering node with the registry")
-		return
+		return errors.Join(err, nodeService.ClusterClient.Close())
 	}
 	nodeService.logger.Info("Successfully registered with registry")
+	// The registry keeps a node it promoted earlier a voter
+	nodeService.Membership.SetRole(nodeService.NodeData.NodeDetails.Role)
+
+	// The registry's node list only seeds the membership, gossip keeps it
+	// up to date from here on
+	nodeService.Membership.Seed()
+
+	// The control plane has to be up before announcing the node so peers
+	// can probe it right away
+	if err := nodeService.BootStrapControlPlaneServer(); err != nil {
+		nodeService.logger.Error("Error in bootstraping control plane server")
+		return errors.Join(err, nodeService.Shutdown(shutdownTimeout))
+	}
 
 	nodeService.logger.Info("Registering Node with peers")
-	errRegisteringWithPeers := nodeService.ClusterClient.RegisterNodeWithPeers(nodeService.NodeData)
-	if errRegisteringWithPeers != nil {
-		nodeService.logger.Error("failed to register service with peers")
-		return
+	nodeService.ClusterClient.RegisterNodeWithPeers(nodeService.NodeData, nodeService.Membership.Incarnation())
+
+	// Setting data plane, heartbeat and gossip
+	if err := nodeService.BootStrapDataPlaneServer(); err != nil {
+		nodeService.logger.Error("Error in bootstraping data plane server")
+		return errors.Join(err, nodeService.Shutdown(shutdownTimeout))
+	}
+	nodeService.runInBackground(func() { nodeService.BootStrapHeartBeat(ctx) })
+	nodeService.runInBackground(func() { nodeService.Membership.Run(ctx, nodeService.ClusterClient) })
+	nodeService.runInBackground(func() { nodeService.Lease.Run(ctx, nodeService.ClusterClient) })
+	// Snapshots and anti-entropy copy the primary's keys to its peers, in
+	// leaderless mode no node holds every key
+	if nodeService.Leaderless == nil {
+		nodeService.runInBackground(func() { nodeService.SnapshotSender.Run(ctx, nodeService.ClusterClient) })
+		nodeService.runInBackground(func() { nodeService.AntiEntropy.Run(ctx, nodeService.ClusterClient) })
 	}
-	nodeService.logger.Info("Successfully registerd with peers")
 
-	// Setting control plane, data plane and heartbeat
-	controlPlanChannel := make(chan bool, 1)
-	dataPlaneChannel := make(chan bool, 1)
+	nodeService.logger.Info("All service started successfully")
 
-	go nodeService.BootStrapControlPlaneServer(controlPlanChannel)
-	go nodeService.BootStrapDataPlaneServer(dataPlaneChannel)
-	go nodeService.BootStrapHeartBeat()
+	drainRequested := nodeService.drainRequested
+	var retryHandOff <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			nodeService.logger.Info("Shutdown requested")
+			nodeService.stopBackground(cancel, shutdownTimeout)
+			return nodeService.Shutdown(shutdownTimeout)
+		case <-drainRequested:
+			nodeService.logger.Info("Drain requested")
+			drainRequested = nil
+		case <-retryHandOff:
+		}
+
+		// The node keeps serving until its data is safe on the peers
+		if err := nodeService.handOffData(); err != nil {
+			nodeService.logger.Error("Failed to hand data off to peers, retrying", "error", err)
+			retryHandOff = time.After(handOffRetryInterval)
+			continue
+		}
+		nodeService.stopBackground(cancel, shutdownTimeout)
+		return nodeService.Shutdown(shutdownTimeout)
+	}
+}
+
+func (nodeService *WorkerNodeService) runInBackground(run func()) {
+	nodeService.background.Add(1)
+	go func() {
+		defer nodeService.background.Done()
+		run()
+	}()
+}
 
-	var bootStrapCPStatus bool = <-controlPlanChannel
-	var bootStrapDPStatus bool = <-dataPlaneChannel
+// stopBackground cancels the background goroutines and waits up to timeout
+// for them to return
+func (nodeService *WorkerNodeService) stopBackground(cancel context.CancelFunc, timeout time.Duration) {
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		nodeService.background.Wait()
+		close(stopped)
+	}()
 
-	if !bootStrapCPStatus && !bootStrapDPStatus {
-		nodeService.logger.Error("Error in bootstraping servers")
-		return
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		nodeService.logger.Warn("Background services did not stop in time", "timeout", timeout)
 	}
+}
 
-	nodeService.logger.Info("All service started successfully")
+// handOffData copies every key to the peers. The registry stopped picking the
+// node as primary when it was drained, so once this succeeds nothing is owned
+// by the node anymore. In leaderless mode each key goes to the replicas it
+// has without the node.
+func (nodeService *WorkerNodeService) handOffData() error {
+	if nodeService.Leaderless != nil {
+		return nodeService.Leaderless.HandOff()
+	}
+	keyValues, err := nodeService.Storage.Scan("", "", 0)
+	if err != nil {
+		return err
+	}
+	if err := nodeService.ClusterClient.HandOffDataToPeers(nodeService.NodeData, keyValues); err != nil {
+		return err
+	}
+	nodeService.logger.Info("Handed data off to peers", "keys", len(keyValues))
+	return nil
+}
 
-	select {}
+// Shutdown deregisters the node, tells its peers it is leaving, drains both
+// servers and replication, closes the store and then the client connections.
+// Every step runs even if an earlier one failed and the errors are returned
+// together.
+func (nodeService *WorkerNodeService) Shutdown(timeout time.Duration) error {
+	var errs []error
+	nodeService.logger.Info("Shutting down the worker node")
+
+	if err := nodeService.ClusterClient.DeregisterNodeFromRegistry(nodeService.NodeData); err != nil {
+		errs = append(errs, err)
+	}
+	nodeService.ClusterClient.RemoveNodeFromPeers(nodeService.NodeData)
+
+	// Open watch streams never finish on their own, so they are ended before
+	// the data plane is drained
+	nodeService.Storage.CancelWatches()
+	if nodeService.dataPlaneServer != nil {
+		if err := stopServer(nodeService.dataPlaneServer, timeout); err != nil {
+			errs = append(errs, fmt.Errorf("data plane: %w", err))
+		}
+	}
+	// Replication streams stay open until told otherwise
+	nodeService.Replicator.Close()
+	nodeService.Replication.Close()
+	if err := nodeService.Hints.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if nodeService.controlPlaneServer != nil {
+		if err := stopServer(nodeService.controlPlaneServer, timeout); err != nil {
+			errs = append(errs, fmt.Errorf("control plane: %w", err))
+		}
+	}
+
+	// Nothing writes to the store anymore
+	if err := nodeService.Storage.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := nodeService.ClusterClient.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		nodeService.logger.Error("Worker node shut down with errors", "error", errors.Join(errs...))
+	} else {
+		nodeService.logger.Info("Worker node shut down")
+	}
+	return errors.Join(errs...)
+}
+
+// stopServer lets in flight RPCs finish and forcefully closes whatever is
+// still running after timeout
+func stopServer(grpcServer *grpc.Server, timeout time.Duration) error {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+		return nil
+	case <-timer.C:
+		grpcServer.Stop()
+		return fmt.Errorf("in flight requests did not finish within %s", timeout)
+	}
 }
@@ -0,0 +1,243 @@
+// Package session gives clients read-your-writes across nodes. Every node
+// numbers the writes it accepts, starting over from a new epoch whenever it
+// restarts, and sends the number along when it replicates the write. Each
+// node tracks per writer the highest number up to which it applied every
+// write, so a read carrying the position of an earlier write can wait until
+// the node serving it caught up to that position.
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	pbControlPlane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	pbDataPlane "github.com/Vahsek/distrokv/pkg/node/dataplane"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrInvalidToken = errors.New("Invalid session token")
+	ErrNotCaughtUp  = errors.New("Node has not applied the session's writes")
+)
+
+// DefaultMaxWait bounds how long a read waits for the node to catch up
+const DefaultMaxWait = time.Second
+
+// maxPendingWrites bounds the writes remembered past a gap in the sequence
+// of a writer. A write that never arrived keeps the node behind that writer
+// for good, reads then fall back to a node that has the write.
+const maxPendingWrites = 1 << 16
+
+type Position struct {
+	NodeID   string
+	Epoch    int64
+	Sequence int64
+}
+
+type writerProgress struct {
+	epoch int64
+	// applied is the highest sequence up to which every write was applied
+	applied int64
+	// pending holds the writes applied past a gap
+	pending map[int64]bool
+}
+
+type Tracker struct {
+	nodeID   string
+	epoch    int64
+	sequence int64
+	writers  map[string]*writerProgress
+	// advanced is closed and replaced whenever a writer's progress moves
+	advanced chan struct{}
+	maxWait  time.Duration
+	mu       sync.Mutex
+	logger   slog.Logger
+}
+
+func NewTracker(nodeID string, maxWait time.Duration, logger slog.Logger) *Tracker {
+	return &Tracker{
+		nodeID:   nodeID,
+		epoch:    time.Now().UnixNano(),
+		writers:  make(map[string]*writerProgress),
+		advanced: make(chan struct{}),
+		maxWait:  maxWait,
+		logger:   logger,
+	}
+}
+
+// Next numbers a write this node just applied. Numbers are handed out after
+// the write is applied, so every lower number is applied locally as well.
+func (tracker *Tracker) Next() Position {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	tracker.sequence++
+	return Position{NodeID: tracker.nodeID, Epoch: tracker.epoch, Sequence: tracker.sequence}
+}
+
+// Current returns the position of the last write this node numbered
+func (tracker *Tracker) Current() Position {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	return Position{NodeID: tracker.nodeID, Epoch: tracker.epoch, Sequence: tracker.sequence}
+}
+
+// AppliedThrough records that every write of a writer up to position was
+// applied at once, as happens when a snapshot is installed
+func (tracker *Tracker) AppliedThrough(position *pbControlPlane.WritePosition) {
+	if position == nil || position.NodeId == "" || position.NodeId == tracker.nodeID {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	writer, exists := tracker.writers[position.NodeId]
+	if !exists || position.Epoch > writer.epoch {
+		writer = &writerProgress{epoch: position.Epoch, pending: make(map[int64]bool)}
+		tracker.writers[position.NodeId] = writer
+	}
+	if position.Epoch < writer.epoch || position.Sequence <= writer.applied {
+		return
+	}
+	writer.applied = position.Sequence
+	for sequence := range writer.pending {
+		if sequence <= writer.applied {
+			delete(writer.pending, sequence)
+		}
+	}
+	tracker.advance(writer)
+}
+
+// Applied records a replicated write. Writes from an older epoch of the
+// writer are ignored and a newer epoch starts its progress over.
+func (tracker *Tracker) Applied(position *pbControlPlane.WritePosition) {
+	if position == nil || position.NodeId == "" {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	writer, exists := tracker.writers[position.NodeId]
+	if !exists || position.Epoch > writer.epoch {
+		writer = &writerProgress{epoch: position.Epoch, pending: make(map[int64]bool)}
+		tracker.writers[position.NodeId] = writer
+	}
+	if position.Epoch < writer.epoch || position.Sequence <= writer.applied {
+		return
+	}
+
+	if position.Sequence != writer.applied+1 {
+		if len(writer.pending) < maxPendingWrites {
+			writer.pending[position.Sequence] = true
+		}
+		return
+	}
+	writer.applied = position.Sequence
+	tracker.advance(writer)
+}
+
+// advance moves the writer past the pending writes that are now contiguous
+// and wakes the waiting reads. It expects the caller to hold the lock.
+func (tracker *Tracker) advance(writer *writerProgress) {
+	for writer.pending[writer.applied+1] {
+		delete(writer.pending, writer.applied+1)
+		writer.applied++
+	}
+	close(tracker.advanced)
+	tracker.advanced = make(chan struct{})
+}
+
+// WaitFor returns nil once this node applied every write up to the position
+// in token. It waits at most the tracker's max wait.
+func (tracker *Tracker) WaitFor(ctx context.Context, token string) error {
+	position, err := DecodeToken(token)
+	if err != nil {
+		return err
+	}
+	timer := time.NewTimer(tracker.maxWait)
+	defer timer.Stop()
+
+	for {
+		tracker.mu.Lock()
+		caughtUp, err := tracker.caughtUp(position)
+		advanced := tracker.advanced
+		tracker.mu.Unlock()
+		if caughtUp || err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return fmt.Errorf("%w: still behind write %d of node %s", ErrNotCaughtUp, position.Sequence, position.NodeID)
+		case <-advanced:
+		}
+	}
+}
+
+// caughtUp expects the caller to hold the lock
+func (tracker *Tracker) caughtUp(position Position) (bool, error) {
+	if position.NodeID == tracker.nodeID {
+		if position.Epoch != tracker.epoch {
+			return false, fmt.Errorf("%w: the node restarted since the write", ErrNotCaughtUp)
+		}
+		return position.Sequence <= tracker.sequence, nil
+	}
+	writer, exists := tracker.writers[position.NodeID]
+	if !exists || writer.epoch < position.Epoch {
+		return false, nil
+	}
+	if writer.epoch > position.Epoch {
+		// Progress of the writer's earlier run is no longer known
+		return false, fmt.Errorf("%w: node %s restarted since the write", ErrNotCaughtUp, position.NodeID)
+	}
+	return position.Sequence <= writer.applied, nil
+}
+
+// ToWritePosition converts a position for replication
+func ToWritePosition(position Position) *pbControlPlane.WritePosition {
+	return &pbControlPlane.WritePosition{
+		NodeId:   position.NodeID,
+		Epoch:    position.Epoch,
+		Sequence: position.Sequence,
+	}
+}
+
+// EncodeToken returns the opaque token clients pass back on reads
+func EncodeToken(position Position) string {
+	encoded, err := proto.Marshal(&pbDataPlane.SessionToken{
+		NodeId:   position.NodeID,
+		Epoch:    position.Epoch,
+		Sequence: position.Sequence,
+	})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func DecodeToken(token string) (Position, error) {
+	encoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Position{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	var sessionToken pbDataPlane.SessionToken
+	if err := proto.Unmarshal(encoded, &sessionToken); err != nil {
+		return Position{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if sessionToken.NodeId == "" {
+		return Position{}, fmt.Errorf("%w: missing node id", ErrInvalidToken)
+	}
+	return Position{
+		NodeID:   sessionToken.NodeId,
+		Epoch:    sessionToken.Epoch,
+		Sequence: sessionToken.Sequence,
+	}, nil
+}
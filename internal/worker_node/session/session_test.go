@@ -0,0 +1,125 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	pbControlPlane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+)
+
+const testMaxWait = 50 * time.Millisecond
+
+func testTracker() *Tracker {
+	return NewTracker("reader", testMaxWait, *slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func writePosition(epoch int64, sequence int64) *pbControlPlane.WritePosition {
+	return &pbControlPlane.WritePosition{NodeId: "writer", Epoch: epoch, Sequence: sequence}
+}
+
+func token(epoch int64, sequence int64) string {
+	return EncodeToken(Position{NodeID: "writer", Epoch: epoch, Sequence: sequence})
+}
+
+func TestGapHoldsBackLaterWrites(t *testing.T) {
+	tracker := testTracker()
+	tracker.Applied(writePosition(1, 1))
+	tracker.Applied(writePosition(1, 3))
+	tracker.Applied(writePosition(1, 4))
+
+	if err := tracker.WaitFor(context.Background(), token(1, 1)); err != nil {
+		t.Fatalf("expected the first write to be caught up, got %v", err)
+	}
+	if err := tracker.WaitFor(context.Background(), token(1, 3)); !errors.Is(err, ErrNotCaughtUp) {
+		t.Fatalf("expected a write past the gap to wait for it, got %v", err)
+	}
+
+	tracker.Applied(writePosition(1, 2))
+	if err := tracker.WaitFor(context.Background(), token(1, 4)); err != nil {
+		t.Fatalf("expected the filled gap to release the pending writes, got %v", err)
+	}
+	if err := tracker.WaitFor(context.Background(), token(1, 5)); !errors.Is(err, ErrNotCaughtUp) {
+		t.Fatalf("expected a write not applied yet to wait, got %v", err)
+	}
+}
+
+func TestWaitForWakesWhenGapFills(t *testing.T) {
+	tracker := testTracker()
+	tracker.maxWait = time.Second
+	tracker.Applied(writePosition(1, 2))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tracker.WaitFor(context.Background(), token(1, 2))
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("read returned before the gap was filled: %v", err)
+	case <-time.After(testMaxWait):
+	}
+	tracker.Applied(writePosition(1, 1))
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected the read to be caught up, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("read was not woken when the gap was filled")
+	}
+}
+
+func TestAppliedThroughFillsGaps(t *testing.T) {
+	tracker := testTracker()
+	tracker.Applied(writePosition(1, 5))
+	tracker.Applied(writePosition(1, 7))
+
+	// A snapshot covers every write up to 5, 6 is still missing
+	tracker.AppliedThrough(writePosition(1, 5))
+	if err := tracker.WaitFor(context.Background(), token(1, 5)); err != nil {
+		t.Fatalf("expected the snapshot to catch the node up, got %v", err)
+	}
+	if err := tracker.WaitFor(context.Background(), token(1, 7)); !errors.Is(err, ErrNotCaughtUp) {
+		t.Fatalf("expected the write after the remaining gap to wait, got %v", err)
+	}
+	tracker.Applied(writePosition(1, 6))
+	if err := tracker.WaitFor(context.Background(), token(1, 7)); err != nil {
+		t.Fatalf("expected the pending write to be released, got %v", err)
+	}
+	if pending := len(tracker.writers["writer"].pending); pending != 0 {
+		t.Fatalf("expected no pending writes to be left, got %d", pending)
+	}
+}
+
+func TestNewEpochStartsOver(t *testing.T) {
+	tracker := testTracker()
+	tracker.Applied(writePosition(1, 1))
+	tracker.Applied(writePosition(1, 3))
+
+	// The writer restarted, its earlier gap can no longer be filled
+	tracker.Applied(writePosition(2, 1))
+	if err := tracker.WaitFor(context.Background(), token(2, 1)); err != nil {
+		t.Fatalf("expected the write of the new epoch to be caught up, got %v", err)
+	}
+	if err := tracker.WaitFor(context.Background(), token(1, 1)); !errors.Is(err, ErrNotCaughtUp) {
+		t.Fatalf("expected a token of the earlier epoch to fail, got %v", err)
+	}
+	// Writes of the earlier epoch arriving late are ignored
+	tracker.Applied(writePosition(1, 2))
+	if writer := tracker.writers["writer"]; writer.epoch != 2 || writer.applied != 1 {
+		t.Fatalf("expected a late write of the earlier epoch to be ignored, got epoch %d applied %d", writer.epoch, writer.applied)
+	}
+}
+
+func TestWaitForRejectsInvalidTokens(t *testing.T) {
+	tracker := testTracker()
+	if err := tracker.WaitFor(context.Background(), "not base64!"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+	if err := tracker.WaitFor(context.Background(), EncodeToken(Position{Epoch: 1, Sequence: 1})); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected a token without a node id to be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,363 @@
+// Package snapshot brings peers that missed writes back in sync. The primary
+// copies its whole store into a snapshot and streams it to the peer in
+// checksummed chunks. The peer keeps what it received across streams, so an
+// interrupted transfer resumes at the offset the peer reports instead of
+// starting over. Writes keep being replicated to the peer while the transfer
+// runs, and keys the peer applied a write for in the meantime keep their
+// value when the snapshot is installed since the write is newer.
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/storage"
+	"github.com/Vahsek/distrokv/internal/worker_node/data"
+	"github.com/Vahsek/distrokv/internal/worker_node/hints"
+	"github.com/Vahsek/distrokv/internal/worker_node/lease"
+	"github.com/Vahsek/distrokv/internal/worker_node/session"
+	pb "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ChunkSize is the most snapshot bytes sent in a single message
+	ChunkSize = 64 << 10
+	// DefaultInterval is how often the primary looks for peers to catch up
+	DefaultInterval = 5 * time.Second
+	// maxRounds bounds the streams opened for a single snapshot
+	maxRounds = 5
+	// streamTimeout bounds a single stream
+	streamTimeout = 30 * time.Second
+)
+
+var ErrTransferFailed = errors.New("Failed to transfer snapshot")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Transport opens snapshot streams to the control plane of a peer
+type Transport interface {
+	InstallSnapshot(ctx context.Context, address string) (grpc.ClientStreamingClient[pb.SnapshotChunk, pb.InstallSnapshotResponse], error)
+}
+
+type Sender struct {
+	store    *storage.KeyValueStore
+	sessions *session.Tracker
+	lease    *lease.Lease
+	hints    *hints.Store
+	nodeData *data.NodeData
+	interval time.Duration
+	logger   slog.Logger
+}
+
+func NewSender(store *storage.KeyValueStore, sessions *session.Tracker, readLease *lease.Lease, hintStore *hints.Store, nodeData *data.NodeData, logger slog.Logger) *Sender {
+	return &Sender{
+		store:    store,
+		sessions: sessions,
+		lease:    readLease,
+		hints:    hintStore,
+		nodeData: nodeData,
+		interval: DefaultInterval,
+		logger:   logger,
+	}
+}
+
+// Run sends a snapshot to every peer that is out of sync every interval while
+// this node is the primary. Peers whose missed writes are all kept as hints
+// are left to the replicator.
+func (sender *Sender) Run(ctx context.Context, transport Transport) {
+	sender.logger.Info("Starting snapshot sender")
+	ticker := time.NewTicker(sender.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			sender.logger.Info("Stopping snapshot sender")
+			return
+		case <-ticker.C:
+		}
+		if !sender.lease.IsPrimary() {
+			continue
+		}
+		for _, nodeID := range sender.lease.OutOfSyncPeers() {
+			if sender.hints.Has(nodeID) {
+				continue
+			}
+			if err := sender.SendTo(ctx, transport, nodeID); err != nil && ctx.Err() == nil {
+				sender.logger.Warn("Failed to send snapshot to peer", "nodeId", nodeID, "error", err)
+			}
+		}
+	}
+}
+
+// SendTo streams a snapshot of the store to a peer and marks it in sync once
+// the peer installed it
+func (sender *Sender) SendTo(ctx context.Context, transport Transport, nodeID string) error {
+	sender.nodeData.Mu.RLock()
+	peer, exists := sender.nodeData.PeerNodes[nodeID]
+	sender.nodeData.Mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("Peer %s is not known to this node", nodeID)
+	}
+
+	// Hints replayed after the snapshot would undo newer writes
+	sender.hints.Drop(nodeID)
+
+	// Both are taken before the scan, every write they cover was applied
+	// locally already and is in the snapshot
+	syncPoint := sender.lease.SyncPoint()
+	position := sender.sessions.Current()
+	keyValues, err := sender.store.Scan("", "", 0)
+	if err != nil {
+		return err
+	}
+	entries := make([]*pb.SnapshotEntry, len(keyValues))
+	for i, keyValue := range keyValues {
+		entries[i] = &pb.SnapshotEntry{Key: keyValue.Key, Value: keyValue.Value, Timestamp: keyValue.Timestamp}
+	}
+	encoded, err := proto.Marshal(&pb.Snapshot{
+		Entries:  entries,
+		Position: session.ToWritePosition(position),
+	})
+	if err != nil {
+		return err
+	}
+
+	snapshotID := fmt.Sprintf("%s-%d", sender.nodeData.NodeDetails.NodeID, time.Now().UnixNano())
+	address := peer.NodeIP + ":" + peer.NodeControlPort
+	sender.logger.Info("Sending snapshot to peer", "nodeId", nodeID, "snapshotId", snapshotID, "keys", len(entries), "bytes", len(encoded))
+	if err := sender.transfer(ctx, transport, address, snapshotID, encoded); err != nil {
+		return err
+	}
+	sender.logger.Info("Peer installed snapshot", "nodeId", nodeID, "snapshotId", snapshotID)
+	sender.lease.MarkInSync(nodeID, syncPoint)
+	return nil
+}
+
+// transfer streams encoded starting over at the offset the peer asks for
+// until the peer installed it or maxRounds streams were opened
+func (sender *Sender) transfer(ctx context.Context, transport Transport, address, snapshotID string, encoded []byte) error {
+	totalChecksum := crc32.Checksum(encoded, castagnoli)
+	var offset int64
+	var lastErr error
+	for round := 0; round < maxRounds; round++ {
+		response, err := sender.stream(ctx, transport, address, snapshotID, encoded, totalChecksum, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// The peer reports how much it kept when the next stream
+			// starts at an offset it does not expect
+			sender.logger.Warn("Snapshot stream failed, resuming", "address", address, "offset", offset, "error", err)
+			lastErr = err
+			continue
+		}
+		if response.Installed {
+			return nil
+		}
+		if response.NextOffset < 0 || response.NextOffset > int64(len(encoded)) {
+			offset = 0
+		} else {
+			offset = response.NextOffset
+		}
+		lastErr = fmt.Errorf("peer asked to resume at offset %d", response.NextOffset)
+	}
+	return fmt.Errorf("%w after %d streams: %v", ErrTransferFailed, maxRounds, lastErr)
+}
+
+// stream sends encoded from offset in a single stream. A peer that does not
+// expect a chunk answers right away, so sending stops once it closed the
+// stream.
+func (sender *Sender) stream(ctx context.Context, transport Transport, address, snapshotID string, encoded []byte, totalChecksum uint32, offset int64) (*pb.InstallSnapshotResponse, error) {
+	streamCtx, cancel := context.WithTimeout(ctx, streamTimeout)
+	defer cancel()
+
+	stream, err := transport.InstallSnapshot(streamCtx, address)
+	if err != nil {
+		return nil, err
+	}
+	totalSize := int64(len(encoded))
+	for {
+		end := min(offset+ChunkSize, totalSize)
+		chunk := encoded[offset:end]
+		err := stream.Send(&pb.SnapshotChunk{
+			SnapshotId:    snapshotID,
+			Offset:        offset,
+			Data:          chunk,
+			Checksum:      crc32.Checksum(chunk, castagnoli),
+			TotalSize:     totalSize,
+			TotalChecksum: totalChecksum,
+		})
+		if errors.Is(err, io.EOF) {
+			// The peer answered early, CloseAndRecv returns its answer
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		offset = end
+		if offset >= totalSize {
+			break
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+type partialSnapshot struct {
+	id            string
+	totalSize     int64
+	totalChecksum uint32
+	data          []byte
+	// touched holds the keys written by replication since the transfer
+	// started
+	touched map[string]bool
+}
+
+// Receiver installs snapshots streamed by the primary
+type Receiver struct {
+	store    *storage.KeyValueStore
+	sessions *session.Tracker
+	// partial is the snapshot being received, it outlives a stream so the
+	// next one can resume it
+	partial *partialSnapshot
+	mu      sync.Mutex
+	logger  slog.Logger
+}
+
+func NewReceiver(store *storage.KeyValueStore, sessions *session.Tracker, logger slog.Logger) *Receiver {
+	return &Receiver{
+		store:    store,
+		sessions: sessions,
+		logger:   logger,
+	}
+}
+
+// Touched records a replicated write to key. It has to be called before the
+// write is applied so a snapshot installed concurrently cannot overwrite it.
+func (receiver *Receiver) Touched(key string) {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if receiver.partial != nil {
+		receiver.partial.touched[key] = true
+	}
+}
+
+// HandleStream receives chunks until the snapshot is complete and installs
+// it. It answers with the offset it expects as soon as a chunk does not
+// continue the snapshot it has.
+func (receiver *Receiver) HandleStream(stream grpc.ClientStreamingServer[pb.SnapshotChunk, pb.InstallSnapshotResponse]) error {
+	var snapshotID string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			// The sender stopped before the snapshot was complete
+			return stream.SendAndClose(&pb.InstallSnapshotResponse{NextOffset: receiver.received(snapshotID)})
+		}
+		if err != nil {
+			return err
+		}
+		snapshotID = chunk.SnapshotId
+
+		nextOffset, accepted := receiver.accept(chunk)
+		if !accepted {
+			receiver.logger.Warn("Rejecting snapshot chunk", "snapshotId", chunk.SnapshotId, "offset", chunk.Offset, "nextOffset", nextOffset)
+			return stream.SendAndClose(&pb.InstallSnapshotResponse{NextOffset: nextOffset})
+		}
+		if nextOffset < chunk.TotalSize {
+			continue
+		}
+		if err := receiver.install(chunk.SnapshotId); err != nil {
+			receiver.logger.Error("Failed to install snapshot", "snapshotId", chunk.SnapshotId, "error", err)
+			return stream.SendAndClose(&pb.InstallSnapshotResponse{NextOffset: 0})
+		}
+		return stream.SendAndClose(&pb.InstallSnapshotResponse{Installed: true, NextOffset: nextOffset})
+	}
+}
+
+// accept appends chunk to the partial snapshot and returns the offset of the
+// next chunk along with whether chunk was appended
+func (receiver *Receiver) accept(chunk *pb.SnapshotChunk) (int64, bool) {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	partial := receiver.partial
+	if chunk.Offset == 0 && (partial == nil || partial.id != chunk.SnapshotId) {
+		// A new snapshot replaces whatever was received before
+		partial = &partialSnapshot{
+			id:            chunk.SnapshotId,
+			totalSize:     chunk.TotalSize,
+			totalChecksum: chunk.TotalChecksum,
+			data:          make([]byte, 0, chunk.TotalSize),
+			touched:       make(map[string]bool),
+		}
+		receiver.partial = partial
+	}
+	if partial == nil || partial.id != chunk.SnapshotId {
+		return 0, false
+	}
+	received := int64(len(partial.data))
+	if chunk.Offset != received {
+		return received, false
+	}
+	if crc32.Checksum(chunk.Data, castagnoli) != chunk.Checksum {
+		return received, false
+	}
+	if received+int64(len(chunk.Data)) > partial.totalSize {
+		receiver.partial = nil
+		return 0, false
+	}
+	partial.data = append(partial.data, chunk.Data...)
+	return int64(len(partial.data)), true
+}
+
+// received returns how much of a snapshot was kept
+func (receiver *Receiver) received(snapshotID string) int64 {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if receiver.partial == nil || receiver.partial.id != snapshotID {
+		return 0
+	}
+	return int64(len(receiver.partial.data))
+}
+
+// install verifies the complete snapshot and replaces the store with it. The
+// lock is held throughout so no write slips in between reading the touched
+// keys and restoring the store.
+func (receiver *Receiver) install(snapshotID string) error {
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	partial := receiver.partial
+	if partial == nil || partial.id != snapshotID {
+		return fmt.Errorf("Snapshot %s is no longer being received", snapshotID)
+	}
+	// Whatever happens the snapshot has to be sent again from the start
+	receiver.partial = nil
+	if checksum := crc32.Checksum(partial.data, castagnoli); checksum != partial.totalChecksum {
+		return fmt.Errorf("Snapshot checksum %08x does not match %08x", checksum, partial.totalChecksum)
+	}
+	var snapshot pb.Snapshot
+	if err := proto.Unmarshal(partial.data, &snapshot); err != nil {
+		return err
+	}
+	keyValues := make([]storage.KeyValue, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		keyValues[i] = storage.KeyValue{Key: entry.Key, Value: entry.Value, Timestamp: entry.Timestamp}
+	}
+	if err := receiver.store.Restore(keyValues, partial.touched); err != nil {
+		return err
+	}
+	receiver.sessions.AppliedThrough(snapshot.Position)
+	receiver.logger.Info("Installed snapshot", "snapshotId", snapshotID, "keys", len(keyValues), "preserved", len(partial.touched))
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package client is the Go SDK for distrokv. A Client bootstraps from the
+// registry, keeps a cached view of the cluster topology and routes every
+// request to the owner of the range holding the key, retrying transient
+// failures with exponential backoff.
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	clientcommon "github.com/Vahsek/distrokv/internal/common/client_common"
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	pb_controlplane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	pb_dataplane "github.com/Vahsek/distrokv/pkg/node/dataplane"
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	DefaultDialTimeout             = 5 * time.Second
+	DefaultRequestTimeout          = 5 * time.Second
+	DefaultMaxRetries              = 5
+	DefaultInitialBackoff          = 50 * time.Millisecond
+	DefaultMaxBackoff              = 2 * time.Second
+	DefaultTopologyRefreshInterval = 30 * time.Second
+)
+
+type Config struct {
+	// RegistryAddress is the host:port of the registry the client bootstraps from
+	RegistryAddress string
+	DialTimeout     time.Duration
+	RequestTimeout  time.Duration
+	// MaxRetries is the number of times a request is retried after a transient failure
+	MaxRetries              int
+	InitialBackoff          time.Duration
+	MaxBackoff              time.Duration
+	TopologyRefreshInterval time.Duration
+	// MaxClockSkew is how far ahead of the local wall clock a timestamp
+	// answered by a node may be for the client to forward it
+	MaxClockSkew time.Duration
+	// DialOptions are added to every connection. Connections are insecure by default.
+	DialOptions []grpc.DialOption
+	Logger      *slog.Logger
+}
+
+type Client struct {
+	config    Config
+	factory   *clientcommon.ClientFactory
+	topology  topologyCache
+	causality causality
+	logger    slog.Logger
+}
+
+// New creates a client and loads the cluster topology from the registry
+func New(ctx context.Context, config Config) (*Client, error) {
+	if config.RegistryAddress == "" {
+		return nil, fmt.Errorf("registry address is required")
+	}
+	applyConfigDefaults(&config)
+
+	client := &Client{
+		config:    config,
+		factory:   clientcommon.InitializeClientFactory(*config.Logger),
+		causality: causality{maxSkew: config.MaxClockSkew, logger: *config.Logger},
+		logger:    *config.Logger,
+	}
+	if err := client.refreshTopology(ctx); err != nil {
+		client.factory.Close()
+		return nil, fmt.Errorf("failed to load cluster topology: %w", err)
+	}
+	return client, nil
+}
+
+func applyConfigDefaults(config *Config) {
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = DefaultDialTimeout
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = DefaultRequestTimeout
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = DefaultInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	if config.TopologyRefreshInterval <= 0 {
+		config.TopologyRefreshInterval = DefaultTopologyRefreshInterval
+	}
+	if config.MaxClockSkew <= 0 {
+		config.MaxClockSkew = hlc.DefaultMaxSkew
+	}
+	if config.Logger == nil {
+		config.Logger = slog.New(slog.DiscardHandler)
+	}
+}
+
+// Close releases every connection held by the client
+func (client *Client) Close() error {
+	return client.factory.Close()
+}
+
+func (client *Client) dialOptions() []grpc.DialOption {
+	options := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	options = append(options, client.causality.dialOptions()...)
+	return append(options, client.config.DialOptions...)
+}
+
+func (client *Client) registryClient(ctx context.Context) (pb_registry.RegistryServiceClient, error) {
+	builder := clientcommon.NewGrpcBuilder(client.config.RegistryAddress, client.logger).
+		SetTimeout(client.config.DialTimeout).
+		SetGrpcDialOptions(client.dialOptions()...)
+	registryClientConstructor := func(conn *grpc.ClientConn) pb_registry.RegistryServiceClient {
+		return pb_registry.NewRegistryServiceClient(conn)
+	}
+	return clientcommon.GetClient(ctx, client.factory, *builder, registryClientConstructor)
+}
+
+func (client *Client) dataPlaneClient(ctx context.Context, address string) (pb_dataplane.NodeKeyValueServiceClient, error) {
+	builder := clientcommon.NewGrpcBuilder(address, client.logger).
+		SetTimeout(client.config.DialTimeout).
+		SetGrpcDialOptions(client.dialOptions()...)
+	dataPlaneClientConstructor := func(conn *grpc.ClientConn) pb_dataplane.NodeKeyValueServiceClient {
+		return pb_dataplane.NewNodeKeyValueServiceClient(conn)
+	}
+	return clientcommon.GetClient(ctx, client.factory, *builder, dataPlaneClientConstructor)
+}
+
+func (client *Client) controlPlaneClient(ctx context.Context, address string) (pb_controlplane.NodeControlPlaneServiceClient, error) {
+	builder := clientcommon.NewGrpcBuilder(address, client.logger).
+		SetTimeout(client.config.DialTimeout).
+		SetGrpcDialOptions(client.dialOptions()...)
+	controlPlaneClientConstructor := func(conn *grpc.ClientConn) pb_controlplane.NodeControlPlaneServiceClient {
+		return pb_controlplane.NewNodeControlPlaneServiceClient(conn)
+	}
+	return clientcommon.GetClient(ctx, client.factory, *builder, controlPlaneClientConstructor)
+}
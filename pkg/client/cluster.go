@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	pb_controlplane "github.com/Vahsek/distrokv/pkg/node/controlplane"
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+type Member struct {
+	ID          string
+	Hostname    string
+	IP          string
+	ControlPort string
+	DataPort    string
+	Leader      bool
+	Draining    bool
+	// Learner members receive every write but do not vote until promoted
+	Learner bool
+	// Health is healthy, suspect or dead as graded by the registry
+	Health string
+	// DivergentRanges and RepairedKeys count what the anti-entropy repairs
+	// run by the member found since it started
+	DivergentRanges int64
+	RepairedKeys    int64
+}
+
+// Configuration lists the voters by member id. While a change is in progress
+// it is joint, NewVoters holds the voters being moved to and every quorum
+// needs a majority of both.
+type Configuration struct {
+	Version   int64
+	Voters    []string
+	NewVoters []string
+	Joint     bool
+	// Acknowledged lists the members that applied this version
+	Acknowledged []string
+}
+
+type Range struct {
+	ID         int64
+	StartKey   string
+	EndKey     string
+	Generation int64
+}
+
+// Members returns every node registered with the registry. The topology is
+// re-read so the result reflects the registry at the time of the call.
+func (client *Client) Members(ctx context.Context) ([]Member, error) {
+	if err := client.refreshTopology(ctx); err != nil {
+		return nil, translateError(err)
+	}
+
+	client.topology.mu.RLock()
+	defer client.topology.mu.RUnlock()
+
+	members := make([]Member, 0, len(client.topology.nodes))
+	for _, node := range client.topology.nodes {
+		member := Member{
+			ID:          node.NodeId,
+			Hostname:    node.NodeHostname,
+			IP:          node.NodeIP,
+			ControlPort: node.NodeControlPort,
+			DataPort:    node.NodeDataPort,
+			Draining:    node.Draining,
+			Learner:     node.Role == pb_registry.NodeRole_LEARNER,
+			Health:      node.Health,
+		}
+		if node.AntiEntropy != nil {
+			member.DivergentRanges = node.AntiEntropy.DivergentRanges
+			member.RepairedKeys = node.AntiEntropy.RepairedKeys
+		}
+		leader := client.topology.leader
+		member.Leader = leader != nil && leader.NodeId == member.ID
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// Leader returns the node currently acting as the cluster primary
+func (client *Client) Leader(ctx context.Context) (*Member, error) {
+	if err := client.refreshTopology(ctx); err != nil {
+		return nil, translateError(err)
+	}
+
+	client.topology.mu.RLock()
+	defer client.topology.mu.RUnlock()
+
+	leader := client.topology.leader
+	if leader == nil {
+		return nil, fmt.Errorf("%w: the registry has no primary node", ErrUnavailable)
+	}
+	member := &Member{
+		ID:          leader.NodeId,
+		Hostname:    leader.Hostname,
+		IP:          leader.IpAddress,
+		ControlPort: leader.PortNumber,
+		DataPort:    leader.DataPlanePort,
+		Leader:      true,
+	}
+	for _, node := range client.topology.nodes {
+		if node.NodeId == leader.NodeId {
+			member.Health = node.Health
+		}
+	}
+	return member, nil
+}
+
+// Drain asks the registry to decommission a member. The member stops being
+// picked as leader right away, hands its data off to the other members and
+// then leaves the cluster on its own.
+func (client *Client) Drain(ctx context.Context, member Member) error {
+	registryClient, err := client.registryClient(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+	_, err = registryClient.DrainNode(ctx, &pb_registry.DrainNodeRequest{
+		NodeId:     member.ID,
+		Hostname:   member.Hostname,
+		IpAddress:  member.IP,
+		PortNumber: member.ControlPort,
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	client.invalidateTopology()
+	return nil
+}
+
+// PromoteLearner asks the registry to turn a learner into a voter. The member
+// then counts toward quorum and can be picked as leader.
+func (client *Client) PromoteLearner(ctx context.Context, member Member) error {
+	registryClient, err := client.registryClient(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+	_, err = registryClient.PromoteLearner(ctx, &pb_registry.PromoteLearnerRequest{
+		NodeId:     member.ID,
+		Hostname:   member.Hostname,
+		IpAddress:  member.IP,
+		PortNumber: member.ControlPort,
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	client.invalidateTopology()
+	return nil
+}
+
+// TransferLeadership asks the leader to hand its role to target, which has to
+// be a voter. The leader stops taking writes until the target took over.
+func (client *Client) TransferLeadership(ctx context.Context, target Member) error {
+	leader, err := client.Leader(ctx)
+	if err != nil {
+		return err
+	}
+	if leader.ID == target.ID {
+		return nil
+	}
+	controlPlaneClient, err := client.controlPlaneClient(ctx, leader.IP+":"+leader.ControlPort)
+	if err != nil {
+		return translateError(err)
+	}
+	_, err = controlPlaneClient.TransferLeadership(ctx, &pb_controlplane.TransferLeadershipRequest{
+		TargetNodeId: target.ID,
+	})
+	if err != nil {
+		return translateError(err)
+	}
+	client.invalidateTopology()
+	return nil
+}
+
+// Configuration returns the voter configuration the registry last published
+func (client *Client) Configuration(ctx context.Context) (*Configuration, error) {
+	registryClient, err := client.registryClient(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	response, err := registryClient.GetClusterConfiguration(ctx, &pb_registry.ClusterConfigurationRequest{})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	configuration := response.GetConfiguration()
+	return &Configuration{
+		Version:      configuration.GetVersion(),
+		Voters:       configuration.GetVoters(),
+		NewVoters:    configuration.GetNewVoters(),
+		Joint:        configuration.GetJoint(),
+		Acknowledged: response.Acknowledged,
+	}, nil
+}
+
+// Ranges returns the range descriptors currently published by the registry
+func (client *Client) Ranges(ctx context.Context) ([]Range, error) {
+	if err := client.refreshTopology(ctx); err != nil {
+		return nil, translateError(err)
+	}
+
+	client.topology.mu.RLock()
+	defer client.topology.mu.RUnlock()
+
+	ranges := make([]Range, 0, len(client.topology.ranges))
+	for _, rangeDescriptor := range client.topology.ranges {
+		ranges = append(ranges, Range{
+			ID:         rangeDescriptor.RangeId,
+			StartKey:   rangeDescriptor.StartKey,
+			EndKey:     rangeDescriptor.EndKey,
+			Generation: rangeDescriptor.Generation,
+		})
+	}
+	return ranges, nil
+}
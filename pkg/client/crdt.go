@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+
+	pb_dataplane "github.com/Vahsek/distrokv/pkg/node/dataplane"
+)
+
+type CRDTType int
+
+const (
+	// CRDTCounter is a counter concurrent increments and decrements add up in
+	CRDTCounter CRDTType = iota
+	// CRDTSet is a set in which an add concurrent with a remove of the same
+	// element wins
+	CRDTSet
+	// CRDTRegister holds the value set last
+	CRDTRegister
+)
+
+// CRDT is the value of a CRDT key, in the field of its Type. Updates through
+// different members are merged rather than overwritten, so they never
+// conflict. A key keeps the type of its first update.
+type CRDT struct {
+	Type     CRDTType
+	Counter  int64
+	Elements []string
+	Register string
+}
+
+// Increment adds delta, which may be negative, to the counter under key and
+// returns its value as the member that applied it saw it. The increment is
+// not retried on a timeout since it may have been applied.
+func (client *Client) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	response, err := client.increment(ctx, key, delta)
+	if err != nil {
+		return 0, err
+	}
+	return response.Counter, nil
+}
+
+func (client *Client) SetAdd(ctx context.Context, key string, element string) error {
+	_, err := client.setAdd(ctx, key, element)
+	return err
+}
+
+// SetRemove removes element from the set under key, an add of it concurrent
+// with the remove keeps it in the set
+func (client *Client) SetRemove(ctx context.Context, key string, element string) error {
+	_, err := client.setRemove(ctx, key, element)
+	return err
+}
+
+func (client *Client) RegisterSet(ctx context.Context, key string, value string) error {
+	_, err := client.registerSet(ctx, key, value)
+	return err
+}
+
+func (client *Client) increment(ctx context.Context, key string, delta int64) (*pb_dataplane.CRDTResponse, error) {
+	return client.updateCRDT(ctx, key, false, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) (*pb_dataplane.CRDTResponse, error) {
+		return kvClient.Increment(ctx, &pb_dataplane.IncrementRequest{Key: key, Delta: delta})
+	})
+}
+
+func (client *Client) setAdd(ctx context.Context, key string, element string) (*pb_dataplane.CRDTResponse, error) {
+	return client.updateCRDT(ctx, key, true, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) (*pb_dataplane.CRDTResponse, error) {
+		return kvClient.SetAdd(ctx, &pb_dataplane.SetElementRequest{Key: key, Element: element})
+	})
+}
+
+func (client *Client) setRemove(ctx context.Context, key string, element string) (*pb_dataplane.CRDTResponse, error) {
+	return client.updateCRDT(ctx, key, true, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) (*pb_dataplane.CRDTResponse, error) {
+		return kvClient.SetRemove(ctx, &pb_dataplane.SetElementRequest{Key: key, Element: element})
+	})
+}
+
+func (client *Client) registerSet(ctx context.Context, key string, value string) (*pb_dataplane.CRDTResponse, error) {
+	return client.updateCRDT(ctx, key, true, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) (*pb_dataplane.CRDTResponse, error) {
+		return kvClient.RegisterSet(ctx, &pb_dataplane.RegisterSetRequest{Key: key, Value: value})
+	})
+}
+
+// updateCRDT returns the response of the member that applied the update,
+// which carries the session token of the write
+func (client *Client) updateCRDT(ctx context.Context, key string, retry bool, update func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) (*pb_dataplane.CRDTResponse, error)) (*pb_dataplane.CRDTResponse, error) {
+	var response *pb_dataplane.CRDTResponse
+	err := client.withRetry(ctx, key, retry, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+		var err error
+		response, err = update(ctx, kvClient)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetCRDT returns the value of the CRDT under key read at the given
+// consistency, or ErrNotFound when it does not exist
+func (client *Client) GetCRDT(ctx context.Context, key string, consistency ReadConsistency) (*CRDT, error) {
+	request := &pb_dataplane.GetCRDTRequest{
+		Key:            key,
+		Consistency:    consistency.level,
+		MaxStalenessMs: consistency.maxStaleness.Milliseconds(),
+		ReadQuorum:     int32(consistency.readQuorum),
+	}
+	var value *CRDT
+	err := client.read(ctx, key, consistency, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+		response, err := kvClient.GetCRDT(ctx, request)
+		if err != nil {
+			return err
+		}
+		value = &CRDT{
+			Type:     CRDTType(response.Type),
+			Counter:  response.Counter,
+			Elements: response.Elements,
+			Register: response.Register,
+		}
+		return nil
+	})
+	return value, err
+}
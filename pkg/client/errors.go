@@ -0,0 +1,69 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrNotFound is returned when the requested key does not exist
+	ErrNotFound = errors.New("distrokv: key not found")
+	// ErrConflict is returned when a conditional write lost against a concurrent
+	// change, or when a write was applied on some members but its outcome is
+	// unknown, which is not retried
+	ErrConflict = errors.New("distrokv: conflict")
+	// ErrUnavailable is returned when no node could serve the request within the retry budget
+	ErrUnavailable = errors.New("distrokv: cluster unavailable")
+	// ErrInvalidArgument is returned when the cluster rejected the request as malformed
+	ErrInvalidArgument = errors.New("distrokv: invalid argument")
+	// ErrClockSkew is returned when a node rejected a timestamp too far ahead of its clock
+	ErrClockSkew = errors.New("distrokv: clock skew")
+)
+
+// translateError maps gRPC status errors onto the typed errors of the package.
+// The original message is kept so callers can still log it.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	if hlc.IsSkew(grpcStatus) {
+		return fmt.Errorf("%w: %s", ErrClockSkew, grpcStatus.Message())
+	}
+	switch grpcStatus.Code() {
+	case codes.NotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, grpcStatus.Message())
+	case codes.Aborted, codes.FailedPrecondition:
+		return fmt.Errorf("%w: %s", ErrConflict, grpcStatus.Message())
+	case codes.Unavailable, codes.ResourceExhausted:
+		return fmt.Errorf("%w: %s", ErrUnavailable, grpcStatus.Message())
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", ErrInvalidArgument, grpcStatus.Message())
+	}
+	return err
+}
+
+// isRetryable reports whether a failed request may be sent again. Nodes only
+// fail writes as unavailable before applying them. Timeouts are only retried
+// for idempotent requests since the first attempt may have been applied.
+func isRetryable(err error, idempotent bool) bool {
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		// Errors that do not come from the server are dial or routing failures
+		return true
+	}
+	switch grpcStatus.Code() {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	case codes.DeadlineExceeded:
+		return idempotent
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWritesWithUnknownOutcomeAreNotRetried(t *testing.T) {
+	unknown := status.Error(codes.Aborted, "Outcome of the write is unknown, primary n1 did not acknowledge it")
+	if isRetryable(unknown, false) || isRetryable(unknown, true) {
+		t.Fatalf("expected a write with an unknown outcome not to be retried")
+	}
+	if err := translateError(unknown); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	misrouted := status.Error(codes.Unavailable, "Range 2 holding the key is owned by node n2")
+	if !isRetryable(misrouted, false) {
+		t.Fatalf("expected a write rejected by a node that does not own the range to be retried")
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// causality keeps the newest hybrid logical clock timestamp any node answered
+// with and sends it along with every request. A node moves its clock past it,
+// so a write the client makes after seeing another is ordered after it even
+// when the clocks of the two nodes disagree. The client keeps no clock of its
+// own, a timestamp more than maxSkew ahead of its wall clock is not kept so a
+// single node with a skewed clock cannot get every request rejected.
+type causality struct {
+	latest  atomic.Int64
+	maxSkew time.Duration
+	logger  slog.Logger
+}
+
+func (causality *causality) dialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(causality.unaryInterceptor),
+		grpc.WithChainStreamInterceptor(causality.streamInterceptor),
+	}
+}
+
+func (causality *causality) outgoing(ctx context.Context) context.Context {
+	if latest := causality.latest.Load(); latest > 0 {
+		return metadata.AppendToOutgoingContext(ctx, hlc.MetadataKey, hlc.Format(latest))
+	}
+	return ctx
+}
+
+func (causality *causality) observe(header metadata.MD) {
+	values := header.Get(hlc.MetadataKey)
+	if len(values) == 0 {
+		return
+	}
+	timestamp, err := hlc.Parse(values[0])
+	if err != nil {
+		return
+	}
+	if err := hlc.CheckSkew(timestamp, causality.maxSkew); err != nil {
+		causality.logger.Warn("Ignored the timestamp of a node with a skewed clock", "error", err)
+		return
+	}
+	for {
+		latest := causality.latest.Load()
+		if timestamp <= latest || causality.latest.CompareAndSwap(latest, timestamp) {
+			return
+		}
+	}
+}
+
+func (causality *causality) unaryInterceptor(ctx context.Context, method string, request any, reply any, conn *grpc.ClientConn, invoker grpc.UnaryInvoker, options ...grpc.CallOption) error {
+	var header metadata.MD
+	err := invoker(causality.outgoing(ctx), method, request, reply, conn, append(options, grpc.Header(&header))...)
+	causality.observe(header)
+	return err
+}
+
+func (causality *causality) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string, streamer grpc.Streamer, options ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(causality.outgoing(ctx), desc, conn, method, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &causalStream{ClientStream: stream, causality: causality}, nil
+}
+
+// causalStream reads the node's timestamp once the first message arrived,
+// reading the header earlier would block
+type causalStream struct {
+	grpc.ClientStream
+	causality *causality
+	once      sync.Once
+}
+
+func (stream *causalStream) RecvMsg(message any) error {
+	err := stream.ClientStream.RecvMsg(message)
+	stream.once.Do(func() {
+		if header, headerErr := stream.Header(); headerErr == nil {
+			stream.causality.observe(header)
+		}
+	})
+	return err
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Vahsek/distrokv/internal/common/hlc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCausalityIgnoresSkewedTimestamps(t *testing.T) {
+	causality := &causality{maxSkew: hlc.DefaultMaxSkew, logger: *slog.New(slog.DiscardHandler)}
+	accepted := hlc.FromTime(time.Now())
+	causality.observe(metadata.Pairs(hlc.MetadataKey, hlc.Format(accepted)))
+	if latest := causality.latest.Load(); latest != accepted {
+		t.Fatalf("expected the timestamp to be kept, got %d instead of %d", latest, accepted)
+	}
+
+	skewed := hlc.FromTime(time.Now().Add(time.Hour))
+	causality.observe(metadata.Pairs(hlc.MetadataKey, hlc.Format(skewed)))
+	if latest := causality.latest.Load(); latest != accepted {
+		t.Fatalf("expected a skewed timestamp not to be forwarded, got %d", latest)
+	}
+}
+
+func TestTranslateErrorTellsSkewFromConflicts(t *testing.T) {
+	skew := hlc.SkewError(hlc.ErrClockSkew)
+	if err := translateError(skew); !errors.Is(err, ErrClockSkew) || errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrClockSkew, got %v", err)
+	}
+	conflict := status.Error(codes.FailedPrecondition, "Node is not in leaderless mode")
+	if err := translateError(conflict); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	pb_dataplane "github.com/Vahsek/distrokv/pkg/node/dataplane"
+)
+
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Siblings are the concurrent values of a key on a cluster with vector
+// clocks. Writing back with Context replaces every one of them.
+type Siblings struct {
+	Values  []string
+	Context string
+}
+
+type EventType int
+
+const (
+	EventPut EventType = iota
+	EventDelete
+)
+
+type WatchEvent struct {
+	Type  EventType
+	Key   string
+	Value string
+}
+
+// ReadConsistency selects which nodes may serve a read
+type ReadConsistency struct {
+	level        pb_dataplane.ReadConsistency
+	maxStaleness time.Duration
+	readQuorum   int
+}
+
+var (
+	// Linearizable reads are served by the primary and see every write
+	// acknowledged before the read started
+	Linearizable = ReadConsistency{level: pb_dataplane.ReadConsistency_LINEARIZABLE}
+	// AnyReplica reads are served by any member, however far behind it is
+	AnyReplica = ReadConsistency{level: pb_dataplane.ReadConsistency_ANY}
+)
+
+// BoundedStaleness reads are served by any member at most maxStaleness behind
+// the primary. A member that is further behind redirects the read to the
+// primary.
+func BoundedStaleness(maxStaleness time.Duration) ReadConsistency {
+	return ReadConsistency{
+		level:        pb_dataplane.ReadConsistency_BOUNDED_STALENESS,
+		maxStaleness: maxStaleness,
+	}
+}
+
+// Quorum reads are for clusters in leaderless mode. The member serving the
+// read answers with the newest value among readQuorum replicas of the key, 0
+// uses the cluster's default.
+func Quorum(readQuorum int) ReadConsistency {
+	return ReadConsistency{
+		level:      pb_dataplane.ReadConsistency_ANY,
+		readQuorum: readQuorum,
+	}
+}
+
+// Get returns the value of key or ErrNotFound when it does not exist. The read
+// is linearizable.
+func (client *Client) Get(ctx context.Context, key string) (string, error) {
+	return client.GetWithConsistency(ctx, key, Linearizable)
+}
+
+// GetWithConsistency returns the value of key read at the given consistency.
+// Reads that do not need the leader are sent to a random member so they spread
+// across the cluster, and fall back to the leader when the member cannot
+// serve them.
+func (client *Client) GetWithConsistency(ctx context.Context, key string, consistency ReadConsistency) (string, error) {
+	response, err := client.get(ctx, key, consistency, "")
+	if err != nil {
+		return "", err
+	}
+	return response.Value, nil
+}
+
+// GetSiblings returns every concurrent value of key on a cluster with vector
+// clocks, and the single value otherwise. Writing back with PutWithContext
+// resolves them.
+func (client *Client) GetSiblings(ctx context.Context, key string, consistency ReadConsistency) (*Siblings, error) {
+	response, err := client.get(ctx, key, consistency, "")
+	if err != nil {
+		return nil, err
+	}
+	siblings := &Siblings{Values: response.Siblings, Context: response.Context}
+	if len(siblings.Values) == 0 {
+		siblings.Values = []string{response.Value}
+	}
+	return siblings, nil
+}
+
+func (client *Client) get(ctx context.Context, key string, consistency ReadConsistency, sessionToken string) (*pb_dataplane.GetResponse, error) {
+	var response *pb_dataplane.GetResponse
+	request := &pb_dataplane.GetRequest{
+		Key:            key,
+		Consistency:    consistency.level,
+		MaxStalenessMs: consistency.maxStaleness.Milliseconds(),
+		SessionToken:   sessionToken,
+		ReadQuorum:     int32(consistency.readQuorum),
+	}
+	err := client.read(ctx, key, consistency, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+		var err error
+		response, err = kvClient.GetKey(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+// read sends a read to a random member unless it has to be served by the
+// primary, and falls back to the primary when the member cannot serve it
+func (client *Client) read(ctx context.Context, key string, consistency ReadConsistency, call dataPlaneCall) error {
+	if consistency.level != pb_dataplane.ReadConsistency_LINEARIZABLE {
+		err := client.attemptOnReplica(ctx, call)
+		if err == nil || !isRetryable(err, true) {
+			return translateError(err)
+		}
+		client.logger.Debug("Member could not serve the read, falling back to the primary", "key", key, "error", err)
+	}
+	return client.withPrimaryRetry(ctx, key, true, call)
+}
+
+func (client *Client) Put(ctx context.Context, key string, value string) error {
+	_, err := client.put(ctx, key, value, 0, "")
+	return err
+}
+
+// PutWithQuorum is Put on a cluster in leaderless mode, returning once
+// writeQuorum replicas of the key stored the value. 0 uses the cluster's
+// default.
+func (client *Client) PutWithQuorum(ctx context.Context, key string, value string, writeQuorum int) error {
+	_, err := client.put(ctx, key, value, writeQuorum, "")
+	return err
+}
+
+// PutWithContext is Put on a cluster with vector clocks. The value replaces
+// the siblings read together with causalContext and becomes a sibling of any
+// value written since.
+func (client *Client) PutWithContext(ctx context.Context, key string, value string, causalContext string) error {
+	_, err := client.put(ctx, key, value, 0, causalContext)
+	return err
+}
+
+// put returns the session token of the write
+func (client *Client) put(ctx context.Context, key string, value string, writeQuorum int, causalContext string) (string, error) {
+	var sessionToken string
+	err := client.withRetry(ctx, key, true, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+		response, err := kvClient.SetKey(ctx, &pb_dataplane.SetRequest{Key: key, Value: value, WriteQuorum: int32(writeQuorum), Context: causalContext})
+		if err != nil {
+			return err
+		}
+		sessionToken = response.SessionToken
+		return nil
+	})
+	return sessionToken, err
+}
+
+// Delete removes key and returns ErrNotFound when it does not exist
+func (client *Client) Delete(ctx context.Context, key string) error {
+	_, err := client.delete(ctx, key, 0, "")
+	return err
+}
+
+// DeleteWithQuorum is Delete on a cluster in leaderless mode, returning once
+// writeQuorum replicas of the key stored a tombstone. It does not return
+// ErrNotFound since replicas cannot tell whether the key existed.
+func (client *Client) DeleteWithQuorum(ctx context.Context, key string, writeQuorum int) error {
+	_, err := client.delete(ctx, key, writeQuorum, "")
+	return err
+}
+
+// DeleteWithContext is Delete on a cluster with vector clocks, removing the
+// siblings read together with causalContext
+func (client *Client) DeleteWithContext(ctx context.Context, key string, causalContext string) error {
+	_, err := client.delete(ctx, key, 0, causalContext)
+	return err
+}
+
+// delete returns the session token of the write
+func (client *Client) delete(ctx context.Context, key string, writeQuorum int, causalContext string) (string, error) {
+	var sessionToken string
+	err := client.withRetry(ctx, key, false, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+		response, err := kvClient.DeleteKey(ctx, &pb_dataplane.DeleteRequest{Key: key, WriteQuorum: int32(writeQuorum), Context: causalContext})
+		if err != nil {
+			return err
+		}
+		sessionToken = response.SessionToken
+		return nil
+	})
+	return sessionToken, err
+}
+
+// Scan returns the keys in [startKey, endKey) in key order. An empty endKey
+// scans to the end of the keyspace and a limit <= 0 returns every key. The
+// scan is split at range boundaries and each piece is sent to the leader of
+// its range.
+func (client *Client) Scan(ctx context.Context, startKey string, endKey string, limit int) ([]KeyValue, error) {
+	if err := client.ensureTopology(ctx); err != nil {
+		return nil, err
+	}
+
+	var keyValues []KeyValue
+	for _, bounds := range client.rangesFor(startKey, endKey) {
+		remaining := 0
+		if limit > 0 {
+			remaining = limit - len(keyValues)
+			if remaining <= 0 {
+				break
+			}
+		}
+
+		request := &pb_dataplane.ScanRequest{
+			StartKey: bounds[0],
+			EndKey:   bounds[1],
+			Limit:    int32(remaining),
+		}
+		err := client.withRetry(ctx, bounds[0], true, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+			response, err := kvClient.ScanKeys(ctx, request)
+			if err != nil {
+				return err
+			}
+			for _, keyValue := range response.KeyValues {
+				keyValues = append(keyValues, KeyValue{Key: keyValue.Key, Value: keyValue.Value})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keyValues, nil
+}
+
+// Watch streams every change to the keys in [startKey, endKey) until ctx is
+// cancelled, after which the returned channel is closed. Broken streams are
+// re-established with backoff; changes made while the stream was down are not
+// replayed.
+func (client *Client) Watch(ctx context.Context, startKey string, endKey string) (<-chan WatchEvent, error) {
+	stream, err := client.openWatch(ctx, startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		backoff := client.config.InitialBackoff
+		for {
+			event, err := stream.Recv()
+			if err == nil {
+				backoff = client.config.InitialBackoff
+				select {
+				case events <- fromWatchEvent(event):
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if !errors.Is(err, io.EOF) {
+				client.logger.Warn("Watch stream broke, reconnecting", "error", err)
+			}
+
+			for {
+				if sleepWithJitter(ctx, backoff) != nil {
+					return
+				}
+				backoff = min(backoff*2, client.config.MaxBackoff)
+				stream, err = client.openWatch(ctx, startKey, endKey)
+				if err == nil {
+					break
+				}
+				client.logger.Warn("Failed to re-establish watch", "error", err)
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (client *Client) openWatch(ctx context.Context, startKey string, endKey string) (pb_dataplane.NodeKeyValueService_WatchKeysClient, error) {
+	var stream pb_dataplane.NodeKeyValueService_WatchKeysClient
+	err := client.withRetry(ctx, startKey, true, func(_ context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+		// The stream outlives a single attempt so it is bound to the caller's context
+		watchStream, err := kvClient.WatchKeys(ctx, &pb_dataplane.WatchRequest{StartKey: startKey, EndKey: endKey})
+		if err != nil {
+			return err
+		}
+		// The node sends headers once the watch is registered, waiting for them
+		// guarantees changes made after Watch returns are seen
+		if _, err := watchStream.Header(); err != nil {
+			return err
+		}
+		stream = watchStream
+		return nil
+	})
+	return stream, err
+}
+
+func fromWatchEvent(event *pb_dataplane.WatchEvent) WatchEvent {
+	eventType := EventPut
+	if event.Type == pb_dataplane.WatchEvent_DELETE {
+		eventType = EventDelete
+	}
+	return WatchEvent{
+		Type:  eventType,
+		Key:   event.Key,
+		Value: event.Value,
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	pb_dataplane "github.com/Vahsek/distrokv/pkg/node/dataplane"
+)
+
+type dataPlaneCall func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error
+
+// router picks the node a request for key is sent to
+type router func(ctx context.Context, key string) (string, error)
+
+// withRetry routes call to the leader for key and retries transient failures
+// with exponential backoff and jitter, refreshing the topology between attempts
+func (client *Client) withRetry(ctx context.Context, key string, idempotent bool, call dataPlaneCall) error {
+	return client.retry(ctx, key, idempotent, client.leaderAddress, call)
+}
+
+// withPrimaryRetry is withRetry for requests only the primary serves
+func (client *Client) withPrimaryRetry(ctx context.Context, key string, idempotent bool, call dataPlaneCall) error {
+	return client.retry(ctx, key, idempotent, client.primaryAddress, call)
+}
+
+func (client *Client) retry(ctx context.Context, key string, idempotent bool, route router, call dataPlaneCall) error {
+	backoff := client.config.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := client.attempt(ctx, key, route, call)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !isRetryable(err, idempotent) || attempt >= client.config.MaxRetries {
+			return translateError(err)
+		}
+
+		client.logger.Warn("Retrying request after transient failure", "key", key, "attempt", attempt+1, "error", err)
+		client.invalidateTopology()
+		if err := sleepWithJitter(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(backoff*2, client.config.MaxBackoff)
+	}
+}
+
+func (client *Client) attempt(ctx context.Context, key string, route router, call dataPlaneCall) error {
+	address, err := route(ctx, key)
+	if err != nil {
+		return err
+	}
+	kvClient, err := client.dataPlaneClient(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, client.config.RequestTimeout)
+	defer cancel()
+	return call(requestCtx, kvClient)
+}
+
+// attemptOnReplica sends call once to a random member
+func (client *Client) attemptOnReplica(ctx context.Context, call dataPlaneCall) error {
+	address, err := client.replicaAddress(ctx)
+	if err != nil {
+		return err
+	}
+	kvClient, err := client.dataPlaneClient(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, client.config.RequestTimeout)
+	defer cancel()
+	return call(requestCtx, kvClient)
+}
+
+// sleepWithJitter waits for a random duration in [backoff/2, backoff)
+func sleepWithJitter(ctx context.Context, backoff time.Duration) error {
+	delay := backoff/2 + time.Duration(rand.Int64N(int64(backoff/2)+1))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// Session gives read-your-writes. Its reads are spread across the members
+// like AnyReplica reads but the member serving a read first waits until it
+// applied every write made through the session. The token can be handed to
+// another process, for example in a cookie, to continue the session there.
+type Session struct {
+	client *Client
+	token  string
+	mu     sync.Mutex
+}
+
+// NewSession starts a session, or continues one when given the Token of an
+// earlier session
+func (client *Client) NewSession(token string) *Session {
+	return &Session{
+		client: client,
+		token:  token,
+	}
+}
+
+// Token returns the opaque position of the session's last write
+func (session *Session) Token() string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	return session.token
+}
+
+func (session *Session) Get(ctx context.Context, key string) (string, error) {
+	response, err := session.client.get(ctx, key, AnyReplica, session.Token())
+	if err != nil {
+		return "", err
+	}
+	return response.Value, nil
+}
+
+func (session *Session) Put(ctx context.Context, key string, value string) error {
+	token, err := session.client.put(ctx, key, value, 0, "")
+	if err != nil {
+		return err
+	}
+	session.advance(token)
+	return nil
+}
+
+func (session *Session) Delete(ctx context.Context, key string) error {
+	token, err := session.client.delete(ctx, key, 0, "")
+	if err != nil {
+		return err
+	}
+	session.advance(token)
+	return nil
+}
+
+func (session *Session) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	response, err := session.client.increment(ctx, key, delta)
+	if err != nil {
+		return 0, err
+	}
+	session.advance(response.SessionToken)
+	return response.Counter, nil
+}
+
+func (session *Session) SetAdd(ctx context.Context, key string, element string) error {
+	response, err := session.client.setAdd(ctx, key, element)
+	if err != nil {
+		return err
+	}
+	session.advance(response.SessionToken)
+	return nil
+}
+
+func (session *Session) SetRemove(ctx context.Context, key string, element string) error {
+	response, err := session.client.setRemove(ctx, key, element)
+	if err != nil {
+		return err
+	}
+	session.advance(response.SessionToken)
+	return nil
+}
+
+func (session *Session) RegisterSet(ctx context.Context, key string, value string) error {
+	response, err := session.client.registerSet(ctx, key, value)
+	if err != nil {
+		return err
+	}
+	session.advance(response.SessionToken)
+	return nil
+}
+
+// advance keeps the token of the latest write. It covers the earlier writes as
+// long as they went through the same node, which holds unless the leader
+// changed in between.
+func (session *Session) advance(token string) {
+	if token == "" {
+		return
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.token = token
+}
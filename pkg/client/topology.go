@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	pb_registry "github.com/Vahsek/distrokv/pkg/registry"
+)
+
+// topologyCache is the client's view of the cluster as last reported by the
+// registry. It is refreshed periodically and whenever a request fails in a way
+// that suggests the view is stale.
+type topologyCache struct {
+	nodes       []*pb_registry.NodeDetails
+	leader      *pb_registry.PrimaryNodeResponse
+	ranges      []*pb_registry.RangeDescriptor
+	refreshedAt time.Time
+	mu          sync.RWMutex
+}
+
+func (client *Client) refreshTopology(ctx context.Context) error {
+	registryClient, err := client.registryClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, client.config.RequestTimeout)
+	defer cancel()
+
+	nodeList, err := registryClient.GetNodeList(ctx, &pb_registry.NodeListRequest{})
+	if err != nil {
+		client.logger.Error("Failed to get the node list from registry", "error", err)
+		return err
+	}
+
+	// A missing leader or range table is not fatal, requests fall back to any
+	// node and to a single range covering the keyspace
+	leader, err := registryClient.GetPrimaryNode(ctx, &pb_registry.PrimaryNodeRequest{})
+	if err != nil {
+		client.logger.Warn("Failed to get the primary node from registry", "error", err)
+		leader = nil
+	}
+	var ranges []*pb_registry.RangeDescriptor
+	rangeResponse, err := registryClient.GetRangeDescriptors(ctx, &pb_registry.RangeDescriptorsRequest{})
+	if err != nil {
+		client.logger.Warn("Failed to get the range descriptors from registry", "error", err)
+	} else {
+		ranges = rangeResponse.Ranges
+		sort.Slice(ranges, func(i, j int) bool {
+			return ranges[i].StartKey < ranges[j].StartKey
+		})
+	}
+
+	client.topology.mu.Lock()
+	defer client.topology.mu.Unlock()
+	client.topology.nodes = nodeList.NodeList
+	client.topology.leader = leader
+	client.topology.ranges = ranges
+	client.topology.refreshedAt = time.Now()
+	client.logger.Info("Refreshed cluster topology", "nodes", len(nodeList.NodeList), "ranges", len(ranges))
+	return nil
+}
+
+func (client *Client) invalidateTopology() {
+	client.topology.mu.Lock()
+	defer client.topology.mu.Unlock()
+	client.topology.refreshedAt = time.Time{}
+}
+
+func (client *Client) ensureTopology(ctx context.Context) error {
+	client.topology.mu.RLock()
+	stale := time.Since(client.topology.refreshedAt) > client.config.TopologyRefreshInterval
+	client.topology.mu.RUnlock()
+
+	if !stale {
+		return nil
+	}
+	return client.refreshTopology(ctx)
+}
+
+// leaderAddress returns the data plane address of the owner of the range
+// holding key. A range without a live owner the client knows of is led by the
+// cluster primary.
+func (client *Client) leaderAddress(ctx context.Context, key string) (string, error) {
+	if err := client.ensureTopology(ctx); err != nil {
+		return "", err
+	}
+
+	client.topology.mu.RLock()
+	defer client.topology.mu.RUnlock()
+
+	if owner := client.topology.rangeOwner(key); owner != nil {
+		return owner.NodeIP + ":" + owner.NodeDataPort, nil
+	}
+	return client.topology.primaryAddress(key)
+}
+
+// primaryAddress returns the data plane address of the cluster primary, for
+// requests only the primary serves
+func (client *Client) primaryAddress(ctx context.Context, key string) (string, error) {
+	if err := client.ensureTopology(ctx); err != nil {
+		return "", err
+	}
+
+	client.topology.mu.RLock()
+	defer client.topology.mu.RUnlock()
+
+	return client.topology.primaryAddress(key)
+}
+
+// primaryAddress falls back to any registered node when the registry has not
+// elected a primary and expects the caller to hold the lock
+func (topology *topologyCache) primaryAddress(key string) (string, error) {
+	if topology.leader != nil {
+		return topology.leader.IpAddress + ":" + topology.leader.DataPlanePort, nil
+	}
+	if len(topology.nodes) == 0 {
+		return "", fmt.Errorf("no nodes registered to route key %q to", key)
+	}
+	node := topology.nodes[0]
+	return node.NodeIP + ":" + node.NodeDataPort, nil
+}
+
+// rangeOwner returns the owner of the range holding key, nil when the range
+// has no owner or the owner is not a live registered node. It expects the
+// caller to hold the lock.
+func (topology *topologyCache) rangeOwner(key string) *pb_registry.NodeDetails {
+	index := sort.Search(len(topology.ranges), func(i int) bool {
+		return topology.ranges[i].StartKey > key
+	}) - 1
+	if index < 0 {
+		return nil
+	}
+	rangeDescriptor := topology.ranges[index]
+	if rangeDescriptor.OwnerNodeId == "" || (rangeDescriptor.EndKey != "" && key >= rangeDescriptor.EndKey) {
+		return nil
+	}
+	for _, node := range topology.nodes {
+		if node.NodeId == rangeDescriptor.OwnerNodeId && node.Health != "dead" {
+			return node
+		}
+	}
+	return nil
+}
+
+// replicaAddress returns the data plane address of a random member the
+// registry does not consider dead
+func (client *Client) replicaAddress(ctx context.Context) (string, error) {
+	if err := client.ensureTopology(ctx); err != nil {
+		return "", err
+	}
+
+	client.topology.mu.RLock()
+	defer client.topology.mu.RUnlock()
+
+	var candidates []*pb_registry.NodeDetails
+	for _, node := range client.topology.nodes {
+		if node.Health != "dead" {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no live nodes registered to read from")
+	}
+	node := candidates[rand.IntN(len(candidates))]
+	return node.NodeIP + ":" + node.NodeDataPort, nil
+}
+
+// rangesFor returns the bounds of every range overlapping [startKey, endKey),
+// clipped to the requested bounds. An empty endKey is unbounded.
+func (client *Client) rangesFor(startKey string, endKey string) [][2]string {
+	client.topology.mu.RLock()
+	defer client.topology.mu.RUnlock()
+
+	if len(client.topology.ranges) == 0 {
+		return [][2]string{{startKey, endKey}}
+	}
+
+	var bounds [][2]string
+	for _, rangeDescriptor := range client.topology.ranges {
+		if rangeDescriptor.EndKey != "" && rangeDescriptor.EndKey <= startKey {
+			continue
+		}
+		if endKey != "" && rangeDescriptor.StartKey >= endKey {
+			break
+		}
+		rangeStart := max(startKey, rangeDescriptor.StartKey)
+		rangeEnd := rangeDescriptor.EndKey
+		if rangeEnd == "" || (endKey != "" && endKey < rangeEnd) {
+			rangeEnd = endKey
+		}
+		bounds = append(bounds, [2]string{rangeStart, rangeEnd})
+	}
+	return bounds
+}
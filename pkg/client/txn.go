@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	pb_dataplane "github.com/Vahsek/distrokv/pkg/node/dataplane"
+)
+
+// Compare is a condition evaluated against the current value of a key
+type Compare struct {
+	compare *pb_dataplane.TxnCompare
+}
+
+func CompareValue(key string, value string) Compare {
+	return Compare{&pb_dataplane.TxnCompare{Key: key, Type: pb_dataplane.TxnCompare_VALUE_EQUALS, Value: value}}
+}
+
+func CompareExists(key string) Compare {
+	return Compare{&pb_dataplane.TxnCompare{Key: key, Type: pb_dataplane.TxnCompare_EXISTS}}
+}
+
+func CompareMissing(key string) Compare {
+	return Compare{&pb_dataplane.TxnCompare{Key: key, Type: pb_dataplane.TxnCompare_NOT_EXISTS}}
+}
+
+// Op is a read or write applied as part of a transaction
+type Op struct {
+	operation *pb_dataplane.TxnOperation
+}
+
+func OpGet(key string) Op {
+	return Op{&pb_dataplane.TxnOperation{Type: pb_dataplane.TxnOperation_GET, Key: key}}
+}
+
+func OpPut(key string, value string) Op {
+	return Op{&pb_dataplane.TxnOperation{Type: pb_dataplane.TxnOperation_PUT, Key: key, Value: value}}
+}
+
+func OpDelete(key string) Op {
+	return Op{&pb_dataplane.TxnOperation{Type: pb_dataplane.TxnOperation_DELETE, Key: key}}
+}
+
+// Txn applies Then when every compare in If holds and Else otherwise, all
+// atomically on the primary since its keys may span ranges with different
+// owners
+type Txn struct {
+	If   []Compare
+	Then []Op
+	Else []Op
+}
+
+type OpResult struct {
+	Key   string
+	Value string
+	Found bool
+}
+
+type TxnResult struct {
+	Succeeded bool
+	Results   []OpResult
+}
+
+// Txn runs a transaction. All keys are expected to live in the range of the
+// first compare or operation. Transactions are not retried after a timeout
+// since they may already have been applied.
+func (client *Client) Txn(ctx context.Context, txn Txn) (*TxnResult, error) {
+	request := &pb_dataplane.TxnRequest{}
+	for _, compare := range txn.If {
+		request.Compares = append(request.Compares, compare.compare)
+	}
+	for _, op := range txn.Then {
+		request.Success = append(request.Success, op.operation)
+	}
+	for _, op := range txn.Else {
+		request.Failure = append(request.Failure, op.operation)
+	}
+
+	var result *TxnResult
+	err := client.withPrimaryRetry(ctx, txnRoutingKey(request), false, func(ctx context.Context, kvClient pb_dataplane.NodeKeyValueServiceClient) error {
+		response, err := kvClient.Txn(ctx, request)
+		if err != nil {
+			return err
+		}
+		result = &TxnResult{Succeeded: response.Succeeded}
+		for _, operationResult := range response.Results {
+			result.Results = append(result.Results, OpResult{
+				Key:   operationResult.Key,
+				Value: operationResult.Value,
+				Found: operationResult.Found,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CompareAndSwap sets key to newValue only if it currently holds oldValue and
+// returns ErrConflict otherwise
+func (client *Client) CompareAndSwap(ctx context.Context, key string, oldValue string, newValue string) error {
+	result, err := client.Txn(ctx, Txn{
+		If:   []Compare{CompareValue(key, oldValue)},
+		Then: []Op{OpPut(key, newValue)},
+	})
+	if err != nil {
+		return err
+	}
+	if !result.Succeeded {
+		return fmt.Errorf("%w: key %q does not hold the expected value", ErrConflict, key)
+	}
+	return nil
+}
+
+func txnRoutingKey(request *pb_dataplane.TxnRequest) string {
+	if len(request.Compares) > 0 {
+		return request.Compares[0].Key
+	}
+	if len(request.Success) > 0 {
+		return request.Success[0].Key
+	}
+	if len(request.Failure) > 0 {
+		return request.Failure[0].Key
+	}
+	return ""
+}
@@ -21,17 +21,134 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type MemberUpdate_State int32
+
+const (
+	MemberUpdate_ALIVE   MemberUpdate_State = 0
+	MemberUpdate_SUSPECT MemberUpdate_State = 1
+	MemberUpdate_DEAD    MemberUpdate_State = 2
+	MemberUpdate_LEFT    MemberUpdate_State = 3
+)
+
+// Enum value maps for MemberUpdate_State.
+var (
+	MemberUpdate_State_name = map[int32]string{
+		0: "ALIVE",
+		1: "SUSPECT",
+		2: "DEAD",
+		3: "LEFT",
+	}
+	MemberUpdate_State_value = map[string]int32{
+		"ALIVE":   0,
+		"SUSPECT": 1,
+		"DEAD":    2,
+		"LEFT":    3,
+	}
+)
+
+func (x MemberUpdate_State) Enum() *MemberUpdate_State {
+	p := new(MemberUpdate_State)
+	*p = x
+	return p
+}
+
+func (x MemberUpdate_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MemberUpdate_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_NodeControlPlane_proto_enumTypes[0].Descriptor()
+}
+
+func (MemberUpdate_State) Type() protoreflect.EnumType {
+	return &file_protos_NodeControlPlane_proto_enumTypes[0]
+}
+
+func (x MemberUpdate_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MemberUpdate_State.Descriptor instead.
+func (MemberUpdate_State) EnumDescriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{16, 0}
+}
+
+// WritePosition identifies a write in the sequence of writes accepted by the
+// node that replicates it. Writes copied without one, such as a hand off, are
+// not tracked.
+type WritePosition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Epoch         int64                  `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Sequence      int64                  `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WritePosition) Reset() {
+	*x = WritePosition{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WritePosition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WritePosition) ProtoMessage() {}
+
+func (x *WritePosition) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WritePosition.ProtoReflect.Descriptor instead.
+func (*WritePosition) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WritePosition) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *WritePosition) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *WritePosition) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
 type SetReplicationRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Position      *WritePosition         `protobuf:"bytes,3,opt,name=position,proto3" json:"position,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SetReplicationRequest) Reset() {
 	*x = SetReplicationRequest{}
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[0]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -43,7 +160,7 @@ func (x *SetReplicationRequest) String() string {
 func (*SetReplicationRequest) ProtoMessage() {}
 
 func (x *SetReplicationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[0]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,7 +173,7 @@ func (x *SetReplicationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetReplicationRequest.ProtoReflect.Descriptor instead.
 func (*SetReplicationRequest) Descriptor() ([]byte, []int) {
-	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{0}
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *SetReplicationRequest) GetKey() string {
@@ -73,6 +190,20 @@ func (x *SetReplicationRequest) GetValue() string {
 	return ""
 }
 
+func (x *SetReplicationRequest) GetPosition() *WritePosition {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *SetReplicationRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
 type SetReplicationResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -84,7 +215,7 @@ type SetReplicationResponse struct {
 
 func (x *SetReplicationResponse) Reset() {
 	*x = SetReplicationResponse{}
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[1]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -96,7 +227,7 @@ func (x *SetReplicationResponse) String() string {
 func (*SetReplicationResponse) ProtoMessage() {}
 
 func (x *SetReplicationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[1]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -109,7 +240,7 @@ func (x *SetReplicationResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetReplicationResponse.ProtoReflect.Descriptor instead.
 func (*SetReplicationResponse) Descriptor() ([]byte, []int) {
-	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{1}
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *SetReplicationResponse) GetKey() string {
@@ -136,13 +267,15 @@ func (x *SetReplicationResponse) GetError() string {
 type DeleteReplicationRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Position      *WritePosition         `protobuf:"bytes,2,opt,name=position,proto3" json:"position,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteReplicationRequest) Reset() {
 	*x = DeleteReplicationRequest{}
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[2]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -154,7 +287,7 @@ func (x *DeleteReplicationRequest) String() string {
 func (*DeleteReplicationRequest) ProtoMessage() {}
 
 func (x *DeleteReplicationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[2]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -167,7 +300,7 @@ func (x *DeleteReplicationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteReplicationRequest.ProtoReflect.Descriptor instead.
 func (*DeleteReplicationRequest) Descriptor() ([]byte, []int) {
-	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{2}
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *DeleteReplicationRequest) GetKey() string {
@@ -177,6 +310,20 @@ func (x *DeleteReplicationRequest) GetKey() string {
 	return ""
 }
 
+func (x *DeleteReplicationRequest) GetPosition() *WritePosition {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *DeleteReplicationRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
 type DeleteReplicationResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -189,7 +336,7 @@ type DeleteReplicationResponse struct {
 
 func (x *DeleteReplicationResponse) Reset() {
 	*x = DeleteReplicationResponse{}
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[3]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -201,7 +348,7 @@ func (x *DeleteReplicationResponse) String() string {
 func (*DeleteReplicationResponse) ProtoMessage() {}
 
 func (x *DeleteReplicationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[3]
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -214,7 +361,7 @@ func (x *DeleteReplicationResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteReplicationResponse.ProtoReflect.Descriptor instead.
 func (*DeleteReplicationResponse) Descriptor() ([]byte, []int) {
-	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{3}
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *DeleteReplicationResponse) GetKey() string {
@@ -245,31 +392,36 @@ func (x *DeleteReplicationResponse) GetError() string {
 	return ""
 }
 
-type NewServerAddRequest struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Hostname         string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	IpAddress        string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
-	ControlPlanePort string                 `protobuf:"bytes,3,opt,name=controlPlanePort,proto3" json:"controlPlanePort,omitempty"`
-	DataPlanePort    string                 `protobuf:"bytes,4,opt,name=dataPlanePort,proto3" json:"dataPlanePort,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+// ReplicationEntry is a single replicated write, a delete when delete is set.
+// timestamp is the hybrid logical clock timestamp the primary stamped the
+// write with, replicas store it as is and keep a tombstone with it for a
+// delete.
+type ReplicationEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Delete        bool                   `protobuf:"varint,3,opt,name=delete,proto3" json:"delete,omitempty"`
+	Position      *WritePosition         `protobuf:"bytes,4,opt,name=position,proto3" json:"position,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NewServerAddRequest) Reset() {
-	*x = NewServerAddRequest{}
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[4]
+func (x *ReplicationEntry) Reset() {
+	*x = ReplicationEntry{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NewServerAddRequest) String() string {
+func (x *ReplicationEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NewServerAddRequest) ProtoMessage() {}
+func (*ReplicationEntry) ProtoMessage() {}
 
-func (x *NewServerAddRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[4]
+func (x *ReplicationEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -280,62 +432,71 @@ func (x *NewServerAddRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NewServerAddRequest.ProtoReflect.Descriptor instead.
-func (*NewServerAddRequest) Descriptor() ([]byte, []int) {
-	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use ReplicationEntry.ProtoReflect.Descriptor instead.
+func (*ReplicationEntry) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *NewServerAddRequest) GetHostname() string {
+func (x *ReplicationEntry) GetKey() string {
 	if x != nil {
-		return x.Hostname
+		return x.Key
 	}
 	return ""
 }
 
-func (x *NewServerAddRequest) GetIpAddress() string {
+func (x *ReplicationEntry) GetValue() string {
 	if x != nil {
-		return x.IpAddress
+		return x.Value
 	}
 	return ""
 }
 
-func (x *NewServerAddRequest) GetControlPlanePort() string {
+func (x *ReplicationEntry) GetDelete() bool {
 	if x != nil {
-		return x.ControlPlanePort
+		return x.Delete
 	}
-	return ""
+	return false
 }
 
-func (x *NewServerAddRequest) GetDataPlanePort() string {
+func (x *ReplicationEntry) GetPosition() *WritePosition {
 	if x != nil {
-		return x.DataPlanePort
+		return x.Position
 	}
-	return ""
+	return nil
 }
 
-type NewServerAddResponse struct {
+func (x *ReplicationEntry) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// Batches on a replication stream are applied in the order they are sent and
+// every batch is acknowledged once all of its entries were applied
+type ReplicationBatch struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	BatchId       int64                  `protobuf:"varint,1,opt,name=batchId,proto3" json:"batchId,omitempty"`
+	Entries       []*ReplicationEntry    `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NewServerAddResponse) Reset() {
-	*x = NewServerAddResponse{}
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[5]
+func (x *ReplicationBatch) Reset() {
+	*x = ReplicationBatch{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NewServerAddResponse) String() string {
+func (x *ReplicationBatch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NewServerAddResponse) ProtoMessage() {}
+func (*ReplicationBatch) ProtoMessage() {}
 
-func (x *NewServerAddResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeControlPlane_proto_msgTypes[5]
+func (x *ReplicationBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -346,109 +507,2843 @@ func (x *NewServerAddResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NewServerAddResponse.ProtoReflect.Descriptor instead.
-func (*NewServerAddResponse) Descriptor() ([]byte, []int) {
-	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use ReplicationBatch.ProtoReflect.Descriptor instead.
+func (*ReplicationBatch) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *NewServerAddResponse) GetStatus() string {
+func (x *ReplicationBatch) GetBatchId() int64 {
+	if x != nil {
+		return x.BatchId
+	}
+	return 0
+}
+
+func (x *ReplicationBatch) GetEntries() []*ReplicationEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type ReplicationAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BatchId       int64                  `protobuf:"varint,1,opt,name=batchId,proto3" json:"batchId,omitempty"`
+	Status        bool                   `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicationAck) Reset() {
+	*x = ReplicationAck{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicationAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicationAck) ProtoMessage() {}
+
+func (x *ReplicationAck) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicationAck.ProtoReflect.Descriptor instead.
+func (*ReplicationAck) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReplicationAck) GetBatchId() int64 {
+	if x != nil {
+		return x.BatchId
+	}
+	return 0
+}
+
+func (x *ReplicationAck) GetStatus() bool {
 	if x != nil {
 		return x.Status
 	}
-	return ""
+	return false
 }
 
-func (x *NewServerAddResponse) GetMessage() string {
+func (x *ReplicationAck) GetError() string {
 	if x != nil {
-		return x.Message
+		return x.Error
 	}
 	return ""
 }
 
-var File_protos_NodeControlPlane_proto protoreflect.FileDescriptor
-
-const file_protos_NodeControlPlane_proto_rawDesc = "" +
-	"\n" +
-	"\x1dprotos/NodeControlPlane.proto\x12\x10nodecontrolplane\"?\n" +
-	"\x15SetReplicationRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\"X\n" +
-	"\x16SetReplicationResponse\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\bR\x06status\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\",\n" +
-	"\x18DeleteReplicationRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"q\n" +
-	"\x19DeleteReplicationResponse\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
-	"\x06status\x18\x03 \x01(\bR\x06status\x12\x14\n" +
-	"\x05error\x18\x04 \x01(\tR\x05error\"\xa1\x01\n" +
-	"\x13NewServerAddRequest\x12\x1a\n" +
-	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
-	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12*\n" +
-	"\x10controlPlanePort\x18\x03 \x01(\tR\x10controlPlanePort\x12$\n" +
-	"\rdataPlanePort\x18\x04 \x01(\tR\rdataPlanePort\"H\n" +
-	"\x14NewServerAddResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xde\x02\n" +
-	"\x17NodeControlPlaneService\x12h\n" +
-	"\x13ReplicateSetRequest\x12'.nodecontrolplane.SetReplicationRequest\x1a(.nodecontrolplane.SetReplicationResponse\x12q\n" +
-	"\x16ReplicateDeleteRequest\x12*.nodecontrolplane.DeleteReplicationRequest\x1a+.nodecontrolplane.DeleteReplicationResponse\x12f\n" +
-	"\x15RegisterNewPeerServer\x12%.nodecontrolplane.NewServerAddRequest\x1a&.nodecontrolplane.NewServerAddResponseB2Z0github.com/Vahsek/distrokv/pkg/node/controlplaneb\x06proto3"
+// nodes are indexes into the Merkle tree of the store, the root is 1 and the
+// children of node i are 2i and 2i+1. hashes holds one hash per node.
+type MerkleHashesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []uint32               `protobuf:"varint,1,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-var (
-	file_protos_NodeControlPlane_proto_rawDescOnce sync.Once
-	file_protos_NodeControlPlane_proto_rawDescData []byte
-)
+func (x *MerkleHashesRequest) Reset() {
+	*x = MerkleHashesRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-func file_protos_NodeControlPlane_proto_rawDescGZIP() []byte {
-	file_protos_NodeControlPlane_proto_rawDescOnce.Do(func() {
-		file_protos_NodeControlPlane_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_protos_NodeControlPlane_proto_rawDesc), len(file_protos_NodeControlPlane_proto_rawDesc)))
-	})
-	return file_protos_NodeControlPlane_proto_rawDescData
+func (x *MerkleHashesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_protos_NodeControlPlane_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
-var file_protos_NodeControlPlane_proto_goTypes = []any{
-	(*SetReplicationRequest)(nil),     // 0: nodecontrolplane.SetReplicationRequest
-	(*SetReplicationResponse)(nil),    // 1: nodecontrolplane.SetReplicationResponse
-	(*DeleteReplicationRequest)(nil),  // 2: nodecontrolplane.DeleteReplicationRequest
-	(*DeleteReplicationResponse)(nil), // 3: nodecontrolplane.DeleteReplicationResponse
-	(*NewServerAddRequest)(nil),       // 4: nodecontrolplane.NewServerAddRequest
-	(*NewServerAddResponse)(nil),      // 5: nodecontrolplane.NewServerAddResponse
+func (*MerkleHashesRequest) ProtoMessage() {}
+
+func (x *MerkleHashesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_protos_NodeControlPlane_proto_depIdxs = []int32{
-	0, // 0: nodecontrolplane.NodeControlPlaneService.ReplicateSetRequest:input_type -> nodecontrolplane.SetReplicationRequest
-	2, // 1: nodecontrolplane.NodeControlPlaneService.ReplicateDeleteRequest:input_type -> nodecontrolplane.DeleteReplicationRequest
-	4, // 2: nodecontrolplane.NodeControlPlaneService.RegisterNewPeerServer:input_type -> nodecontrolplane.NewServerAddRequest
-	1, // 3: nodecontrolplane.NodeControlPlaneService.ReplicateSetRequest:output_type -> nodecontrolplane.SetReplicationResponse
-	3, // 4: nodecontrolplane.NodeControlPlaneService.ReplicateDeleteRequest:output_type -> nodecontrolplane.DeleteReplicationResponse
-	5, // 5: nodecontrolplane.NodeControlPlaneService.RegisterNewPeerServer:output_type -> nodecontrolplane.NewServerAddResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+
+// Deprecated: Use MerkleHashesRequest.ProtoReflect.Descriptor instead.
+func (*MerkleHashesRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{8}
 }
 
-func init() { file_protos_NodeControlPlane_proto_init() }
+func (x *MerkleHashesRequest) GetNodes() []uint32 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type MerkleHashesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hashes        []uint64               `protobuf:"varint,1,rep,packed,name=hashes,proto3" json:"hashes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MerkleHashesResponse) Reset() {
+	*x = MerkleHashesResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MerkleHashesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MerkleHashesResponse) ProtoMessage() {}
+
+func (x *MerkleHashesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MerkleHashesResponse.ProtoReflect.Descriptor instead.
+func (*MerkleHashesResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MerkleHashesResponse) GetHashes() []uint64 {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+// The first message of a repair names the leaves whose ranges are repaired.
+// The primary sends their entries once the peer answered it is ready, the
+// last message ends the repair.
+type RepairMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RepairId      string                 `protobuf:"bytes,1,opt,name=repairId,proto3" json:"repairId,omitempty"`
+	Leaves        []uint32               `protobuf:"varint,2,rep,packed,name=leaves,proto3" json:"leaves,omitempty"`
+	Entries       []*SnapshotEntry       `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`
+	Last          bool                   `protobuf:"varint,4,opt,name=last,proto3" json:"last,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RepairMessage) Reset() {
+	*x = RepairMessage{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RepairMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RepairMessage) ProtoMessage() {}
+
+func (x *RepairMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RepairMessage.ProtoReflect.Descriptor instead.
+func (*RepairMessage) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RepairMessage) GetRepairId() string {
+	if x != nil {
+		return x.RepairId
+	}
+	return ""
+}
+
+func (x *RepairMessage) GetLeaves() []uint32 {
+	if x != nil {
+		return x.Leaves
+	}
+	return nil
+}
+
+func (x *RepairMessage) GetEntries() []*SnapshotEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *RepairMessage) GetLast() bool {
+	if x != nil {
+		return x.Last
+	}
+	return false
+}
+
+// newer holds the keys a repaired peer kept because they were stamped later
+// than what the primary sent, the primary takes them over.
+type RepairResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ready         bool                   `protobuf:"varint,1,opt,name=ready,proto3" json:"ready,omitempty"`
+	RepairedKeys  int64                  `protobuf:"varint,2,opt,name=repairedKeys,proto3" json:"repairedKeys,omitempty"`
+	Newer         []*SnapshotEntry       `protobuf:"bytes,3,rep,name=newer,proto3" json:"newer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RepairResponse) Reset() {
+	*x = RepairResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RepairResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RepairResponse) ProtoMessage() {}
+
+func (x *RepairResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RepairResponse.ProtoReflect.Descriptor instead.
+func (*RepairResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RepairResponse) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *RepairResponse) GetRepairedKeys() int64 {
+	if x != nil {
+		return x.RepairedKeys
+	}
+	return 0
+}
+
+func (x *RepairResponse) GetNewer() []*SnapshotEntry {
+	if x != nil {
+		return x.Newer
+	}
+	return nil
+}
+
+type NewServerAddRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Hostname         string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	IpAddress        string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+	ControlPlanePort string                 `protobuf:"bytes,3,opt,name=controlPlanePort,proto3" json:"controlPlanePort,omitempty"`
+	DataPlanePort    string                 `protobuf:"bytes,4,opt,name=dataPlanePort,proto3" json:"dataPlanePort,omitempty"`
+	NodeId           string                 `protobuf:"bytes,5,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Incarnation      int64                  `protobuf:"varint,6,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	Learner          bool                   `protobuf:"varint,7,opt,name=learner,proto3" json:"learner,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *NewServerAddRequest) Reset() {
+	*x = NewServerAddRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NewServerAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewServerAddRequest) ProtoMessage() {}
+
+func (x *NewServerAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewServerAddRequest.ProtoReflect.Descriptor instead.
+func (*NewServerAddRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NewServerAddRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *NewServerAddRequest) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *NewServerAddRequest) GetControlPlanePort() string {
+	if x != nil {
+		return x.ControlPlanePort
+	}
+	return ""
+}
+
+func (x *NewServerAddRequest) GetDataPlanePort() string {
+	if x != nil {
+		return x.DataPlanePort
+	}
+	return ""
+}
+
+func (x *NewServerAddRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *NewServerAddRequest) GetIncarnation() int64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+func (x *NewServerAddRequest) GetLearner() bool {
+	if x != nil {
+		return x.Learner
+	}
+	return false
+}
+
+type NewServerAddResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NewServerAddResponse) Reset() {
+	*x = NewServerAddResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NewServerAddResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewServerAddResponse) ProtoMessage() {}
+
+func (x *NewServerAddResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewServerAddResponse.ProtoReflect.Descriptor instead.
+func (*NewServerAddResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *NewServerAddResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *NewServerAddResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type RemovePeerRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Hostname         string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	IpAddress        string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+	ControlPlanePort string                 `protobuf:"bytes,3,opt,name=controlPlanePort,proto3" json:"controlPlanePort,omitempty"`
+	NodeId           string                 `protobuf:"bytes,4,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *RemovePeerRequest) Reset() {
+	*x = RemovePeerRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovePeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePeerRequest) ProtoMessage() {}
+
+func (x *RemovePeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePeerRequest.ProtoReflect.Descriptor instead.
+func (*RemovePeerRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RemovePeerRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *RemovePeerRequest) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *RemovePeerRequest) GetControlPlanePort() string {
+	if x != nil {
+		return x.ControlPlanePort
+	}
+	return ""
+}
+
+func (x *RemovePeerRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type RemovePeerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemovePeerResponse) Reset() {
+	*x = RemovePeerResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovePeerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePeerResponse) ProtoMessage() {}
+
+func (x *RemovePeerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePeerResponse.ProtoReflect.Descriptor instead.
+func (*RemovePeerResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RemovePeerResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RemovePeerResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// MemberUpdate is a SWIM membership update. Updates about the same node are
+// ordered by incarnation, which only the node itself increases.
+type MemberUpdate struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	NodeId           string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Hostname         string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	IpAddress        string                 `protobuf:"bytes,3,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+	ControlPlanePort string                 `protobuf:"bytes,4,opt,name=controlPlanePort,proto3" json:"controlPlanePort,omitempty"`
+	DataPlanePort    string                 `protobuf:"bytes,5,opt,name=dataPlanePort,proto3" json:"dataPlanePort,omitempty"`
+	State            MemberUpdate_State     `protobuf:"varint,6,opt,name=state,proto3,enum=nodecontrolplane.MemberUpdate_State" json:"state,omitempty"`
+	Incarnation      int64                  `protobuf:"varint,7,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	Learner          bool                   `protobuf:"varint,8,opt,name=learner,proto3" json:"learner,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MemberUpdate) Reset() {
+	*x = MemberUpdate{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemberUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemberUpdate) ProtoMessage() {}
+
+func (x *MemberUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemberUpdate.ProtoReflect.Descriptor instead.
+func (*MemberUpdate) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *MemberUpdate) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *MemberUpdate) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *MemberUpdate) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *MemberUpdate) GetControlPlanePort() string {
+	if x != nil {
+		return x.ControlPlanePort
+	}
+	return ""
+}
+
+func (x *MemberUpdate) GetDataPlanePort() string {
+	if x != nil {
+		return x.DataPlanePort
+	}
+	return ""
+}
+
+func (x *MemberUpdate) GetState() MemberUpdate_State {
+	if x != nil {
+		return x.State
+	}
+	return MemberUpdate_ALIVE
+}
+
+func (x *MemberUpdate) GetIncarnation() int64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+func (x *MemberUpdate) GetLearner() bool {
+	if x != nil {
+		return x.Learner
+	}
+	return false
+}
+
+// Every gossip message carries the sender's own state and piggybacks the
+// updates it is still disseminating
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        *MemberUpdate          `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Updates       []*MemberUpdate        `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PingRequest) GetSource() *MemberUpdate {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *PingRequest) GetUpdates() []*MemberUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+type PingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        *MemberUpdate          `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Updates       []*MemberUpdate        `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingResponse) Reset() {
+	*x = PingResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingResponse) ProtoMessage() {}
+
+func (x *PingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PingResponse) GetSource() *MemberUpdate {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *PingResponse) GetUpdates() []*MemberUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+// PingReq asks the receiver to ping targetAddress on the sender's behalf
+type PingReqRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        *MemberUpdate          `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Updates       []*MemberUpdate        `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	TargetNodeId  string                 `protobuf:"bytes,3,opt,name=targetNodeId,proto3" json:"targetNodeId,omitempty"`
+	TargetAddress string                 `protobuf:"bytes,4,opt,name=targetAddress,proto3" json:"targetAddress,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingReqRequest) Reset() {
+	*x = PingReqRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingReqRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingReqRequest) ProtoMessage() {}
+
+func (x *PingReqRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingReqRequest.ProtoReflect.Descriptor instead.
+func (*PingReqRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PingReqRequest) GetSource() *MemberUpdate {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *PingReqRequest) GetUpdates() []*MemberUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+func (x *PingReqRequest) GetTargetNodeId() string {
+	if x != nil {
+		return x.TargetNodeId
+	}
+	return ""
+}
+
+func (x *PingReqRequest) GetTargetAddress() string {
+	if x != nil {
+		return x.TargetAddress
+	}
+	return ""
+}
+
+// A node that grants a lease promises not to grant one to another node for
+// durationMs, measured from when it granted. inSync tells the receiver that
+// every write the sender acknowledged so far was replicated to it. A durationMs
+// of 0 releases a lease granted to the sender. A preVote request only asks
+// whether the lease would be granted and changes nothing on the receiver.
+type LeaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,2,opt,name=durationMs,proto3" json:"durationMs,omitempty"`
+	InSync        bool                   `protobuf:"varint,3,opt,name=inSync,proto3" json:"inSync,omitempty"`
+	PreVote       bool                   `protobuf:"varint,4,opt,name=preVote,proto3" json:"preVote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseRequest) Reset() {
+	*x = LeaseRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseRequest) ProtoMessage() {}
+
+func (x *LeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseRequest.ProtoReflect.Descriptor instead.
+func (*LeaseRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *LeaseRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *LeaseRequest) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *LeaseRequest) GetInSync() bool {
+	if x != nil {
+		return x.InSync
+	}
+	return false
+}
+
+func (x *LeaseRequest) GetPreVote() bool {
+	if x != nil {
+		return x.PreVote
+	}
+	return false
+}
+
+type LeaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Granted       bool                   `protobuf:"varint,1,opt,name=granted,proto3" json:"granted,omitempty"`
+	HolderNodeId  string                 `protobuf:"bytes,2,opt,name=holderNodeId,proto3" json:"holderNodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaseResponse) Reset() {
+	*x = LeaseResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaseResponse) ProtoMessage() {}
+
+func (x *LeaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaseResponse.ProtoReflect.Descriptor instead.
+func (*LeaseResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *LeaseResponse) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+func (x *LeaseResponse) GetHolderNodeId() string {
+	if x != nil {
+		return x.HolderNodeId
+	}
+	return ""
+}
+
+// A snapshot is a serialized Snapshot sent in chunks. Every chunk carries its
+// offset and the CRC-32C of its data so a transfer that broke off, or hit a
+// corrupt chunk, resumes at the first byte the receiver is missing.
+type SnapshotChunk struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	SnapshotId string                 `protobuf:"bytes,1,opt,name=snapshotId,proto3" json:"snapshotId,omitempty"`
+	Offset     int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data       []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Checksum   uint32                 `protobuf:"varint,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	TotalSize  int64                  `protobuf:"varint,5,opt,name=totalSize,proto3" json:"totalSize,omitempty"`
+	// totalChecksum is the CRC-32C of the whole snapshot
+	TotalChecksum uint32 `protobuf:"varint,6,opt,name=totalChecksum,proto3" json:"totalChecksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotChunk) Reset() {
+	*x = SnapshotChunk{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotChunk) ProtoMessage() {}
+
+func (x *SnapshotChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotChunk.ProtoReflect.Descriptor instead.
+func (*SnapshotChunk) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SnapshotChunk) GetSnapshotId() string {
+	if x != nil {
+		return x.SnapshotId
+	}
+	return ""
+}
+
+func (x *SnapshotChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SnapshotChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *SnapshotChunk) GetChecksum() uint32 {
+	if x != nil {
+		return x.Checksum
+	}
+	return 0
+}
+
+func (x *SnapshotChunk) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *SnapshotChunk) GetTotalChecksum() uint32 {
+	if x != nil {
+		return x.TotalChecksum
+	}
+	return 0
+}
+
+// The receiver answers once the snapshot is installed or as soon as a chunk
+// does not continue where it left off, in which case the sender resumes from
+// nextOffset
+type InstallSnapshotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Installed     bool                   `protobuf:"varint,1,opt,name=installed,proto3" json:"installed,omitempty"`
+	NextOffset    int64                  `protobuf:"varint,2,opt,name=nextOffset,proto3" json:"nextOffset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstallSnapshotResponse) Reset() {
+	*x = InstallSnapshotResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstallSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallSnapshotResponse) ProtoMessage() {}
+
+func (x *InstallSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*InstallSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *InstallSnapshotResponse) GetInstalled() bool {
+	if x != nil {
+		return x.Installed
+	}
+	return false
+}
+
+func (x *InstallSnapshotResponse) GetNextOffset() int64 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+// A deleted entry is a tombstone, only repairs send those.
+type SnapshotEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Deleted       bool                   `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotEntry) Reset() {
+	*x = SnapshotEntry{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotEntry) ProtoMessage() {}
+
+func (x *SnapshotEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotEntry.ProtoReflect.Descriptor instead.
+func (*SnapshotEntry) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SnapshotEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SnapshotEntry) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *SnapshotEntry) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SnapshotEntry) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+// position is the last write of the sender that the snapshot contains
+type Snapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*SnapshotEntry       `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Position      *WritePosition         `protobuf:"bytes,2,opt,name=position,proto3" json:"position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Snapshot) GetEntries() []*SnapshotEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *Snapshot) GetPosition() *WritePosition {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+// TransferLeadership asks the primary to hand its role to targetNodeId
+type TransferLeadershipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TargetNodeId  string                 `protobuf:"bytes,1,opt,name=targetNodeId,proto3" json:"targetNodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferLeadershipRequest) Reset() {
+	*x = TransferLeadershipRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferLeadershipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferLeadershipRequest) ProtoMessage() {}
+
+func (x *TransferLeadershipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferLeadershipRequest.ProtoReflect.Descriptor instead.
+func (*TransferLeadershipRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *TransferLeadershipRequest) GetTargetNodeId() string {
+	if x != nil {
+		return x.TargetNodeId
+	}
+	return ""
+}
+
+type TransferLeadershipResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	PrimaryNodeId string                 `protobuf:"bytes,3,opt,name=primaryNodeId,proto3" json:"primaryNodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferLeadershipResponse) Reset() {
+	*x = TransferLeadershipResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferLeadershipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferLeadershipResponse) ProtoMessage() {}
+
+func (x *TransferLeadershipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferLeadershipResponse.ProtoReflect.Descriptor instead.
+func (*TransferLeadershipResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *TransferLeadershipResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *TransferLeadershipResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TransferLeadershipResponse) GetPrimaryNodeId() string {
+	if x != nil {
+		return x.PrimaryNodeId
+	}
+	return ""
+}
+
+// TimeoutNow tells the target of a leadership transfer that the primary
+// stepped down and it should take over right away
+type TimeoutNowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromNodeId    string                 `protobuf:"bytes,1,opt,name=fromNodeId,proto3" json:"fromNodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeoutNowRequest) Reset() {
+	*x = TimeoutNowRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeoutNowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeoutNowRequest) ProtoMessage() {}
+
+func (x *TimeoutNowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeoutNowRequest.ProtoReflect.Descriptor instead.
+func (*TimeoutNowRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *TimeoutNowRequest) GetFromNodeId() string {
+	if x != nil {
+		return x.FromNodeId
+	}
+	return ""
+}
+
+type TimeoutNowResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TookOver      bool                   `protobuf:"varint,1,opt,name=tookOver,proto3" json:"tookOver,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeoutNowResponse) Reset() {
+	*x = TimeoutNowResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeoutNowResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeoutNowResponse) ProtoMessage() {}
+
+func (x *TimeoutNowResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeoutNowResponse.ProtoReflect.Descriptor instead.
+func (*TimeoutNowResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TimeoutNowResponse) GetTookOver() bool {
+	if x != nil {
+		return x.TookOver
+	}
+	return false
+}
+
+// VersionedValue is a value as replicas store it in leaderless mode. The
+// version with the newest timestamp wins and the node id of the coordinator
+// breaks ties. A delete leaves a tombstone so a late older write cannot bring
+// the key back.
+//
+// With vector clocks the node id and timestamp identify the write instead and
+// clock is the causal context it was made with, the newest timestamp of every
+// node the writer had seen. A version replaces exactly the versions its clock
+// covers, versions neither covers are concurrent and kept side by side as
+// siblings.
+type VersionedValue struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Value     string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	NodeId    string                 `protobuf:"bytes,3,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Deleted   bool                   `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Clock     map[string]int64       `protobuf:"bytes,5,rep,name=clock,proto3" json:"clock,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// crdt is set instead of value for CRDT keys. Two CRDT versions are
+	// merged rather than one replacing the other.
+	Crdt          *CRDTState `protobuf:"bytes,6,opt,name=crdt,proto3" json:"crdt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionedValue) Reset() {
+	*x = VersionedValue{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionedValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionedValue) ProtoMessage() {}
+
+func (x *VersionedValue) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionedValue.ProtoReflect.Descriptor instead.
+func (*VersionedValue) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *VersionedValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *VersionedValue) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *VersionedValue) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *VersionedValue) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *VersionedValue) GetClock() map[string]int64 {
+	if x != nil {
+		return x.Clock
+	}
+	return nil
+}
+
+func (x *VersionedValue) GetCrdt() *CRDTState {
+	if x != nil {
+		return x.Crdt
+	}
+	return nil
+}
+
+// Siblings is how a replica stores a key, one version under last writer wins
+// and every concurrent version with vector clocks
+type Siblings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*VersionedValue      `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Siblings) Reset() {
+	*x = Siblings{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Siblings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Siblings) ProtoMessage() {}
+
+func (x *Siblings) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Siblings.ProtoReflect.Descriptor instead.
+func (*Siblings) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Siblings) GetVersions() []*VersionedValue {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+// CausalContext is encoded into the opaque context a vector clock read returns
+// and a write passes back
+type CausalContext struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Clock         map[string]int64       `protobuf:"bytes,1,rep,name=clock,proto3" json:"clock,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CausalContext) Reset() {
+	*x = CausalContext{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CausalContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CausalContext) ProtoMessage() {}
+
+func (x *CausalContext) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CausalContext.ProtoReflect.Descriptor instead.
+func (*CausalContext) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *CausalContext) GetClock() map[string]int64 {
+	if x != nil {
+		return x.Clock
+	}
+	return nil
+}
+
+type VersionedEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Version       *VersionedValue        `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionedEntry) Reset() {
+	*x = VersionedEntry{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionedEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionedEntry) ProtoMessage() {}
+
+func (x *VersionedEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionedEntry.ProtoReflect.Descriptor instead.
+func (*VersionedEntry) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *VersionedEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *VersionedEntry) GetVersion() *VersionedValue {
+	if x != nil {
+		return x.Version
+	}
+	return nil
+}
+
+type ReplicaReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaReadRequest) Reset() {
+	*x = ReplicaReadRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaReadRequest) ProtoMessage() {}
+
+func (x *ReplicaReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaReadRequest.ProtoReflect.Descriptor instead.
+func (*ReplicaReadRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ReplicaReadRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// versions is empty when the replica holds no version of the key, not even a
+// tombstone
+type ReplicaReadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*VersionedValue      `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaReadResponse) Reset() {
+	*x = ReplicaReadResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaReadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaReadResponse) ProtoMessage() {}
+
+func (x *ReplicaReadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaReadResponse.ProtoReflect.Descriptor instead.
+func (*ReplicaReadResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ReplicaReadResponse) GetVersions() []*VersionedValue {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+// Each entry is kept only if no version the replica holds replaces it
+type ReplicaWriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*VersionedEntry      `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaWriteRequest) Reset() {
+	*x = ReplicaWriteRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaWriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaWriteRequest) ProtoMessage() {}
+
+func (x *ReplicaWriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaWriteRequest.ProtoReflect.Descriptor instead.
+func (*ReplicaWriteRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ReplicaWriteRequest) GetEntries() []*VersionedEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type ReplicaWriteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        bool                   `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaWriteResponse) Reset() {
+	*x = ReplicaWriteResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaWriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaWriteResponse) ProtoMessage() {}
+
+func (x *ReplicaWriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaWriteResponse.ProtoReflect.Descriptor instead.
+func (*ReplicaWriteResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ReplicaWriteResponse) GetStatus() bool {
+	if x != nil {
+		return x.Status
+	}
+	return false
+}
+
+func (x *ReplicaWriteResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// CRDTState is the value of a CRDT key, stored as is by the primary and
+// inside a VersionedValue in leaderless mode
+type CRDTState struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to State:
+	//
+	//	*CRDTState_Counter
+	//	*CRDTState_Set
+	//	*CRDTState_Register
+	State         isCRDTState_State `protobuf_oneof:"state"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CRDTState) Reset() {
+	*x = CRDTState{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CRDTState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CRDTState) ProtoMessage() {}
+
+func (x *CRDTState) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CRDTState.ProtoReflect.Descriptor instead.
+func (*CRDTState) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CRDTState) GetState() isCRDTState_State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *CRDTState) GetCounter() *PNCounter {
+	if x != nil {
+		if x, ok := x.State.(*CRDTState_Counter); ok {
+			return x.Counter
+		}
+	}
+	return nil
+}
+
+func (x *CRDTState) GetSet() *ORSet {
+	if x != nil {
+		if x, ok := x.State.(*CRDTState_Set); ok {
+			return x.Set
+		}
+	}
+	return nil
+}
+
+func (x *CRDTState) GetRegister() *LWWRegister {
+	if x != nil {
+		if x, ok := x.State.(*CRDTState_Register); ok {
+			return x.Register
+		}
+	}
+	return nil
+}
+
+type isCRDTState_State interface {
+	isCRDTState_State()
+}
+
+type CRDTState_Counter struct {
+	Counter *PNCounter `protobuf:"bytes,1,opt,name=counter,proto3,oneof"`
+}
+
+type CRDTState_Set struct {
+	Set *ORSet `protobuf:"bytes,2,opt,name=set,proto3,oneof"`
+}
+
+type CRDTState_Register struct {
+	Register *LWWRegister `protobuf:"bytes,3,opt,name=register,proto3,oneof"`
+}
+
+func (*CRDTState_Counter) isCRDTState_State() {}
+
+func (*CRDTState_Set) isCRDTState_State() {}
+
+func (*CRDTState_Register) isCRDTState_State() {}
+
+// PNCounter keeps the increments and the decrements every node applied, its
+// value is the sum of the increments minus the sum of the decrements
+type PNCounter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Increments    map[string]int64       `protobuf:"bytes,1,rep,name=increments,proto3" json:"increments,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Decrements    map[string]int64       `protobuf:"bytes,2,rep,name=decrements,proto3" json:"decrements,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PNCounter) Reset() {
+	*x = PNCounter{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PNCounter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PNCounter) ProtoMessage() {}
+
+func (x *PNCounter) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PNCounter.ProtoReflect.Descriptor instead.
+func (*PNCounter) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *PNCounter) GetIncrements() map[string]int64 {
+	if x != nil {
+		return x.Increments
+	}
+	return nil
+}
+
+func (x *PNCounter) GetDecrements() map[string]int64 {
+	if x != nil {
+		return x.Decrements
+	}
+	return nil
+}
+
+// Dot identifies a single add to an ORSet
+type Dot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Counter       int64                  `protobuf:"varint,2,opt,name=counter,proto3" json:"counter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Dot) Reset() {
+	*x = Dot{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Dot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Dot) ProtoMessage() {}
+
+func (x *Dot) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Dot.ProtoReflect.Descriptor instead.
+func (*Dot) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *Dot) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *Dot) GetCounter() int64 {
+	if x != nil {
+		return x.Counter
+	}
+	return 0
+}
+
+type ORSetElement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Added         []*Dot                 `protobuf:"bytes,1,rep,name=added,proto3" json:"added,omitempty"`
+	Removed       []*Dot                 `protobuf:"bytes,2,rep,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ORSetElement) Reset() {
+	*x = ORSetElement{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ORSetElement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ORSetElement) ProtoMessage() {}
+
+func (x *ORSetElement) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ORSetElement.ProtoReflect.Descriptor instead.
+func (*ORSetElement) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ORSetElement) GetAdded() []*Dot {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *ORSetElement) GetRemoved() []*Dot {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+// ORSet holds an element while one of its adds was not removed
+type ORSet struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Elements      map[string]*ORSetElement `protobuf:"bytes,1,rep,name=elements,proto3" json:"elements,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ORSet) Reset() {
+	*x = ORSet{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ORSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ORSet) ProtoMessage() {}
+
+func (x *ORSet) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ORSet.ProtoReflect.Descriptor instead.
+func (*ORSet) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ORSet) GetElements() map[string]*ORSetElement {
+	if x != nil {
+		return x.Elements
+	}
+	return nil
+}
+
+type LWWRegister struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	NodeId        string                 `protobuf:"bytes,3,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LWWRegister) Reset() {
+	*x = LWWRegister{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LWWRegister) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LWWRegister) ProtoMessage() {}
+
+func (x *LWWRegister) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LWWRegister.ProtoReflect.Descriptor instead.
+func (*LWWRegister) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *LWWRegister) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *LWWRegister) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *LWWRegister) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type CRDTOperation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Operation:
+	//
+	//	*CRDTOperation_Increment
+	//	*CRDTOperation_SetAdd
+	//	*CRDTOperation_SetRemove
+	//	*CRDTOperation_RegisterSet
+	Operation     isCRDTOperation_Operation `protobuf_oneof:"operation"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CRDTOperation) Reset() {
+	*x = CRDTOperation{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CRDTOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CRDTOperation) ProtoMessage() {}
+
+func (x *CRDTOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CRDTOperation.ProtoReflect.Descriptor instead.
+func (*CRDTOperation) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CRDTOperation) GetOperation() isCRDTOperation_Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+func (x *CRDTOperation) GetIncrement() int64 {
+	if x != nil {
+		if x, ok := x.Operation.(*CRDTOperation_Increment); ok {
+			return x.Increment
+		}
+	}
+	return 0
+}
+
+func (x *CRDTOperation) GetSetAdd() string {
+	if x != nil {
+		if x, ok := x.Operation.(*CRDTOperation_SetAdd); ok {
+			return x.SetAdd
+		}
+	}
+	return ""
+}
+
+func (x *CRDTOperation) GetSetRemove() string {
+	if x != nil {
+		if x, ok := x.Operation.(*CRDTOperation_SetRemove); ok {
+			return x.SetRemove
+		}
+	}
+	return ""
+}
+
+func (x *CRDTOperation) GetRegisterSet() string {
+	if x != nil {
+		if x, ok := x.Operation.(*CRDTOperation_RegisterSet); ok {
+			return x.RegisterSet
+		}
+	}
+	return ""
+}
+
+type isCRDTOperation_Operation interface {
+	isCRDTOperation_Operation()
+}
+
+type CRDTOperation_Increment struct {
+	Increment int64 `protobuf:"varint,1,opt,name=increment,proto3,oneof"`
+}
+
+type CRDTOperation_SetAdd struct {
+	SetAdd string `protobuf:"bytes,2,opt,name=setAdd,proto3,oneof"`
+}
+
+type CRDTOperation_SetRemove struct {
+	SetRemove string `protobuf:"bytes,3,opt,name=setRemove,proto3,oneof"`
+}
+
+type CRDTOperation_RegisterSet struct {
+	RegisterSet string `protobuf:"bytes,4,opt,name=registerSet,proto3,oneof"`
+}
+
+func (*CRDTOperation_Increment) isCRDTOperation_Operation() {}
+
+func (*CRDTOperation_SetAdd) isCRDTOperation_Operation() {}
+
+func (*CRDTOperation_SetRemove) isCRDTOperation_Operation() {}
+
+func (*CRDTOperation_RegisterSet) isCRDTOperation_Operation() {}
+
+// The replica applies the operation as itself and returns the version it
+// stored, which the coordinator sends on to the other replicas
+type ReplicaUpdateCRDTRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Operation     *CRDTOperation         `protobuf:"bytes,2,opt,name=operation,proto3" json:"operation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaUpdateCRDTRequest) Reset() {
+	*x = ReplicaUpdateCRDTRequest{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaUpdateCRDTRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaUpdateCRDTRequest) ProtoMessage() {}
+
+func (x *ReplicaUpdateCRDTRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaUpdateCRDTRequest.ProtoReflect.Descriptor instead.
+func (*ReplicaUpdateCRDTRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *ReplicaUpdateCRDTRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ReplicaUpdateCRDTRequest) GetOperation() *CRDTOperation {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+type ReplicaUpdateCRDTResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       *VersionedValue        `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaUpdateCRDTResponse) Reset() {
+	*x = ReplicaUpdateCRDTResponse{}
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaUpdateCRDTResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaUpdateCRDTResponse) ProtoMessage() {}
+
+func (x *ReplicaUpdateCRDTResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeControlPlane_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaUpdateCRDTResponse.ProtoReflect.Descriptor instead.
+func (*ReplicaUpdateCRDTResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeControlPlane_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ReplicaUpdateCRDTResponse) GetVersion() *VersionedValue {
+	if x != nil {
+		return x.Version
+	}
+	return nil
+}
+
+var File_protos_NodeControlPlane_proto protoreflect.FileDescriptor
+
+const file_protos_NodeControlPlane_proto_rawDesc = "" +
+	"\n" +
+	"\x1dprotos/NodeControlPlane.proto\x12\x10nodecontrolplane\"Y\n" +
+	"\rWritePosition\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x03R\x05epoch\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x03R\bsequence\"\x9a\x01\n" +
+	"\x15SetReplicationRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12;\n" +
+	"\bposition\x18\x03 \x01(\v2\x1f.nodecontrolplane.WritePositionR\bposition\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"X\n" +
+	"\x16SetReplicationResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x87\x01\n" +
+	"\x18DeleteReplicationRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12;\n" +
+	"\bposition\x18\x02 \x01(\v2\x1f.nodecontrolplane.WritePositionR\bposition\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"q\n" +
+	"\x19DeleteReplicationResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\xad\x01\n" +
+	"\x10ReplicationEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06delete\x18\x03 \x01(\bR\x06delete\x12;\n" +
+	"\bposition\x18\x04 \x01(\v2\x1f.nodecontrolplane.WritePositionR\bposition\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"j\n" +
+	"\x10ReplicationBatch\x12\x18\n" +
+	"\abatchId\x18\x01 \x01(\x03R\abatchId\x12<\n" +
+	"\aentries\x18\x02 \x03(\v2\".nodecontrolplane.ReplicationEntryR\aentries\"X\n" +
+	"\x0eReplicationAck\x12\x18\n" +
+	"\abatchId\x18\x01 \x01(\x03R\abatchId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"+\n" +
+	"\x13MerkleHashesRequest\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\rR\x05nodes\".\n" +
+	"\x14MerkleHashesResponse\x12\x16\n" +
+	"\x06hashes\x18\x01 \x03(\x04R\x06hashes\"\x92\x01\n" +
+	"\rRepairMessage\x12\x1a\n" +
+	"\brepairId\x18\x01 \x01(\tR\brepairId\x12\x16\n" +
+	"\x06leaves\x18\x02 \x03(\rR\x06leaves\x129\n" +
+	"\aentries\x18\x03 \x03(\v2\x1f.nodecontrolplane.SnapshotEntryR\aentries\x12\x12\n" +
+	"\x04last\x18\x04 \x01(\bR\x04last\"\x81\x01\n" +
+	"\x0eRepairResponse\x12\x14\n" +
+	"\x05ready\x18\x01 \x01(\bR\x05ready\x12\"\n" +
+	"\frepairedKeys\x18\x02 \x01(\x03R\frepairedKeys\x125\n" +
+	"\x05newer\x18\x03 \x03(\v2\x1f.nodecontrolplane.SnapshotEntryR\x05newer\"\xf5\x01\n" +
+	"\x13NewServerAddRequest\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
+	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12*\n" +
+	"\x10controlPlanePort\x18\x03 \x01(\tR\x10controlPlanePort\x12$\n" +
+	"\rdataPlanePort\x18\x04 \x01(\tR\rdataPlanePort\x12\x16\n" +
+	"\x06nodeId\x18\x05 \x01(\tR\x06nodeId\x12 \n" +
+	"\vincarnation\x18\x06 \x01(\x03R\vincarnation\x12\x18\n" +
+	"\alearner\x18\a \x01(\bR\alearner\"H\n" +
+	"\x14NewServerAddResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x91\x01\n" +
+	"\x11RemovePeerRequest\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
+	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12*\n" +
+	"\x10controlPlanePort\x18\x03 \x01(\tR\x10controlPlanePort\x12\x16\n" +
+	"\x06nodeId\x18\x04 \x01(\tR\x06nodeId\"F\n" +
+	"\x12RemovePeerResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xdf\x02\n" +
+	"\fMemberUpdate\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12\x1a\n" +
+	"\bhostname\x18\x02 \x01(\tR\bhostname\x12\x1c\n" +
+	"\tipAddress\x18\x03 \x01(\tR\tipAddress\x12*\n" +
+	"\x10controlPlanePort\x18\x04 \x01(\tR\x10controlPlanePort\x12$\n" +
+	"\rdataPlanePort\x18\x05 \x01(\tR\rdataPlanePort\x12:\n" +
+	"\x05state\x18\x06 \x01(\x0e2$.nodecontrolplane.MemberUpdate.StateR\x05state\x12 \n" +
+	"\vincarnation\x18\a \x01(\x03R\vincarnation\x12\x18\n" +
+	"\alearner\x18\b \x01(\bR\alearner\"3\n" +
+	"\x05State\x12\t\n" +
+	"\x05ALIVE\x10\x00\x12\v\n" +
+	"\aSUSPECT\x10\x01\x12\b\n" +
+	"\x04DEAD\x10\x02\x12\b\n" +
+	"\x04LEFT\x10\x03\"\x7f\n" +
+	"\vPingRequest\x126\n" +
+	"\x06source\x18\x01 \x01(\v2\x1e.nodecontrolplane.MemberUpdateR\x06source\x128\n" +
+	"\aupdates\x18\x02 \x03(\v2\x1e.nodecontrolplane.MemberUpdateR\aupdates\"\x80\x01\n" +
+	"\fPingResponse\x126\n" +
+	"\x06source\x18\x01 \x01(\v2\x1e.nodecontrolplane.MemberUpdateR\x06source\x128\n" +
+	"\aupdates\x18\x02 \x03(\v2\x1e.nodecontrolplane.MemberUpdateR\aupdates\"\xcc\x01\n" +
+	"\x0ePingReqRequest\x126\n" +
+	"\x06source\x18\x01 \x01(\v2\x1e.nodecontrolplane.MemberUpdateR\x06source\x128\n" +
+	"\aupdates\x18\x02 \x03(\v2\x1e.nodecontrolplane.MemberUpdateR\aupdates\x12\"\n" +
+	"\ftargetNodeId\x18\x03 \x01(\tR\ftargetNodeId\x12$\n" +
+	"\rtargetAddress\x18\x04 \x01(\tR\rtargetAddress\"x\n" +
+	"\fLeaseRequest\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12\x1e\n" +
+	"\n" +
+	"durationMs\x18\x02 \x01(\x03R\n" +
+	"durationMs\x12\x16\n" +
+	"\x06inSync\x18\x03 \x01(\bR\x06inSync\x12\x18\n" +
+	"\apreVote\x18\x04 \x01(\bR\apreVote\"M\n" +
+	"\rLeaseResponse\x12\x18\n" +
+	"\agranted\x18\x01 \x01(\bR\agranted\x12\"\n" +
+	"\fholderNodeId\x18\x02 \x01(\tR\fholderNodeId\"\xbb\x01\n" +
+	"\rSnapshotChunk\x12\x1e\n" +
+	"\n" +
+	"snapshotId\x18\x01 \x01(\tR\n" +
+	"snapshotId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x1a\n" +
+	"\bchecksum\x18\x04 \x01(\rR\bchecksum\x12\x1c\n" +
+	"\ttotalSize\x18\x05 \x01(\x03R\ttotalSize\x12$\n" +
+	"\rtotalChecksum\x18\x06 \x01(\rR\rtotalChecksum\"W\n" +
+	"\x17InstallSnapshotResponse\x12\x1c\n" +
+	"\tinstalled\x18\x01 \x01(\bR\tinstalled\x12\x1e\n" +
+	"\n" +
+	"nextOffset\x18\x02 \x01(\x03R\n" +
+	"nextOffset\"o\n" +
+	"\rSnapshotEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x18\n" +
+	"\adeleted\x18\x04 \x01(\bR\adeleted\"\x82\x01\n" +
+	"\bSnapshot\x129\n" +
+	"\aentries\x18\x01 \x03(\v2\x1f.nodecontrolplane.SnapshotEntryR\aentries\x12;\n" +
+	"\bposition\x18\x02 \x01(\v2\x1f.nodecontrolplane.WritePositionR\bposition\"?\n" +
+	"\x19TransferLeadershipRequest\x12\"\n" +
+	"\ftargetNodeId\x18\x01 \x01(\tR\ftargetNodeId\"t\n" +
+	"\x1aTransferLeadershipResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12$\n" +
+	"\rprimaryNodeId\x18\x03 \x01(\tR\rprimaryNodeId\"3\n" +
+	"\x11TimeoutNowRequest\x12\x1e\n" +
+	"\n" +
+	"fromNodeId\x18\x01 \x01(\tR\n" +
+	"fromNodeId\"0\n" +
+	"\x12TimeoutNowResponse\x12\x1a\n" +
+	"\btookOver\x18\x01 \x01(\bR\btookOver\"\xa4\x02\n" +
+	"\x0eVersionedValue\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x16\n" +
+	"\x06nodeId\x18\x03 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\adeleted\x18\x04 \x01(\bR\adeleted\x12A\n" +
+	"\x05clock\x18\x05 \x03(\v2+.nodecontrolplane.VersionedValue.ClockEntryR\x05clock\x12/\n" +
+	"\x04crdt\x18\x06 \x01(\v2\x1b.nodecontrolplane.CRDTStateR\x04crdt\x1a8\n" +
+	"\n" +
+	"ClockEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"H\n" +
+	"\bSiblings\x12<\n" +
+	"\bversions\x18\x01 \x03(\v2 .nodecontrolplane.VersionedValueR\bversions\"\x8b\x01\n" +
+	"\rCausalContext\x12@\n" +
+	"\x05clock\x18\x01 \x03(\v2*.nodecontrolplane.CausalContext.ClockEntryR\x05clock\x1a8\n" +
+	"\n" +
+	"ClockEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"^\n" +
+	"\x0eVersionedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12:\n" +
+	"\aversion\x18\x02 \x01(\v2 .nodecontrolplane.VersionedValueR\aversion\"&\n" +
+	"\x12ReplicaReadRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"S\n" +
+	"\x13ReplicaReadResponse\x12<\n" +
+	"\bversions\x18\x01 \x03(\v2 .nodecontrolplane.VersionedValueR\bversions\"Q\n" +
+	"\x13ReplicaWriteRequest\x12:\n" +
+	"\aentries\x18\x01 \x03(\v2 .nodecontrolplane.VersionedEntryR\aentries\"D\n" +
+	"\x14ReplicaWriteResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xb7\x01\n" +
+	"\tCRDTState\x127\n" +
+	"\acounter\x18\x01 \x01(\v2\x1b.nodecontrolplane.PNCounterH\x00R\acounter\x12+\n" +
+	"\x03set\x18\x02 \x01(\v2\x17.nodecontrolplane.ORSetH\x00R\x03set\x12;\n" +
+	"\bregister\x18\x03 \x01(\v2\x1d.nodecontrolplane.LWWRegisterH\x00R\bregisterB\a\n" +
+	"\x05state\"\xa3\x02\n" +
+	"\tPNCounter\x12K\n" +
+	"\n" +
+	"increments\x18\x01 \x03(\v2+.nodecontrolplane.PNCounter.IncrementsEntryR\n" +
+	"increments\x12K\n" +
+	"\n" +
+	"decrements\x18\x02 \x03(\v2+.nodecontrolplane.PNCounter.DecrementsEntryR\n" +
+	"decrements\x1a=\n" +
+	"\x0fIncrementsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1a=\n" +
+	"\x0fDecrementsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"7\n" +
+	"\x03Dot\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\acounter\x18\x02 \x01(\x03R\acounter\"l\n" +
+	"\fORSetElement\x12+\n" +
+	"\x05added\x18\x01 \x03(\v2\x15.nodecontrolplane.DotR\x05added\x12/\n" +
+	"\aremoved\x18\x02 \x03(\v2\x15.nodecontrolplane.DotR\aremoved\"\xa7\x01\n" +
+	"\x05ORSet\x12A\n" +
+	"\belements\x18\x01 \x03(\v2%.nodecontrolplane.ORSet.ElementsEntryR\belements\x1a[\n" +
+	"\rElementsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x124\n" +
+	"\x05value\x18\x02 \x01(\v2\x1e.nodecontrolplane.ORSetElementR\x05value:\x028\x01\"Y\n" +
+	"\vLWWRegister\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x16\n" +
+	"\x06nodeId\x18\x03 \x01(\tR\x06nodeId\"\x9a\x01\n" +
+	"\rCRDTOperation\x12\x1e\n" +
+	"\tincrement\x18\x01 \x01(\x03H\x00R\tincrement\x12\x18\n" +
+	"\x06setAdd\x18\x02 \x01(\tH\x00R\x06setAdd\x12\x1e\n" +
+	"\tsetRemove\x18\x03 \x01(\tH\x00R\tsetRemove\x12\"\n" +
+	"\vregisterSet\x18\x04 \x01(\tH\x00R\vregisterSetB\v\n" +
+	"\toperation\"k\n" +
+	"\x18ReplicaUpdateCRDTRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12=\n" +
+	"\toperation\x18\x02 \x01(\v2\x1f.nodecontrolplane.CRDTOperationR\toperation\"W\n" +
+	"\x19ReplicaUpdateCRDTResponse\x12:\n" +
+	"\aversion\x18\x01 \x01(\v2 .nodecontrolplane.VersionedValueR\aversion2\x86\f\n" +
+	"\x17NodeControlPlaneService\x12h\n" +
+	"\x13ReplicateSetRequest\x12'.nodecontrolplane.SetReplicationRequest\x1a(.nodecontrolplane.SetReplicationResponse\x12q\n" +
+	"\x16ReplicateDeleteRequest\x12*.nodecontrolplane.DeleteReplicationRequest\x1a+.nodecontrolplane.DeleteReplicationResponse\x12f\n" +
+	"\x15RegisterNewPeerServer\x12%.nodecontrolplane.NewServerAddRequest\x1a&.nodecontrolplane.NewServerAddResponse\x12]\n" +
+	"\x10RemovePeerServer\x12#.nodecontrolplane.RemovePeerRequest\x1a$.nodecontrolplane.RemovePeerResponse\x12E\n" +
+	"\x04Ping\x12\x1d.nodecontrolplane.PingRequest\x1a\x1e.nodecontrolplane.PingResponse\x12K\n" +
+	"\aPingReq\x12 .nodecontrolplane.PingReqRequest\x1a\x1e.nodecontrolplane.PingResponse\x12O\n" +
+	"\fRequestLease\x12\x1e.nodecontrolplane.LeaseRequest\x1a\x1f.nodecontrolplane.LeaseResponse\x12_\n" +
+	"\x0fInstallSnapshot\x12\x1f.nodecontrolplane.SnapshotChunk\x1a).nodecontrolplane.InstallSnapshotResponse(\x01\x12o\n" +
+	"\x12TransferLeadership\x12+.nodecontrolplane.TransferLeadershipRequest\x1a,.nodecontrolplane.TransferLeadershipResponse\x12W\n" +
+	"\n" +
+	"TimeoutNow\x12#.nodecontrolplane.TimeoutNowRequest\x1a$.nodecontrolplane.TimeoutNowResponse\x12U\n" +
+	"\tReplicate\x12\".nodecontrolplane.ReplicationBatch\x1a .nodecontrolplane.ReplicationAck(\x010\x01\x12`\n" +
+	"\x0fGetMerkleHashes\x12%.nodecontrolplane.MerkleHashesRequest\x1a&.nodecontrolplane.MerkleHashesResponse\x12U\n" +
+	"\fRepairRanges\x12\x1f.nodecontrolplane.RepairMessage\x1a .nodecontrolplane.RepairResponse(\x010\x01\x12Z\n" +
+	"\vReplicaRead\x12$.nodecontrolplane.ReplicaReadRequest\x1a%.nodecontrolplane.ReplicaReadResponse\x12]\n" +
+	"\fReplicaWrite\x12%.nodecontrolplane.ReplicaWriteRequest\x1a&.nodecontrolplane.ReplicaWriteResponse\x12l\n" +
+	"\x11ReplicaUpdateCRDT\x12*.nodecontrolplane.ReplicaUpdateCRDTRequest\x1a+.nodecontrolplane.ReplicaUpdateCRDTResponseB2Z0github.com/Vahsek/distrokv/pkg/node/controlplaneb\x06proto3"
+
+var (
+	file_protos_NodeControlPlane_proto_rawDescOnce sync.Once
+	file_protos_NodeControlPlane_proto_rawDescData []byte
+)
+
+func file_protos_NodeControlPlane_proto_rawDescGZIP() []byte {
+	file_protos_NodeControlPlane_proto_rawDescOnce.Do(func() {
+		file_protos_NodeControlPlane_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_protos_NodeControlPlane_proto_rawDesc), len(file_protos_NodeControlPlane_proto_rawDesc)))
+	})
+	return file_protos_NodeControlPlane_proto_rawDescData
+}
+
+var file_protos_NodeControlPlane_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_protos_NodeControlPlane_proto_msgTypes = make([]protoimpl.MessageInfo, 52)
+var file_protos_NodeControlPlane_proto_goTypes = []any{
+	(MemberUpdate_State)(0),            // 0: nodecontrolplane.MemberUpdate.State
+	(*WritePosition)(nil),              // 1: nodecontrolplane.WritePosition
+	(*SetReplicationRequest)(nil),      // 2: nodecontrolplane.SetReplicationRequest
+	(*SetReplicationResponse)(nil),     // 3: nodecontrolplane.SetReplicationResponse
+	(*DeleteReplicationRequest)(nil),   // 4: nodecontrolplane.DeleteReplicationRequest
+	(*DeleteReplicationResponse)(nil),  // 5: nodecontrolplane.DeleteReplicationResponse
+	(*ReplicationEntry)(nil),           // 6: nodecontrolplane.ReplicationEntry
+	(*ReplicationBatch)(nil),           // 7: nodecontrolplane.ReplicationBatch
+	(*ReplicationAck)(nil),             // 8: nodecontrolplane.ReplicationAck
+	(*MerkleHashesRequest)(nil),        // 9: nodecontrolplane.MerkleHashesRequest
+	(*MerkleHashesResponse)(nil),       // 10: nodecontrolplane.MerkleHashesResponse
+	(*RepairMessage)(nil),              // 11: nodecontrolplane.RepairMessage
+	(*RepairResponse)(nil),             // 12: nodecontrolplane.RepairResponse
+	(*NewServerAddRequest)(nil),        // 13: nodecontrolplane.NewServerAddRequest
+	(*NewServerAddResponse)(nil),       // 14: nodecontrolplane.NewServerAddResponse
+	(*RemovePeerRequest)(nil),          // 15: nodecontrolplane.RemovePeerRequest
+	(*RemovePeerResponse)(nil),         // 16: nodecontrolplane.RemovePeerResponse
+	(*MemberUpdate)(nil),               // 17: nodecontrolplane.MemberUpdate
+	(*PingRequest)(nil),                // 18: nodecontrolplane.PingRequest
+	(*PingResponse)(nil),               // 19: nodecontrolplane.PingResponse
+	(*PingReqRequest)(nil),             // 20: nodecontrolplane.PingReqRequest
+	(*LeaseRequest)(nil),               // 21: nodecontrolplane.LeaseRequest
+	(*LeaseResponse)(nil),              // 22: nodecontrolplane.LeaseResponse
+	(*SnapshotChunk)(nil),              // 23: nodecontrolplane.SnapshotChunk
+	(*InstallSnapshotResponse)(nil),    // 24: nodecontrolplane.InstallSnapshotResponse
+	(*SnapshotEntry)(nil),              // 25: nodecontrolplane.SnapshotEntry
+	(*Snapshot)(nil),                   // 26: nodecontrolplane.Snapshot
+	(*TransferLeadershipRequest)(nil),  // 27: nodecontrolplane.TransferLeadershipRequest
+	(*TransferLeadershipResponse)(nil), // 28: nodecontrolplane.TransferLeadershipResponse
+	(*TimeoutNowRequest)(nil),          // 29: nodecontrolplane.TimeoutNowRequest
+	(*TimeoutNowResponse)(nil),         // 30: nodecontrolplane.TimeoutNowResponse
+	(*VersionedValue)(nil),             // 31: nodecontrolplane.VersionedValue
+	(*Siblings)(nil),                   // 32: nodecontrolplane.Siblings
+	(*CausalContext)(nil),              // 33: nodecontrolplane.CausalContext
+	(*VersionedEntry)(nil),             // 34: nodecontrolplane.VersionedEntry
+	(*ReplicaReadRequest)(nil),         // 35: nodecontrolplane.ReplicaReadRequest
+	(*ReplicaReadResponse)(nil),        // 36: nodecontrolplane.ReplicaReadResponse
+	(*ReplicaWriteRequest)(nil),        // 37: nodecontrolplane.ReplicaWriteRequest
+	(*ReplicaWriteResponse)(nil),       // 38: nodecontrolplane.ReplicaWriteResponse
+	(*CRDTState)(nil),                  // 39: nodecontrolplane.CRDTState
+	(*PNCounter)(nil),                  // 40: nodecontrolplane.PNCounter
+	(*Dot)(nil),                        // 41: nodecontrolplane.Dot
+	(*ORSetElement)(nil),               // 42: nodecontrolplane.ORSetElement
+	(*ORSet)(nil),                      // 43: nodecontrolplane.ORSet
+	(*LWWRegister)(nil),                // 44: nodecontrolplane.LWWRegister
+	(*CRDTOperation)(nil),              // 45: nodecontrolplane.CRDTOperation
+	(*ReplicaUpdateCRDTRequest)(nil),   // 46: nodecontrolplane.ReplicaUpdateCRDTRequest
+	(*ReplicaUpdateCRDTResponse)(nil),  // 47: nodecontrolplane.ReplicaUpdateCRDTResponse
+	nil,                                // 48: nodecontrolplane.VersionedValue.ClockEntry
+	nil,                                // 49: nodecontrolplane.CausalContext.ClockEntry
+	nil,                                // 50: nodecontrolplane.PNCounter.IncrementsEntry
+	nil,                                // 51: nodecontrolplane.PNCounter.DecrementsEntry
+	nil,                                // 52: nodecontrolplane.ORSet.ElementsEntry
+}
+var file_protos_NodeControlPlane_proto_depIdxs = []int32{
+	1,  // 0: nodecontrolplane.SetReplicationRequest.position:type_name -> nodecontrolplane.WritePosition
+	1,  // 1: nodecontrolplane.DeleteReplicationRequest.position:type_name -> nodecontrolplane.WritePosition
+	1,  // 2: nodecontrolplane.ReplicationEntry.position:type_name -> nodecontrolplane.WritePosition
+	6,  // 3: nodecontrolplane.ReplicationBatch.entries:type_name -> nodecontrolplane.ReplicationEntry
+	25, // 4: nodecontrolplane.RepairMessage.entries:type_name -> nodecontrolplane.SnapshotEntry
+	25, // 5: nodecontrolplane.RepairResponse.newer:type_name -> nodecontrolplane.SnapshotEntry
+	0,  // 6: nodecontrolplane.MemberUpdate.state:type_name -> nodecontrolplane.MemberUpdate.State
+	17, // 7: nodecontrolplane.PingRequest.source:type_name -> nodecontrolplane.MemberUpdate
+	17, // 8: nodecontrolplane.PingRequest.updates:type_name -> nodecontrolplane.MemberUpdate
+	17, // 9: nodecontrolplane.PingResponse.source:type_name -> nodecontrolplane.MemberUpdate
+	17, // 10: nodecontrolplane.PingResponse.updates:type_name -> nodecontrolplane.MemberUpdate
+	17, // 11: nodecontrolplane.PingReqRequest.source:type_name -> nodecontrolplane.MemberUpdate
+	17, // 12: nodecontrolplane.PingReqRequest.updates:type_name -> nodecontrolplane.MemberUpdate
+	25, // 13: nodecontrolplane.Snapshot.entries:type_name -> nodecontrolplane.SnapshotEntry
+	1,  // 14: nodecontrolplane.Snapshot.position:type_name -> nodecontrolplane.WritePosition
+	48, // 15: nodecontrolplane.VersionedValue.clock:type_name -> nodecontrolplane.VersionedValue.ClockEntry
+	39, // 16: nodecontrolplane.VersionedValue.crdt:type_name -> nodecontrolplane.CRDTState
+	31, // 17: nodecontrolplane.Siblings.versions:type_name -> nodecontrolplane.VersionedValue
+	49, // 18: nodecontrolplane.CausalContext.clock:type_name -> nodecontrolplane.CausalContext.ClockEntry
+	31, // 19: nodecontrolplane.VersionedEntry.version:type_name -> nodecontrolplane.VersionedValue
+	31, // 20: nodecontrolplane.ReplicaReadResponse.versions:type_name -> nodecontrolplane.VersionedValue
+	34, // 21: nodecontrolplane.ReplicaWriteRequest.entries:type_name -> nodecontrolplane.VersionedEntry
+	40, // 22: nodecontrolplane.CRDTState.counter:type_name -> nodecontrolplane.PNCounter
+	43, // 23: nodecontrolplane.CRDTState.set:type_name -> nodecontrolplane.ORSet
+	44, // 24: nodecontrolplane.CRDTState.register:type_name -> nodecontrolplane.LWWRegister
+	50, // 25: nodecontrolplane.PNCounter.increments:type_name -> nodecontrolplane.PNCounter.IncrementsEntry
+	51, // 26: nodecontrolplane.PNCounter.decrements:type_name -> nodecontrolplane.PNCounter.DecrementsEntry
+	41, // 27: nodecontrolplane.ORSetElement.added:type_name -> nodecontrolplane.Dot
+	41, // 28: nodecontrolplane.ORSetElement.removed:type_name -> nodecontrolplane.Dot
+	52, // 29: nodecontrolplane.ORSet.elements:type_name -> nodecontrolplane.ORSet.ElementsEntry
+	45, // 30: nodecontrolplane.ReplicaUpdateCRDTRequest.operation:type_name -> nodecontrolplane.CRDTOperation
+	31, // 31: nodecontrolplane.ReplicaUpdateCRDTResponse.version:type_name -> nodecontrolplane.VersionedValue
+	42, // 32: nodecontrolplane.ORSet.ElementsEntry.value:type_name -> nodecontrolplane.ORSetElement
+	2,  // 33: nodecontrolplane.NodeControlPlaneService.ReplicateSetRequest:input_type -> nodecontrolplane.SetReplicationRequest
+	4,  // 34: nodecontrolplane.NodeControlPlaneService.ReplicateDeleteRequest:input_type -> nodecontrolplane.DeleteReplicationRequest
+	13, // 35: nodecontrolplane.NodeControlPlaneService.RegisterNewPeerServer:input_type -> nodecontrolplane.NewServerAddRequest
+	15, // 36: nodecontrolplane.NodeControlPlaneService.RemovePeerServer:input_type -> nodecontrolplane.RemovePeerRequest
+	18, // 37: nodecontrolplane.NodeControlPlaneService.Ping:input_type -> nodecontrolplane.PingRequest
+	20, // 38: nodecontrolplane.NodeControlPlaneService.PingReq:input_type -> nodecontrolplane.PingReqRequest
+	21, // 39: nodecontrolplane.NodeControlPlaneService.RequestLease:input_type -> nodecontrolplane.LeaseRequest
+	23, // 40: nodecontrolplane.NodeControlPlaneService.InstallSnapshot:input_type -> nodecontrolplane.SnapshotChunk
+	27, // 41: nodecontrolplane.NodeControlPlaneService.TransferLeadership:input_type -> nodecontrolplane.TransferLeadershipRequest
+	29, // 42: nodecontrolplane.NodeControlPlaneService.TimeoutNow:input_type -> nodecontrolplane.TimeoutNowRequest
+	7,  // 43: nodecontrolplane.NodeControlPlaneService.Replicate:input_type -> nodecontrolplane.ReplicationBatch
+	9,  // 44: nodecontrolplane.NodeControlPlaneService.GetMerkleHashes:input_type -> nodecontrolplane.MerkleHashesRequest
+	11, // 45: nodecontrolplane.NodeControlPlaneService.RepairRanges:input_type -> nodecontrolplane.RepairMessage
+	35, // 46: nodecontrolplane.NodeControlPlaneService.ReplicaRead:input_type -> nodecontrolplane.ReplicaReadRequest
+	37, // 47: nodecontrolplane.NodeControlPlaneService.ReplicaWrite:input_type -> nodecontrolplane.ReplicaWriteRequest
+	46, // 48: nodecontrolplane.NodeControlPlaneService.ReplicaUpdateCRDT:input_type -> nodecontrolplane.ReplicaUpdateCRDTRequest
+	3,  // 49: nodecontrolplane.NodeControlPlaneService.ReplicateSetRequest:output_type -> nodecontrolplane.SetReplicationResponse
+	5,  // 50: nodecontrolplane.NodeControlPlaneService.ReplicateDeleteRequest:output_type -> nodecontrolplane.DeleteReplicationResponse
+	14, // 51: nodecontrolplane.NodeControlPlaneService.RegisterNewPeerServer:output_type -> nodecontrolplane.NewServerAddResponse
+	16, // 52: nodecontrolplane.NodeControlPlaneService.RemovePeerServer:output_type -> nodecontrolplane.RemovePeerResponse
+	19, // 53: nodecontrolplane.NodeControlPlaneService.Ping:output_type -> nodecontrolplane.PingResponse
+	19, // 54: nodecontrolplane.NodeControlPlaneService.PingReq:output_type -> nodecontrolplane.PingResponse
+	22, // 55: nodecontrolplane.NodeControlPlaneService.RequestLease:output_type -> nodecontrolplane.LeaseResponse
+	24, // 56: nodecontrolplane.NodeControlPlaneService.InstallSnapshot:output_type -> nodecontrolplane.InstallSnapshotResponse
+	28, // 57: nodecontrolplane.NodeControlPlaneService.TransferLeadership:output_type -> nodecontrolplane.TransferLeadershipResponse
+	30, // 58: nodecontrolplane.NodeControlPlaneService.TimeoutNow:output_type -> nodecontrolplane.TimeoutNowResponse
+	8,  // 59: nodecontrolplane.NodeControlPlaneService.Replicate:output_type -> nodecontrolplane.ReplicationAck
+	10, // 60: nodecontrolplane.NodeControlPlaneService.GetMerkleHashes:output_type -> nodecontrolplane.MerkleHashesResponse
+	12, // 61: nodecontrolplane.NodeControlPlaneService.RepairRanges:output_type -> nodecontrolplane.RepairResponse
+	36, // 62: nodecontrolplane.NodeControlPlaneService.ReplicaRead:output_type -> nodecontrolplane.ReplicaReadResponse
+	38, // 63: nodecontrolplane.NodeControlPlaneService.ReplicaWrite:output_type -> nodecontrolplane.ReplicaWriteResponse
+	47, // 64: nodecontrolplane.NodeControlPlaneService.ReplicaUpdateCRDT:output_type -> nodecontrolplane.ReplicaUpdateCRDTResponse
+	49, // [49:65] is the sub-list for method output_type
+	33, // [33:49] is the sub-list for method input_type
+	33, // [33:33] is the sub-list for extension type_name
+	33, // [33:33] is the sub-list for extension extendee
+	0,  // [0:33] is the sub-list for field type_name
+}
+
+func init() { file_protos_NodeControlPlane_proto_init() }
 func file_protos_NodeControlPlane_proto_init() {
 	if File_protos_NodeControlPlane_proto != nil {
 		return
 	}
+	file_protos_NodeControlPlane_proto_msgTypes[38].OneofWrappers = []any{
+		(*CRDTState_Counter)(nil),
+		(*CRDTState_Set)(nil),
+		(*CRDTState_Register)(nil),
+	}
+	file_protos_NodeControlPlane_proto_msgTypes[44].OneofWrappers = []any{
+		(*CRDTOperation_Increment)(nil),
+		(*CRDTOperation_SetAdd)(nil),
+		(*CRDTOperation_SetRemove)(nil),
+		(*CRDTOperation_RegisterSet)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_protos_NodeControlPlane_proto_rawDesc), len(file_protos_NodeControlPlane_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   52,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_protos_NodeControlPlane_proto_goTypes,
 		DependencyIndexes: file_protos_NodeControlPlane_proto_depIdxs,
+		EnumInfos:         file_protos_NodeControlPlane_proto_enumTypes,
 		MessageInfos:      file_protos_NodeControlPlane_proto_msgTypes,
 	}.Build()
 	File_protos_NodeControlPlane_proto = out.File
@@ -23,6 +23,19 @@ const (
 	NodeControlPlaneService_ReplicateSetRequest_FullMethodName    = "/nodecontrolplane.NodeControlPlaneService/ReplicateSetRequest"
 	NodeControlPlaneService_ReplicateDeleteRequest_FullMethodName = "/nodecontrolplane.NodeControlPlaneService/ReplicateDeleteRequest"
 	NodeControlPlaneService_RegisterNewPeerServer_FullMethodName  = "/nodecontrolplane.NodeControlPlaneService/RegisterNewPeerServer"
+	NodeControlPlaneService_RemovePeerServer_FullMethodName       = "/nodecontrolplane.NodeControlPlaneService/RemovePeerServer"
+	NodeControlPlaneService_Ping_FullMethodName                   = "/nodecontrolplane.NodeControlPlaneService/Ping"
+	NodeControlPlaneService_PingReq_FullMethodName                = "/nodecontrolplane.NodeControlPlaneService/PingReq"
+	NodeControlPlaneService_RequestLease_FullMethodName           = "/nodecontrolplane.NodeControlPlaneService/RequestLease"
+	NodeControlPlaneService_InstallSnapshot_FullMethodName        = "/nodecontrolplane.NodeControlPlaneService/InstallSnapshot"
+	NodeControlPlaneService_TransferLeadership_FullMethodName     = "/nodecontrolplane.NodeControlPlaneService/TransferLeadership"
+	NodeControlPlaneService_TimeoutNow_FullMethodName             = "/nodecontrolplane.NodeControlPlaneService/TimeoutNow"
+	NodeControlPlaneService_Replicate_FullMethodName              = "/nodecontrolplane.NodeControlPlaneService/Replicate"
+	NodeControlPlaneService_GetMerkleHashes_FullMethodName        = "/nodecontrolplane.NodeControlPlaneService/GetMerkleHashes"
+	NodeControlPlaneService_RepairRanges_FullMethodName           = "/nodecontrolplane.NodeControlPlaneService/RepairRanges"
+	NodeControlPlaneService_ReplicaRead_FullMethodName            = "/nodecontrolplane.NodeControlPlaneService/ReplicaRead"
+	NodeControlPlaneService_ReplicaWrite_FullMethodName           = "/nodecontrolplane.NodeControlPlaneService/ReplicaWrite"
+	NodeControlPlaneService_ReplicaUpdateCRDT_FullMethodName      = "/nodecontrolplane.NodeControlPlaneService/ReplicaUpdateCRDT"
 )
 
 // NodeControlPlaneServiceClient is the client API for NodeControlPlaneService service.
@@ -32,6 +45,19 @@ type NodeControlPlaneServiceClient interface {
 	ReplicateSetRequest(ctx context.Context, in *SetReplicationRequest, opts ...grpc.CallOption) (*SetReplicationResponse, error)
 	ReplicateDeleteRequest(ctx context.Context, in *DeleteReplicationRequest, opts ...grpc.CallOption) (*DeleteReplicationResponse, error)
 	RegisterNewPeerServer(ctx context.Context, in *NewServerAddRequest, opts ...grpc.CallOption) (*NewServerAddResponse, error)
+	RemovePeerServer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerResponse, error)
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	RequestLease(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*LeaseResponse, error)
+	InstallSnapshot(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SnapshotChunk, InstallSnapshotResponse], error)
+	TransferLeadership(ctx context.Context, in *TransferLeadershipRequest, opts ...grpc.CallOption) (*TransferLeadershipResponse, error)
+	TimeoutNow(ctx context.Context, in *TimeoutNowRequest, opts ...grpc.CallOption) (*TimeoutNowResponse, error)
+	Replicate(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ReplicationBatch, ReplicationAck], error)
+	GetMerkleHashes(ctx context.Context, in *MerkleHashesRequest, opts ...grpc.CallOption) (*MerkleHashesResponse, error)
+	RepairRanges(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RepairMessage, RepairResponse], error)
+	ReplicaRead(ctx context.Context, in *ReplicaReadRequest, opts ...grpc.CallOption) (*ReplicaReadResponse, error)
+	ReplicaWrite(ctx context.Context, in *ReplicaWriteRequest, opts ...grpc.CallOption) (*ReplicaWriteResponse, error)
+	ReplicaUpdateCRDT(ctx context.Context, in *ReplicaUpdateCRDTRequest, opts ...grpc.CallOption) (*ReplicaUpdateCRDTResponse, error)
 }
 
 type nodeControlPlaneServiceClient struct {
@@ -72,6 +98,145 @@ func (c *nodeControlPlaneServiceClient) RegisterNewPeerServer(ctx context.Contex
 	return out, nil
 }
 
+func (c *nodeControlPlaneServiceClient) RemovePeerServer(ctx context.Context, in *RemovePeerRequest, opts ...grpc.CallOption) (*RemovePeerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemovePeerResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_RemovePeerServer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) PingReq(ctx context.Context, in *PingReqRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_PingReq_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) RequestLease(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*LeaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaseResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_RequestLease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) InstallSnapshot(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SnapshotChunk, InstallSnapshotResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NodeControlPlaneService_ServiceDesc.Streams[0], NodeControlPlaneService_InstallSnapshot_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SnapshotChunk, InstallSnapshotResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeControlPlaneService_InstallSnapshotClient = grpc.ClientStreamingClient[SnapshotChunk, InstallSnapshotResponse]
+
+func (c *nodeControlPlaneServiceClient) TransferLeadership(ctx context.Context, in *TransferLeadershipRequest, opts ...grpc.CallOption) (*TransferLeadershipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferLeadershipResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_TransferLeadership_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) TimeoutNow(ctx context.Context, in *TimeoutNowRequest, opts ...grpc.CallOption) (*TimeoutNowResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TimeoutNowResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_TimeoutNow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) Replicate(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ReplicationBatch, ReplicationAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NodeControlPlaneService_ServiceDesc.Streams[1], NodeControlPlaneService_Replicate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReplicationBatch, ReplicationAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeControlPlaneService_ReplicateClient = grpc.BidiStreamingClient[ReplicationBatch, ReplicationAck]
+
+func (c *nodeControlPlaneServiceClient) GetMerkleHashes(ctx context.Context, in *MerkleHashesRequest, opts ...grpc.CallOption) (*MerkleHashesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MerkleHashesResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_GetMerkleHashes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) RepairRanges(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[RepairMessage, RepairResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NodeControlPlaneService_ServiceDesc.Streams[2], NodeControlPlaneService_RepairRanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RepairMessage, RepairResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeControlPlaneService_RepairRangesClient = grpc.BidiStreamingClient[RepairMessage, RepairResponse]
+
+func (c *nodeControlPlaneServiceClient) ReplicaRead(ctx context.Context, in *ReplicaReadRequest, opts ...grpc.CallOption) (*ReplicaReadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicaReadResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_ReplicaRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) ReplicaWrite(ctx context.Context, in *ReplicaWriteRequest, opts ...grpc.CallOption) (*ReplicaWriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicaWriteResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_ReplicaWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeControlPlaneServiceClient) ReplicaUpdateCRDT(ctx context.Context, in *ReplicaUpdateCRDTRequest, opts ...grpc.CallOption) (*ReplicaUpdateCRDTResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicaUpdateCRDTResponse)
+	err := c.cc.Invoke(ctx, NodeControlPlaneService_ReplicaUpdateCRDT_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodeControlPlaneServiceServer is the server API for NodeControlPlaneService service.
 // All implementations must embed UnimplementedNodeControlPlaneServiceServer
 // for forward compatibility.
@@ -79,6 +244,19 @@ type NodeControlPlaneServiceServer interface {
 	ReplicateSetRequest(context.Context, *SetReplicationRequest) (*SetReplicationResponse, error)
 	ReplicateDeleteRequest(context.Context, *DeleteReplicationRequest) (*DeleteReplicationResponse, error)
 	RegisterNewPeerServer(context.Context, *NewServerAddRequest) (*NewServerAddResponse, error)
+	RemovePeerServer(context.Context, *RemovePeerRequest) (*RemovePeerResponse, error)
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	PingReq(context.Context, *PingReqRequest) (*PingResponse, error)
+	RequestLease(context.Context, *LeaseRequest) (*LeaseResponse, error)
+	InstallSnapshot(grpc.ClientStreamingServer[SnapshotChunk, InstallSnapshotResponse]) error
+	TransferLeadership(context.Context, *TransferLeadershipRequest) (*TransferLeadershipResponse, error)
+	TimeoutNow(context.Context, *TimeoutNowRequest) (*TimeoutNowResponse, error)
+	Replicate(grpc.BidiStreamingServer[ReplicationBatch, ReplicationAck]) error
+	GetMerkleHashes(context.Context, *MerkleHashesRequest) (*MerkleHashesResponse, error)
+	RepairRanges(grpc.BidiStreamingServer[RepairMessage, RepairResponse]) error
+	ReplicaRead(context.Context, *ReplicaReadRequest) (*ReplicaReadResponse, error)
+	ReplicaWrite(context.Context, *ReplicaWriteRequest) (*ReplicaWriteResponse, error)
+	ReplicaUpdateCRDT(context.Context, *ReplicaUpdateCRDTRequest) (*ReplicaUpdateCRDTResponse, error)
 	mustEmbedUnimplementedNodeControlPlaneServiceServer()
 }
 
@@ -98,6 +276,45 @@ func (UnimplementedNodeControlPlaneServiceServer) ReplicateDeleteRequest(context
 func (UnimplementedNodeControlPlaneServiceServer) RegisterNewPeerServer(context.Context, *NewServerAddRequest) (*NewServerAddResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterNewPeerServer not implemented")
 }
+func (UnimplementedNodeControlPlaneServiceServer) RemovePeerServer(context.Context, *RemovePeerRequest) (*RemovePeerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePeerServer not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) PingReq(context.Context, *PingReqRequest) (*PingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PingReq not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) RequestLease(context.Context, *LeaseRequest) (*LeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestLease not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) InstallSnapshot(grpc.ClientStreamingServer[SnapshotChunk, InstallSnapshotResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) TransferLeadership(context.Context, *TransferLeadershipRequest) (*TransferLeadershipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferLeadership not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) TimeoutNow(context.Context, *TimeoutNowRequest) (*TimeoutNowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TimeoutNow not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) Replicate(grpc.BidiStreamingServer[ReplicationBatch, ReplicationAck]) error {
+	return status.Errorf(codes.Unimplemented, "method Replicate not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) GetMerkleHashes(context.Context, *MerkleHashesRequest) (*MerkleHashesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMerkleHashes not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) RepairRanges(grpc.BidiStreamingServer[RepairMessage, RepairResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method RepairRanges not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) ReplicaRead(context.Context, *ReplicaReadRequest) (*ReplicaReadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicaRead not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) ReplicaWrite(context.Context, *ReplicaWriteRequest) (*ReplicaWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicaWrite not implemented")
+}
+func (UnimplementedNodeControlPlaneServiceServer) ReplicaUpdateCRDT(context.Context, *ReplicaUpdateCRDTRequest) (*ReplicaUpdateCRDTResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicaUpdateCRDT not implemented")
+}
 func (UnimplementedNodeControlPlaneServiceServer) mustEmbedUnimplementedNodeControlPlaneServiceServer() {
 }
 func (UnimplementedNodeControlPlaneServiceServer) testEmbeddedByValue() {}
@@ -174,6 +391,207 @@ func _NodeControlPlaneService_RegisterNewPeerServer_Handler(srv interface{}, ctx
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NodeControlPlaneService_RemovePeerServer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemovePeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).RemovePeerServer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_RemovePeerServer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).RemovePeerServer(ctx, req.(*RemovePeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_PingReq_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingReqRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).PingReq(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_PingReq_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).PingReq(ctx, req.(*PingReqRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_RequestLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).RequestLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_RequestLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).RequestLease(ctx, req.(*LeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_InstallSnapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NodeControlPlaneServiceServer).InstallSnapshot(&grpc.GenericServerStream[SnapshotChunk, InstallSnapshotResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeControlPlaneService_InstallSnapshotServer = grpc.ClientStreamingServer[SnapshotChunk, InstallSnapshotResponse]
+
+func _NodeControlPlaneService_TransferLeadership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferLeadershipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).TransferLeadership(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_TransferLeadership_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).TransferLeadership(ctx, req.(*TransferLeadershipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_TimeoutNow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeoutNowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).TimeoutNow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_TimeoutNow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).TimeoutNow(ctx, req.(*TimeoutNowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_Replicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NodeControlPlaneServiceServer).Replicate(&grpc.GenericServerStream[ReplicationBatch, ReplicationAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeControlPlaneService_ReplicateServer = grpc.BidiStreamingServer[ReplicationBatch, ReplicationAck]
+
+func _NodeControlPlaneService_GetMerkleHashes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleHashesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).GetMerkleHashes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_GetMerkleHashes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).GetMerkleHashes(ctx, req.(*MerkleHashesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_RepairRanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NodeControlPlaneServiceServer).RepairRanges(&grpc.GenericServerStream[RepairMessage, RepairResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeControlPlaneService_RepairRangesServer = grpc.BidiStreamingServer[RepairMessage, RepairResponse]
+
+func _NodeControlPlaneService_ReplicaRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).ReplicaRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_ReplicaRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).ReplicaRead(ctx, req.(*ReplicaReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_ReplicaWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).ReplicaWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_ReplicaWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).ReplicaWrite(ctx, req.(*ReplicaWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeControlPlaneService_ReplicaUpdateCRDT_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaUpdateCRDTRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeControlPlaneServiceServer).ReplicaUpdateCRDT(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeControlPlaneService_ReplicaUpdateCRDT_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeControlPlaneServiceServer).ReplicaUpdateCRDT(ctx, req.(*ReplicaUpdateCRDTRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // NodeControlPlaneService_ServiceDesc is the grpc.ServiceDesc for NodeControlPlaneService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -193,7 +611,65 @@ var NodeControlPlaneService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterNewPeerServer",
 			Handler:    _NodeControlPlaneService_RegisterNewPeerServer_Handler,
 		},
+		{
+			MethodName: "RemovePeerServer",
+			Handler:    _NodeControlPlaneService_RemovePeerServer_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _NodeControlPlaneService_Ping_Handler,
+		},
+		{
+			MethodName: "PingReq",
+			Handler:    _NodeControlPlaneService_PingReq_Handler,
+		},
+		{
+			MethodName: "RequestLease",
+			Handler:    _NodeControlPlaneService_RequestLease_Handler,
+		},
+		{
+			MethodName: "TransferLeadership",
+			Handler:    _NodeControlPlaneService_TransferLeadership_Handler,
+		},
+		{
+			MethodName: "TimeoutNow",
+			Handler:    _NodeControlPlaneService_TimeoutNow_Handler,
+		},
+		{
+			MethodName: "GetMerkleHashes",
+			Handler:    _NodeControlPlaneService_GetMerkleHashes_Handler,
+		},
+		{
+			MethodName: "ReplicaRead",
+			Handler:    _NodeControlPlaneService_ReplicaRead_Handler,
+		},
+		{
+			MethodName: "ReplicaWrite",
+			Handler:    _NodeControlPlaneService_ReplicaWrite_Handler,
+		},
+		{
+			MethodName: "ReplicaUpdateCRDT",
+			Handler:    _NodeControlPlaneService_ReplicaUpdateCRDT_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "InstallSnapshot",
+			Handler:       _NodeControlPlaneService_InstallSnapshot_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Replicate",
+			Handler:       _NodeControlPlaneService_Replicate_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "RepairRanges",
+			Handler:       _NodeControlPlaneService_RepairRanges_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "protos/NodeControlPlane.proto",
 }
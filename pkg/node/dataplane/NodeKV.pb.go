@@ -21,11 +21,268 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// LINEARIZABLE reads are only served by the primary. BOUNDED_STALENESS reads
+// may be served by any node that is at most maxStalenessMs behind the primary
+// and ANY reads by any node.
+type ReadConsistency int32
+
+const (
+	ReadConsistency_LINEARIZABLE      ReadConsistency = 0
+	ReadConsistency_BOUNDED_STALENESS ReadConsistency = 1
+	ReadConsistency_ANY               ReadConsistency = 2
+)
+
+// Enum value maps for ReadConsistency.
+var (
+	ReadConsistency_name = map[int32]string{
+		0: "LINEARIZABLE",
+		1: "BOUNDED_STALENESS",
+		2: "ANY",
+	}
+	ReadConsistency_value = map[string]int32{
+		"LINEARIZABLE":      0,
+		"BOUNDED_STALENESS": 1,
+		"ANY":               2,
+	}
+)
+
+func (x ReadConsistency) Enum() *ReadConsistency {
+	p := new(ReadConsistency)
+	*p = x
+	return p
+}
+
+func (x ReadConsistency) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReadConsistency) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_NodeKV_proto_enumTypes[0].Descriptor()
+}
+
+func (ReadConsistency) Type() protoreflect.EnumType {
+	return &file_protos_NodeKV_proto_enumTypes[0]
+}
+
+func (x ReadConsistency) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReadConsistency.Descriptor instead.
+func (ReadConsistency) EnumDescriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{0}
+}
+
+// CRDT keys hold a value that replicas merge instead of overwriting, so
+// concurrent updates through different nodes are never lost. A key keeps the
+// type of its first update, COUNTER a PN-counter, SET an observed-remove set
+// and REGISTER a last writer wins register.
+type CRDTType int32
+
+const (
+	CRDTType_COUNTER  CRDTType = 0
+	CRDTType_SET      CRDTType = 1
+	CRDTType_REGISTER CRDTType = 2
+)
+
+// Enum value maps for CRDTType.
+var (
+	CRDTType_name = map[int32]string{
+		0: "COUNTER",
+		1: "SET",
+		2: "REGISTER",
+	}
+	CRDTType_value = map[string]int32{
+		"COUNTER":  0,
+		"SET":      1,
+		"REGISTER": 2,
+	}
+)
+
+func (x CRDTType) Enum() *CRDTType {
+	p := new(CRDTType)
+	*p = x
+	return p
+}
+
+func (x CRDTType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CRDTType) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_NodeKV_proto_enumTypes[1].Descriptor()
+}
+
+func (CRDTType) Type() protoreflect.EnumType {
+	return &file_protos_NodeKV_proto_enumTypes[1]
+}
+
+func (x CRDTType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CRDTType.Descriptor instead.
+func (CRDTType) EnumDescriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{1}
+}
+
+type WatchEvent_EventType int32
+
+const (
+	WatchEvent_PUT    WatchEvent_EventType = 0
+	WatchEvent_DELETE WatchEvent_EventType = 1
+)
+
+// Enum value maps for WatchEvent_EventType.
+var (
+	WatchEvent_EventType_name = map[int32]string{
+		0: "PUT",
+		1: "DELETE",
+	}
+	WatchEvent_EventType_value = map[string]int32{
+		"PUT":    0,
+		"DELETE": 1,
+	}
+)
+
+func (x WatchEvent_EventType) Enum() *WatchEvent_EventType {
+	p := new(WatchEvent_EventType)
+	*p = x
+	return p
+}
+
+func (x WatchEvent_EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchEvent_EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_NodeKV_proto_enumTypes[2].Descriptor()
+}
+
+func (WatchEvent_EventType) Type() protoreflect.EnumType {
+	return &file_protos_NodeKV_proto_enumTypes[2]
+}
+
+func (x WatchEvent_EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchEvent_EventType.Descriptor instead.
+func (WatchEvent_EventType) EnumDescriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type TxnCompare_CompareType int32
+
+const (
+	TxnCompare_VALUE_EQUALS TxnCompare_CompareType = 0
+	TxnCompare_EXISTS       TxnCompare_CompareType = 1
+	TxnCompare_NOT_EXISTS   TxnCompare_CompareType = 2
+)
+
+// Enum value maps for TxnCompare_CompareType.
+var (
+	TxnCompare_CompareType_name = map[int32]string{
+		0: "VALUE_EQUALS",
+		1: "EXISTS",
+		2: "NOT_EXISTS",
+	}
+	TxnCompare_CompareType_value = map[string]int32{
+		"VALUE_EQUALS": 0,
+		"EXISTS":       1,
+		"NOT_EXISTS":   2,
+	}
+)
+
+func (x TxnCompare_CompareType) Enum() *TxnCompare_CompareType {
+	p := new(TxnCompare_CompareType)
+	*p = x
+	return p
+}
+
+func (x TxnCompare_CompareType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TxnCompare_CompareType) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_NodeKV_proto_enumTypes[3].Descriptor()
+}
+
+func (TxnCompare_CompareType) Type() protoreflect.EnumType {
+	return &file_protos_NodeKV_proto_enumTypes[3]
+}
+
+func (x TxnCompare_CompareType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TxnCompare_CompareType.Descriptor instead.
+func (TxnCompare_CompareType) EnumDescriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{12, 0}
+}
+
+type TxnOperation_OperationType int32
+
+const (
+	TxnOperation_GET    TxnOperation_OperationType = 0
+	TxnOperation_PUT    TxnOperation_OperationType = 1
+	TxnOperation_DELETE TxnOperation_OperationType = 2
+)
+
+// Enum value maps for TxnOperation_OperationType.
+var (
+	TxnOperation_OperationType_name = map[int32]string{
+		0: "GET",
+		1: "PUT",
+		2: "DELETE",
+	}
+	TxnOperation_OperationType_value = map[string]int32{
+		"GET":    0,
+		"PUT":    1,
+		"DELETE": 2,
+	}
+)
+
+func (x TxnOperation_OperationType) Enum() *TxnOperation_OperationType {
+	p := new(TxnOperation_OperationType)
+	*p = x
+	return p
+}
+
+func (x TxnOperation_OperationType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TxnOperation_OperationType) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_NodeKV_proto_enumTypes[4].Descriptor()
+}
+
+func (TxnOperation_OperationType) Type() protoreflect.EnumType {
+	return &file_protos_NodeKV_proto_enumTypes[4]
+}
+
+func (x TxnOperation_OperationType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TxnOperation_OperationType.Descriptor instead.
+func (TxnOperation_OperationType) EnumDescriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{13, 0}
+}
+
+// A sessionToken returned by a write makes the node serving a later read
+// wait until it applied that write. In leaderless mode the consistency and
+// the session token are ignored, the read waits for readQuorum of the key's
+// replicas instead, or for the node's default when it is 0.
 type GetRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Key            string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Consistency    ReadConsistency        `protobuf:"varint,2,opt,name=consistency,proto3,enum=nodedataplane.ReadConsistency" json:"consistency,omitempty"`
+	MaxStalenessMs int64                  `protobuf:"varint,3,opt,name=maxStalenessMs,proto3" json:"maxStalenessMs,omitempty"`
+	SessionToken   string                 `protobuf:"bytes,4,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	ReadQuorum     int32                  `protobuf:"varint,5,opt,name=readQuorum,proto3" json:"readQuorum,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GetRequest) Reset() {
@@ -65,19 +322,114 @@ func (x *GetRequest) GetKey() string {
 	return ""
 }
 
+func (x *GetRequest) GetConsistency() ReadConsistency {
+	if x != nil {
+		return x.Consistency
+	}
+	return ReadConsistency_LINEARIZABLE
+}
+
+func (x *GetRequest) GetMaxStalenessMs() int64 {
+	if x != nil {
+		return x.MaxStalenessMs
+	}
+	return 0
+}
+
+func (x *GetRequest) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+func (x *GetRequest) GetReadQuorum() int32 {
+	if x != nil {
+		return x.ReadQuorum
+	}
+	return 0
+}
+
+// SessionToken is the position of a write in the sequence of writes accepted
+// by nodeId since it started at epoch. Clients only see it base64 encoded.
+type SessionToken struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	Epoch         int64                  `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Sequence      int64                  `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionToken) Reset() {
+	*x = SessionToken{}
+	mi := &file_protos_NodeKV_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionToken) ProtoMessage() {}
+
+func (x *SessionToken) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionToken.ProtoReflect.Descriptor instead.
+func (*SessionToken) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SessionToken) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *SessionToken) GetEpoch() int64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *SessionToken) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+// With vector clocks siblings holds every concurrent value of the key and
+// value the first of them. Writing back with context replaces the siblings
+// the read returned.
 type GetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
 	Status        bool                   `protobuf:"varint,3,opt,name=status,proto3" json:"status,omitempty"`
 	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	Siblings      []string               `protobuf:"bytes,5,rep,name=siblings,proto3" json:"siblings,omitempty"`
+	Context       string                 `protobuf:"bytes,6,opt,name=context,proto3" json:"context,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetResponse) Reset() {
 	*x = GetResponse{}
-	mi := &file_protos_NodeKV_proto_msgTypes[1]
+	mi := &file_protos_NodeKV_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -89,7 +441,7 @@ func (x *GetResponse) String() string {
 func (*GetResponse) ProtoMessage() {}
 
 func (x *GetResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeKV_proto_msgTypes[1]
+	mi := &file_protos_NodeKV_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -102,7 +454,7 @@ func (x *GetResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
 func (*GetResponse) Descriptor() ([]byte, []int) {
-	return file_protos_NodeKV_proto_rawDescGZIP(), []int{1}
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *GetResponse) GetKey() string {
@@ -133,17 +485,37 @@ func (x *GetResponse) GetError() string {
 	return ""
 }
 
+func (x *GetResponse) GetSiblings() []string {
+	if x != nil {
+		return x.Siblings
+	}
+	return nil
+}
+
+func (x *GetResponse) GetContext() string {
+	if x != nil {
+		return x.Context
+	}
+	return ""
+}
+
+// writeQuorum is the number of the key's replicas that must acknowledge the
+// write in leaderless mode, 0 uses the node's default. context is the one a
+// read with vector clocks returned, a write without one is concurrent with
+// every value already stored.
 type SetRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	WriteQuorum   int32                  `protobuf:"varint,3,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	Context       string                 `protobuf:"bytes,4,opt,name=context,proto3" json:"context,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SetRequest) Reset() {
 	*x = SetRequest{}
-	mi := &file_protos_NodeKV_proto_msgTypes[2]
+	mi := &file_protos_NodeKV_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -155,7 +527,7 @@ func (x *SetRequest) String() string {
 func (*SetRequest) ProtoMessage() {}
 
 func (x *SetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeKV_proto_msgTypes[2]
+	mi := &file_protos_NodeKV_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -168,7 +540,7 @@ func (x *SetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetRequest.ProtoReflect.Descriptor instead.
 func (*SetRequest) Descriptor() ([]byte, []int) {
-	return file_protos_NodeKV_proto_rawDescGZIP(), []int{2}
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *SetRequest) GetKey() string {
@@ -185,18 +557,33 @@ func (x *SetRequest) GetValue() string {
 	return ""
 }
 
+func (x *SetRequest) GetWriteQuorum() int32 {
+	if x != nil {
+		return x.WriteQuorum
+	}
+	return 0
+}
+
+func (x *SetRequest) GetContext() string {
+	if x != nil {
+		return x.Context
+	}
+	return ""
+}
+
 type SetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Status        bool                   `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
 	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,4,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SetResponse) Reset() {
 	*x = SetResponse{}
-	mi := &file_protos_NodeKV_proto_msgTypes[3]
+	mi := &file_protos_NodeKV_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -208,7 +595,7 @@ func (x *SetResponse) String() string {
 func (*SetResponse) ProtoMessage() {}
 
 func (x *SetResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeKV_proto_msgTypes[3]
+	mi := &file_protos_NodeKV_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -221,7 +608,7 @@ func (x *SetResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetResponse.ProtoReflect.Descriptor instead.
 func (*SetResponse) Descriptor() ([]byte, []int) {
-	return file_protos_NodeKV_proto_rawDescGZIP(), []int{3}
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SetResponse) GetKey() string {
@@ -245,16 +632,27 @@ func (x *SetResponse) GetError() string {
 	return ""
 }
 
+func (x *SetResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+// In leaderless mode a delete writes a tombstone whether or not the key
+// exists
 type DeleteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	WriteQuorum   int32                  `protobuf:"varint,2,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	Context       string                 `protobuf:"bytes,3,opt,name=context,proto3" json:"context,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteRequest) Reset() {
 	*x = DeleteRequest{}
-	mi := &file_protos_NodeKV_proto_msgTypes[4]
+	mi := &file_protos_NodeKV_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -266,7 +664,7 @@ func (x *DeleteRequest) String() string {
 func (*DeleteRequest) ProtoMessage() {}
 
 func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeKV_proto_msgTypes[4]
+	mi := &file_protos_NodeKV_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -279,7 +677,7 @@ func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
 func (*DeleteRequest) Descriptor() ([]byte, []int) {
-	return file_protos_NodeKV_proto_rawDescGZIP(), []int{4}
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *DeleteRequest) GetKey() string {
@@ -289,19 +687,34 @@ func (x *DeleteRequest) GetKey() string {
 	return ""
 }
 
+func (x *DeleteRequest) GetWriteQuorum() int32 {
+	if x != nil {
+		return x.WriteQuorum
+	}
+	return 0
+}
+
+func (x *DeleteRequest) GetContext() string {
+	if x != nil {
+		return x.Context
+	}
+	return ""
+}
+
 type DeleteResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
 	Status        bool                   `protobuf:"varint,3,opt,name=status,proto3" json:"status,omitempty"`
 	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,5,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteResponse) Reset() {
 	*x = DeleteResponse{}
-	mi := &file_protos_NodeKV_proto_msgTypes[5]
+	mi := &file_protos_NodeKV_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -313,7 +726,7 @@ func (x *DeleteResponse) String() string {
 func (*DeleteResponse) ProtoMessage() {}
 
 func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_NodeKV_proto_msgTypes[5]
+	mi := &file_protos_NodeKV_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -326,7 +739,7 @@ func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
 func (*DeleteResponse) Descriptor() ([]byte, []int) {
-	return file_protos_NodeKV_proto_rawDescGZIP(), []int{5}
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *DeleteResponse) GetKey() string {
@@ -357,38 +770,1122 @@ func (x *DeleteResponse) GetError() string {
 	return ""
 }
 
-var File_protos_NodeKV_proto protoreflect.FileDescriptor
+func (x *DeleteResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
 
-const file_protos_NodeKV_proto_rawDesc = "" +
-	"\n" +
-	"\x13protos/NodeKV.proto\x12\rnodedataplane\"\x1e\n" +
-	"\n" +
-	"GetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"c\n" +
-	"\vGetResponse\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
-	"\x06status\x18\x03 \x01(\bR\x06status\x12\x14\n" +
-	"\x05error\x18\x04 \x01(\tR\x05error\"4\n" +
-	"\n" +
-	"SetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\"M\n" +
-	"\vSetResponse\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
-	"\x06status\x18\x02 \x01(\bR\x06status\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"!\n" +
-	"\rDeleteRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"f\n" +
-	"\x0eDeleteResponse\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
-	"\x06status\x18\x03 \x01(\bR\x06status\x12\x14\n" +
-	"\x05error\x18\x04 \x01(\tR\x05error2\xe1\x01\n" +
+// Scans return keys in [startKey, endKey) in key order. An empty endKey
+// scans to the end of the keyspace and a limit of 0 returns every key.
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartKey      string                 `protobuf:"bytes,1,opt,name=startKey,proto3" json:"startKey,omitempty"`
+	EndKey        string                 `protobuf:"bytes,2,opt,name=endKey,proto3" json:"endKey,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_protos_NodeKV_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ScanRequest) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type KeyValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_protos_NodeKV_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyValues     []*KeyValue            `protobuf:"bytes,1,rep,name=keyValues,proto3" json:"keyValues,omitempty"`
+	Status        bool                   `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_protos_NodeKV_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ScanResponse) GetKeyValues() []*KeyValue {
+	if x != nil {
+		return x.KeyValues
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetStatus() bool {
+	if x != nil {
+		return x.Status
+	}
+	return false
+}
+
+func (x *ScanResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartKey      string                 `protobuf:"bytes,1,opt,name=startKey,proto3" json:"startKey,omitempty"`
+	EndKey        string                 `protobuf:"bytes,2,opt,name=endKey,proto3" json:"endKey,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_protos_NodeKV_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WatchRequest) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          WatchEvent_EventType   `protobuf:"varint,1,opt,name=type,proto3,enum=nodedataplane.WatchEvent_EventType" json:"type,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_protos_NodeKV_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchEvent) GetType() WatchEvent_EventType {
+	if x != nil {
+		return x.Type
+	}
+	return WatchEvent_PUT
+}
+
+func (x *WatchEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type TxnCompare struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Type          TxnCompare_CompareType `protobuf:"varint,2,opt,name=type,proto3,enum=nodedataplane.TxnCompare_CompareType" json:"type,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnCompare) Reset() {
+	*x = TxnCompare{}
+	mi := &file_protos_NodeKV_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnCompare) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnCompare) ProtoMessage() {}
+
+func (x *TxnCompare) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnCompare.ProtoReflect.Descriptor instead.
+func (*TxnCompare) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TxnCompare) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TxnCompare) GetType() TxnCompare_CompareType {
+	if x != nil {
+		return x.Type
+	}
+	return TxnCompare_VALUE_EQUALS
+}
+
+func (x *TxnCompare) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type TxnOperation struct {
+	state         protoimpl.MessageState     `protogen:"open.v1"`
+	Type          TxnOperation_OperationType `protobuf:"varint,1,opt,name=type,proto3,enum=nodedataplane.TxnOperation_OperationType" json:"type,omitempty"`
+	Key           string                     `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                     `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnOperation) Reset() {
+	*x = TxnOperation{}
+	mi := &file_protos_NodeKV_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnOperation) ProtoMessage() {}
+
+func (x *TxnOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnOperation.ProtoReflect.Descriptor instead.
+func (*TxnOperation) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TxnOperation) GetType() TxnOperation_OperationType {
+	if x != nil {
+		return x.Type
+	}
+	return TxnOperation_GET
+}
+
+func (x *TxnOperation) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TxnOperation) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// The success operations are applied when every compare holds, otherwise the
+// failure operations are applied. Both happen atomically with the compares.
+type TxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Compares      []*TxnCompare          `protobuf:"bytes,1,rep,name=compares,proto3" json:"compares,omitempty"`
+	Success       []*TxnOperation        `protobuf:"bytes,2,rep,name=success,proto3" json:"success,omitempty"`
+	Failure       []*TxnOperation        `protobuf:"bytes,3,rep,name=failure,proto3" json:"failure,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnRequest) Reset() {
+	*x = TxnRequest{}
+	mi := &file_protos_NodeKV_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnRequest) ProtoMessage() {}
+
+func (x *TxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnRequest.ProtoReflect.Descriptor instead.
+func (*TxnRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TxnRequest) GetCompares() []*TxnCompare {
+	if x != nil {
+		return x.Compares
+	}
+	return nil
+}
+
+func (x *TxnRequest) GetSuccess() []*TxnOperation {
+	if x != nil {
+		return x.Success
+	}
+	return nil
+}
+
+func (x *TxnRequest) GetFailure() []*TxnOperation {
+	if x != nil {
+		return x.Failure
+	}
+	return nil
+}
+
+type TxnOperationResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Found         bool                   `protobuf:"varint,3,opt,name=found,proto3" json:"found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnOperationResult) Reset() {
+	*x = TxnOperationResult{}
+	mi := &file_protos_NodeKV_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnOperationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnOperationResult) ProtoMessage() {}
+
+func (x *TxnOperationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnOperationResult.ProtoReflect.Descriptor instead.
+func (*TxnOperationResult) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TxnOperationResult) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TxnOperationResult) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *TxnOperationResult) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+type TxnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Succeeded     bool                   `protobuf:"varint,1,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Results       []*TxnOperationResult  `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	Status        bool                   `protobuf:"varint,3,opt,name=status,proto3" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,5,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnResponse) Reset() {
+	*x = TxnResponse{}
+	mi := &file_protos_NodeKV_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnResponse) ProtoMessage() {}
+
+func (x *TxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnResponse.ProtoReflect.Descriptor instead.
+func (*TxnResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TxnResponse) GetSucceeded() bool {
+	if x != nil {
+		return x.Succeeded
+	}
+	return false
+}
+
+func (x *TxnResponse) GetResults() []*TxnOperationResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *TxnResponse) GetStatus() bool {
+	if x != nil {
+		return x.Status
+	}
+	return false
+}
+
+func (x *TxnResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TxnResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+// delta may be negative to decrement
+type IncrementRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	WriteQuorum   int32                  `protobuf:"varint,3,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrementRequest) Reset() {
+	*x = IncrementRequest{}
+	mi := &file_protos_NodeKV_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrementRequest) ProtoMessage() {}
+
+func (x *IncrementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrementRequest.ProtoReflect.Descriptor instead.
+func (*IncrementRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *IncrementRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *IncrementRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *IncrementRequest) GetWriteQuorum() int32 {
+	if x != nil {
+		return x.WriteQuorum
+	}
+	return 0
+}
+
+// Removing an element only removes the adds the node has seen, an add
+// concurrent with the remove wins
+type SetElementRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Element       string                 `protobuf:"bytes,2,opt,name=element,proto3" json:"element,omitempty"`
+	WriteQuorum   int32                  `protobuf:"varint,3,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetElementRequest) Reset() {
+	*x = SetElementRequest{}
+	mi := &file_protos_NodeKV_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetElementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetElementRequest) ProtoMessage() {}
+
+func (x *SetElementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetElementRequest.ProtoReflect.Descriptor instead.
+func (*SetElementRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SetElementRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SetElementRequest) GetElement() string {
+	if x != nil {
+		return x.Element
+	}
+	return ""
+}
+
+func (x *SetElementRequest) GetWriteQuorum() int32 {
+	if x != nil {
+		return x.WriteQuorum
+	}
+	return 0
+}
+
+type RegisterSetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	WriteQuorum   int32                  `protobuf:"varint,3,opt,name=writeQuorum,proto3" json:"writeQuorum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSetRequest) Reset() {
+	*x = RegisterSetRequest{}
+	mi := &file_protos_NodeKV_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSetRequest) ProtoMessage() {}
+
+func (x *RegisterSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSetRequest.ProtoReflect.Descriptor instead.
+func (*RegisterSetRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RegisterSetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RegisterSetRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *RegisterSetRequest) GetWriteQuorum() int32 {
+	if x != nil {
+		return x.WriteQuorum
+	}
+	return 0
+}
+
+type GetCRDTRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Key            string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Consistency    ReadConsistency        `protobuf:"varint,2,opt,name=consistency,proto3,enum=nodedataplane.ReadConsistency" json:"consistency,omitempty"`
+	MaxStalenessMs int64                  `protobuf:"varint,3,opt,name=maxStalenessMs,proto3" json:"maxStalenessMs,omitempty"`
+	ReadQuorum     int32                  `protobuf:"varint,4,opt,name=readQuorum,proto3" json:"readQuorum,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetCRDTRequest) Reset() {
+	*x = GetCRDTRequest{}
+	mi := &file_protos_NodeKV_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCRDTRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCRDTRequest) ProtoMessage() {}
+
+func (x *GetCRDTRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCRDTRequest.ProtoReflect.Descriptor instead.
+func (*GetCRDTRequest) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetCRDTRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *GetCRDTRequest) GetConsistency() ReadConsistency {
+	if x != nil {
+		return x.Consistency
+	}
+	return ReadConsistency_LINEARIZABLE
+}
+
+func (x *GetCRDTRequest) GetMaxStalenessMs() int64 {
+	if x != nil {
+		return x.MaxStalenessMs
+	}
+	return 0
+}
+
+func (x *GetCRDTRequest) GetReadQuorum() int32 {
+	if x != nil {
+		return x.ReadQuorum
+	}
+	return 0
+}
+
+// The value of the key after the update or at the read, in the field of its
+// type
+type CRDTResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Type          CRDTType               `protobuf:"varint,2,opt,name=type,proto3,enum=nodedataplane.CRDTType" json:"type,omitempty"`
+	Counter       int64                  `protobuf:"varint,3,opt,name=counter,proto3" json:"counter,omitempty"`
+	Elements      []string               `protobuf:"bytes,4,rep,name=elements,proto3" json:"elements,omitempty"`
+	Register      string                 `protobuf:"bytes,5,opt,name=register,proto3" json:"register,omitempty"`
+	Status        bool                   `protobuf:"varint,6,opt,name=status,proto3" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,8,opt,name=sessionToken,proto3" json:"sessionToken,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CRDTResponse) Reset() {
+	*x = CRDTResponse{}
+	mi := &file_protos_NodeKV_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CRDTResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CRDTResponse) ProtoMessage() {}
+
+func (x *CRDTResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_NodeKV_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CRDTResponse.ProtoReflect.Descriptor instead.
+func (*CRDTResponse) Descriptor() ([]byte, []int) {
+	return file_protos_NodeKV_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CRDTResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CRDTResponse) GetType() CRDTType {
+	if x != nil {
+		return x.Type
+	}
+	return CRDTType_COUNTER
+}
+
+func (x *CRDTResponse) GetCounter() int64 {
+	if x != nil {
+		return x.Counter
+	}
+	return 0
+}
+
+func (x *CRDTResponse) GetElements() []string {
+	if x != nil {
+		return x.Elements
+	}
+	return nil
+}
+
+func (x *CRDTResponse) GetRegister() string {
+	if x != nil {
+		return x.Register
+	}
+	return ""
+}
+
+func (x *CRDTResponse) GetStatus() bool {
+	if x != nil {
+		return x.Status
+	}
+	return false
+}
+
+func (x *CRDTResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *CRDTResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+var File_protos_NodeKV_proto protoreflect.FileDescriptor
+
+const file_protos_NodeKV_proto_rawDesc = "" +
+	"\n" +
+	"\x13protos/NodeKV.proto\x12\rnodedataplane\"\xcc\x01\n" +
+	"\n" +
+	"GetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12@\n" +
+	"\vconsistency\x18\x02 \x01(\x0e2\x1e.nodedataplane.ReadConsistencyR\vconsistency\x12&\n" +
+	"\x0emaxStalenessMs\x18\x03 \x01(\x03R\x0emaxStalenessMs\x12\"\n" +
+	"\fsessionToken\x18\x04 \x01(\tR\fsessionToken\x12\x1e\n" +
+	"\n" +
+	"readQuorum\x18\x05 \x01(\x05R\n" +
+	"readQuorum\"X\n" +
+	"\fSessionToken\x12\x16\n" +
+	"\x06nodeId\x18\x01 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x03R\x05epoch\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x03R\bsequence\"\x99\x01\n" +
+	"\vGetResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1a\n" +
+	"\bsiblings\x18\x05 \x03(\tR\bsiblings\x12\x18\n" +
+	"\acontext\x18\x06 \x01(\tR\acontext\"p\n" +
+	"\n" +
+	"SetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12 \n" +
+	"\vwriteQuorum\x18\x03 \x01(\x05R\vwriteQuorum\x12\x18\n" +
+	"\acontext\x18\x04 \x01(\tR\acontext\"q\n" +
+	"\vSetResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\"\n" +
+	"\fsessionToken\x18\x04 \x01(\tR\fsessionToken\"]\n" +
+	"\rDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12 \n" +
+	"\vwriteQuorum\x18\x02 \x01(\x05R\vwriteQuorum\x12\x18\n" +
+	"\acontext\x18\x03 \x01(\tR\acontext\"\x8a\x01\n" +
+	"\x0eDeleteResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\"\n" +
+	"\fsessionToken\x18\x05 \x01(\tR\fsessionToken\"W\n" +
+	"\vScanRequest\x12\x1a\n" +
+	"\bstartKey\x18\x01 \x01(\tR\bstartKey\x12\x16\n" +
+	"\x06endKey\x18\x02 \x01(\tR\x06endKey\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"2\n" +
+	"\bKeyValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"s\n" +
+	"\fScanResponse\x125\n" +
+	"\tkeyValues\x18\x01 \x03(\v2\x17.nodedataplane.KeyValueR\tkeyValues\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"B\n" +
+	"\fWatchRequest\x12\x1a\n" +
+	"\bstartKey\x18\x01 \x01(\tR\bstartKey\x12\x16\n" +
+	"\x06endKey\x18\x02 \x01(\tR\x06endKey\"\x8f\x01\n" +
+	"\n" +
+	"WatchEvent\x127\n" +
+	"\x04type\x18\x01 \x01(\x0e2#.nodedataplane.WatchEvent.EventTypeR\x04type\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\" \n" +
+	"\tEventType\x12\a\n" +
+	"\x03PUT\x10\x00\x12\n" +
+	"\n" +
+	"\x06DELETE\x10\x01\"\xac\x01\n" +
+	"\n" +
+	"TxnCompare\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x129\n" +
+	"\x04type\x18\x02 \x01(\x0e2%.nodedataplane.TxnCompare.CompareTypeR\x04type\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\";\n" +
+	"\vCompareType\x12\x10\n" +
+	"\fVALUE_EQUALS\x10\x00\x12\n" +
+	"\n" +
+	"\x06EXISTS\x10\x01\x12\x0e\n" +
+	"\n" +
+	"NOT_EXISTS\x10\x02\"\xa4\x01\n" +
+	"\fTxnOperation\x12=\n" +
+	"\x04type\x18\x01 \x01(\x0e2).nodedataplane.TxnOperation.OperationTypeR\x04type\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"-\n" +
+	"\rOperationType\x12\a\n" +
+	"\x03GET\x10\x00\x12\a\n" +
+	"\x03PUT\x10\x01\x12\n" +
+	"\n" +
+	"\x06DELETE\x10\x02\"\xb1\x01\n" +
+	"\n" +
+	"TxnRequest\x125\n" +
+	"\bcompares\x18\x01 \x03(\v2\x19.nodedataplane.TxnCompareR\bcompares\x125\n" +
+	"\asuccess\x18\x02 \x03(\v2\x1b.nodedataplane.TxnOperationR\asuccess\x125\n" +
+	"\afailure\x18\x03 \x03(\v2\x1b.nodedataplane.TxnOperationR\afailure\"R\n" +
+	"\x12TxnOperationResult\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x14\n" +
+	"\x05found\x18\x03 \x01(\bR\x05found\"\xba\x01\n" +
+	"\vTxnResponse\x12\x1c\n" +
+	"\tsucceeded\x18\x01 \x01(\bR\tsucceeded\x12;\n" +
+	"\aresults\x18\x02 \x03(\v2!.nodedataplane.TxnOperationResultR\aresults\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\"\n" +
+	"\fsessionToken\x18\x05 \x01(\tR\fsessionToken\"\\\n" +
+	"\x10IncrementRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12 \n" +
+	"\vwriteQuorum\x18\x03 \x01(\x05R\vwriteQuorum\"a\n" +
+	"\x11SetElementRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\aelement\x18\x02 \x01(\tR\aelement\x12 \n" +
+	"\vwriteQuorum\x18\x03 \x01(\x05R\vwriteQuorum\"^\n" +
+	"\x12RegisterSetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12 \n" +
+	"\vwriteQuorum\x18\x03 \x01(\x05R\vwriteQuorum\"\xac\x01\n" +
+	"\x0eGetCRDTRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12@\n" +
+	"\vconsistency\x18\x02 \x01(\x0e2\x1e.nodedataplane.ReadConsistencyR\vconsistency\x12&\n" +
+	"\x0emaxStalenessMs\x18\x03 \x01(\x03R\x0emaxStalenessMs\x12\x1e\n" +
+	"\n" +
+	"readQuorum\x18\x04 \x01(\x05R\n" +
+	"readQuorum\"\xf1\x01\n" +
+	"\fCRDTResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x17.nodedataplane.CRDTTypeR\x04type\x12\x18\n" +
+	"\acounter\x18\x03 \x01(\x03R\acounter\x12\x1a\n" +
+	"\belements\x18\x04 \x03(\tR\belements\x12\x1a\n" +
+	"\bregister\x18\x05 \x01(\tR\bregister\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\bR\x06status\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\"\n" +
+	"\fsessionToken\x18\b \x01(\tR\fsessionToken*C\n" +
+	"\x0fReadConsistency\x12\x10\n" +
+	"\fLINEARIZABLE\x10\x00\x12\x15\n" +
+	"\x11BOUNDED_STALENESS\x10\x01\x12\a\n" +
+	"\x03ANY\x10\x02*.\n" +
+	"\bCRDTType\x12\v\n" +
+	"\aCOUNTER\x10\x00\x12\a\n" +
+	"\x03SET\x10\x01\x12\f\n" +
+	"\bREGISTER\x10\x022\xa1\x06\n" +
 	"\x13NodeKeyValueService\x12?\n" +
 	"\x06GetKey\x12\x19.nodedataplane.GetRequest\x1a\x1a.nodedataplane.GetResponse\x12?\n" +
 	"\x06SetKey\x12\x19.nodedataplane.SetRequest\x1a\x1a.nodedataplane.SetResponse\x12H\n" +
-	"\tDeleteKey\x12\x1c.nodedataplane.DeleteRequest\x1a\x1d.nodedataplane.DeleteResponseB/Z-github.com/Vahsek/distrokv/pkg/node/dataplaneb\x06proto3"
+	"\tDeleteKey\x12\x1c.nodedataplane.DeleteRequest\x1a\x1d.nodedataplane.DeleteResponse\x12C\n" +
+	"\bScanKeys\x12\x1a.nodedataplane.ScanRequest\x1a\x1b.nodedataplane.ScanResponse\x12E\n" +
+	"\tWatchKeys\x12\x1b.nodedataplane.WatchRequest\x1a\x19.nodedataplane.WatchEvent0\x01\x12<\n" +
+	"\x03Txn\x12\x19.nodedataplane.TxnRequest\x1a\x1a.nodedataplane.TxnResponse\x12I\n" +
+	"\tIncrement\x12\x1f.nodedataplane.IncrementRequest\x1a\x1b.nodedataplane.CRDTResponse\x12G\n" +
+	"\x06SetAdd\x12 .nodedataplane.SetElementRequest\x1a\x1b.nodedataplane.CRDTResponse\x12J\n" +
+	"\tSetRemove\x12 .nodedataplane.SetElementRequest\x1a\x1b.nodedataplane.CRDTResponse\x12M\n" +
+	"\vRegisterSet\x12!.nodedataplane.RegisterSetRequest\x1a\x1b.nodedataplane.CRDTResponse\x12E\n" +
+	"\aGetCRDT\x12\x1d.nodedataplane.GetCRDTRequest\x1a\x1b.nodedataplane.CRDTResponseB/Z-github.com/Vahsek/distrokv/pkg/node/dataplaneb\x06proto3"
 
 var (
 	file_protos_NodeKV_proto_rawDescOnce sync.Once
@@ -402,27 +1899,76 @@ func file_protos_NodeKV_proto_rawDescGZIP() []byte {
 	return file_protos_NodeKV_proto_rawDescData
 }
 
-var file_protos_NodeKV_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_protos_NodeKV_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_protos_NodeKV_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_protos_NodeKV_proto_goTypes = []any{
-	(*GetRequest)(nil),     // 0: nodedataplane.GetRequest
-	(*GetResponse)(nil),    // 1: nodedataplane.GetResponse
-	(*SetRequest)(nil),     // 2: nodedataplane.SetRequest
-	(*SetResponse)(nil),    // 3: nodedataplane.SetResponse
-	(*DeleteRequest)(nil),  // 4: nodedataplane.DeleteRequest
-	(*DeleteResponse)(nil), // 5: nodedataplane.DeleteResponse
+	(ReadConsistency)(0),            // 0: nodedataplane.ReadConsistency
+	(CRDTType)(0),                   // 1: nodedataplane.CRDTType
+	(WatchEvent_EventType)(0),       // 2: nodedataplane.WatchEvent.EventType
+	(TxnCompare_CompareType)(0),     // 3: nodedataplane.TxnCompare.CompareType
+	(TxnOperation_OperationType)(0), // 4: nodedataplane.TxnOperation.OperationType
+	(*GetRequest)(nil),              // 5: nodedataplane.GetRequest
+	(*SessionToken)(nil),            // 6: nodedataplane.SessionToken
+	(*GetResponse)(nil),             // 7: nodedataplane.GetResponse
+	(*SetRequest)(nil),              // 8: nodedataplane.SetRequest
+	(*SetResponse)(nil),             // 9: nodedataplane.SetResponse
+	(*DeleteRequest)(nil),           // 10: nodedataplane.DeleteRequest
+	(*DeleteResponse)(nil),          // 11: nodedataplane.DeleteResponse
+	(*ScanRequest)(nil),             // 12: nodedataplane.ScanRequest
+	(*KeyValue)(nil),                // 13: nodedataplane.KeyValue
+	(*ScanResponse)(nil),            // 14: nodedataplane.ScanResponse
+	(*WatchRequest)(nil),            // 15: nodedataplane.WatchRequest
+	(*WatchEvent)(nil),              // 16: nodedataplane.WatchEvent
+	(*TxnCompare)(nil),              // 17: nodedataplane.TxnCompare
+	(*TxnOperation)(nil),            // 18: nodedataplane.TxnOperation
+	(*TxnRequest)(nil),              // 19: nodedataplane.TxnRequest
+	(*TxnOperationResult)(nil),      // 20: nodedataplane.TxnOperationResult
+	(*TxnResponse)(nil),             // 21: nodedataplane.TxnResponse
+	(*IncrementRequest)(nil),        // 22: nodedataplane.IncrementRequest
+	(*SetElementRequest)(nil),       // 23: nodedataplane.SetElementRequest
+	(*RegisterSetRequest)(nil),      // 24: nodedataplane.RegisterSetRequest
+	(*GetCRDTRequest)(nil),          // 25: nodedataplane.GetCRDTRequest
+	(*CRDTResponse)(nil),            // 26: nodedataplane.CRDTResponse
 }
 var file_protos_NodeKV_proto_depIdxs = []int32{
-	0, // 0: nodedataplane.NodeKeyValueService.GetKey:input_type -> nodedataplane.GetRequest
-	2, // 1: nodedataplane.NodeKeyValueService.SetKey:input_type -> nodedataplane.SetRequest
-	4, // 2: nodedataplane.NodeKeyValueService.DeleteKey:input_type -> nodedataplane.DeleteRequest
-	1, // 3: nodedataplane.NodeKeyValueService.GetKey:output_type -> nodedataplane.GetResponse
-	3, // 4: nodedataplane.NodeKeyValueService.SetKey:output_type -> nodedataplane.SetResponse
-	5, // 5: nodedataplane.NodeKeyValueService.DeleteKey:output_type -> nodedataplane.DeleteResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: nodedataplane.GetRequest.consistency:type_name -> nodedataplane.ReadConsistency
+	13, // 1: nodedataplane.ScanResponse.keyValues:type_name -> nodedataplane.KeyValue
+	2,  // 2: nodedataplane.WatchEvent.type:type_name -> nodedataplane.WatchEvent.EventType
+	3,  // 3: nodedataplane.TxnCompare.type:type_name -> nodedataplane.TxnCompare.CompareType
+	4,  // 4: nodedataplane.TxnOperation.type:type_name -> nodedataplane.TxnOperation.OperationType
+	17, // 5: nodedataplane.TxnRequest.compares:type_name -> nodedataplane.TxnCompare
+	18, // 6: nodedataplane.TxnRequest.success:type_name -> nodedataplane.TxnOperation
+	18, // 7: nodedataplane.TxnRequest.failure:type_name -> nodedataplane.TxnOperation
+	20, // 8: nodedataplane.TxnResponse.results:type_name -> nodedataplane.TxnOperationResult
+	0,  // 9: nodedataplane.GetCRDTRequest.consistency:type_name -> nodedataplane.ReadConsistency
+	1,  // 10: nodedataplane.CRDTResponse.type:type_name -> nodedataplane.CRDTType
+	5,  // 11: nodedataplane.NodeKeyValueService.GetKey:input_type -> nodedataplane.GetRequest
+	8,  // 12: nodedataplane.NodeKeyValueService.SetKey:input_type -> nodedataplane.SetRequest
+	10, // 13: nodedataplane.NodeKeyValueService.DeleteKey:input_type -> nodedataplane.DeleteRequest
+	12, // 14: nodedataplane.NodeKeyValueService.ScanKeys:input_type -> nodedataplane.ScanRequest
+	15, // 15: nodedataplane.NodeKeyValueService.WatchKeys:input_type -> nodedataplane.WatchRequest
+	19, // 16: nodedataplane.NodeKeyValueService.Txn:input_type -> nodedataplane.TxnRequest
+	22, // 17: nodedataplane.NodeKeyValueService.Increment:input_type -> nodedataplane.IncrementRequest
+	23, // 18: nodedataplane.NodeKeyValueService.SetAdd:input_type -> nodedataplane.SetElementRequest
+	23, // 19: nodedataplane.NodeKeyValueService.SetRemove:input_type -> nodedataplane.SetElementRequest
+	24, // 20: nodedataplane.NodeKeyValueService.RegisterSet:input_type -> nodedataplane.RegisterSetRequest
+	25, // 21: nodedataplane.NodeKeyValueService.GetCRDT:input_type -> nodedataplane.GetCRDTRequest
+	7,  // 22: nodedataplane.NodeKeyValueService.GetKey:output_type -> nodedataplane.GetResponse
+	9,  // 23: nodedataplane.NodeKeyValueService.SetKey:output_type -> nodedataplane.SetResponse
+	11, // 24: nodedataplane.NodeKeyValueService.DeleteKey:output_type -> nodedataplane.DeleteResponse
+	14, // 25: nodedataplane.NodeKeyValueService.ScanKeys:output_type -> nodedataplane.ScanResponse
+	16, // 26: nodedataplane.NodeKeyValueService.WatchKeys:output_type -> nodedataplane.WatchEvent
+	21, // 27: nodedataplane.NodeKeyValueService.Txn:output_type -> nodedataplane.TxnResponse
+	26, // 28: nodedataplane.NodeKeyValueService.Increment:output_type -> nodedataplane.CRDTResponse
+	26, // 29: nodedataplane.NodeKeyValueService.SetAdd:output_type -> nodedataplane.CRDTResponse
+	26, // 30: nodedataplane.NodeKeyValueService.SetRemove:output_type -> nodedataplane.CRDTResponse
+	26, // 31: nodedataplane.NodeKeyValueService.RegisterSet:output_type -> nodedataplane.CRDTResponse
+	26, // 32: nodedataplane.NodeKeyValueService.GetCRDT:output_type -> nodedataplane.CRDTResponse
+	22, // [22:33] is the sub-list for method output_type
+	11, // [11:22] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_protos_NodeKV_proto_init() }
@@ -435,13 +1981,14 @@ func file_protos_NodeKV_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_protos_NodeKV_proto_rawDesc), len(file_protos_NodeKV_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      5,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_protos_NodeKV_proto_goTypes,
 		DependencyIndexes: file_protos_NodeKV_proto_depIdxs,
+		EnumInfos:         file_protos_NodeKV_proto_enumTypes,
 		MessageInfos:      file_protos_NodeKV_proto_msgTypes,
 	}.Build()
 	File_protos_NodeKV_proto = out.File
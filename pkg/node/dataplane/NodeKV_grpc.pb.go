@@ -19,9 +19,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	NodeKeyValueService_GetKey_FullMethodName    = "/nodedataplane.NodeKeyValueService/GetKey"
-	NodeKeyValueService_SetKey_FullMethodName    = "/nodedataplane.NodeKeyValueService/SetKey"
-	NodeKeyValueService_DeleteKey_FullMethodName = "/nodedataplane.NodeKeyValueService/DeleteKey"
+	NodeKeyValueService_GetKey_FullMethodName      = "/nodedataplane.NodeKeyValueService/GetKey"
+	NodeKeyValueService_SetKey_FullMethodName      = "/nodedataplane.NodeKeyValueService/SetKey"
+	NodeKeyValueService_DeleteKey_FullMethodName   = "/nodedataplane.NodeKeyValueService/DeleteKey"
+	NodeKeyValueService_ScanKeys_FullMethodName    = "/nodedataplane.NodeKeyValueService/ScanKeys"
+	NodeKeyValueService_WatchKeys_FullMethodName   = "/nodedataplane.NodeKeyValueService/WatchKeys"
+	NodeKeyValueService_Txn_FullMethodName         = "/nodedataplane.NodeKeyValueService/Txn"
+	NodeKeyValueService_Increment_FullMethodName   = "/nodedataplane.NodeKeyValueService/Increment"
+	NodeKeyValueService_SetAdd_FullMethodName      = "/nodedataplane.NodeKeyValueService/SetAdd"
+	NodeKeyValueService_SetRemove_FullMethodName   = "/nodedataplane.NodeKeyValueService/SetRemove"
+	NodeKeyValueService_RegisterSet_FullMethodName = "/nodedataplane.NodeKeyValueService/RegisterSet"
+	NodeKeyValueService_GetCRDT_FullMethodName     = "/nodedataplane.NodeKeyValueService/GetCRDT"
 )
 
 // NodeKeyValueServiceClient is the client API for NodeKeyValueService service.
@@ -31,6 +39,14 @@ type NodeKeyValueServiceClient interface {
 	GetKey(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	SetKey(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
 	DeleteKey(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	ScanKeys(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	WatchKeys(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+	Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error)
+	Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*CRDTResponse, error)
+	SetAdd(ctx context.Context, in *SetElementRequest, opts ...grpc.CallOption) (*CRDTResponse, error)
+	SetRemove(ctx context.Context, in *SetElementRequest, opts ...grpc.CallOption) (*CRDTResponse, error)
+	RegisterSet(ctx context.Context, in *RegisterSetRequest, opts ...grpc.CallOption) (*CRDTResponse, error)
+	GetCRDT(ctx context.Context, in *GetCRDTRequest, opts ...grpc.CallOption) (*CRDTResponse, error)
 }
 
 type nodeKeyValueServiceClient struct {
@@ -71,6 +87,95 @@ func (c *nodeKeyValueServiceClient) DeleteKey(ctx context.Context, in *DeleteReq
 	return out, nil
 }
 
+func (c *nodeKeyValueServiceClient) ScanKeys(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, NodeKeyValueService_ScanKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeKeyValueServiceClient) WatchKeys(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NodeKeyValueService_ServiceDesc.Streams[0], NodeKeyValueService_WatchKeys_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeKeyValueService_WatchKeysClient = grpc.ServerStreamingClient[WatchEvent]
+
+func (c *nodeKeyValueServiceClient) Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TxnResponse)
+	err := c.cc.Invoke(ctx, NodeKeyValueService_Txn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeKeyValueServiceClient) Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*CRDTResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CRDTResponse)
+	err := c.cc.Invoke(ctx, NodeKeyValueService_Increment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeKeyValueServiceClient) SetAdd(ctx context.Context, in *SetElementRequest, opts ...grpc.CallOption) (*CRDTResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CRDTResponse)
+	err := c.cc.Invoke(ctx, NodeKeyValueService_SetAdd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeKeyValueServiceClient) SetRemove(ctx context.Context, in *SetElementRequest, opts ...grpc.CallOption) (*CRDTResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CRDTResponse)
+	err := c.cc.Invoke(ctx, NodeKeyValueService_SetRemove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeKeyValueServiceClient) RegisterSet(ctx context.Context, in *RegisterSetRequest, opts ...grpc.CallOption) (*CRDTResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CRDTResponse)
+	err := c.cc.Invoke(ctx, NodeKeyValueService_RegisterSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeKeyValueServiceClient) GetCRDT(ctx context.Context, in *GetCRDTRequest, opts ...grpc.CallOption) (*CRDTResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CRDTResponse)
+	err := c.cc.Invoke(ctx, NodeKeyValueService_GetCRDT_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodeKeyValueServiceServer is the server API for NodeKeyValueService service.
 // All implementations must embed UnimplementedNodeKeyValueServiceServer
 // for forward compatibility.
@@ -78,6 +183,14 @@ type NodeKeyValueServiceServer interface {
 	GetKey(context.Context, *GetRequest) (*GetResponse, error)
 	SetKey(context.Context, *SetRequest) (*SetResponse, error)
 	DeleteKey(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	ScanKeys(context.Context, *ScanRequest) (*ScanResponse, error)
+	WatchKeys(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	Txn(context.Context, *TxnRequest) (*TxnResponse, error)
+	Increment(context.Context, *IncrementRequest) (*CRDTResponse, error)
+	SetAdd(context.Context, *SetElementRequest) (*CRDTResponse, error)
+	SetRemove(context.Context, *SetElementRequest) (*CRDTResponse, error)
+	RegisterSet(context.Context, *RegisterSetRequest) (*CRDTResponse, error)
+	GetCRDT(context.Context, *GetCRDTRequest) (*CRDTResponse, error)
 	mustEmbedUnimplementedNodeKeyValueServiceServer()
 }
 
@@ -97,6 +210,30 @@ func (UnimplementedNodeKeyValueServiceServer) SetKey(context.Context, *SetReques
 func (UnimplementedNodeKeyValueServiceServer) DeleteKey(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteKey not implemented")
 }
+func (UnimplementedNodeKeyValueServiceServer) ScanKeys(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScanKeys not implemented")
+}
+func (UnimplementedNodeKeyValueServiceServer) WatchKeys(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchKeys not implemented")
+}
+func (UnimplementedNodeKeyValueServiceServer) Txn(context.Context, *TxnRequest) (*TxnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Txn not implemented")
+}
+func (UnimplementedNodeKeyValueServiceServer) Increment(context.Context, *IncrementRequest) (*CRDTResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedNodeKeyValueServiceServer) SetAdd(context.Context, *SetElementRequest) (*CRDTResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAdd not implemented")
+}
+func (UnimplementedNodeKeyValueServiceServer) SetRemove(context.Context, *SetElementRequest) (*CRDTResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRemove not implemented")
+}
+func (UnimplementedNodeKeyValueServiceServer) RegisterSet(context.Context, *RegisterSetRequest) (*CRDTResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterSet not implemented")
+}
+func (UnimplementedNodeKeyValueServiceServer) GetCRDT(context.Context, *GetCRDTRequest) (*CRDTResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCRDT not implemented")
+}
 func (UnimplementedNodeKeyValueServiceServer) mustEmbedUnimplementedNodeKeyValueServiceServer() {}
 func (UnimplementedNodeKeyValueServiceServer) testEmbeddedByValue()                             {}
 
@@ -172,6 +309,143 @@ func _NodeKeyValueService_DeleteKey_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NodeKeyValueService_ScanKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeKeyValueServiceServer).ScanKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeKeyValueService_ScanKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeKeyValueServiceServer).ScanKeys(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeKeyValueService_WatchKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeKeyValueServiceServer).WatchKeys(m, &grpc.GenericServerStream[WatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NodeKeyValueService_WatchKeysServer = grpc.ServerStreamingServer[WatchEvent]
+
+func _NodeKeyValueService_Txn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeKeyValueServiceServer).Txn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeKeyValueService_Txn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeKeyValueServiceServer).Txn(ctx, req.(*TxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeKeyValueService_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeKeyValueServiceServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeKeyValueService_Increment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeKeyValueServiceServer).Increment(ctx, req.(*IncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeKeyValueService_SetAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetElementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeKeyValueServiceServer).SetAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeKeyValueService_SetAdd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeKeyValueServiceServer).SetAdd(ctx, req.(*SetElementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeKeyValueService_SetRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetElementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeKeyValueServiceServer).SetRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeKeyValueService_SetRemove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeKeyValueServiceServer).SetRemove(ctx, req.(*SetElementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeKeyValueService_RegisterSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeKeyValueServiceServer).RegisterSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeKeyValueService_RegisterSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeKeyValueServiceServer).RegisterSet(ctx, req.(*RegisterSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NodeKeyValueService_GetCRDT_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCRDTRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeKeyValueServiceServer).GetCRDT(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NodeKeyValueService_GetCRDT_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeKeyValueServiceServer).GetCRDT(ctx, req.(*GetCRDTRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // NodeKeyValueService_ServiceDesc is the grpc.ServiceDesc for NodeKeyValueService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,7 +465,41 @@ var NodeKeyValueService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteKey",
 			Handler:    _NodeKeyValueService_DeleteKey_Handler,
 		},
+		{
+			MethodName: "ScanKeys",
+			Handler:    _NodeKeyValueService_ScanKeys_Handler,
+		},
+		{
+			MethodName: "Txn",
+			Handler:    _NodeKeyValueService_Txn_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _NodeKeyValueService_Increment_Handler,
+		},
+		{
+			MethodName: "SetAdd",
+			Handler:    _NodeKeyValueService_SetAdd_Handler,
+		},
+		{
+			MethodName: "SetRemove",
+			Handler:    _NodeKeyValueService_SetRemove_Handler,
+		},
+		{
+			MethodName: "RegisterSet",
+			Handler:    _NodeKeyValueService_RegisterSet_Handler,
+		},
+		{
+			MethodName: "GetCRDT",
+			Handler:    _NodeKeyValueService_GetCRDT_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchKeys",
+			Handler:       _NodeKeyValueService_WatchKeys_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "protos/NodeKV.proto",
 }
@@ -21,11 +21,65 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Learners receive every write but do not count toward quorum and are never
+// picked as primary
+type NodeRole int32
+
+const (
+	NodeRole_VOTER   NodeRole = 0
+	NodeRole_LEARNER NodeRole = 1
+)
+
+// Enum value maps for NodeRole.
+var (
+	NodeRole_name = map[int32]string{
+		0: "VOTER",
+		1: "LEARNER",
+	}
+	NodeRole_value = map[string]int32{
+		"VOTER":   0,
+		"LEARNER": 1,
+	}
+)
+
+func (x NodeRole) Enum() *NodeRole {
+	p := new(NodeRole)
+	*p = x
+	return p
+}
+
+func (x NodeRole) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NodeRole) Descriptor() protoreflect.EnumDescriptor {
+	return file_protos_registry_proto_enumTypes[0].Descriptor()
+}
+
+func (NodeRole) Type() protoreflect.EnumType {
+	return &file_protos_registry_proto_enumTypes[0]
+}
+
+func (x NodeRole) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NodeRole.Descriptor instead.
+func (NodeRole) EnumDescriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{0}
+}
+
+// Nodes are identified by nodeId, a UUID the node generates on first start and
+// keeps in its data directory. The address fields may change between restarts.
 type RegisterNodeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
 	IpAddress     string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
 	PortNumber    string                 `protobuf:"bytes,3,opt,name=portNumber,proto3" json:"portNumber,omitempty"`
+	DataPlanePort string                 `protobuf:"bytes,4,opt,name=dataPlanePort,proto3" json:"dataPlanePort,omitempty"`
+	NodeId        string                 `protobuf:"bytes,5,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	// role is only honoured for a new node, a promoted node stays a voter
+	Role          NodeRole `protobuf:"varint,6,opt,name=role,proto3,enum=registry.NodeRole" json:"role,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,10 +135,34 @@ func (x *RegisterNodeRequest) GetPortNumber() string {
 	return ""
 }
 
+func (x *RegisterNodeRequest) GetDataPlanePort() string {
+	if x != nil {
+		return x.DataPlanePort
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetRole() NodeRole {
+	if x != nil {
+		return x.Role
+	}
+	return NodeRole_VOTER
+}
+
 type RegisterNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	PrimaryNodeId string                 `protobuf:"bytes,3,opt,name=primaryNodeId,proto3" json:"primaryNodeId,omitempty"`
+	Role          NodeRole               `protobuf:"varint,4,opt,name=role,proto3,enum=registry.NodeRole" json:"role,omitempty"`
+	Configuration *ClusterConfiguration  `protobuf:"bytes,5,opt,name=configuration,proto3" json:"configuration,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -133,26 +211,51 @@ func (x *RegisterNodeResponse) GetMessage() string {
 	return ""
 }
 
-type PrimaryNodeRequest struct {
+func (x *RegisterNodeResponse) GetPrimaryNodeId() string {
+	if x != nil {
+		return x.PrimaryNodeId
+	}
+	return ""
+}
+
+func (x *RegisterNodeResponse) GetRole() NodeRole {
+	if x != nil {
+		return x.Role
+	}
+	return NodeRole_VOTER
+}
+
+func (x *RegisterNodeResponse) GetConfiguration() *ClusterConfiguration {
+	if x != nil {
+		return x.Configuration
+	}
+	return nil
+}
+
+type DeregisterNodeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	IpAddress     string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+	PortNumber    string                 `protobuf:"bytes,3,opt,name=portNumber,proto3" json:"portNumber,omitempty"`
+	NodeId        string                 `protobuf:"bytes,4,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PrimaryNodeRequest) Reset() {
-	*x = PrimaryNodeRequest{}
+func (x *DeregisterNodeRequest) Reset() {
+	*x = DeregisterNodeRequest{}
 	mi := &file_protos_registry_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PrimaryNodeRequest) String() string {
+func (x *DeregisterNodeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PrimaryNodeRequest) ProtoMessage() {}
+func (*DeregisterNodeRequest) ProtoMessage() {}
 
-func (x *PrimaryNodeRequest) ProtoReflect() protoreflect.Message {
+func (x *DeregisterNodeRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_registry_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -164,34 +267,61 @@ func (x *PrimaryNodeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PrimaryNodeRequest.ProtoReflect.Descriptor instead.
-func (*PrimaryNodeRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeregisterNodeRequest.ProtoReflect.Descriptor instead.
+func (*DeregisterNodeRequest) Descriptor() ([]byte, []int) {
 	return file_protos_registry_proto_rawDescGZIP(), []int{2}
 }
 
-type PrimaryNodeResponse struct {
+func (x *DeregisterNodeRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *DeregisterNodeRequest) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *DeregisterNodeRequest) GetPortNumber() string {
+	if x != nil {
+		return x.PortNumber
+	}
+	return ""
+}
+
+func (x *DeregisterNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type DeregisterNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	IpAddress     string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
-	PortNumber    string                 `protobuf:"bytes,3,opt,name=portNumber,proto3" json:"portNumber,omitempty"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PrimaryNodeResponse) Reset() {
-	*x = PrimaryNodeResponse{}
+func (x *DeregisterNodeResponse) Reset() {
+	*x = DeregisterNodeResponse{}
 	mi := &file_protos_registry_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PrimaryNodeResponse) String() string {
+func (x *DeregisterNodeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PrimaryNodeResponse) ProtoMessage() {}
+func (*DeregisterNodeResponse) ProtoMessage() {}
 
-func (x *PrimaryNodeResponse) ProtoReflect() protoreflect.Message {
+func (x *DeregisterNodeResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_registry_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -203,55 +333,51 @@ func (x *PrimaryNodeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PrimaryNodeResponse.ProtoReflect.Descriptor instead.
-func (*PrimaryNodeResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeregisterNodeResponse.ProtoReflect.Descriptor instead.
+func (*DeregisterNodeResponse) Descriptor() ([]byte, []int) {
 	return file_protos_registry_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *PrimaryNodeResponse) GetHostname() string {
-	if x != nil {
-		return x.Hostname
-	}
-	return ""
-}
-
-func (x *PrimaryNodeResponse) GetIpAddress() string {
+func (x *DeregisterNodeResponse) GetStatus() string {
 	if x != nil {
-		return x.IpAddress
+		return x.Status
 	}
 	return ""
 }
 
-func (x *PrimaryNodeResponse) GetPortNumber() string {
+func (x *DeregisterNodeResponse) GetMessage() string {
 	if x != nil {
-		return x.PortNumber
+		return x.Message
 	}
 	return ""
 }
 
-type HeartBeatRequest struct {
+// A drained node stops being picked as primary and is told through its
+// heartbeat to hand its data off to its peers, after which it deregisters
+type DrainNodeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
 	IpAddress     string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
 	PortNumber    string                 `protobuf:"bytes,3,opt,name=portNumber,proto3" json:"portNumber,omitempty"`
+	NodeId        string                 `protobuf:"bytes,4,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HeartBeatRequest) Reset() {
-	*x = HeartBeatRequest{}
+func (x *DrainNodeRequest) Reset() {
+	*x = DrainNodeRequest{}
 	mi := &file_protos_registry_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HeartBeatRequest) String() string {
+func (x *DrainNodeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HeartBeatRequest) ProtoMessage() {}
+func (*DrainNodeRequest) ProtoMessage() {}
 
-func (x *HeartBeatRequest) ProtoReflect() protoreflect.Message {
+func (x *DrainNodeRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_registry_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -263,33 +389,40 @@ func (x *HeartBeatRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HeartBeatRequest.ProtoReflect.Descriptor instead.
-func (*HeartBeatRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DrainNodeRequest.ProtoReflect.Descriptor instead.
+func (*DrainNodeRequest) Descriptor() ([]byte, []int) {
 	return file_protos_registry_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *HeartBeatRequest) GetHostname() string {
+func (x *DrainNodeRequest) GetHostname() string {
 	if x != nil {
 		return x.Hostname
 	}
 	return ""
 }
 
-func (x *HeartBeatRequest) GetIpAddress() string {
+func (x *DrainNodeRequest) GetIpAddress() string {
 	if x != nil {
 		return x.IpAddress
 	}
 	return ""
 }
 
-func (x *HeartBeatRequest) GetPortNumber() string {
+func (x *DrainNodeRequest) GetPortNumber() string {
 	if x != nil {
 		return x.PortNumber
 	}
 	return ""
 }
 
-type HeartBeatResponse struct {
+func (x *DrainNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type DrainNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
@@ -297,20 +430,20 @@ type HeartBeatResponse struct {
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HeartBeatResponse) Reset() {
-	*x = HeartBeatResponse{}
+func (x *DrainNodeResponse) Reset() {
+	*x = DrainNodeResponse{}
 	mi := &file_protos_registry_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HeartBeatResponse) String() string {
+func (x *DrainNodeResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HeartBeatResponse) ProtoMessage() {}
+func (*DrainNodeResponse) ProtoMessage() {}
 
-func (x *HeartBeatResponse) ProtoReflect() protoreflect.Message {
+func (x *DrainNodeResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_registry_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -322,45 +455,51 @@ func (x *HeartBeatResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HeartBeatResponse.ProtoReflect.Descriptor instead.
-func (*HeartBeatResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use DrainNodeResponse.ProtoReflect.Descriptor instead.
+func (*DrainNodeResponse) Descriptor() ([]byte, []int) {
 	return file_protos_registry_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *HeartBeatResponse) GetStatus() string {
+func (x *DrainNodeResponse) GetStatus() string {
 	if x != nil {
 		return x.Status
 	}
 	return ""
 }
 
-func (x *HeartBeatResponse) GetMessage() string {
+func (x *DrainNodeResponse) GetMessage() string {
 	if x != nil {
 		return x.Message
 	}
 	return ""
 }
 
-type NodeListRequest struct {
+// A promoted learner starts counting toward quorum and can be picked as
+// primary. Nodes learn about it through their heartbeats.
+type PromoteLearnerRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	IpAddress     string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+	PortNumber    string                 `protobuf:"bytes,3,opt,name=portNumber,proto3" json:"portNumber,omitempty"`
+	NodeId        string                 `protobuf:"bytes,4,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NodeListRequest) Reset() {
-	*x = NodeListRequest{}
+func (x *PromoteLearnerRequest) Reset() {
+	*x = PromoteLearnerRequest{}
 	mi := &file_protos_registry_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NodeListRequest) String() string {
+func (x *PromoteLearnerRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodeListRequest) ProtoMessage() {}
+func (*PromoteLearnerRequest) ProtoMessage() {}
 
-func (x *NodeListRequest) ProtoReflect() protoreflect.Message {
+func (x *PromoteLearnerRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_registry_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -372,32 +511,61 @@ func (x *NodeListRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodeListRequest.ProtoReflect.Descriptor instead.
-func (*NodeListRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use PromoteLearnerRequest.ProtoReflect.Descriptor instead.
+func (*PromoteLearnerRequest) Descriptor() ([]byte, []int) {
 	return file_protos_registry_proto_rawDescGZIP(), []int{6}
 }
 
-type NodeListResponse struct {
+func (x *PromoteLearnerRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *PromoteLearnerRequest) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *PromoteLearnerRequest) GetPortNumber() string {
+	if x != nil {
+		return x.PortNumber
+	}
+	return ""
+}
+
+func (x *PromoteLearnerRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type PromoteLearnerResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeList      []*NodeDetails         `protobuf:"bytes,1,rep,name=nodeList,proto3" json:"nodeList,omitempty"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NodeListResponse) Reset() {
-	*x = NodeListResponse{}
+func (x *PromoteLearnerResponse) Reset() {
+	*x = PromoteLearnerResponse{}
 	mi := &file_protos_registry_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NodeListResponse) String() string {
+func (x *PromoteLearnerResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodeListResponse) ProtoMessage() {}
+func (*PromoteLearnerResponse) ProtoMessage() {}
 
-func (x *NodeListResponse) ProtoReflect() protoreflect.Message {
+func (x *PromoteLearnerResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_registry_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -409,41 +577,45 @@ func (x *NodeListResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodeListResponse.ProtoReflect.Descriptor instead.
-func (*NodeListResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use PromoteLearnerResponse.ProtoReflect.Descriptor instead.
+func (*PromoteLearnerResponse) Descriptor() ([]byte, []int) {
 	return file_protos_registry_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *NodeListResponse) GetNodeList() []*NodeDetails {
+func (x *PromoteLearnerResponse) GetStatus() string {
 	if x != nil {
-		return x.NodeList
+		return x.Status
 	}
-	return nil
+	return ""
 }
 
-type NodeDetails struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	NodeIP          string                 `protobuf:"bytes,1,opt,name=nodeIP,proto3" json:"nodeIP,omitempty"`
-	NodeHostname    string                 `protobuf:"bytes,2,opt,name=nodeHostname,proto3" json:"nodeHostname,omitempty"`
-	NodeControlPort string                 `protobuf:"bytes,3,opt,name=nodeControlPort,proto3" json:"nodeControlPort,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *PromoteLearnerResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
 }
 
-func (x *NodeDetails) Reset() {
-	*x = NodeDetails{}
+type PrimaryNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrimaryNodeRequest) Reset() {
+	*x = PrimaryNodeRequest{}
 	mi := &file_protos_registry_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NodeDetails) String() string {
+func (x *PrimaryNodeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodeDetails) ProtoMessage() {}
+func (*PrimaryNodeRequest) ProtoMessage() {}
 
-func (x *NodeDetails) ProtoReflect() protoreflect.Message {
+func (x *PrimaryNodeRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_registry_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -455,28 +627,1055 @@ func (x *NodeDetails) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodeDetails.ProtoReflect.Descriptor instead.
-func (*NodeDetails) Descriptor() ([]byte, []int) {
+// Deprecated: Use PrimaryNodeRequest.ProtoReflect.Descriptor instead.
+func (*PrimaryNodeRequest) Descriptor() ([]byte, []int) {
 	return file_protos_registry_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *NodeDetails) GetNodeIP() string {
+type PrimaryNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	IpAddress     string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+	PortNumber    string                 `protobuf:"bytes,3,opt,name=portNumber,proto3" json:"portNumber,omitempty"`
+	DataPlanePort string                 `protobuf:"bytes,4,opt,name=dataPlanePort,proto3" json:"dataPlanePort,omitempty"`
+	NodeId        string                 `protobuf:"bytes,5,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrimaryNodeResponse) Reset() {
+	*x = PrimaryNodeResponse{}
+	mi := &file_protos_registry_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrimaryNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrimaryNodeResponse) ProtoMessage() {}
+
+func (x *PrimaryNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[9]
 	if x != nil {
-		return x.NodeIP
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *NodeDetails) GetNodeHostname() string {
+// Deprecated: Use PrimaryNodeResponse.ProtoReflect.Descriptor instead.
+func (*PrimaryNodeResponse) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PrimaryNodeResponse) GetHostname() string {
 	if x != nil {
-		return x.NodeHostname
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *PrimaryNodeResponse) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *PrimaryNodeResponse) GetPortNumber() string {
+	if x != nil {
+		return x.PortNumber
+	}
+	return ""
+}
+
+func (x *PrimaryNodeResponse) GetDataPlanePort() string {
+	if x != nil {
+		return x.DataPlanePort
+	}
+	return ""
+}
+
+func (x *PrimaryNodeResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type HeartBeatRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Hostname   string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	IpAddress  string                 `protobuf:"bytes,2,opt,name=ipAddress,proto3" json:"ipAddress,omitempty"`
+	PortNumber string                 `protobuf:"bytes,3,opt,name=portNumber,proto3" json:"portNumber,omitempty"`
+	RangeStats []*RangeStats          `protobuf:"bytes,4,rep,name=rangeStats,proto3" json:"rangeStats,omitempty"`
+	NodeId     string                 `protobuf:"bytes,5,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	// configVersion is the latest configuration the node applied
+	ConfigVersion int64 `protobuf:"varint,6,opt,name=configVersion,proto3" json:"configVersion,omitempty"`
+	// steppedDown is set by a primary that lost contact with a quorum
+	SteppedDown   bool              `protobuf:"varint,7,opt,name=steppedDown,proto3" json:"steppedDown,omitempty"`
+	AntiEntropy   *AntiEntropyStats `protobuf:"bytes,8,opt,name=antiEntropy,proto3" json:"antiEntropy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartBeatRequest) Reset() {
+	*x = HeartBeatRequest{}
+	mi := &file_protos_registry_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartBeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartBeatRequest) ProtoMessage() {}
+
+func (x *HeartBeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartBeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartBeatRequest) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *HeartBeatRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *HeartBeatRequest) GetIpAddress() string {
+	if x != nil {
+		return x.IpAddress
+	}
+	return ""
+}
+
+func (x *HeartBeatRequest) GetPortNumber() string {
+	if x != nil {
+		return x.PortNumber
+	}
+	return ""
+}
+
+func (x *HeartBeatRequest) GetRangeStats() []*RangeStats {
+	if x != nil {
+		return x.RangeStats
+	}
+	return nil
+}
+
+func (x *HeartBeatRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *HeartBeatRequest) GetConfigVersion() int64 {
+	if x != nil {
+		return x.ConfigVersion
+	}
+	return 0
+}
+
+func (x *HeartBeatRequest) GetSteppedDown() bool {
+	if x != nil {
+		return x.SteppedDown
+	}
+	return false
+}
+
+func (x *HeartBeatRequest) GetAntiEntropy() *AntiEntropyStats {
+	if x != nil {
+		return x.AntiEntropy
+	}
+	return nil
+}
+
+// AntiEntropyStats counts what the anti-entropy repairs run by a node found
+// since it started
+type AntiEntropyStats struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Comparisons     int64                  `protobuf:"varint,1,opt,name=comparisons,proto3" json:"comparisons,omitempty"`
+	DivergentRanges int64                  `protobuf:"varint,2,opt,name=divergentRanges,proto3" json:"divergentRanges,omitempty"`
+	RepairedKeys    int64                  `protobuf:"varint,3,opt,name=repairedKeys,proto3" json:"repairedKeys,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AntiEntropyStats) Reset() {
+	*x = AntiEntropyStats{}
+	mi := &file_protos_registry_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AntiEntropyStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AntiEntropyStats) ProtoMessage() {}
+
+func (x *AntiEntropyStats) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AntiEntropyStats.ProtoReflect.Descriptor instead.
+func (*AntiEntropyStats) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AntiEntropyStats) GetComparisons() int64 {
+	if x != nil {
+		return x.Comparisons
+	}
+	return 0
+}
+
+func (x *AntiEntropyStats) GetDivergentRanges() int64 {
+	if x != nil {
+		return x.DivergentRanges
+	}
+	return 0
+}
+
+func (x *AntiEntropyStats) GetRepairedKeys() int64 {
+	if x != nil {
+		return x.RepairedKeys
+	}
+	return 0
+}
+
+type HeartBeatResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Ranges        []*RangeDescriptor     `protobuf:"bytes,3,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	Draining      bool                   `protobuf:"varint,4,opt,name=draining,proto3" json:"draining,omitempty"`
+	PrimaryNodeId string                 `protobuf:"bytes,5,opt,name=primaryNodeId,proto3" json:"primaryNodeId,omitempty"`
+	Role          NodeRole               `protobuf:"varint,6,opt,name=role,proto3,enum=registry.NodeRole" json:"role,omitempty"`
+	Configuration *ClusterConfiguration  `protobuf:"bytes,7,opt,name=configuration,proto3" json:"configuration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartBeatResponse) Reset() {
+	*x = HeartBeatResponse{}
+	mi := &file_protos_registry_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartBeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartBeatResponse) ProtoMessage() {}
+
+func (x *HeartBeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartBeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartBeatResponse) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HeartBeatResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HeartBeatResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *HeartBeatResponse) GetRanges() []*RangeDescriptor {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+func (x *HeartBeatResponse) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *HeartBeatResponse) GetPrimaryNodeId() string {
+	if x != nil {
+		return x.PrimaryNodeId
+	}
+	return ""
+}
+
+func (x *HeartBeatResponse) GetRole() NodeRole {
+	if x != nil {
+		return x.Role
+	}
+	return NodeRole_VOTER
+}
+
+func (x *HeartBeatResponse) GetConfiguration() *ClusterConfiguration {
+	if x != nil {
+		return x.Configuration
+	}
+	return nil
+}
+
+type NodeListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeListRequest) Reset() {
+	*x = NodeListRequest{}
+	mi := &file_protos_registry_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeListRequest) ProtoMessage() {}
+
+func (x *NodeListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeListRequest.ProtoReflect.Descriptor instead.
+func (*NodeListRequest) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{13}
+}
+
+type NodeListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeList      []*NodeDetails         `protobuf:"bytes,1,rep,name=nodeList,proto3" json:"nodeList,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeListResponse) Reset() {
+	*x = NodeListResponse{}
+	mi := &file_protos_registry_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeListResponse) ProtoMessage() {}
+
+func (x *NodeListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeListResponse.ProtoReflect.Descriptor instead.
+func (*NodeListResponse) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *NodeListResponse) GetNodeList() []*NodeDetails {
+	if x != nil {
+		return x.NodeList
+	}
+	return nil
+}
+
+type NodeDetails struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	NodeIP          string                 `protobuf:"bytes,1,opt,name=nodeIP,proto3" json:"nodeIP,omitempty"`
+	NodeHostname    string                 `protobuf:"bytes,2,opt,name=nodeHostname,proto3" json:"nodeHostname,omitempty"`
+	NodeControlPort string                 `protobuf:"bytes,3,opt,name=nodeControlPort,proto3" json:"nodeControlPort,omitempty"`
+	NodeDataPort    string                 `protobuf:"bytes,4,opt,name=nodeDataPort,proto3" json:"nodeDataPort,omitempty"`
+	Draining        bool                   `protobuf:"varint,5,opt,name=draining,proto3" json:"draining,omitempty"`
+	NodeId          string                 `protobuf:"bytes,6,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	// healthy, suspect or dead as graded by the registry's failure detector
+	Health        string            `protobuf:"bytes,7,opt,name=health,proto3" json:"health,omitempty"`
+	Role          NodeRole          `protobuf:"varint,8,opt,name=role,proto3,enum=registry.NodeRole" json:"role,omitempty"`
+	AntiEntropy   *AntiEntropyStats `protobuf:"bytes,9,opt,name=antiEntropy,proto3" json:"antiEntropy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeDetails) Reset() {
+	*x = NodeDetails{}
+	mi := &file_protos_registry_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeDetails) ProtoMessage() {}
+
+func (x *NodeDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeDetails.ProtoReflect.Descriptor instead.
+func (*NodeDetails) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *NodeDetails) GetNodeIP() string {
+	if x != nil {
+		return x.NodeIP
+	}
+	return ""
+}
+
+func (x *NodeDetails) GetNodeHostname() string {
+	if x != nil {
+		return x.NodeHostname
 	}
 	return ""
 }
 
 func (x *NodeDetails) GetNodeControlPort() string {
 	if x != nil {
-		return x.NodeControlPort
+		return x.NodeControlPort
+	}
+	return ""
+}
+
+func (x *NodeDetails) GetNodeDataPort() string {
+	if x != nil {
+		return x.NodeDataPort
+	}
+	return ""
+}
+
+func (x *NodeDetails) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *NodeDetails) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *NodeDetails) GetHealth() string {
+	if x != nil {
+		return x.Health
+	}
+	return ""
+}
+
+func (x *NodeDetails) GetRole() NodeRole {
+	if x != nil {
+		return x.Role
+	}
+	return NodeRole_VOTER
+}
+
+func (x *NodeDetails) GetAntiEntropy() *AntiEntropyStats {
+	if x != nil {
+		return x.AntiEntropy
+	}
+	return nil
+}
+
+// A range covers the keys in [startKey, endKey). An empty endKey means the
+// range is unbounded. The generation is bumped on every split or merge so
+// stale stats and routing entries can be detected. ownerNodeId coordinates the
+// writes to the range and replicaNodeIds are the nodes holding a copy of it,
+// an empty owner leaves the range to the primary.
+type RangeDescriptor struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RangeId        int64                  `protobuf:"varint,1,opt,name=rangeId,proto3" json:"rangeId,omitempty"`
+	StartKey       string                 `protobuf:"bytes,2,opt,name=startKey,proto3" json:"startKey,omitempty"`
+	EndKey         string                 `protobuf:"bytes,3,opt,name=endKey,proto3" json:"endKey,omitempty"`
+	Generation     int64                  `protobuf:"varint,4,opt,name=generation,proto3" json:"generation,omitempty"`
+	OwnerNodeId    string                 `protobuf:"bytes,5,opt,name=ownerNodeId,proto3" json:"ownerNodeId,omitempty"`
+	ReplicaNodeIds []string               `protobuf:"bytes,6,rep,name=replicaNodeIds,proto3" json:"replicaNodeIds,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RangeDescriptor) Reset() {
+	*x = RangeDescriptor{}
+	mi := &file_protos_registry_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeDescriptor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeDescriptor) ProtoMessage() {}
+
+func (x *RangeDescriptor) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeDescriptor.ProtoReflect.Descriptor instead.
+func (*RangeDescriptor) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RangeDescriptor) GetRangeId() int64 {
+	if x != nil {
+		return x.RangeId
+	}
+	return 0
+}
+
+func (x *RangeDescriptor) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *RangeDescriptor) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+func (x *RangeDescriptor) GetGeneration() int64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+func (x *RangeDescriptor) GetOwnerNodeId() string {
+	if x != nil {
+		return x.OwnerNodeId
+	}
+	return ""
+}
+
+func (x *RangeDescriptor) GetReplicaNodeIds() []string {
+	if x != nil {
+		return x.ReplicaNodeIds
+	}
+	return nil
+}
+
+type RangeStats struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	RangeId          int64                  `protobuf:"varint,1,opt,name=rangeId,proto3" json:"rangeId,omitempty"`
+	Generation       int64                  `protobuf:"varint,2,opt,name=generation,proto3" json:"generation,omitempty"`
+	KeyCount         int64                  `protobuf:"varint,3,opt,name=keyCount,proto3" json:"keyCount,omitempty"`
+	SizeBytes        int64                  `protobuf:"varint,4,opt,name=sizeBytes,proto3" json:"sizeBytes,omitempty"`
+	QueriesPerSecond float64                `protobuf:"fixed64,5,opt,name=queriesPerSecond,proto3" json:"queriesPerSecond,omitempty"`
+	SplitKey         string                 `protobuf:"bytes,6,opt,name=splitKey,proto3" json:"splitKey,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *RangeStats) Reset() {
+	*x = RangeStats{}
+	mi := &file_protos_registry_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeStats) ProtoMessage() {}
+
+func (x *RangeStats) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeStats.ProtoReflect.Descriptor instead.
+func (*RangeStats) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RangeStats) GetRangeId() int64 {
+	if x != nil {
+		return x.RangeId
+	}
+	return 0
+}
+
+func (x *RangeStats) GetGeneration() int64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+func (x *RangeStats) GetKeyCount() int64 {
+	if x != nil {
+		return x.KeyCount
+	}
+	return 0
+}
+
+func (x *RangeStats) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *RangeStats) GetQueriesPerSecond() float64 {
+	if x != nil {
+		return x.QueriesPerSecond
+	}
+	return 0
+}
+
+func (x *RangeStats) GetSplitKey() string {
+	if x != nil {
+		return x.SplitKey
+	}
+	return ""
+}
+
+type RangeDescriptorsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeDescriptorsRequest) Reset() {
+	*x = RangeDescriptorsRequest{}
+	mi := &file_protos_registry_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeDescriptorsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeDescriptorsRequest) ProtoMessage() {}
+
+func (x *RangeDescriptorsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeDescriptorsRequest.ProtoReflect.Descriptor instead.
+func (*RangeDescriptorsRequest) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{18}
+}
+
+type RangeDescriptorsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ranges        []*RangeDescriptor     `protobuf:"bytes,1,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeDescriptorsResponse) Reset() {
+	*x = RangeDescriptorsResponse{}
+	mi := &file_protos_registry_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeDescriptorsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeDescriptorsResponse) ProtoMessage() {}
+
+func (x *RangeDescriptorsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeDescriptorsResponse.ProtoReflect.Descriptor instead.
+func (*RangeDescriptorsResponse) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RangeDescriptorsResponse) GetRanges() []*RangeDescriptor {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+// ClusterConfiguration lists the voters by node id. While a change is in
+// progress the configuration is joint, newVoters holds the voters being moved
+// to and every quorum needs a majority of voters and of newVoters.
+type ClusterConfiguration struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Voters        []string               `protobuf:"bytes,2,rep,name=voters,proto3" json:"voters,omitempty"`
+	NewVoters     []string               `protobuf:"bytes,3,rep,name=newVoters,proto3" json:"newVoters,omitempty"`
+	Joint         bool                   `protobuf:"varint,4,opt,name=joint,proto3" json:"joint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterConfiguration) Reset() {
+	*x = ClusterConfiguration{}
+	mi := &file_protos_registry_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterConfiguration) ProtoMessage() {}
+
+func (x *ClusterConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterConfiguration.ProtoReflect.Descriptor instead.
+func (*ClusterConfiguration) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ClusterConfiguration) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ClusterConfiguration) GetVoters() []string {
+	if x != nil {
+		return x.Voters
+	}
+	return nil
+}
+
+func (x *ClusterConfiguration) GetNewVoters() []string {
+	if x != nil {
+		return x.NewVoters
+	}
+	return nil
+}
+
+func (x *ClusterConfiguration) GetJoint() bool {
+	if x != nil {
+		return x.Joint
+	}
+	return false
+}
+
+type ClusterConfigurationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterConfigurationRequest) Reset() {
+	*x = ClusterConfigurationRequest{}
+	mi := &file_protos_registry_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterConfigurationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterConfigurationRequest) ProtoMessage() {}
+
+func (x *ClusterConfigurationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterConfigurationRequest.ProtoReflect.Descriptor instead.
+func (*ClusterConfigurationRequest) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{21}
+}
+
+// acknowledged lists the nodes that applied the current version
+type ClusterConfigurationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Configuration *ClusterConfiguration  `protobuf:"bytes,1,opt,name=configuration,proto3" json:"configuration,omitempty"`
+	Acknowledged  []string               `protobuf:"bytes,2,rep,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterConfigurationResponse) Reset() {
+	*x = ClusterConfigurationResponse{}
+	mi := &file_protos_registry_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterConfigurationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterConfigurationResponse) ProtoMessage() {}
+
+func (x *ClusterConfigurationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterConfigurationResponse.ProtoReflect.Descriptor instead.
+func (*ClusterConfigurationResponse) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ClusterConfigurationResponse) GetConfiguration() *ClusterConfiguration {
+	if x != nil {
+		return x.Configuration
+	}
+	return nil
+}
+
+func (x *ClusterConfigurationResponse) GetAcknowledged() []string {
+	if x != nil {
+		return x.Acknowledged
+	}
+	return nil
+}
+
+// TransferPrimary makes toNodeId the primary, provided fromNodeId still is.
+// The target of a leadership transfer sends it once the primary stepped down.
+type TransferPrimaryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromNodeId    string                 `protobuf:"bytes,1,opt,name=fromNodeId,proto3" json:"fromNodeId,omitempty"`
+	ToNodeId      string                 `protobuf:"bytes,2,opt,name=toNodeId,proto3" json:"toNodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferPrimaryRequest) Reset() {
+	*x = TransferPrimaryRequest{}
+	mi := &file_protos_registry_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferPrimaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferPrimaryRequest) ProtoMessage() {}
+
+func (x *TransferPrimaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferPrimaryRequest.ProtoReflect.Descriptor instead.
+func (*TransferPrimaryRequest) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *TransferPrimaryRequest) GetFromNodeId() string {
+	if x != nil {
+		return x.FromNodeId
+	}
+	return ""
+}
+
+func (x *TransferPrimaryRequest) GetToNodeId() string {
+	if x != nil {
+		return x.ToNodeId
+	}
+	return ""
+}
+
+type TransferPrimaryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	PrimaryNodeId string                 `protobuf:"bytes,3,opt,name=primaryNodeId,proto3" json:"primaryNodeId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferPrimaryResponse) Reset() {
+	*x = TransferPrimaryResponse{}
+	mi := &file_protos_registry_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferPrimaryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferPrimaryResponse) ProtoMessage() {}
+
+func (x *TransferPrimaryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_registry_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferPrimaryResponse.ProtoReflect.Descriptor instead.
+func (*TransferPrimaryResponse) Descriptor() ([]byte, []int) {
+	return file_protos_registry_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *TransferPrimaryResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *TransferPrimaryResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TransferPrimaryResponse) GetPrimaryNodeId() string {
+	if x != nil {
+		return x.PrimaryNodeId
 	}
 	return ""
 }
@@ -485,44 +1684,153 @@ var File_protos_registry_proto protoreflect.FileDescriptor
 
 const file_protos_registry_proto_rawDesc = "" +
 	"\n" +
-	"\x15protos/registry.proto\x12\bregistry\"o\n" +
+	"\x15protos/registry.proto\x12\bregistry\"\xd5\x01\n" +
 	"\x13RegisterNodeRequest\x12\x1a\n" +
 	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
 	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12\x1e\n" +
 	"\n" +
 	"portNumber\x18\x03 \x01(\tR\n" +
-	"portNumber\"H\n" +
+	"portNumber\x12$\n" +
+	"\rdataPlanePort\x18\x04 \x01(\tR\rdataPlanePort\x12\x16\n" +
+	"\x06nodeId\x18\x05 \x01(\tR\x06nodeId\x12&\n" +
+	"\x04role\x18\x06 \x01(\x0e2\x12.registry.NodeRoleR\x04role\"\xdc\x01\n" +
 	"\x14RegisterNodeResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12$\n" +
+	"\rprimaryNodeId\x18\x03 \x01(\tR\rprimaryNodeId\x12&\n" +
+	"\x04role\x18\x04 \x01(\x0e2\x12.registry.NodeRoleR\x04role\x12D\n" +
+	"\rconfiguration\x18\x05 \x01(\v2\x1e.registry.ClusterConfigurationR\rconfiguration\"\x89\x01\n" +
+	"\x15DeregisterNodeRequest\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
+	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12\x1e\n" +
+	"\n" +
+	"portNumber\x18\x03 \x01(\tR\n" +
+	"portNumber\x12\x16\n" +
+	"\x06nodeId\x18\x04 \x01(\tR\x06nodeId\"J\n" +
+	"\x16DeregisterNodeResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x84\x01\n" +
+	"\x10DrainNodeRequest\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
+	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12\x1e\n" +
+	"\n" +
+	"portNumber\x18\x03 \x01(\tR\n" +
+	"portNumber\x12\x16\n" +
+	"\x06nodeId\x18\x04 \x01(\tR\x06nodeId\"E\n" +
+	"\x11DrainNodeResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x89\x01\n" +
+	"\x15PromoteLearnerRequest\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
+	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12\x1e\n" +
+	"\n" +
+	"portNumber\x18\x03 \x01(\tR\n" +
+	"portNumber\x12\x16\n" +
+	"\x06nodeId\x18\x04 \x01(\tR\x06nodeId\"J\n" +
+	"\x16PromoteLearnerResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\"\x14\n" +
-	"\x12PrimaryNodeRequest\"o\n" +
+	"\x12PrimaryNodeRequest\"\xad\x01\n" +
 	"\x13PrimaryNodeResponse\x12\x1a\n" +
 	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
 	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12\x1e\n" +
 	"\n" +
 	"portNumber\x18\x03 \x01(\tR\n" +
-	"portNumber\"l\n" +
+	"portNumber\x12$\n" +
+	"\rdataPlanePort\x18\x04 \x01(\tR\rdataPlanePort\x12\x16\n" +
+	"\x06nodeId\x18\x05 \x01(\tR\x06nodeId\"\xc0\x02\n" +
 	"\x10HeartBeatRequest\x12\x1a\n" +
 	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x1c\n" +
 	"\tipAddress\x18\x02 \x01(\tR\tipAddress\x12\x1e\n" +
 	"\n" +
 	"portNumber\x18\x03 \x01(\tR\n" +
-	"portNumber\"E\n" +
+	"portNumber\x124\n" +
+	"\n" +
+	"rangeStats\x18\x04 \x03(\v2\x14.registry.RangeStatsR\n" +
+	"rangeStats\x12\x16\n" +
+	"\x06nodeId\x18\x05 \x01(\tR\x06nodeId\x12$\n" +
+	"\rconfigVersion\x18\x06 \x01(\x03R\rconfigVersion\x12 \n" +
+	"\vsteppedDown\x18\a \x01(\bR\vsteppedDown\x12<\n" +
+	"\vantiEntropy\x18\b \x01(\v2\x1a.registry.AntiEntropyStatsR\vantiEntropy\"\x82\x01\n" +
+	"\x10AntiEntropyStats\x12 \n" +
+	"\vcomparisons\x18\x01 \x01(\x03R\vcomparisons\x12(\n" +
+	"\x0fdivergentRanges\x18\x02 \x01(\x03R\x0fdivergentRanges\x12\"\n" +
+	"\frepairedKeys\x18\x03 \x01(\x03R\frepairedKeys\"\xa8\x02\n" +
 	"\x11HeartBeatResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\x11\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x121\n" +
+	"\x06ranges\x18\x03 \x03(\v2\x19.registry.RangeDescriptorR\x06ranges\x12\x1a\n" +
+	"\bdraining\x18\x04 \x01(\bR\bdraining\x12$\n" +
+	"\rprimaryNodeId\x18\x05 \x01(\tR\rprimaryNodeId\x12&\n" +
+	"\x04role\x18\x06 \x01(\x0e2\x12.registry.NodeRoleR\x04role\x12D\n" +
+	"\rconfiguration\x18\a \x01(\v2\x1e.registry.ClusterConfigurationR\rconfiguration\"\x11\n" +
 	"\x0fNodeListRequest\"E\n" +
 	"\x10NodeListResponse\x121\n" +
-	"\bnodeList\x18\x01 \x03(\v2\x15.registry.NodeDetailsR\bnodeList\"s\n" +
+	"\bnodeList\x18\x01 \x03(\v2\x15.registry.NodeDetailsR\bnodeList\"\xc9\x02\n" +
 	"\vNodeDetails\x12\x16\n" +
 	"\x06nodeIP\x18\x01 \x01(\tR\x06nodeIP\x12\"\n" +
 	"\fnodeHostname\x18\x02 \x01(\tR\fnodeHostname\x12(\n" +
-	"\x0fnodeControlPort\x18\x03 \x01(\tR\x0fnodeControlPort2\xbf\x02\n" +
+	"\x0fnodeControlPort\x18\x03 \x01(\tR\x0fnodeControlPort\x12\"\n" +
+	"\fnodeDataPort\x18\x04 \x01(\tR\fnodeDataPort\x12\x1a\n" +
+	"\bdraining\x18\x05 \x01(\bR\bdraining\x12\x16\n" +
+	"\x06nodeId\x18\x06 \x01(\tR\x06nodeId\x12\x16\n" +
+	"\x06health\x18\a \x01(\tR\x06health\x12&\n" +
+	"\x04role\x18\b \x01(\x0e2\x12.registry.NodeRoleR\x04role\x12<\n" +
+	"\vantiEntropy\x18\t \x01(\v2\x1a.registry.AntiEntropyStatsR\vantiEntropy\"\xc9\x01\n" +
+	"\x0fRangeDescriptor\x12\x18\n" +
+	"\arangeId\x18\x01 \x01(\x03R\arangeId\x12\x1a\n" +
+	"\bstartKey\x18\x02 \x01(\tR\bstartKey\x12\x16\n" +
+	"\x06endKey\x18\x03 \x01(\tR\x06endKey\x12\x1e\n" +
+	"\n" +
+	"generation\x18\x04 \x01(\x03R\n" +
+	"generation\x12 \n" +
+	"\vownerNodeId\x18\x05 \x01(\tR\vownerNodeId\x12&\n" +
+	"\x0ereplicaNodeIds\x18\x06 \x03(\tR\x0ereplicaNodeIds\"\xc8\x01\n" +
+	"\n" +
+	"RangeStats\x12\x18\n" +
+	"\arangeId\x18\x01 \x01(\x03R\arangeId\x12\x1e\n" +
+	"\n" +
+	"generation\x18\x02 \x01(\x03R\n" +
+	"generation\x12\x1a\n" +
+	"\bkeyCount\x18\x03 \x01(\x03R\bkeyCount\x12\x1c\n" +
+	"\tsizeBytes\x18\x04 \x01(\x03R\tsizeBytes\x12*\n" +
+	"\x10queriesPerSecond\x18\x05 \x01(\x01R\x10queriesPerSecond\x12\x1a\n" +
+	"\bsplitKey\x18\x06 \x01(\tR\bsplitKey\"\x19\n" +
+	"\x17RangeDescriptorsRequest\"M\n" +
+	"\x18RangeDescriptorsResponse\x121\n" +
+	"\x06ranges\x18\x01 \x03(\v2\x19.registry.RangeDescriptorR\x06ranges\"|\n" +
+	"\x14ClusterConfiguration\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12\x16\n" +
+	"\x06voters\x18\x02 \x03(\tR\x06voters\x12\x1c\n" +
+	"\tnewVoters\x18\x03 \x03(\tR\tnewVoters\x12\x14\n" +
+	"\x05joint\x18\x04 \x01(\bR\x05joint\"\x1d\n" +
+	"\x1bClusterConfigurationRequest\"\x88\x01\n" +
+	"\x1cClusterConfigurationResponse\x12D\n" +
+	"\rconfiguration\x18\x01 \x01(\v2\x1e.registry.ClusterConfigurationR\rconfiguration\x12\"\n" +
+	"\facknowledged\x18\x02 \x03(\tR\facknowledged\"T\n" +
+	"\x16TransferPrimaryRequest\x12\x1e\n" +
+	"\n" +
+	"fromNodeId\x18\x01 \x01(\tR\n" +
+	"fromNodeId\x12\x1a\n" +
+	"\btoNodeId\x18\x02 \x01(\tR\btoNodeId\"q\n" +
+	"\x17TransferPrimaryResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12$\n" +
+	"\rprimaryNodeId\x18\x03 \x01(\tR\rprimaryNodeId*\"\n" +
+	"\bNodeRole\x12\t\n" +
+	"\x05VOTER\x10\x00\x12\v\n" +
+	"\aLEARNER\x10\x012\xcf\x06\n" +
 	"\x0fRegistryService\x12M\n" +
 	"\fRegisterNode\x12\x1d.registry.RegisterNodeRequest\x1a\x1e.registry.RegisterNodeResponse\x12M\n" +
 	"\x0eGetPrimaryNode\x12\x1c.registry.PrimaryNodeRequest\x1a\x1d.registry.PrimaryNodeResponse\x12H\n" +
 	"\rNodeHeartBeat\x12\x1a.registry.HeartBeatRequest\x1a\x1b.registry.HeartBeatResponse\x12D\n" +
-	"\vGetNodeList\x12\x19.registry.NodeListRequest\x1a\x1a.registry.NodeListResponseB)Z'github.com/Vahsek/distrokv/pkg/registryb\x06proto3"
+	"\vGetNodeList\x12\x19.registry.NodeListRequest\x1a\x1a.registry.NodeListResponse\x12\\\n" +
+	"\x13GetRangeDescriptors\x12!.registry.RangeDescriptorsRequest\x1a\".registry.RangeDescriptorsResponse\x12S\n" +
+	"\x0eDeregisterNode\x12\x1f.registry.DeregisterNodeRequest\x1a .registry.DeregisterNodeResponse\x12D\n" +
+	"\tDrainNode\x12\x1a.registry.DrainNodeRequest\x1a\x1b.registry.DrainNodeResponse\x12S\n" +
+	"\x0ePromoteLearner\x12\x1f.registry.PromoteLearnerRequest\x1a .registry.PromoteLearnerResponse\x12h\n" +
+	"\x17GetClusterConfiguration\x12%.registry.ClusterConfigurationRequest\x1a&.registry.ClusterConfigurationResponse\x12V\n" +
+	"\x0fTransferPrimary\x12 .registry.TransferPrimaryRequest\x1a!.registry.TransferPrimaryResponseB)Z'github.com/Vahsek/distrokv/pkg/registryb\x06proto3"
 
 var (
 	file_protos_registry_proto_rawDescOnce sync.Once
@@ -536,33 +1844,75 @@ func file_protos_registry_proto_rawDescGZIP() []byte {
 	return file_protos_registry_proto_rawDescData
 }
 
-var file_protos_registry_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_protos_registry_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_protos_registry_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
 var file_protos_registry_proto_goTypes = []any{
-	(*RegisterNodeRequest)(nil),  // 0: registry.RegisterNodeRequest
-	(*RegisterNodeResponse)(nil), // 1: registry.RegisterNodeResponse
-	(*PrimaryNodeRequest)(nil),   // 2: registry.PrimaryNodeRequest
-	(*PrimaryNodeResponse)(nil),  // 3: registry.PrimaryNodeResponse
-	(*HeartBeatRequest)(nil),     // 4: registry.HeartBeatRequest
-	(*HeartBeatResponse)(nil),    // 5: registry.HeartBeatResponse
-	(*NodeListRequest)(nil),      // 6: registry.NodeListRequest
-	(*NodeListResponse)(nil),     // 7: registry.NodeListResponse
-	(*NodeDetails)(nil),          // 8: registry.NodeDetails
+	(NodeRole)(0),                        // 0: registry.NodeRole
+	(*RegisterNodeRequest)(nil),          // 1: registry.RegisterNodeRequest
+	(*RegisterNodeResponse)(nil),         // 2: registry.RegisterNodeResponse
+	(*DeregisterNodeRequest)(nil),        // 3: registry.DeregisterNodeRequest
+	(*DeregisterNodeResponse)(nil),       // 4: registry.DeregisterNodeResponse
+	(*DrainNodeRequest)(nil),             // 5: registry.DrainNodeRequest
+	(*DrainNodeResponse)(nil),            // 6: registry.DrainNodeResponse
+	(*PromoteLearnerRequest)(nil),        // 7: registry.PromoteLearnerRequest
+	(*PromoteLearnerResponse)(nil),       // 8: registry.PromoteLearnerResponse
+	(*PrimaryNodeRequest)(nil),           // 9: registry.PrimaryNodeRequest
+	(*PrimaryNodeResponse)(nil),          // 10: registry.PrimaryNodeResponse
+	(*HeartBeatRequest)(nil),             // 11: registry.HeartBeatRequest
+	(*AntiEntropyStats)(nil),             // 12: registry.AntiEntropyStats
+	(*HeartBeatResponse)(nil),            // 13: registry.HeartBeatResponse
+	(*NodeListRequest)(nil),              // 14: registry.NodeListRequest
+	(*NodeListResponse)(nil),             // 15: registry.NodeListResponse
+	(*NodeDetails)(nil),                  // 16: registry.NodeDetails
+	(*RangeDescriptor)(nil),              // 17: registry.RangeDescriptor
+	(*RangeStats)(nil),                   // 18: registry.RangeStats
+	(*RangeDescriptorsRequest)(nil),      // 19: registry.RangeDescriptorsRequest
+	(*RangeDescriptorsResponse)(nil),     // 20: registry.RangeDescriptorsResponse
+	(*ClusterConfiguration)(nil),         // 21: registry.ClusterConfiguration
+	(*ClusterConfigurationRequest)(nil),  // 22: registry.ClusterConfigurationRequest
+	(*ClusterConfigurationResponse)(nil), // 23: registry.ClusterConfigurationResponse
+	(*TransferPrimaryRequest)(nil),       // 24: registry.TransferPrimaryRequest
+	(*TransferPrimaryResponse)(nil),      // 25: registry.TransferPrimaryResponse
 }
 var file_protos_registry_proto_depIdxs = []int32{
-	8, // 0: registry.NodeListResponse.nodeList:type_name -> registry.NodeDetails
-	0, // 1: registry.RegistryService.RegisterNode:input_type -> registry.RegisterNodeRequest
-	2, // 2: registry.RegistryService.GetPrimaryNode:input_type -> registry.PrimaryNodeRequest
-	4, // 3: registry.RegistryService.NodeHeartBeat:input_type -> registry.HeartBeatRequest
-	6, // 4: registry.RegistryService.GetNodeList:input_type -> registry.NodeListRequest
-	1, // 5: registry.RegistryService.RegisterNode:output_type -> registry.RegisterNodeResponse
-	3, // 6: registry.RegistryService.GetPrimaryNode:output_type -> registry.PrimaryNodeResponse
-	5, // 7: registry.RegistryService.NodeHeartBeat:output_type -> registry.HeartBeatResponse
-	7, // 8: registry.RegistryService.GetNodeList:output_type -> registry.NodeListResponse
-	5, // [5:9] is the sub-list for method output_type
-	1, // [1:5] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: registry.RegisterNodeRequest.role:type_name -> registry.NodeRole
+	0,  // 1: registry.RegisterNodeResponse.role:type_name -> registry.NodeRole
+	21, // 2: registry.RegisterNodeResponse.configuration:type_name -> registry.ClusterConfiguration
+	18, // 3: registry.HeartBeatRequest.rangeStats:type_name -> registry.RangeStats
+	12, // 4: registry.HeartBeatRequest.antiEntropy:type_name -> registry.AntiEntropyStats
+	17, // 5: registry.HeartBeatResponse.ranges:type_name -> registry.RangeDescriptor
+	0,  // 6: registry.HeartBeatResponse.role:type_name -> registry.NodeRole
+	21, // 7: registry.HeartBeatResponse.configuration:type_name -> registry.ClusterConfiguration
+	16, // 8: registry.NodeListResponse.nodeList:type_name -> registry.NodeDetails
+	0,  // 9: registry.NodeDetails.role:type_name -> registry.NodeRole
+	12, // 10: registry.NodeDetails.antiEntropy:type_name -> registry.AntiEntropyStats
+	17, // 11: registry.RangeDescriptorsResponse.ranges:type_name -> registry.RangeDescriptor
+	21, // 12: registry.ClusterConfigurationResponse.configuration:type_name -> registry.ClusterConfiguration
+	1,  // 13: registry.RegistryService.RegisterNode:input_type -> registry.RegisterNodeRequest
+	9,  // 14: registry.RegistryService.GetPrimaryNode:input_type -> registry.PrimaryNodeRequest
+	11, // 15: registry.RegistryService.NodeHeartBeat:input_type -> registry.HeartBeatRequest
+	14, // 16: registry.RegistryService.GetNodeList:input_type -> registry.NodeListRequest
+	19, // 17: registry.RegistryService.GetRangeDescriptors:input_type -> registry.RangeDescriptorsRequest
+	3,  // 18: registry.RegistryService.DeregisterNode:input_type -> registry.DeregisterNodeRequest
+	5,  // 19: registry.RegistryService.DrainNode:input_type -> registry.DrainNodeRequest
+	7,  // 20: registry.RegistryService.PromoteLearner:input_type -> registry.PromoteLearnerRequest
+	22, // 21: registry.RegistryService.GetClusterConfiguration:input_type -> registry.ClusterConfigurationRequest
+	24, // 22: registry.RegistryService.TransferPrimary:input_type -> registry.TransferPrimaryRequest
+	2,  // 23: registry.RegistryService.RegisterNode:output_type -> registry.RegisterNodeResponse
+	10, // 24: registry.RegistryService.GetPrimaryNode:output_type -> registry.PrimaryNodeResponse
+	13, // 25: registry.RegistryService.NodeHeartBeat:output_type -> registry.HeartBeatResponse
+	15, // 26: registry.RegistryService.GetNodeList:output_type -> registry.NodeListResponse
+	20, // 27: registry.RegistryService.GetRangeDescriptors:output_type -> registry.RangeDescriptorsResponse
+	4,  // 28: registry.RegistryService.DeregisterNode:output_type -> registry.DeregisterNodeResponse
+	6,  // 29: registry.RegistryService.DrainNode:output_type -> registry.DrainNodeResponse
+	8,  // 30: registry.RegistryService.PromoteLearner:output_type -> registry.PromoteLearnerResponse
+	23, // 31: registry.RegistryService.GetClusterConfiguration:output_type -> registry.ClusterConfigurationResponse
+	25, // 32: registry.RegistryService.TransferPrimary:output_type -> registry.TransferPrimaryResponse
+	23, // [23:33] is the sub-list for method output_type
+	13, // [13:23] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_protos_registry_proto_init() }
@@ -575,13 +1925,14 @@ func file_protos_registry_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_protos_registry_proto_rawDesc), len(file_protos_registry_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   9,
+			NumEnums:      1,
+			NumMessages:   25,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_protos_registry_proto_goTypes,
 		DependencyIndexes: file_protos_registry_proto_depIdxs,
+		EnumInfos:         file_protos_registry_proto_enumTypes,
 		MessageInfos:      file_protos_registry_proto_msgTypes,
 	}.Build()
 	File_protos_registry_proto = out.File
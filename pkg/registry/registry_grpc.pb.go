@@ -20,10 +20,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	RegistryService_RegisterNode_FullMethodName   = "/registry.RegistryService/RegisterNode"
-	RegistryService_GetPrimaryNode_FullMethodName = "/registry.RegistryService/GetPrimaryNode"
-	RegistryService_NodeHeartBeat_FullMethodName  = "/registry.RegistryService/NodeHeartBeat"
-	RegistryService_GetNodeList_FullMethodName    = "/registry.RegistryService/GetNodeList"
+	RegistryService_RegisterNode_FullMethodName            = "/registry.RegistryService/RegisterNode"
+	RegistryService_GetPrimaryNode_FullMethodName          = "/registry.RegistryService/GetPrimaryNode"
+	RegistryService_NodeHeartBeat_FullMethodName           = "/registry.RegistryService/NodeHeartBeat"
+	RegistryService_GetNodeList_FullMethodName             = "/registry.RegistryService/GetNodeList"
+	RegistryService_GetRangeDescriptors_FullMethodName     = "/registry.RegistryService/GetRangeDescriptors"
+	RegistryService_DeregisterNode_FullMethodName          = "/registry.RegistryService/DeregisterNode"
+	RegistryService_DrainNode_FullMethodName               = "/registry.RegistryService/DrainNode"
+	RegistryService_PromoteLearner_FullMethodName          = "/registry.RegistryService/PromoteLearner"
+	RegistryService_GetClusterConfiguration_FullMethodName = "/registry.RegistryService/GetClusterConfiguration"
+	RegistryService_TransferPrimary_FullMethodName         = "/registry.RegistryService/TransferPrimary"
 )
 
 // RegistryServiceClient is the client API for RegistryService service.
@@ -34,6 +40,12 @@ type RegistryServiceClient interface {
 	GetPrimaryNode(ctx context.Context, in *PrimaryNodeRequest, opts ...grpc.CallOption) (*PrimaryNodeResponse, error)
 	NodeHeartBeat(ctx context.Context, in *HeartBeatRequest, opts ...grpc.CallOption) (*HeartBeatResponse, error)
 	GetNodeList(ctx context.Context, in *NodeListRequest, opts ...grpc.CallOption) (*NodeListResponse, error)
+	GetRangeDescriptors(ctx context.Context, in *RangeDescriptorsRequest, opts ...grpc.CallOption) (*RangeDescriptorsResponse, error)
+	DeregisterNode(ctx context.Context, in *DeregisterNodeRequest, opts ...grpc.CallOption) (*DeregisterNodeResponse, error)
+	DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error)
+	PromoteLearner(ctx context.Context, in *PromoteLearnerRequest, opts ...grpc.CallOption) (*PromoteLearnerResponse, error)
+	GetClusterConfiguration(ctx context.Context, in *ClusterConfigurationRequest, opts ...grpc.CallOption) (*ClusterConfigurationResponse, error)
+	TransferPrimary(ctx context.Context, in *TransferPrimaryRequest, opts ...grpc.CallOption) (*TransferPrimaryResponse, error)
 }
 
 type registryServiceClient struct {
@@ -84,6 +96,66 @@ func (c *registryServiceClient) GetNodeList(ctx context.Context, in *NodeListReq
 	return out, nil
 }
 
+func (c *registryServiceClient) GetRangeDescriptors(ctx context.Context, in *RangeDescriptorsRequest, opts ...grpc.CallOption) (*RangeDescriptorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RangeDescriptorsResponse)
+	err := c.cc.Invoke(ctx, RegistryService_GetRangeDescriptors_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryServiceClient) DeregisterNode(ctx context.Context, in *DeregisterNodeRequest, opts ...grpc.CallOption) (*DeregisterNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeregisterNodeResponse)
+	err := c.cc.Invoke(ctx, RegistryService_DeregisterNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryServiceClient) DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainNodeResponse)
+	err := c.cc.Invoke(ctx, RegistryService_DrainNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryServiceClient) PromoteLearner(ctx context.Context, in *PromoteLearnerRequest, opts ...grpc.CallOption) (*PromoteLearnerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PromoteLearnerResponse)
+	err := c.cc.Invoke(ctx, RegistryService_PromoteLearner_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryServiceClient) GetClusterConfiguration(ctx context.Context, in *ClusterConfigurationRequest, opts ...grpc.CallOption) (*ClusterConfigurationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterConfigurationResponse)
+	err := c.cc.Invoke(ctx, RegistryService_GetClusterConfiguration_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registryServiceClient) TransferPrimary(ctx context.Context, in *TransferPrimaryRequest, opts ...grpc.CallOption) (*TransferPrimaryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferPrimaryResponse)
+	err := c.cc.Invoke(ctx, RegistryService_TransferPrimary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RegistryServiceServer is the server API for RegistryService service.
 // All implementations must embed UnimplementedRegistryServiceServer
 // for forward compatibility.
@@ -92,6 +164,12 @@ type RegistryServiceServer interface {
 	GetPrimaryNode(context.Context, *PrimaryNodeRequest) (*PrimaryNodeResponse, error)
 	NodeHeartBeat(context.Context, *HeartBeatRequest) (*HeartBeatResponse, error)
 	GetNodeList(context.Context, *NodeListRequest) (*NodeListResponse, error)
+	GetRangeDescriptors(context.Context, *RangeDescriptorsRequest) (*RangeDescriptorsResponse, error)
+	DeregisterNode(context.Context, *DeregisterNodeRequest) (*DeregisterNodeResponse, error)
+	DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error)
+	PromoteLearner(context.Context, *PromoteLearnerRequest) (*PromoteLearnerResponse, error)
+	GetClusterConfiguration(context.Context, *ClusterConfigurationRequest) (*ClusterConfigurationResponse, error)
+	TransferPrimary(context.Context, *TransferPrimaryRequest) (*TransferPrimaryResponse, error)
 	mustEmbedUnimplementedRegistryServiceServer()
 }
 
@@ -114,6 +192,24 @@ func (UnimplementedRegistryServiceServer) NodeHeartBeat(context.Context, *HeartB
 func (UnimplementedRegistryServiceServer) GetNodeList(context.Context, *NodeListRequest) (*NodeListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetNodeList not implemented")
 }
+func (UnimplementedRegistryServiceServer) GetRangeDescriptors(context.Context, *RangeDescriptorsRequest) (*RangeDescriptorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRangeDescriptors not implemented")
+}
+func (UnimplementedRegistryServiceServer) DeregisterNode(context.Context, *DeregisterNodeRequest) (*DeregisterNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeregisterNode not implemented")
+}
+func (UnimplementedRegistryServiceServer) DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainNode not implemented")
+}
+func (UnimplementedRegistryServiceServer) PromoteLearner(context.Context, *PromoteLearnerRequest) (*PromoteLearnerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PromoteLearner not implemented")
+}
+func (UnimplementedRegistryServiceServer) GetClusterConfiguration(context.Context, *ClusterConfigurationRequest) (*ClusterConfigurationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClusterConfiguration not implemented")
+}
+func (UnimplementedRegistryServiceServer) TransferPrimary(context.Context, *TransferPrimaryRequest) (*TransferPrimaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferPrimary not implemented")
+}
 func (UnimplementedRegistryServiceServer) mustEmbedUnimplementedRegistryServiceServer() {}
 func (UnimplementedRegistryServiceServer) testEmbeddedByValue()                         {}
 
@@ -207,6 +303,114 @@ func _RegistryService_GetNodeList_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RegistryService_GetRangeDescriptors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RangeDescriptorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServiceServer).GetRangeDescriptors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RegistryService_GetRangeDescriptors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServiceServer).GetRangeDescriptors(ctx, req.(*RangeDescriptorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistryService_DeregisterNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeregisterNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServiceServer).DeregisterNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RegistryService_DeregisterNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServiceServer).DeregisterNode(ctx, req.(*DeregisterNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistryService_DrainNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServiceServer).DrainNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RegistryService_DrainNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServiceServer).DrainNode(ctx, req.(*DrainNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistryService_PromoteLearner_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PromoteLearnerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServiceServer).PromoteLearner(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RegistryService_PromoteLearner_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServiceServer).PromoteLearner(ctx, req.(*PromoteLearnerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistryService_GetClusterConfiguration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterConfigurationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServiceServer).GetClusterConfiguration(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RegistryService_GetClusterConfiguration_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServiceServer).GetClusterConfiguration(ctx, req.(*ClusterConfigurationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistryService_TransferPrimary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferPrimaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistryServiceServer).TransferPrimary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RegistryService_TransferPrimary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistryServiceServer).TransferPrimary(ctx, req.(*TransferPrimaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RegistryService_ServiceDesc is the grpc.ServiceDesc for RegistryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -230,6 +434,30 @@ var RegistryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetNodeList",
 			Handler:    _RegistryService_GetNodeList_Handler,
 		},
+		{
+			MethodName: "GetRangeDescriptors",
+			Handler:    _RegistryService_GetRangeDescriptors_Handler,
+		},
+		{
+			MethodName: "DeregisterNode",
+			Handler:    _RegistryService_DeregisterNode_Handler,
+		},
+		{
+			MethodName: "DrainNode",
+			Handler:    _RegistryService_DrainNode_Handler,
+		},
+		{
+			MethodName: "PromoteLearner",
+			Handler:    _RegistryService_PromoteLearner_Handler,
+		},
+		{
+			MethodName: "GetClusterConfiguration",
+			Handler:    _RegistryService_GetClusterConfiguration_Handler,
+		},
+		{
+			MethodName: "TransferPrimary",
+			Handler:    _RegistryService_TransferPrimary_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "protos/registry.proto",